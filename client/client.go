@@ -90,9 +90,10 @@ func parseResponse(resp *http.Response, v any) error {
 			return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, resp.Status)
 		}
 		return &APIError{
-			Code:       errResp.Error.Code,
-			Message:    errResp.Error.Message,
-			StatusCode: resp.StatusCode,
+			Code:        errResp.Error.Code,
+			Message:     errResp.Error.Message,
+			StatusCode:  resp.StatusCode,
+			Diagnostics: errResp.Error.Diagnostics,
 		}
 	}
 
@@ -107,9 +108,10 @@ func parseResponse(resp *http.Response, v any) error {
 
 // APIError represents an error returned by the API
 type APIError struct {
-	Code       string
-	Message    string
-	StatusCode int
+	Code        string
+	Message     string
+	StatusCode  int
+	Diagnostics []ScriptDiagnostic // set when a script fails validation
 }
 
 func (e *APIError) Error() string {
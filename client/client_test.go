@@ -97,7 +97,8 @@ func TestEvaluateScript(t *testing.T) {
 			"success": true,
 			"result": 5,
 			"output": ["info", "test"],
-			"duration": "1.2ms"
+			"duration": "1.2ms",
+			"logs": [{"level": "info", "message": "adding", "timestamp": "2024-05-01T12:00:00Z"}]
 		}`))
 	}))
 	defer server.Close()
@@ -120,6 +121,9 @@ func TestEvaluateScript(t *testing.T) {
 	if len(result.Output) != 2 {
 		t.Errorf("Expected 2 output items, got %d", len(result.Output))
 	}
+	if len(result.Logs) != 1 || result.Logs[0].Message != "adding" {
+		t.Errorf("Expected 1 log entry 'adding', got %v", result.Logs)
+	}
 }
 
 func TestAPIError(t *testing.T) {
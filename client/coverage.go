@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetRuleCoverage retrieves the line coverage of the script of a rule
+func (c *Client) GetRuleCoverage(ctx context.Context, id uuid.UUID) (*ScriptCoverageInfo, error) {
+	return c.getCoverage(ctx, fmt.Sprintf("/api/v1/rules/%s/coverage", id.String()))
+}
+
+// GetTriggerCoverage retrieves the line coverage of the condition script of a trigger
+func (c *Client) GetTriggerCoverage(ctx context.Context, id uuid.UUID) (*ScriptCoverageInfo, error) {
+	return c.getCoverage(ctx, fmt.Sprintf("/api/v1/triggers/%s/coverage", id.String()))
+}
+
+// GetActionCoverage retrieves the line coverage of the script of an action
+func (c *Client) GetActionCoverage(ctx context.Context, id uuid.UUID) (*ScriptCoverageInfo, error) {
+	return c.getCoverage(ctx, fmt.Sprintf("/api/v1/actions/%s/coverage", id.String()))
+}
+
+// ResetCoverage drops the line coverage recorded for every script
+func (c *Client) ResetCoverage(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "DELETE", "/api/v1/coverage", nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 204 {
+		return parseResponse(resp, nil)
+	}
+
+	return nil
+}
+
+func (c *Client) getCoverage(ctx context.Context, path string) (*ScriptCoverageInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var coverage ScriptCoverageInfo
+	if err := parseResponse(resp, &coverage); err != nil {
+		return nil, err
+	}
+
+	return &coverage, nil
+}
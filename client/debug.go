@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// StartDebugSession runs a Lua script under the debugger.
+// The returned session is paused, or finished if no breakpoint was hit.
+func (c *Client) StartDebugSession(ctx context.Context, req StartDebugSessionRequest) (*DebugSessionInfo, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/debug/sessions", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var session DebugSessionInfo
+	if err := parseResponse(resp, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetDebugSession retrieves the state of a debug session
+func (c *Client) GetDebugSession(ctx context.Context, id string) (*DebugSessionInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/debug/sessions/%s", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var session DebugSessionInfo
+	if err := parseResponse(resp, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// SetDebugBreakpoints replaces the breakpoints of a debug session
+func (c *Client) SetDebugBreakpoints(ctx context.Context, id string, lines []int) (*DebugSessionInfo, error) {
+	resp, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/debug/sessions/%s/breakpoints", id), SetBreakpointsRequest{Breakpoints: lines})
+	if err != nil {
+		return nil, err
+	}
+
+	var session DebugSessionInfo
+	if err := parseResponse(resp, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ContinueDebugSession resumes a paused debug session until the next breakpoint
+func (c *Client) ContinueDebugSession(ctx context.Context, id string) (*DebugSessionInfo, error) {
+	return c.resumeDebugSession(ctx, id, "continue")
+}
+
+// StepDebugSession resumes a paused debug session until the next line, entering called functions
+func (c *Client) StepDebugSession(ctx context.Context, id string) (*DebugSessionInfo, error) {
+	return c.resumeDebugSession(ctx, id, "step")
+}
+
+// NextDebugSession resumes a paused debug session until the next line, stepping over called functions
+func (c *Client) NextDebugSession(ctx context.Context, id string) (*DebugSessionInfo, error) {
+	return c.resumeDebugSession(ctx, id, "next")
+}
+
+func (c *Client) resumeDebugSession(ctx context.Context, id, command string) (*DebugSessionInfo, error) {
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("/api/v1/debug/sessions/%s/%s", id, command), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var session DebugSessionInfo
+	if err := parseResponse(resp, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// StopDebugSession aborts and discards a debug session
+func (c *Client) StopDebugSession(ctx context.Context, id string) error {
+	resp, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/debug/sessions/%s", id), nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 204 {
+		return parseResponse(resp, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ListRuleExecutions retrieves the paginated execution history of a rule, most recent first
+func (c *Client) ListRuleExecutions(ctx context.Context, ruleID uuid.UUID, limit, offset int) (*PaginatedExecutionsResponse, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	path := fmt.Sprintf("/api/v1/rules/%s/executions", ruleID.String())
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PaginatedExecutionsResponse
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetExecution retrieves a recorded rule execution by ID
+func (c *Client) GetExecution(ctx context.Context, id int64) (*ExecutionInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/executions/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var execution ExecutionInfo
+	if err := parseResponse(resp, &execution); err != nil {
+		return nil, err
+	}
+
+	return &execution, nil
+}
+
+// ReplayExecution re-runs a recorded execution against its recorded inputs.
+// The current script of the rule is run when script is empty.
+func (c *Client) ReplayExecution(ctx context.Context, id int64, script string) (*ReplayExecutionResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("/api/v1/executions/%d/replay", id), ReplayExecutionRequest{Script: script})
+	if err != nil {
+		return nil, err
+	}
+
+	var result ReplayExecutionResponse
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// CreateLibrary creates a new shared Lua library
+func (c *Client) CreateLibrary(ctx context.Context, req CreateLibraryRequest) (*LibraryInfo, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/libraries", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var library LibraryInfo
+	if err := parseResponse(resp, &library); err != nil {
+		return nil, err
+	}
+
+	return &library, nil
+}
+
+// GetLibrary retrieves a library by ID
+func (c *Client) GetLibrary(ctx context.Context, id uuid.UUID) (*LibraryInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/libraries/%s", id.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var library LibraryInfo
+	if err := parseResponse(resp, &library); err != nil {
+		return nil, err
+	}
+
+	return &library, nil
+}
+
+// ListLibraries retrieves a paginated list of libraries
+func (c *Client) ListLibraries(ctx context.Context, limit, offset int) (*PaginatedLibrariesResponse, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	path := "/api/v1/libraries"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PaginatedLibrariesResponse
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateLibrary updates a library by ID using JSON Patch
+func (c *Client) UpdateLibrary(ctx context.Context, id uuid.UUID, req UpdateLibraryRequest) (*LibraryInfo, error) {
+	resp, err := c.doRequest(ctx, "PATCH", fmt.Sprintf("/api/v1/libraries/%s", id.String()), req.Patches)
+	if err != nil {
+		return nil, err
+	}
+
+	var library LibraryInfo
+	if err := parseResponse(resp, &library); err != nil {
+		return nil, err
+	}
+
+	return &library, nil
+}
+
+// DeleteLibrary deletes a library by ID
+func (c *Client) DeleteLibrary(ctx context.Context, id uuid.UUID) error {
+	resp, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/libraries/%s", id.String()), nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 204 {
+		return parseResponse(resp, nil)
+	}
+
+	return nil
+}
+
+// ListLibraryVersions retrieves the versions of a library, most recent first
+func (c *Client) ListLibraryVersions(ctx context.Context, id uuid.UUID) (*LibraryVersionsResponse, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/libraries/%s/versions", id.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result LibraryVersionsResponse
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetLibraryVersion retrieves the code of a library at the given version
+func (c *Client) GetLibraryVersion(ctx context.Context, id uuid.UUID, version int) (*LibraryVersionInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/libraries/%s/versions/%d", id.String(), version), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result LibraryVersionInfo
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
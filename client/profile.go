@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetRuleProfile retrieves the aggregated execution profile of a rule
+func (c *Client) GetRuleProfile(ctx context.Context, id uuid.UUID) (*RuleProfileInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/rules/%s/profile", id.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile RuleProfileInfo
+	if err := parseResponse(resp, &profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// ResetRuleProfile drops the execution profile recorded for a rule
+func (c *Client) ResetRuleProfile(ctx context.Context, id uuid.UUID) error {
+	resp, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/rules/%s/profile", id.String()), nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 204 {
+		return parseResponse(resp, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// CreateSecret creates a new secret
+func (c *Client) CreateSecret(ctx context.Context, req CreateSecretRequest) (*SecretInfo, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/secrets", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret SecretInfo
+	if err := parseResponse(resp, &secret); err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// GetSecret retrieves the metadata of a secret by ID
+func (c *Client) GetSecret(ctx context.Context, id uuid.UUID) (*SecretInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/secrets/%s", id.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret SecretInfo
+	if err := parseResponse(resp, &secret); err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// ListSecrets retrieves a paginated list of the metadata of secrets
+func (c *Client) ListSecrets(ctx context.Context, limit, offset int) (*PaginatedSecretsResponse, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	path := "/api/v1/secrets"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PaginatedSecretsResponse
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateSecret updates a secret by ID
+func (c *Client) UpdateSecret(ctx context.Context, id uuid.UUID, req UpdateSecretRequest) (*SecretInfo, error) {
+	resp, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/secrets/%s", id.String()), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret SecretInfo
+	if err := parseResponse(resp, &secret); err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// DeleteSecret deletes a secret by ID
+func (c *Client) DeleteSecret(ctx context.Context, id uuid.UUID) error {
+	resp, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/secrets/%s", id.String()), nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 204 {
+		return parseResponse(resp, nil)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,15 +9,25 @@ import (
 
 // RuleInfo represents a rule in the system
 type RuleInfo struct {
-	ID        uuid.UUID     `json:"id"`
-	Name      string        `json:"name"`
-	LuaScript string        `json:"lua_script"`
-	Priority  int           `json:"priority"`
-	Enabled   bool          `json:"enabled"`
-	Triggers  []TriggerInfo `json:"triggers,omitempty"`
-	Actions   []ActionInfo  `json:"actions,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID        uuid.UUID       `json:"id"`
+	Name      string          `json:"name"`
+	LuaScript string          `json:"lua_script"`
+	Priority  int             `json:"priority"`
+	Enabled   bool            `json:"enabled"`
+	TimeoutMs int             `json:"timeout_ms"`
+	Limits    ExecutionLimits `json:"limits"`
+	Triggers  []TriggerInfo   `json:"triggers,omitempty"`
+	Actions   []ActionInfo    `json:"actions,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ExecutionLimits represents per-rule sandbox limit overrides (0 uses the engine default)
+type ExecutionLimits struct {
+	MaxInstructions   int64 `json:"max_instructions,omitempty"`
+	MaxCallStackDepth int   `json:"max_call_stack_depth,omitempty"`
+	MaxRegistrySize   int   `json:"max_registry_size,omitempty"`
+	MaxMemoryBytes    int64 `json:"max_memory_bytes,omitempty"`
 }
 
 // TriggerInfo represents a trigger in the system
@@ -25,27 +36,63 @@ type TriggerInfo struct {
 	RuleID          uuid.UUID `json:"rule_id"`
 	Type            string    `json:"type"` // CONDITIONAL or CRON
 	ConditionScript string    `json:"condition_script"`
+	Language        string    `json:"language"` // lua or expr
 	Enabled         bool      `json:"enabled"`
+	TimeoutMs       int       `json:"timeout_ms"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // ActionInfo represents an action in the system
 type ActionInfo struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	LuaScript string    `json:"lua_script"`
-	Enabled   bool      `json:"enabled"`
+	ID        uuid.UUID       `json:"id"`
+	Name      string          `json:"name"`
+	Type      string          `json:"type"` // lua_script, publish_event or notify
+	LuaScript string          `json:"lua_script"`
+	Params    json.RawMessage `json:"params,omitempty"` // params of publish_event and notify actions
+	Enabled   bool            `json:"enabled"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// LibraryInfo represents a shared Lua library that scripts load with require(name)
+type LibraryInfo struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Code        string    `json:"code"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LibraryVersionInfo represents a version of the code of a library
+type LibraryVersionInfo struct {
+	LibraryID uuid.UUID `json:"library_id"`
+	Version   int       `json:"version"`
+	Code      string    `json:"code"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SecretInfo represents the metadata of a secret that the scripts of its
+// rules read with secrets.get(name). The value is never returned.
+type SecretInfo struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	RuleIDs     []uuid.UUID `json:"rule_ids"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
 // CreateRuleRequest represents a request to create a rule
 type CreateRuleRequest struct {
-	Name      string `json:"name"`
-	LuaScript string `json:"lua_script"`
-	Priority  *int   `json:"priority,omitempty"`
-	Enabled   *bool  `json:"enabled,omitempty"`
+	Name      string           `json:"name"`
+	LuaScript string           `json:"lua_script"`
+	Priority  *int             `json:"priority,omitempty"`
+	Enabled   *bool            `json:"enabled,omitempty"`
+	TimeoutMs *int             `json:"timeout_ms,omitempty"`
+	Limits    *ExecutionLimits `json:"limits,omitempty"`
 }
 
 // CreateTriggerRequest represents a request to create a trigger
@@ -53,29 +100,179 @@ type CreateTriggerRequest struct {
 	RuleID          uuid.UUID `json:"rule_id"`
 	Type            string    `json:"type"` // CONDITIONAL or CRON
 	ConditionScript string    `json:"condition_script"`
+	Language        string    `json:"language,omitempty"` // lua (default) or expr
 	Enabled         *bool     `json:"enabled,omitempty"`
+	TimeoutMs       *int      `json:"timeout_ms,omitempty"`
 }
 
 // CreateActionRequest represents a request to create an action
 type CreateActionRequest struct {
-	Name      string `json:"name,omitempty"`
-	LuaScript string `json:"lua_script"`
-	Enabled   *bool  `json:"enabled,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Type      string          `json:"type,omitempty"` // lua_script (default), publish_event or notify
+	LuaScript string          `json:"lua_script,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"` // templated params of publish_event and notify actions
+	Enabled   *bool           `json:"enabled,omitempty"`
 }
 
-// EvaluateScriptRequest represents a request to evaluate a Lua script
+// CreateLibraryRequest represents a request to create a library
+type CreateLibraryRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Code        string `json:"code"`
+}
+
+// CreateSecretRequest represents a request to create a secret
+type CreateSecretRequest struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Value       string      `json:"value"`
+	RuleIDs     []uuid.UUID `json:"rule_ids,omitempty"`
+}
+
+// EvaluateScriptRequest represents a request to evaluate a Lua script or an expression
 type EvaluateScriptRequest struct {
-	Script  string         `json:"script"`
-	Context map[string]any `json:"context,omitempty"`
+	Script   string         `json:"script"`
+	Language string         `json:"language,omitempty"` // lua (default) or expr
+	Context  map[string]any `json:"context,omitempty"`
+	Coverage bool           `json:"coverage,omitempty"` // count the lines the script runs
+	Profile  bool           `json:"profile,omitempty"`  // time the script and its module calls
 }
 
 // EvaluateScriptResponse represents the response from script evaluation
 type EvaluateScriptResponse struct {
-	Success  bool   `json:"success"`
-	Result   any    `json:"result,omitempty"`
-	Output   []any  `json:"output,omitempty"`
-	Error    string `json:"error,omitempty"`
-	Duration string `json:"duration"`
+	Success       bool                  `json:"success"`
+	Result        any                   `json:"result,omitempty"`
+	Output        []any                 `json:"output,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	Duration      string                `json:"duration"`
+	Logs          []ScriptLogEntry      `json:"logs,omitempty"`
+	LogsTruncated bool                  `json:"logs_truncated,omitempty"`
+	Coverage      map[int]int           `json:"coverage,omitempty"` // times each line ran, when requested
+	Profile       *ExecutionProfileInfo `json:"profile,omitempty"`  // where the time went, when requested
+}
+
+// ExecutionProfileInfo breaks the duration of an execution down into the time
+// spent running the script and in each platform module function
+type ExecutionProfileInfo struct {
+	TotalMs float64           `json:"total_ms"`
+	VMMs    float64           `json:"vm_ms"`
+	Calls   []ProfileCallInfo `json:"calls"`
+}
+
+// ProfileCallInfo represents the time spent in one platform module function, e.g. http.get
+type ProfileCallInfo struct {
+	Name       string  `json:"name"`
+	Count      int64   `json:"count"`
+	DurationMs float64 `json:"duration_ms"`
+	AverageMs  float64 `json:"average_ms,omitempty"`
+}
+
+// RuleProfileInfo represents the aggregated profile of the executions of a rule
+type RuleProfileInfo struct {
+	RuleID    string            `json:"rule_id"`
+	Runs      int64             `json:"runs"`
+	TotalMs   float64           `json:"total_ms"`
+	AverageMs float64           `json:"average_ms"`
+	MaxMs     float64           `json:"max_ms"`
+	VMMs      float64           `json:"vm_ms"`
+	Calls     []ProfileCallInfo `json:"calls"`
+	LastRunAt *time.Time        `json:"last_run_at,omitempty"`
+}
+
+// ScriptCoverageInfo represents the line coverage of a script, aggregated over its runs
+type ScriptCoverageInfo struct {
+	ScriptHash      string             `json:"script_hash"`
+	Runs            int                `json:"runs"`
+	ExecutableLines int                `json:"executable_lines"`
+	CoveredLines    int                `json:"covered_lines"`
+	Percent         float64            `json:"percent"`
+	Lines           []LineCoverageInfo `json:"lines"`
+	LastRunAt       *time.Time         `json:"last_run_at,omitempty"`
+}
+
+// LineCoverageInfo represents how many times a script line ran
+type LineCoverageInfo struct {
+	Line int   `json:"line"`
+	Hits int64 `json:"hits"`
+}
+
+// ScriptLogEntry represents a line printed or logged by a script
+type ScriptLogEntry struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StartDebugSessionRequest represents a request to run a Lua script under the debugger
+type StartDebugSessionRequest struct {
+	Script      string         `json:"script"`
+	Context     map[string]any `json:"context,omitempty"`
+	Breakpoints []int          `json:"breakpoints,omitempty"`
+	StopOnEntry bool           `json:"stop_on_entry,omitempty"`
+}
+
+// SetBreakpointsRequest represents a request to replace the breakpoints of a debug session
+type SetBreakpointsRequest struct {
+	Breakpoints []int `json:"breakpoints"`
+}
+
+// DebugSessionInfo represents the state of a debug session
+type DebugSessionInfo struct {
+	ID          string                  `json:"id"`
+	Status      string                  `json:"status"` // running, paused or finished
+	Reason      string                  `json:"reason,omitempty"`
+	Line        int                     `json:"line,omitempty"`
+	Breakpoints []int                   `json:"breakpoints"`
+	Stack       []DebugFrame            `json:"stack,omitempty"`     // innermost frame first
+	Result      *EvaluateScriptResponse `json:"result,omitempty"`    // set once the script has finished
+	ExpiresAt   time.Time               `json:"expires_at,omitzero"` // unset while the script runs
+}
+
+// DebugFrame represents one level of the call stack of a paused script
+type DebugFrame struct {
+	Function string          `json:"function"`
+	Source   string          `json:"source"`
+	Line     int             `json:"line,omitempty"`
+	Locals   []DebugVariable `json:"locals,omitempty"`
+	Upvalues []DebugVariable `json:"upvalues,omitempty"`
+}
+
+// DebugVariable represents a local or upvalue of a paused script
+type DebugVariable struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// ExecutionInfo represents a recorded rule execution
+type ExecutionInfo struct {
+	ID            int64            `json:"id"`
+	RuleID        uuid.UUID        `json:"rule_id"`
+	TriggerID     *uuid.UUID       `json:"trigger_id,omitempty"`
+	Status        string           `json:"status"` // SUCCESS, FAILURE or TIMEOUT
+	TriggeredAt   time.Time        `json:"triggered_at"`
+	DurationMs    int64            `json:"duration_ms"`
+	Output        []any            `json:"output,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	Logs          []ScriptLogEntry `json:"logs,omitempty"`
+	LogsTruncated bool             `json:"logs_truncated,omitempty"`
+	Replayable    bool             `json:"replayable"` // the inputs of the execution were recorded
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// ReplayExecutionRequest represents a request to replay a recorded execution
+type ReplayExecutionRequest struct {
+	Script string `json:"script,omitempty"` // run instead of the current script of the rule when set
+}
+
+// ReplayExecutionResponse represents the outcome of replaying a recorded execution
+type ReplayExecutionResponse struct {
+	ExecutionID    int64                   `json:"execution_id"`
+	Script         string                  `json:"script"` // current or proposed
+	Result         *EvaluateScriptResponse `json:"result"`
+	OriginalStatus string                  `json:"original_status"`
+	OriginalOutput []any                   `json:"original_output,omitempty"`
+	Matches        bool                    `json:"matches"`
+	Divergences    []string                `json:"divergences,omitempty"`
 }
 
 // AddActionToRuleRequest represents a request to add an action to a rule
@@ -98,6 +295,19 @@ type UpdateActionRequest struct {
 	Patches PatchRequest `json:"-"` // Not serialized, used for JSON Patch
 }
 
+// UpdateLibraryRequest represents a request to update a library
+type UpdateLibraryRequest struct {
+	Patches PatchRequest `json:"-"` // Not serialized, used for JSON Patch
+}
+
+// UpdateSecretRequest represents a request to update a secret. The description
+// and the rules are replaced, the value only when it is set.
+type UpdateSecretRequest struct {
+	Description string      `json:"description"`
+	Value       *string     `json:"value,omitempty"`
+	RuleIDs     []uuid.UUID `json:"rule_ids"`
+}
+
 // UpdateTriggerRequest represents a request to update a trigger
 type UpdateTriggerRequest struct {
 	Patches PatchRequest `json:"-"` // Not serialized, used for JSON Patch
@@ -118,6 +328,15 @@ type PaginatedRulesResponse struct {
 	Total  int        `json:"total"`
 }
 
+// PaginatedExecutionsResponse represents a paginated list of rule executions
+type PaginatedExecutionsResponse struct {
+	Executions []ExecutionInfo `json:"executions"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	Count      int             `json:"count"`
+	Total      int             `json:"total"`
+}
+
 // PaginatedTriggersResponse represents a paginated list of triggers
 type PaginatedTriggersResponse struct {
 	Triggers []TriggerInfo `json:"triggers"`
@@ -136,6 +355,30 @@ type PaginatedActionsResponse struct {
 	Total   int          `json:"total"`
 }
 
+// PaginatedLibrariesResponse represents a paginated list of libraries
+type PaginatedLibrariesResponse struct {
+	Libraries []LibraryInfo `json:"libraries"`
+	Limit     int           `json:"limit"`
+	Offset    int           `json:"offset"`
+	Count     int           `json:"count"`
+	Total     int           `json:"total"`
+}
+
+// PaginatedSecretsResponse represents a paginated list of secrets
+type PaginatedSecretsResponse struct {
+	Secrets []SecretInfo `json:"secrets"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+	Count   int          `json:"count"`
+	Total   int          `json:"total"`
+}
+
+// LibraryVersionsResponse represents the versions of a library, most recent first
+type LibraryVersionsResponse struct {
+	Versions []LibraryVersionInfo `json:"versions"`
+	Count    int                  `json:"count"`
+}
+
 // ErrorResponse represents an error response from the API
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
@@ -143,6 +386,17 @@ type ErrorResponse struct {
 
 // ErrorDetail represents the details of an error
 type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code        string             `json:"code"`
+	Message     string             `json:"message"`
+	Diagnostics []ScriptDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// ScriptDiagnostic represents a problem found by static validation of a script
+type ScriptDiagnostic struct {
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
 }
@@ -4,17 +4,74 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/library"
+	"github.com/malyshevhen/rule-engine/internal/notify"
 )
 
 // Config holds application configuration
 type Config struct {
-	Port               string
-	DBURL              string
-	NATSURL            string
-	RedisURL           string
-	AlertingEnabled    bool
-	AlertWebhookURL    string
-	AlertRetryAttempts int
+	Port                string
+	DBURL               string
+	NATSURL             string
+	RedisURL            string
+	AlertingEnabled     bool
+	AlertWebhookURL     string
+	AlertRetryAttempts  int
+	ScriptTimeout       time.Duration
+	ScriptLimits        execCtx.Limits
+	ScriptPoolSize      int
+	ScriptCacheSize     int
+	ScriptLegacyGlobals bool
+	ScriptMaxLogEntries int
+	ScriptMaxLogBytes   int
+	ScriptReplayBytes   int // 0 unless executions are recorded for replay
+	ScriptCoverage      bool
+	ScriptProfiling     bool
+	StoreMaxKeys        int
+	StoreMaxValueBytes  int
+	WindowRetention     time.Duration
+	WindowMaxPoints     int
+	WindowMaxSeries     int
+	EventsAllowed       events.AllowList
+	EventsMaxChainDepth int
+	DeviceServiceURL    string
+	DeviceServiceToken  string
+	DeviceSimulatorFile string
+	HTTPAllowedHosts    string
+	HTTPDeniedHosts     string
+	HTTPAllowPrivate    bool
+	HTTPMaxBodyBytes    int
+	HTTPRuleQuota       int
+	HTTPQuotaWindow     time.Duration
+	SecretsKey          string // base64-encoded master key; secrets cannot be written or read without one
+	SecretsPreviousKeys string // comma-separated master keys still decrypting the values written before a rotation
+	NotifySMTPAddr      string // host:port of the SMTP server of the email channel
+	NotifySMTPUser      string
+	NotifySMTPPassword  string
+	NotifySMTPFrom      string
+	NotifySMTPTo        string // comma-separated recipients of the emails that name none
+	NotifyWebhookURL    string
+	NotifyWebhookToken  string // bearer token of the webhook channel
+	NotifySlackURL      string
+	NotifyTelegramToken string
+	NotifyTelegramChat  string
+	NotifyTelegramAPI   string
+	DebugSessionTTL     time.Duration
+	DebugMaxSessions    int
+	LibraryPollInterval time.Duration
+
+	// DeviceClient gives scripts access to the devices; when nil it is built
+	// from the device service URL, or from the simulator file
+	DeviceClient modules.DeviceClient
 }
 
 // loadConfig loads configuration from environment variables
@@ -63,13 +120,254 @@ func loadConfig() Config {
 		}
 	}
 
+	// Script execution configuration
+	scriptTimeout := 5 * time.Second // default
+	if timeoutStr := os.Getenv("SCRIPT_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil && timeout > 0 {
+			scriptTimeout = timeout
+		}
+	}
+
+	// Script resource budgets; zero keeps the executor defaults
+	scriptLimits := execCtx.Limits{
+		MaxInstructions:   envInt64("SCRIPT_MAX_INSTRUCTIONS"),
+		MaxCallStackDepth: int(envInt64("SCRIPT_MAX_CALL_STACK_DEPTH")),
+		MaxRegistrySize:   int(envInt64("SCRIPT_MAX_REGISTRY_SIZE")),
+		MaxMemoryBytes:    envInt64("SCRIPT_MAX_MEMORY_BYTES"),
+	}
+
+	scriptPoolSize := executor.DefaultStatePoolSize
+	if sizeStr := os.Getenv("SCRIPT_STATE_POOL_SIZE"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size >= 0 {
+			scriptPoolSize = size
+		}
+	}
+
+	scriptCacheSize := executor.DefaultScriptCacheSize
+	if sizeStr := os.Getenv("SCRIPT_CACHE_SIZE"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size >= 0 {
+			scriptCacheSize = size
+		}
+	}
+
+	// Bounds of the print and logger output captured per execution; 0 disables a bound
+	scriptMaxLogEntries := scriptlog.DefaultMaxEntries
+	if entriesStr := os.Getenv("SCRIPT_MAX_LOG_ENTRIES"); entriesStr != "" {
+		if entries, err := strconv.Atoi(entriesStr); err == nil && entries >= 0 {
+			scriptMaxLogEntries = entries
+		}
+	}
+
+	scriptMaxLogBytes := scriptlog.DefaultMaxBytes
+	if bytesStr := os.Getenv("SCRIPT_MAX_LOG_BYTES"); bytesStr != "" {
+		if bytes, err := strconv.Atoi(bytesStr); err == nil && bytes >= 0 {
+			scriptMaxLogBytes = bytes
+		}
+	}
+
+	// Executions are recorded for replay on demand, the HTTP bodies kept per execution are bounded
+	scriptReplayBytes := 0
+	if os.Getenv("SCRIPT_RECORD_REPLAY") == "true" {
+		scriptReplayBytes = replay.DefaultMaxBytes
+		if bytesStr := os.Getenv("SCRIPT_REPLAY_MAX_BYTES"); bytesStr != "" {
+			if bytes, err := strconv.Atoi(bytesStr); err == nil && bytes > 0 {
+				scriptReplayBytes = bytes
+			}
+		}
+	}
+
+	// Line coverage of every rule, trigger and action script run, for test environments
+	scriptCoverage := os.Getenv("SCRIPT_COVERAGE") == "true"
+
+	// Time spent in the script and each platform module call of every execution, aggregated per rule
+	scriptProfiling := os.Getenv("SCRIPT_PROFILING") == "true"
+
+	// Quotas of the state each rule keeps with the store module; 0 disables a quota
+	storeMaxKeys := modules.DefaultStoreMaxKeys
+	if keysStr := os.Getenv("STORE_MAX_KEYS"); keysStr != "" {
+		if keys, err := strconv.Atoi(keysStr); err == nil && keys >= 0 {
+			storeMaxKeys = keys
+		}
+	}
+
+	storeMaxValueBytes := modules.DefaultStoreMaxValueBytes
+	if bytesStr := os.Getenv("STORE_MAX_VALUE_BYTES"); bytesStr != "" {
+		if bytes, err := strconv.Atoi(bytesStr); err == nil && bytes >= 0 {
+			storeMaxValueBytes = bytes
+		}
+	}
+
+	// Retention and quotas of the series each rule aggregates with the window module; 0 disables a quota
+	windowRetention := modules.DefaultWindowRetention
+	if retentionStr := os.Getenv("WINDOW_RETENTION"); retentionStr != "" {
+		if retention, err := time.ParseDuration(retentionStr); err == nil && retention > 0 {
+			windowRetention = retention
+		}
+	}
+
+	windowMaxPoints := modules.DefaultWindowMaxPoints
+	if pointsStr := os.Getenv("WINDOW_MAX_POINTS"); pointsStr != "" {
+		if points, err := strconv.Atoi(pointsStr); err == nil && points >= 0 {
+			windowMaxPoints = points
+		}
+	}
+
+	windowMaxSeries := modules.DefaultWindowMaxSeries
+	if seriesStr := os.Getenv("WINDOW_MAX_SERIES"); seriesStr != "" {
+		if series, err := strconv.Atoi(seriesStr); err == nil && series >= 0 {
+			windowMaxSeries = series
+		}
+	}
+
+	// Subjects scripts and publish_event actions may publish on; none by default
+	eventsAllowed := events.ParseAllowList(os.Getenv("EVENTS_ALLOWED_SUBJECTS"))
+
+	eventsMaxChainDepth := events.DefaultMaxChainDepth
+	if depthStr := os.Getenv("EVENTS_MAX_CHAIN_DEPTH"); depthStr != "" {
+		if depth, err := strconv.Atoi(depthStr); err == nil && depth > 0 {
+			eventsMaxChainDepth = depth
+		}
+	}
+
+	// Devices are reached through the device service, or simulated from a file of devices
+	deviceServiceURL := os.Getenv("DEVICE_SERVICE_URL")
+	deviceServiceToken := os.Getenv("DEVICE_SERVICE_TOKEN")
+	deviceSimulatorFile := os.Getenv("DEVICE_SIMULATOR_FILE")
+
+	// Hosts and networks the http module may reach, as comma-separated lists of
+	// host names, *.wildcards, addresses and CIDRs; private networks are blocked by default
+	httpAllowedHosts := os.Getenv("HTTP_ALLOWED_HOSTS")
+	httpDeniedHosts := os.Getenv("HTTP_DENIED_HOSTS")
+	httpAllowPrivate := os.Getenv("HTTP_ALLOW_PRIVATE_NETWORKS") == "true"
+
+	httpMaxBodyBytes := modules.DefaultHTTPMaxResponseBytes
+	if bytesStr := os.Getenv("HTTP_MAX_RESPONSE_BYTES"); bytesStr != "" {
+		if bytes, err := strconv.Atoi(bytesStr); err == nil && bytes >= 0 {
+			httpMaxBodyBytes = bytes
+		}
+	}
+
+	// Requests each rule may make per window; 0 disables the quota.
+	// Every instance counts its own requests, so replicas multiply the limit.
+	httpRuleQuota := 0
+	if quotaStr := os.Getenv("HTTP_RULE_QUOTA"); quotaStr != "" {
+		if quota, err := strconv.Atoi(quotaStr); err == nil && quota >= 0 {
+			httpRuleQuota = quota
+		}
+	}
+
+	httpQuotaWindow := time.Minute
+	if windowStr := os.Getenv("HTTP_RULE_QUOTA_WINDOW"); windowStr != "" {
+		if window, err := time.ParseDuration(windowStr); err == nil && window > 0 {
+			httpQuotaWindow = window
+		}
+	}
+
+	// Master keys encrypting the data keys of secrets
+	secretsKey := os.Getenv("SECRETS_KEY")
+	secretsPreviousKeys := os.Getenv("SECRETS_PREVIOUS_KEYS")
+
+	// Notification channels; a channel is available when its address is set
+	notifySMTPAddr := os.Getenv("NOTIFY_SMTP_ADDR")
+	notifySMTPUser := os.Getenv("NOTIFY_SMTP_USERNAME")
+	notifySMTPPassword := os.Getenv("NOTIFY_SMTP_PASSWORD")
+	notifySMTPFrom := os.Getenv("NOTIFY_SMTP_FROM")
+	notifySMTPTo := os.Getenv("NOTIFY_SMTP_TO")
+	notifyWebhookURL := os.Getenv("NOTIFY_WEBHOOK_URL")
+	notifyWebhookToken := os.Getenv("NOTIFY_WEBHOOK_TOKEN")
+	notifySlackURL := os.Getenv("NOTIFY_SLACK_WEBHOOK_URL")
+	notifyTelegramToken := os.Getenv("NOTIFY_TELEGRAM_BOT_TOKEN")
+	notifyTelegramChat := os.Getenv("NOTIFY_TELEGRAM_CHAT_ID")
+	notifyTelegramAPI := os.Getenv("NOTIFY_TELEGRAM_API_URL")
+	if notifyTelegramAPI == "" {
+		notifyTelegramAPI = notify.DefaultTelegramAPIURL
+	}
+
+	// Debug sessions expire once paused or finished for their time-to-live
+	debugSessionTTL := debugger.DefaultSessionTTL
+	if ttlStr := os.Getenv("DEBUG_SESSION_TTL"); ttlStr != "" {
+		if ttl, err := time.ParseDuration(ttlStr); err == nil && ttl > 0 {
+			debugSessionTTL = ttl
+		}
+	}
+
+	debugMaxSessions := debugger.DefaultMaxSessions
+	if sessionsStr := os.Getenv("DEBUG_MAX_SESSIONS"); sessionsStr != "" {
+		if sessions, err := strconv.Atoi(sessionsStr); err == nil && sessions > 0 {
+			debugMaxSessions = sessions
+		}
+	}
+
+	// Libraries changed through other instances are picked up by polling the store
+	libraryPollInterval := library.DefaultReloadInterval
+	if intervalStr := os.Getenv("LIBRARY_RELOAD_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil && interval > 0 {
+			libraryPollInterval = interval
+		}
+	}
+
+	// Event fields as globals stay on until scripts have moved to the ctx table
+	scriptLegacyGlobals := os.Getenv("SCRIPT_LEGACY_GLOBALS") != "false"
+
 	return Config{
-		Port:               port,
-		DBURL:              dbURL,
-		NATSURL:            natsURL,
-		RedisURL:           redisURL,
-		AlertingEnabled:    alertingEnabled,
-		AlertWebhookURL:    alertWebhookURL,
-		AlertRetryAttempts: alertRetryAttempts,
+		Port:                port,
+		DBURL:               dbURL,
+		NATSURL:             natsURL,
+		RedisURL:            redisURL,
+		AlertingEnabled:     alertingEnabled,
+		AlertWebhookURL:     alertWebhookURL,
+		AlertRetryAttempts:  alertRetryAttempts,
+		ScriptTimeout:       scriptTimeout,
+		ScriptLimits:        scriptLimits,
+		ScriptPoolSize:      scriptPoolSize,
+		ScriptCacheSize:     scriptCacheSize,
+		ScriptLegacyGlobals: scriptLegacyGlobals,
+		ScriptMaxLogEntries: scriptMaxLogEntries,
+		ScriptMaxLogBytes:   scriptMaxLogBytes,
+		ScriptReplayBytes:   scriptReplayBytes,
+		ScriptCoverage:      scriptCoverage,
+		ScriptProfiling:     scriptProfiling,
+		StoreMaxKeys:        storeMaxKeys,
+		StoreMaxValueBytes:  storeMaxValueBytes,
+		WindowRetention:     windowRetention,
+		WindowMaxPoints:     windowMaxPoints,
+		WindowMaxSeries:     windowMaxSeries,
+		EventsAllowed:       eventsAllowed,
+		EventsMaxChainDepth: eventsMaxChainDepth,
+		DeviceServiceURL:    deviceServiceURL,
+		DeviceServiceToken:  deviceServiceToken,
+		DeviceSimulatorFile: deviceSimulatorFile,
+		HTTPAllowedHosts:    httpAllowedHosts,
+		HTTPDeniedHosts:     httpDeniedHosts,
+		HTTPAllowPrivate:    httpAllowPrivate,
+		HTTPMaxBodyBytes:    httpMaxBodyBytes,
+		HTTPRuleQuota:       httpRuleQuota,
+		HTTPQuotaWindow:     httpQuotaWindow,
+		SecretsKey:          secretsKey,
+		SecretsPreviousKeys: secretsPreviousKeys,
+		NotifySMTPAddr:      notifySMTPAddr,
+		NotifySMTPUser:      notifySMTPUser,
+		NotifySMTPPassword:  notifySMTPPassword,
+		NotifySMTPFrom:      notifySMTPFrom,
+		NotifySMTPTo:        notifySMTPTo,
+		NotifyWebhookURL:    notifyWebhookURL,
+		NotifyWebhookToken:  notifyWebhookToken,
+		NotifySlackURL:      notifySlackURL,
+		NotifyTelegramToken: notifyTelegramToken,
+		NotifyTelegramChat:  notifyTelegramChat,
+		NotifyTelegramAPI:   notifyTelegramAPI,
+		DebugSessionTTL:     debugSessionTTL,
+		DebugMaxSessions:    debugMaxSessions,
+		LibraryPollInterval: libraryPollInterval,
+	}
+}
+
+// envInt64 returns the positive integer stored in the named variable, or 0
+func envInt64(name string) int64 {
+	if str := os.Getenv(name); str != "" {
+		if value, err := strconv.ParseInt(str, 10, 64); err == nil && value > 0 {
+			return value
+		}
 	}
+	return 0
 }
@@ -2,10 +2,12 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,15 +16,30 @@ import (
 	"github.com/malyshevhen/rule-engine/internal/alerting"
 	"github.com/malyshevhen/rule-engine/internal/analytics"
 	"github.com/malyshevhen/rule-engine/internal/api"
+	"github.com/malyshevhen/rule-engine/internal/device"
+	"github.com/malyshevhen/rule-engine/internal/engine/actions"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/coverage"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/egress"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
 	"github.com/malyshevhen/rule-engine/internal/engine/manager"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/library"
+	"github.com/malyshevhen/rule-engine/internal/notify"
 	"github.com/malyshevhen/rule-engine/internal/queue"
 	"github.com/malyshevhen/rule-engine/internal/rule"
+	"github.com/malyshevhen/rule-engine/internal/secret"
 	"github.com/malyshevhen/rule-engine/internal/storage"
 	"github.com/malyshevhen/rule-engine/internal/storage/db"
+	"github.com/malyshevhen/rule-engine/internal/storage/kv"
 	redisClient "github.com/malyshevhen/rule-engine/internal/storage/redis"
+	"github.com/malyshevhen/rule-engine/internal/storage/series"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 	"github.com/malyshevhen/rule-engine/pkg/tracing"
 	"github.com/nats-io/nats.go"
@@ -37,6 +54,7 @@ type App struct {
 	server      *http.Server
 	manager     *manager.Manager
 	workerPool  *queue.WorkerPool
+	librarySvc  *library.Service
 	alertingSvc *alerting.Service
 	nc          *nats.Conn
 	cron        *cron.Cron
@@ -92,15 +110,108 @@ func New() *App {
 		slog.Info("Redis rate limiter initialized")
 	}
 
-	// Initialize services
-	ruleSvc := rule.NewService(sqlStore, redisCli)
-	triggerSvc := trigger.NewService(sqlStore, redisCli)
-	actionSvc := action.NewService(sqlStore)
+	// Initialize NATS connection
+	nc, err := nats.Connect(config.NATSURL)
+	if err != nil {
+		slog.Error("Failed to connect to NATS", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Connected to NATS")
+
+	// Events published by scripts and publish_event actions
+	eventPublisher := events.NewPublisher(nc, config.EventsAllowed,
+		events.WithMaxChainDepth(config.EventsMaxChainDepth))
 
 	// Initialize executor components
-	contextSvc := execCtx.NewService()
-	platformSvc := platform.NewService()
-	executorSvc := executor.NewService(contextSvc, platformSvc)
+	contextSvc := execCtx.NewService(
+		execCtx.WithCoverage(config.ScriptCoverage),
+		execCtx.WithProfiling(config.ScriptProfiling),
+	)
+	// Scripts keep their state in Redis when it is available, in Postgres otherwise
+	var scriptState interface {
+		modules.StoreBackend
+		rule.ScriptState
+	} = kv.NewPostgresStore(pool)
+	// Series of the window module are kept in Redis when it is available, in memory otherwise
+	var windowSeries interface {
+		modules.WindowBackend
+		rule.ScriptState
+	} = series.NewMemoryStore()
+	if redisCli != nil {
+		scriptState = kv.NewRedisStore(redisCli.GetClient())
+		windowSeries = series.NewRedisStore(redisCli.GetClient())
+	}
+	httpModule, err := newHTTPModule(config)
+	if err != nil {
+		slog.Error("Failed to set up the http module", "error", err)
+		os.Exit(1)
+	}
+	secretSvc, err := newSecretService(config, sqlStore)
+	if err != nil {
+		slog.Error("Failed to set up secrets", "error", err)
+		os.Exit(1)
+	}
+	platformOpts := []platform.ServiceOption{
+		platform.WithModule(httpModule),
+		platform.WithModule(modules.NewSecretsModule(secretSvc)),
+		platform.WithModule(modules.NewStoreModule(scriptState,
+			modules.WithStoreMaxKeys(config.StoreMaxKeys),
+			modules.WithStoreMaxValueBytes(config.StoreMaxValueBytes),
+		)),
+		platform.WithModule(modules.NewWindowModule(windowSeries,
+			modules.WithWindowRetention(config.WindowRetention),
+			modules.WithWindowMaxPoints(config.WindowMaxPoints),
+			modules.WithWindowMaxSeries(config.WindowMaxSeries),
+		)),
+		platform.WithModule(modules.NewEventsModule(eventPublisher)),
+	}
+	notifier, err := newNotifier(config)
+	if err != nil {
+		slog.Error("Failed to set up notifications", "error", err)
+		os.Exit(1)
+	}
+	platformOpts = append(platformOpts, platform.WithModule(modules.NewNotifyModule(notifier)))
+	deviceClient, err := newDeviceClient(config)
+	if err != nil {
+		slog.Error("Failed to set up the device client", "error", err)
+		os.Exit(1)
+	}
+	if deviceClient != nil {
+		platformOpts = append(platformOpts, platform.WithModule(modules.NewDeviceModule(deviceClient)))
+	} else {
+		slog.Warn("No device service configured, the device module is not available to scripts")
+	}
+	platformSvc := platform.NewService(platformOpts...)
+	coverageCollector := coverage.NewCollector(coverage.DefaultMaxScripts)
+	profileCollector := profile.NewCollector(profile.DefaultMaxRules)
+	executorSvc := executor.NewService(contextSvc, platformSvc,
+		executor.WithTimeout(config.ScriptTimeout),
+		executor.WithLimits(config.ScriptLimits),
+		executor.WithStatePoolSize(config.ScriptPoolSize),
+		executor.WithScriptCacheSize(config.ScriptCacheSize),
+		executor.WithLegacyGlobals(config.ScriptLegacyGlobals),
+		executor.WithLogLimits(config.ScriptMaxLogEntries, config.ScriptMaxLogBytes),
+		executor.WithReplayRecording(config.ScriptReplayBytes),
+		executor.WithCoverageRecorder(coverageCollector),
+		executor.WithProfileRecorder(profileCollector),
+	)
+
+	// Initialize services; script updates evict compiled scripts from the executor cache
+	ruleSvc := rule.NewService(sqlStore, redisCli,
+		rule.WithScriptCache(executorSvc),
+		rule.WithScriptState(scriptState),
+		rule.WithScriptState(windowSeries),
+	)
+	triggerSvc := trigger.NewService(sqlStore, redisCli, trigger.WithScriptCache(executorSvc))
+	actionSvc := action.NewService(sqlStore, action.WithScriptCache(executorSvc))
+	executionSvc := execution.NewService(sqlStore, execution.WithReplay(executorSvc, ruleSvc))
+	librarySvc := library.NewService(sqlStore, library.WithRegistry(executorSvc))
+	if err := librarySvc.Load(ctx); err != nil {
+		slog.Error("Failed to load shared libraries, scripts requiring them will fail", "error", err)
+	}
+	debugSvc := debugger.NewManager(executorSvc,
+		debugger.WithSessionTTL(config.DebugSessionTTL),
+		debugger.WithMaxSessions(config.DebugMaxSessions))
 
 	// Initialize trigger evaluator
 	triggerEval := trigger.NewEvaluator(executorSvc)
@@ -115,7 +226,13 @@ func New() *App {
 		slog.Info("Using in-memory execution queue")
 	}
 
-	workerPool := queue.NewWorkerPool(execQueue, ruleSvc, executorSvc, 5)
+	// Queued and synchronous executions are recorded and run their actions alike
+	actionDispatcher := actions.NewDispatcher(
+		actions.WithExecutionRecorder(executionSvc),
+		actions.WithEventPublisher(eventPublisher),
+		actions.WithNotificationSender(notifier))
+
+	workerPool := queue.NewWorkerPool(execQueue, ruleSvc, executorSvc, 5, queue.WithActions(actionDispatcher))
 	workerPool.Start(ctx)
 
 	// Initialize alerting service
@@ -129,26 +246,20 @@ func New() *App {
 	// Initialize analytics service
 	analyticsSvc := analytics.NewService()
 
-	// Initialize NATS connection
-	nc, err := nats.Connect(config.NATSURL)
-	if err != nil {
-		slog.Error("Failed to connect to NATS", "error", err)
-		os.Exit(1)
-	}
-	slog.Info("Connected to NATS")
-
 	// Initialize cron scheduler
 	c := cron.New()
 
 	// Initialize trigger manager
-	mgr := manager.NewManager(nc, c, ruleSvc, triggerSvc, triggerEval, executorSvc, alertingSvc, execQueue)
+	mgr := manager.NewManager(nc, c, ruleSvc, triggerSvc, triggerEval, executorSvc, alertingSvc, execQueue,
+		manager.WithActions(actionDispatcher))
 
 	// Initialize Health Check service
 	healthSvc := api.NewHealth(pool, redisCli)
 
 	// Initialize HTTP server
 	serverConfig := &api.ServerConfig{Port: config.Port}
-	server := api.NewServer(serverConfig, healthSvc, ruleSvc, triggerSvc, actionSvc, analyticsSvc, executorSvc, true)
+	scriptValidator := validation.NewValidator(platformSvc.ModuleNames()...).WithModuleSource(platformSvc)
+	server := api.NewServer(serverConfig, healthSvc, ruleSvc, triggerSvc, actionSvc, librarySvc, secretSvc, analyticsSvc, executorSvc, executionSvc, debugSvc, coverageCollector, profileCollector, scriptValidator, true)
 
 	return &App{
 		config:      config,
@@ -157,12 +268,138 @@ func New() *App {
 		server:      server,
 		manager:     mgr,
 		workerPool:  workerPool,
+		librarySvc:  librarySvc,
 		alertingSvc: alertingSvc,
 		nc:          nc,
 		cron:        c,
 	}
 }
 
+// newHTTPModule returns the http module, restricted by the egress policy and
+// the per-rule quota of the configuration
+func newHTTPModule(config Config) (*modules.HTTPModule, error) {
+	allowed, err := egress.ParseRules(config.HTTPAllowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("allowed hosts: %w", err)
+	}
+	denied, err := egress.ParseRules(config.HTTPDeniedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("denied hosts: %w", err)
+	}
+	opts := []modules.HTTPModuleOption{
+		modules.WithHTTPPolicy(egress.NewPolicy(
+			egress.WithAllow(allowed),
+			egress.WithDeny(denied),
+			egress.WithPrivateNetworks(config.HTTPAllowPrivate),
+		)),
+		modules.WithHTTPMaxResponseBytes(int64(config.HTTPMaxBodyBytes)),
+	}
+	if config.HTTPRuleQuota > 0 {
+		opts = append(opts, modules.WithHTTPRuleQuota(egress.NewQuota(config.HTTPRuleQuota, config.HTTPQuotaWindow)))
+	}
+	return modules.NewHTTPModule(opts...), nil
+}
+
+// newSecretService returns the secret service, encrypting with the master keys
+// of the configuration
+func newSecretService(config Config, store secret.Store) (*secret.Service, error) {
+	if config.SecretsKey == "" {
+		slog.Warn("No secrets master key configured, secrets cannot be written or read by scripts")
+		return secret.NewService(store), nil
+	}
+	primary, err := secret.ParseKey(config.SecretsKey)
+	if err != nil {
+		return nil, err
+	}
+	var previous [][]byte
+	for _, encoded := range strings.Split(config.SecretsPreviousKeys, ",") {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		key, err := secret.ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("previous key: %w", err)
+		}
+		previous = append(previous, key)
+	}
+	keyring, err := secret.NewKeyring(primary, previous...)
+	if err != nil {
+		return nil, err
+	}
+	return secret.NewService(store, secret.WithKeyring(keyring)), nil
+}
+
+// newNotifier returns the dispatcher of the notification channels of the
+// configuration: email, webhook, slack and telegram
+func newNotifier(config Config) (*notify.Dispatcher, error) {
+	var opts []notify.DispatcherOption
+	if config.NotifySMTPAddr != "" {
+		var smtpOpts []notify.SMTPOption
+		if config.NotifySMTPUser != "" {
+			smtpOpts = append(smtpOpts, notify.WithSMTPAuth(config.NotifySMTPUser, config.NotifySMTPPassword))
+		}
+		var recipients []string
+		for _, recipient := range strings.Split(config.NotifySMTPTo, ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				recipients = append(recipients, recipient)
+			}
+		}
+		if len(recipients) > 0 {
+			smtpOpts = append(smtpOpts, notify.WithSMTPRecipients(recipients...))
+		}
+		notifier, err := notify.NewSMTPNotifier(config.NotifySMTPAddr, config.NotifySMTPFrom, smtpOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("email channel: %w", err)
+		}
+		opts = append(opts, notify.WithChannel("email", notifier))
+	}
+	if config.NotifyWebhookURL != "" {
+		var webhookOpts []notify.WebhookOption
+		if config.NotifyWebhookToken != "" {
+			webhookOpts = append(webhookOpts, notify.WithWebhookHeader("Authorization", "Bearer "+config.NotifyWebhookToken))
+		}
+		notifier, err := notify.NewWebhookNotifier(config.NotifyWebhookURL, webhookOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("webhook channel: %w", err)
+		}
+		opts = append(opts, notify.WithChannel("webhook", notifier))
+	}
+	if config.NotifySlackURL != "" {
+		notifier, err := notify.NewSlackNotifier(config.NotifySlackURL)
+		if err != nil {
+			return nil, fmt.Errorf("slack channel: %w", err)
+		}
+		opts = append(opts, notify.WithChannel("slack", notifier))
+	}
+	if config.NotifyTelegramToken != "" {
+		notifier, err := notify.NewTelegramNotifier(config.NotifyTelegramToken, config.NotifyTelegramChat,
+			notify.WithTelegramAPIURL(config.NotifyTelegramAPI))
+		if err != nil {
+			return nil, fmt.Errorf("telegram channel: %w", err)
+		}
+		opts = append(opts, notify.WithChannel("telegram", notifier))
+	}
+	dispatcher := notify.NewDispatcher(opts...)
+	slog.Info("Notification channels configured", "channels", dispatcher.Channels())
+	return dispatcher, nil
+}
+
+// newDeviceClient returns the client of the devices scripts reach with the
+// device module, or nil when none is configured
+func newDeviceClient(config Config) (modules.DeviceClient, error) {
+	switch {
+	case config.DeviceClient != nil:
+		return config.DeviceClient, nil
+	case config.DeviceServiceURL != "":
+		slog.Info("Using the device service", "url", config.DeviceServiceURL)
+		return device.NewHTTPClient(config.DeviceServiceURL, device.WithToken(config.DeviceServiceToken)), nil
+	case config.DeviceSimulatorFile != "":
+		slog.Info("Simulating devices", "file", config.DeviceSimulatorFile)
+		return device.LoadFake(config.DeviceSimulatorFile)
+	}
+	return nil, nil
+}
+
 // Run starts the application
 func (a *App) Run() error {
 	slog.Info("Starting rule engine app", "port", a.config.Port)
@@ -178,6 +415,11 @@ func (a *App) Run() error {
 	}
 	slog.Info("Trigger manager started")
 
+	// Pick up the library changes made through other instances
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	go a.librarySvc.Watch(watchCtx, a.config.LibraryPollInterval)
+
 	// Redis rate limiter is already initialized and doesn't require cleanup
 
 	// Start server in a goroutine
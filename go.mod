@@ -3,6 +3,7 @@ module github.com/malyshevhen/rule-engine
 go 1.24.6
 
 require (
+	github.com/alicebob/miniredis/v2 v2.35.0
 	github.com/evanphx/json-patch/v5 v5.9.11
 	github.com/go-playground/validator/v10 v10.27.0
 	github.com/go-redis/redis_rate/v10 v10.0.1
@@ -19,7 +20,7 @@ require (
 	github.com/stretchr/testify v1.11.1
 	github.com/testcontainers/testcontainers-go v0.38.0
 	github.com/testcontainers/testcontainers-go/modules/compose v0.38.0
-	github.com/yuin/gopher-lua v1.1.1
+	github.com/yuin/gopher-lua v1.1.1 // pinned: internal/engine/executor/frame.go mirrors its private layout
 	go.opentelemetry.io/otel v1.38.0
 	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.38.0
 	go.opentelemetry.io/otel/sdk v1.38.0
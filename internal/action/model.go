@@ -6,6 +6,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// Action types
+const (
+	// TypeLuaScript runs the Lua script held in the params
+	TypeLuaScript = "lua_script"
+	// TypeExecuteRule runs the rule whose ID is held in the params
+	TypeExecuteRule = "execute_rule"
+	// TypePublishEvent publishes an event described by the JSON params
+	TypePublishEvent = "publish_event"
+	// TypeNotify sends a notification described by the JSON params
+	TypeNotify = "notify"
+)
+
 // Action represents an action in the business domain
 type Action struct {
 	ID        uuid.UUID `json:"id"`
@@ -23,23 +23,45 @@ type Store interface {
 	GetStore() *storage.Store
 }
 
+// ScriptCache drops compiled scripts that are no longer current
+type ScriptCache interface {
+	InvalidateScript(script string)
+}
+
 // Service handles business logic for actions
 type Service struct {
-	store Store
+	store   Store
+	scripts ScriptCache
+}
+
+// ServiceOption allows to configure the action service
+type ServiceOption func(s *Service) *Service
+
+// WithScriptCache sets the cache invalidated when an action script changes
+func WithScriptCache(scripts ScriptCache) ServiceOption {
+	return func(s *Service) *Service {
+		s.scripts = scripts
+		return s
+	}
 }
 
 // NewService creates a new action service
-func NewService(store Store) *Service {
-	return &Service{store: store}
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Create creates a new action
 func (s *Service) Create(ctx context.Context, action *Action) error {
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
+		actionType, params := storedParams(action)
 		storageAction := &actionStorage.Action{
 			Name:    action.Name,
-			Type:    "lua_script",
-			Params:  action.LuaScript,
+			Type:    actionType,
+			Params:  params,
 			Enabled: action.Enabled,
 		}
 		err := q.ActionRepository.Create(ctx, storageAction)
@@ -71,7 +93,7 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Action, error) {
 		UpdatedAt: storageAction.UpdatedAt,
 	}
 	// For backward compatibility
-	if storageAction.Type == "lua_script" {
+	if storageAction.Type == TypeLuaScript {
 		action.LuaScript = storageAction.Params
 	}
 
@@ -97,7 +119,7 @@ func (s *Service) List(ctx context.Context, limit, offset int) ([]*Action, int,
 			UpdatedAt: storageAction.UpdatedAt,
 		}
 		// For backward compatibility
-		if storageAction.Type == "lua_script" {
+		if storageAction.Type == TypeLuaScript {
 			action.LuaScript = storageAction.Params
 		}
 		actions[i] = action
@@ -109,20 +131,67 @@ func (s *Service) List(ctx context.Context, limit, offset int) ([]*Action, int,
 // Update modifies an existing action
 func (s *Service) Update(ctx context.Context, action *Action) error {
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
+		actionType, params := storedParams(action)
 		storageAction := &actionStorage.Action{
 			ID:      action.ID,
 			Name:    action.Name,
-			Type:    "lua_script",
-			Params:  action.LuaScript,
+			Type:    actionType,
+			Params:  params,
 			Enabled: action.Enabled,
 		}
-		return q.ActionRepository.Update(ctx, storageAction)
+		previousScript, err := s.currentScript(ctx, q, action.ID)
+		if err != nil {
+			return err
+		}
+		if err := q.ActionRepository.Update(ctx, storageAction); err != nil {
+			return err
+		}
+		if previousScript != params {
+			s.invalidateScript(previousScript)
+		}
+		return nil
 	})
 }
 
 // Delete removes an action
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
-		return q.ActionRepository.Delete(ctx, id)
+		previousScript, err := s.currentScript(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		if err := q.ActionRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+		s.invalidateScript(previousScript)
+		return nil
 	})
 }
+
+// storedParams returns the type and params an action is stored with.
+// Actions without a type are Lua scripts, whose params are the script.
+func storedParams(action *Action) (string, string) {
+	if action.Type == "" || action.Type == TypeLuaScript {
+		return TypeLuaScript, action.LuaScript
+	}
+	return action.Type, action.Params
+}
+
+// currentScript returns the stored script of an action when a script cache is configured
+func (s *Service) currentScript(ctx context.Context, q *storage.Store, id uuid.UUID) (string, error) {
+	if s.scripts == nil {
+		return "", nil
+	}
+	storageAction, err := q.ActionRepository.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return storageAction.Params, nil
+}
+
+// invalidateScript drops the compiled form of a replaced or deleted script
+func (s *Service) invalidateScript(script string) {
+	if s.scripts != nil && script != "" {
+		s.scripts.InvalidateScript(script)
+	}
+}
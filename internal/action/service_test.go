@@ -118,6 +118,28 @@ func TestService_Create(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
+func TestService_Create_PublishEvent(t *testing.T) {
+	mockStore := newMockSQLStore()
+	service := NewService(mockStore)
+
+	action := &Action{
+		Type:    TypePublishEvent,
+		Params:  `{"subject": "events.derived.overheat"}`,
+		Enabled: true,
+	}
+
+	mockStore.actionRepo.(*mockActionRepository).On("Create", mock.Anything, mock.MatchedBy(func(a *actionStorage.Action) bool {
+		return a.Type == TypePublishEvent && a.Params == action.Params
+	})).Return(nil)
+
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.Create(context.Background(), action)
+
+	assert.NoError(t, err)
+	mockStore.actionRepo.(*mockActionRepository).AssertExpectations(t)
+}
+
 func TestService_Create_Error(t *testing.T) {
 	mockStore := newMockSQLStore()
 	service := NewService(mockStore)
@@ -177,3 +199,35 @@ func TestService_GetByID_Error(t *testing.T) {
 	assert.Nil(t, action)
 	mockStore.actionRepo.(*mockActionRepository).AssertExpectations(t)
 }
+
+// mockScriptCache is a mock implementation of the ScriptCache interface
+type mockScriptCache struct {
+	mock.Mock
+}
+
+func (m *mockScriptCache) InvalidateScript(script string) {
+	m.Called(script)
+}
+
+func TestService_Update_InvalidatesScript(t *testing.T) {
+	mockStore := newMockSQLStore()
+	scripts := &mockScriptCache{}
+	service := NewService(mockStore, WithScriptCache(scripts))
+
+	action := &Action{
+		ID:        uuid.New(),
+		LuaScript: "print('updated')",
+		Enabled:   true,
+	}
+
+	mockStore.actionRepo.(*mockActionRepository).On("GetByID", mock.Anything, action.ID).Return(&actionStorage.Action{ID: action.ID, Type: "lua_script", Params: "print('original')"}, nil)
+	mockStore.actionRepo.(*mockActionRepository).On("Update", mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+	scripts.On("InvalidateScript", "print('original')").Return()
+
+	err := service.Update(context.Background(), action)
+
+	assert.NoError(t, err)
+	mockStore.actionRepo.(*mockActionRepository).AssertExpectations(t)
+	scripts.AssertExpectations(t)
+}
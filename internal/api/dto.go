@@ -1,11 +1,21 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/malyshevhen/rule-engine/internal/action"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/coverage"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/library"
 	"github.com/malyshevhen/rule-engine/internal/rule"
+	"github.com/malyshevhen/rule-engine/internal/secret"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 )
 
@@ -13,6 +23,7 @@ import (
 type APIConfig struct {
 	MaxRuleNameLength  int
 	MaxLuaScriptLength int
+	MaxScriptTimeoutMs int
 	DefaultRulesLimit  int
 	MaxRulesLimit      int
 	DefaultRulesOffset int
@@ -23,6 +34,7 @@ func DefaultAPIConfig() *APIConfig {
 	return &APIConfig{
 		MaxRuleNameLength:  255,
 		MaxLuaScriptLength: 10000,
+		MaxScriptTimeoutMs: 60000,
 		DefaultRulesLimit:  50,
 		MaxRulesLimit:      1000,
 		DefaultRulesOffset: 0,
@@ -34,15 +46,25 @@ var apiConfig = DefaultAPIConfig()
 
 // RuleInfo represents a rule for API responses
 type RuleInfo struct {
-	ID        uuid.UUID     `json:"id"`
-	Name      string        `json:"name"`
-	LuaScript string        `json:"lua_script"`
-	Priority  int           `json:"priority"`
-	Enabled   bool          `json:"enabled"`
-	Triggers  []TriggerInfo `json:"triggers"`
-	Actions   []ActionInfo  `json:"actions"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID        uuid.UUID       `json:"id"`
+	Name      string          `json:"name"`
+	LuaScript string          `json:"lua_script"`
+	Priority  int             `json:"priority"`
+	Enabled   bool            `json:"enabled"`
+	TimeoutMs int             `json:"timeout_ms"`
+	Limits    ExecutionLimits `json:"limits"`
+	Triggers  []TriggerInfo   `json:"triggers"`
+	Actions   []ActionInfo    `json:"actions"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ExecutionLimits represents per-rule sandbox limit overrides (0 uses the engine default)
+type ExecutionLimits struct {
+	MaxInstructions   int64 `json:"max_instructions,omitempty" validate:"min=0" example:"1000000"`
+	MaxCallStackDepth int   `json:"max_call_stack_depth,omitempty" validate:"min=0" example:"64"`
+	MaxRegistrySize   int   `json:"max_registry_size,omitempty" validate:"min=0" example:"8192"`
+	MaxMemoryBytes    int64 `json:"max_memory_bytes,omitempty" validate:"min=0" example:"8388608"`
 }
 
 // TriggerInfo represents a trigger for API responses
@@ -51,35 +73,43 @@ type TriggerInfo struct {
 	RuleID          uuid.UUID `json:"rule_id"`
 	Type            string    `json:"type"`
 	ConditionScript string    `json:"condition_script"`
+	Language        string    `json:"language" example:"lua"`
 	Enabled         bool      `json:"enabled"`
+	TimeoutMs       int       `json:"timeout_ms"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // ActionInfo represents an action for API responses
 type ActionInfo struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	LuaScript string    `json:"lua_script"`
-	Enabled   bool      `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID       `json:"id"`
+	Name      string          `json:"name"`
+	Type      string          `json:"type" example:"lua_script"` // lua_script, publish_event or notify
+	LuaScript string          `json:"lua_script"`
+	Params    json.RawMessage `json:"params,omitempty" swaggertype:"object"` // params of publish_event and notify actions
+	Enabled   bool            `json:"enabled"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 // CreateRuleRequest represents a request to create a rule
 type CreateRuleRequest struct {
-	Name      string `json:"name" validate:"required,rule_name_length" example:"Temperature Alert Rule"`
-	LuaScript string `json:"lua_script" validate:"required,lua_script_length" example:"if event.temperature > 25 then return true end"`
-	Priority  *int   `json:"priority,omitempty" example:"0"`
-	Enabled   *bool  `json:"enabled,omitempty" example:"true"`
+	Name      string           `json:"name" validate:"required,rule_name_length" example:"Temperature Alert Rule"`
+	LuaScript string           `json:"lua_script" validate:"required,lua_script_length" example:"if event.temperature > 25 then return true end"`
+	Priority  *int             `json:"priority,omitempty" example:"0"`
+	Enabled   *bool            `json:"enabled,omitempty" example:"true"`
+	TimeoutMs *int             `json:"timeout_ms,omitempty" validate:"omitempty,script_timeout" example:"2000"`
+	Limits    *ExecutionLimits `json:"limits,omitempty"`
 }
 
 // UpdateRuleRequest represents a request to update a rule
 type UpdateRuleRequest struct {
-	Name      *string `json:"name,omitempty" validate:"omitempty,rule_name_length" example:"Updated Rule Name"`
-	LuaScript *string `json:"lua_script,omitempty" validate:"omitempty,lua_script_length" example:"if event.temperature > 30 then return true end"`
-	Priority  *int    `json:"priority,omitempty" example:"5"`
-	Enabled   *bool   `json:"enabled,omitempty" example:"false"`
+	Name      *string          `json:"name,omitempty" validate:"omitempty,rule_name_length" example:"Updated Rule Name"`
+	LuaScript *string          `json:"lua_script,omitempty" validate:"omitempty,lua_script_length" example:"if event.temperature > 30 then return true end"`
+	Priority  *int             `json:"priority,omitempty" example:"5"`
+	Enabled   *bool            `json:"enabled,omitempty" example:"false"`
+	TimeoutMs *int             `json:"timeout_ms,omitempty" validate:"omitempty,script_timeout" example:"2000"`
+	Limits    *ExecutionLimits `json:"limits,omitempty"`
 }
 
 // CreateTriggerRequest represents a request to create a trigger
@@ -87,29 +117,219 @@ type CreateTriggerRequest struct {
 	RuleID          uuid.UUID `json:"rule_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Type            string    `json:"type" validate:"required,oneof=CONDITIONAL CRON" example:"CONDITIONAL"`
 	ConditionScript string    `json:"condition_script" validate:"required,lua_script_length" example:"if event.device_id == 'sensor_1' then return true end"`
+	Language        string    `json:"language,omitempty" validate:"omitempty,oneof=lua expr" example:"lua"` // lua (default) or expr
 	Enabled         *bool     `json:"enabled,omitempty" example:"true"`
+	TimeoutMs       *int      `json:"timeout_ms,omitempty" validate:"omitempty,script_timeout" example:"500"`
 }
 
 // CreateActionRequest represents a request to create an action
 type CreateActionRequest struct {
-	Name      string `json:"name" example:"Send Temperature Alert"`
-	LuaScript string `json:"lua_script" validate:"required,lua_script_length" example:"log_message('info', 'Temperature alert triggered')"`
-	Enabled   *bool  `json:"enabled,omitempty" example:"true"`
+	Name      string          `json:"name" example:"Send Temperature Alert"`
+	Type      string          `json:"type,omitempty" validate:"omitempty,oneof=lua_script publish_event notify" example:"lua_script"` // lua_script (default), publish_event or notify
+	LuaScript string          `json:"lua_script,omitempty" validate:"omitempty,lua_script_length" example:"log_message('info', 'Temperature alert triggered')"`
+	Params    json.RawMessage `json:"params,omitempty" swaggertype:"object"` // templated params of publish_event and notify actions
+	Enabled   *bool           `json:"enabled,omitempty" example:"true"`
+}
+
+// CreateLibraryRequest represents a request to create a shared Lua library
+type CreateLibraryRequest struct {
+	Name        string `json:"name" validate:"required,library_name" example:"thresholds"`
+	Description string `json:"description,omitempty" example:"Comfort thresholds shared by the climate rules"`
+	Code        string `json:"code" validate:"required,lua_script_length" example:"local M = {}\nfunction M.too_hot(t) return t > 28 end\nreturn M"`
+}
+
+// LibraryInfo represents a shared Lua library for API responses
+type LibraryInfo struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name" example:"thresholds"` // scripts load the library with require(name)
+	Description string    `json:"description" example:"Comfort thresholds shared by the climate rules"`
+	Code        string    `json:"code" example:"local M = {}\nfunction M.too_hot(t) return t > 28 end\nreturn M"`
+	Version     int       `json:"version" example:"3"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// EvaluateScriptRequest represents a request to evaluate a Lua script
+// LibraryVersionInfo represents a version of the code of a library for API responses
+type LibraryVersionInfo struct {
+	LibraryID uuid.UUID `json:"library_id"`
+	Version   int       `json:"version" example:"3"`
+	Code      string    `json:"code" example:"local M = {}\nfunction M.too_hot(t) return t > 28 end\nreturn M"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateSecretRequest represents a request to create a secret
+type CreateSecretRequest struct {
+	Name        string      `json:"name" validate:"required,secret_name" example:"weather_api_key"`
+	Description string      `json:"description,omitempty" example:"Key of the weather API"`
+	Value       string      `json:"value" validate:"required,max=65536" example:"0123456789abcdef"` // never returned by the API
+	RuleIDs     []uuid.UUID `json:"rule_ids,omitempty"`                                             // rules whose scripts may read the secret
+}
+
+// UpdateSecretRequest represents a request to update a secret.
+// The description and the rules are replaced, the value only when it is given.
+type UpdateSecretRequest struct {
+	Description string      `json:"description" example:"Key of the weather API"`
+	Value       *string     `json:"value,omitempty" validate:"omitempty,min=1,max=65536" example:"fedcba9876543210"`
+	RuleIDs     []uuid.UUID `json:"rule_ids"`
+}
+
+// SecretInfo represents the metadata of a secret for API responses, without its value
+type SecretInfo struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name" example:"weather_api_key"` // scripts read the secret with secrets.get(name)
+	Description string      `json:"description" example:"Key of the weather API"`
+	RuleIDs     []uuid.UUID `json:"rule_ids"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// EvaluateScriptRequest represents a request to evaluate a Lua script or an expression
 type EvaluateScriptRequest struct {
-	Script  string         `json:"script" validate:"required,lua_script_length" example:"return 2 + 2"`
-	Context map[string]any `json:"context,omitempty"`
+	Script   string         `json:"script" validate:"required,lua_script_length" example:"return 2 + 2"`
+	Language string         `json:"language,omitempty" validate:"omitempty,oneof=lua expr" example:"lua"` // lua (default) or expr
+	Context  map[string]any `json:"context,omitempty"`
+	Coverage bool           `json:"coverage,omitempty" example:"true"` // count the lines the script runs
+	Profile  bool           `json:"profile,omitempty" example:"true"`  // time the script and its module calls
 }
 
 // EvaluateScriptResponse represents the result of script evaluation
 type EvaluateScriptResponse struct {
-	Success  bool   `json:"success" example:"true"`
-	Result   any    `json:"result,omitempty"`
-	Output   []any  `json:"output,omitempty"`
-	Error    string `json:"error,omitempty" example:"syntax error"`
-	Duration string `json:"duration" example:"1.5ms"`
+	Success       bool                  `json:"success" example:"true"`
+	Status        string                `json:"status" example:"SUCCESS"`
+	Result        any                   `json:"result,omitempty"` // first value returned by the script
+	Output        []any                 `json:"output,omitempty"` // every value returned by the script
+	Error         string                `json:"error,omitempty" example:"syntax error"`
+	Duration      string                `json:"duration" example:"1.5ms"`
+	Logs          []ScriptLogEntry      `json:"logs,omitempty"` // lines printed or logged by the script
+	LogsTruncated bool                  `json:"logs_truncated,omitempty"`
+	Coverage      map[int]int           `json:"coverage,omitempty"` // times each line ran, when requested
+	Profile       *ExecutionProfileInfo `json:"profile,omitempty"`  // where the time went, when requested
+}
+
+// ExecutionProfileInfo breaks the duration of an execution down into the time
+// spent running the script and in each platform module function
+type ExecutionProfileInfo struct {
+	TotalMs float64           `json:"total_ms" example:"42.5"`
+	VMMs    float64           `json:"vm_ms" example:"2.1"`
+	Calls   []ProfileCallInfo `json:"calls"` // slowest first
+}
+
+// ProfileCallInfo represents the time spent in one platform module function
+type ProfileCallInfo struct {
+	Name       string  `json:"name" example:"http.get"`
+	Count      int64   `json:"count" example:"2"`
+	DurationMs float64 `json:"duration_ms" example:"40.4"`
+	AverageMs  float64 `json:"average_ms,omitempty" example:"20.2"` // per call, in rule profiles
+}
+
+// RuleProfileInfo represents the aggregated profile of the executions of a rule,
+// its trigger conditions and actions
+type RuleProfileInfo struct {
+	RuleID    string            `json:"rule_id"`
+	Runs      int64             `json:"runs" example:"12"` // executions recorded with profiling
+	TotalMs   float64           `json:"total_ms" example:"510"`
+	AverageMs float64           `json:"average_ms" example:"42.5"`
+	MaxMs     float64           `json:"max_ms" example:"120.3"`
+	VMMs      float64           `json:"vm_ms" example:"25.2"`
+	Calls     []ProfileCallInfo `json:"calls"` // slowest first
+	LastRunAt *time.Time        `json:"last_run_at,omitempty"`
+}
+
+// ScriptLogEntry represents a line printed or logged by a script
+type ScriptLogEntry struct {
+	Level     string    `json:"level" example:"info"`
+	Message   string    `json:"message" example:"temperature is 28.5"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ExecutionInfo represents a recorded rule execution for API responses
+type ExecutionInfo struct {
+	ID            int64            `json:"id" example:"42"`
+	RuleID        uuid.UUID        `json:"rule_id"`
+	TriggerID     *uuid.UUID       `json:"trigger_id,omitempty"`
+	Status        string           `json:"status" example:"SUCCESS"`
+	TriggeredAt   time.Time        `json:"triggered_at"`
+	DurationMs    int64            `json:"duration_ms" example:"3"`
+	Output        []any            `json:"output,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	Logs          []ScriptLogEntry `json:"logs,omitempty"`
+	LogsTruncated bool             `json:"logs_truncated,omitempty"`
+	Replayable    bool             `json:"replayable" example:"true"` // the inputs of the execution were recorded
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// ReplayExecutionRequest represents a request to replay a recorded execution
+type ReplayExecutionRequest struct {
+	// Script is run instead of the current script of the rule when set
+	Script string `json:"script,omitempty" validate:"omitempty,lua_script_length" example:"return ctx.event.payload.temperature > 30"`
+}
+
+// ReplayExecutionResponse represents the outcome of replaying a recorded execution
+type ReplayExecutionResponse struct {
+	ExecutionID    int64                   `json:"execution_id" example:"42"`
+	Script         string                  `json:"script" example:"current" enums:"current,proposed"`
+	Result         *EvaluateScriptResponse `json:"result"`
+	OriginalStatus string                  `json:"original_status" example:"SUCCESS"`
+	OriginalOutput []any                   `json:"original_output,omitempty"`
+	Matches        bool                    `json:"matches" example:"true"` // same status and output as the recorded execution
+	Divergences    []string                `json:"divergences,omitempty"`  // calls the recording could not serve
+}
+
+// ScriptCoverageInfo represents the line coverage of a script for API responses
+type ScriptCoverageInfo struct {
+	ScriptHash      string             `json:"script_hash"`
+	Runs            int                `json:"runs" example:"12"` // executions recorded with coverage
+	ExecutableLines int                `json:"executable_lines" example:"8"`
+	CoveredLines    int                `json:"covered_lines" example:"6"`
+	Percent         float64            `json:"percent" example:"75"`
+	Lines           []LineCoverageInfo `json:"lines"` // every executable line, in source order
+	LastRunAt       *time.Time         `json:"last_run_at,omitempty"`
+}
+
+// LineCoverageInfo represents how many times a script line ran
+type LineCoverageInfo struct {
+	Line int   `json:"line" example:"3"`
+	Hits int64 `json:"hits" example:"12"`
+}
+
+// StartDebugSessionRequest represents a request to run a Lua script under the debugger
+type StartDebugSessionRequest struct {
+	Script      string         `json:"script" validate:"required,lua_script_length" example:"local x = 1\nreturn x + 1"`
+	Context     map[string]any `json:"context,omitempty"`
+	Breakpoints []int          `json:"breakpoints,omitempty" validate:"dive,gt=0" example:"2"`
+	StopOnEntry bool           `json:"stop_on_entry,omitempty" example:"true"` // pause before the first line
+}
+
+// SetBreakpointsRequest represents a request to replace the breakpoints of a debug session
+type SetBreakpointsRequest struct {
+	Breakpoints []int `json:"breakpoints" validate:"dive,gt=0" example:"2"`
+}
+
+// DebugSessionInfo represents the state of a debug session for API responses
+type DebugSessionInfo struct {
+	ID          string                  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status      string                  `json:"status" example:"paused"` // running, paused or finished
+	Reason      string                  `json:"reason,omitempty" example:"breakpoint"`
+	Line        int                     `json:"line,omitempty" example:"2"`
+	Breakpoints []int                   `json:"breakpoints"`
+	Stack       []DebugFrame            `json:"stack,omitempty"`     // innermost frame first
+	Result      *EvaluateScriptResponse `json:"result,omitempty"`    // set once the script has finished
+	ExpiresAt   time.Time               `json:"expires_at,omitzero"` // unset while the script runs
+}
+
+// DebugFrame represents one level of the call stack of a paused script
+type DebugFrame struct {
+	Function string          `json:"function" example:"main chunk"`
+	Source   string          `json:"source" example:"<string>"`
+	Line     int             `json:"line,omitempty" example:"2"`
+	Locals   []DebugVariable `json:"locals,omitempty"`
+	Upvalues []DebugVariable `json:"upvalues,omitempty"`
+}
+
+// DebugVariable represents a local or upvalue of a paused script
+type DebugVariable struct {
+	Name  string `json:"name" example:"x"`
+	Value any    `json:"value"`
 }
 
 // AddActionToRuleRequest represents a request to add an action to a rule
@@ -122,6 +342,28 @@ type APIErrorResponse struct {
 	Error string `json:"error" example:"Error message"`
 }
 
+// ScriptDiagnostic describes a problem found by static validation of a script
+type ScriptDiagnostic struct {
+	Field    string `json:"field" example:"lua_script"`
+	Severity string `json:"severity" example:"error"`
+	Code     string `json:"code" example:"syntax_error"`
+	Message  string `json:"message" example:"unexpected symbol near 'then'"`
+	Line     int    `json:"line,omitempty" example:"3"`
+	Column   int    `json:"column,omitempty" example:"12"`
+}
+
+// ScriptValidationError represents the body of a script validation error
+type ScriptValidationError struct {
+	Code        string             `json:"code" example:"SCRIPT_VALIDATION_ERROR"`
+	Message     string             `json:"message" example:"Script validation failed"`
+	Diagnostics []ScriptDiagnostic `json:"diagnostics"`
+}
+
+// ScriptValidationErrorResponse represents a script validation error response for API documentation
+type ScriptValidationErrorResponse struct {
+	Error ScriptValidationError `json:"error"`
+}
+
 // JSON Patch (RFC 6902) types
 
 // PatchOperation represents a single JSON Patch operation
@@ -144,6 +386,8 @@ func RuleToRuleInfo(r *rule.Rule) *RuleInfo {
 		LuaScript: r.LuaScript,
 		Priority:  r.Priority,
 		Enabled:   r.Enabled,
+		TimeoutMs: r.TimeoutMs,
+		Limits:    LimitsToExecutionLimits(r.Limits),
 		CreatedAt: r.CreatedAt,
 		UpdatedAt: r.UpdatedAt,
 		Triggers:  make([]TriggerInfo, len(r.Triggers)),
@@ -151,6 +395,26 @@ func RuleToRuleInfo(r *rule.Rule) *RuleInfo {
 	}
 }
 
+// LimitsToExecutionLimits converts execution limits to the ExecutionLimits DTO
+func LimitsToExecutionLimits(l execCtx.Limits) ExecutionLimits {
+	return ExecutionLimits{
+		MaxInstructions:   l.MaxInstructions,
+		MaxCallStackDepth: l.MaxCallStackDepth,
+		MaxRegistrySize:   l.MaxRegistrySize,
+		MaxMemoryBytes:    l.MaxMemoryBytes,
+	}
+}
+
+// ExecutionLimitsToLimits converts the ExecutionLimits DTO to execution limits
+func ExecutionLimitsToLimits(l ExecutionLimits) execCtx.Limits {
+	return execCtx.Limits{
+		MaxInstructions:   l.MaxInstructions,
+		MaxCallStackDepth: l.MaxCallStackDepth,
+		MaxRegistrySize:   l.MaxRegistrySize,
+		MaxMemoryBytes:    l.MaxMemoryBytes,
+	}
+}
+
 // RulesToRuleInfos converts a slice of rule domain models to RuleInfo DTOs
 func RulesToRuleInfos(rules []*rule.Rule) []*RuleInfo {
 	result := make([]*RuleInfo, len(rules))
@@ -167,7 +431,9 @@ func TriggerToTriggerInfo(t *trigger.Trigger) *TriggerInfo {
 		RuleID:          t.RuleID,
 		Type:            string(t.Type),
 		ConditionScript: t.ConditionScript,
+		Language:        t.Language,
 		Enabled:         t.Enabled,
+		TimeoutMs:       t.TimeoutMs,
 		CreatedAt:       t.CreatedAt,
 		UpdatedAt:       t.UpdatedAt,
 	}
@@ -175,12 +441,248 @@ func TriggerToTriggerInfo(t *trigger.Trigger) *TriggerInfo {
 
 // ActionToActionInfo converts an action domain model to ActionInfo DTO
 func ActionToActionInfo(a *action.Action) *ActionInfo {
-	return &ActionInfo{
+	info := &ActionInfo{
 		ID:        a.ID,
 		Name:      a.Name,
+		Type:      a.Type,
 		LuaScript: a.LuaScript,
 		Enabled:   a.Enabled,
 		CreatedAt: a.CreatedAt,
 		UpdatedAt: a.UpdatedAt,
 	}
+	if info.Type == "" {
+		info.Type = action.TypeLuaScript
+	}
+	// The params of a Lua action are its script, already given as lua_script
+	if info.Type != action.TypeLuaScript && a.Params != "" {
+		info.Params = json.RawMessage(a.Params)
+	}
+	return info
+}
+
+// ActionInfoToAction converts an ActionInfo DTO to an action domain model
+func ActionInfoToAction(info *ActionInfo) *action.Action {
+	a := &action.Action{
+		ID:        info.ID,
+		Name:      info.Name,
+		Type:      info.Type,
+		Enabled:   info.Enabled,
+		CreatedAt: info.CreatedAt,
+		UpdatedAt: info.UpdatedAt,
+	}
+	if a.Type == "" || a.Type == action.TypeLuaScript {
+		a.Type = action.TypeLuaScript
+		a.LuaScript = info.LuaScript
+		a.Params = info.LuaScript
+	} else {
+		a.Params = string(info.Params)
+	}
+	return a
+}
+
+// LibraryToLibraryInfo converts a library domain model to LibraryInfo DTO
+func LibraryToLibraryInfo(l *library.Library) *LibraryInfo {
+	return &LibraryInfo{
+		ID:          l.ID,
+		Name:        l.Name,
+		Description: l.Description,
+		Code:        l.Code,
+		Version:     l.Version,
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+	}
+}
+
+// LibraryVersionToLibraryVersionInfo converts a library version to LibraryVersionInfo DTO
+func LibraryVersionToLibraryVersionInfo(v *library.Version) *LibraryVersionInfo {
+	return &LibraryVersionInfo{
+		LibraryID: v.LibraryID,
+		Version:   v.Version,
+		Code:      v.Code,
+		CreatedAt: v.CreatedAt,
+	}
+}
+
+// SecretToSecretInfo converts a secret domain model to SecretInfo DTO
+func SecretToSecretInfo(s *secret.Secret) *SecretInfo {
+	return &SecretInfo{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		RuleIDs:     s.RuleIDs,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// LogEntriesToScriptLogEntries converts captured script log entries to ScriptLogEntry DTOs
+func LogEntriesToScriptLogEntries(entries []scriptlog.Entry) []ScriptLogEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	result := make([]ScriptLogEntry, len(entries))
+	for i, e := range entries {
+		result[i] = ScriptLogEntry{Level: e.Level, Message: e.Message, Timestamp: e.Timestamp}
+	}
+	return result
+}
+
+// ExecutionToExecutionInfo converts an execution domain model to ExecutionInfo DTO
+func ExecutionToExecutionInfo(e *execution.Execution) *ExecutionInfo {
+	info := &ExecutionInfo{
+		ID:            e.ID,
+		RuleID:        e.RuleID,
+		Status:        e.Status,
+		TriggeredAt:   e.TriggeredAt,
+		DurationMs:    e.Duration.Milliseconds(),
+		Output:        e.Output,
+		Error:         e.Error,
+		Logs:          LogEntriesToScriptLogEntries(e.Logs),
+		LogsTruncated: e.LogsTruncated,
+		Replayable:    e.Replayable,
+		CreatedAt:     e.CreatedAt,
+	}
+	if e.TriggerID != uuid.Nil {
+		triggerID := e.TriggerID
+		info.TriggerID = &triggerID
+	}
+	return info
+}
+
+// ExecutionsToExecutionInfos converts a slice of execution domain models to ExecutionInfo DTOs
+func ExecutionsToExecutionInfos(executions []*execution.Execution) []*ExecutionInfo {
+	result := make([]*ExecutionInfo, len(executions))
+	for i, e := range executions {
+		result[i] = ExecutionToExecutionInfo(e)
+	}
+	return result
+}
+
+// ReplayToReplayExecutionResponse converts an execution replay to a ReplayExecutionResponse DTO
+func ReplayToReplayExecutionResponse(r *execution.Replay) *ReplayExecutionResponse {
+	return &ReplayExecutionResponse{
+		ExecutionID:    r.Execution.ID,
+		Script:         r.Script,
+		Result:         ExecuteResultToEvaluateScriptResponse(r.Result),
+		OriginalStatus: r.Execution.Status,
+		OriginalOutput: r.Execution.Output,
+		Matches:        r.Matches,
+		Divergences:    r.Divergences,
+	}
+}
+
+// CoverageReportToScriptCoverageInfo converts a coverage report to a ScriptCoverageInfo DTO
+func CoverageReportToScriptCoverageInfo(report *coverage.Report) *ScriptCoverageInfo {
+	lines := make([]LineCoverageInfo, len(report.Lines))
+	for i, line := range report.Lines {
+		lines[i] = LineCoverageInfo{Line: line.Line, Hits: line.Hits}
+	}
+	return &ScriptCoverageInfo{
+		ScriptHash:      report.ScriptHash,
+		Runs:            report.Runs,
+		ExecutableLines: report.ExecutableLines,
+		CoveredLines:    report.CoveredLines,
+		Percent:         report.Percent,
+		Lines:           lines,
+		LastRunAt:       report.LastRunAt,
+	}
+}
+
+// ExecuteResultToEvaluateScriptResponse converts an executor result to an EvaluateScriptResponse DTO
+func ExecuteResultToEvaluateScriptResponse(result *executor.ExecuteResult) *EvaluateScriptResponse {
+	response := &EvaluateScriptResponse{
+		Success:       result.Success,
+		Status:        string(result.Status),
+		Output:        result.Output,
+		Error:         result.Error,
+		Duration:      result.Duration.String(),
+		Logs:          LogEntriesToScriptLogEntries(result.Logs),
+		LogsTruncated: result.LogsTruncated,
+		Coverage:      result.Coverage,
+	}
+	if result.Profile != nil {
+		response.Profile = ProfileToExecutionProfileInfo(result.Profile)
+	}
+	// Result holds the first returned value, Output every returned value
+	if len(result.Output) > 0 {
+		response.Result = result.Output[0]
+	}
+	return response
+}
+
+// DebugSnapshotToDebugSessionInfo converts a debug session snapshot to a DebugSessionInfo DTO
+func DebugSnapshotToDebugSessionInfo(s *debugger.Snapshot) *DebugSessionInfo {
+	info := &DebugSessionInfo{
+		ID:          s.ID,
+		Status:      string(s.Status),
+		Reason:      s.Reason,
+		Line:        s.Line,
+		Breakpoints: s.Breakpoints,
+		ExpiresAt:   s.ExpiresAt,
+	}
+	for _, frame := range s.Stack {
+		info.Stack = append(info.Stack, DebugFrame{
+			Function: frame.Function,
+			Source:   frame.Source,
+			Line:     frame.Line,
+			Locals:   debugVariables(frame.Locals),
+			Upvalues: debugVariables(frame.Upvalues),
+		})
+	}
+	if s.Result != nil {
+		info.Result = ExecuteResultToEvaluateScriptResponse(s.Result)
+	}
+	return info
+}
+
+func debugVariables(vars []debugger.Variable) []DebugVariable {
+	if len(vars) == 0 {
+		return nil
+	}
+	result := make([]DebugVariable, len(vars))
+	for i, v := range vars {
+		result[i] = DebugVariable{Name: v.Name, Value: v.Value}
+	}
+	return result
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ProfileToExecutionProfileInfo converts the profile of an execution to an ExecutionProfileInfo DTO
+func ProfileToExecutionProfileInfo(p *profile.Profile) *ExecutionProfileInfo {
+	calls := make([]ProfileCallInfo, len(p.Calls))
+	for i, call := range p.Calls {
+		calls[i] = ProfileCallInfo{Name: call.Name, Count: int64(call.Count), DurationMs: milliseconds(call.Duration)}
+	}
+	return &ExecutionProfileInfo{
+		TotalMs: milliseconds(p.Total),
+		VMMs:    milliseconds(p.VM),
+		Calls:   calls,
+	}
+}
+
+// ProfileSummaryToRuleProfileInfo converts the aggregated profile of a rule to a RuleProfileInfo DTO
+func ProfileSummaryToRuleProfileInfo(s *profile.Summary) *RuleProfileInfo {
+	calls := make([]ProfileCallInfo, len(s.Calls))
+	for i, call := range s.Calls {
+		calls[i] = ProfileCallInfo{
+			Name:       call.Name,
+			Count:      call.Count,
+			DurationMs: milliseconds(call.Duration),
+			AverageMs:  milliseconds(call.Average),
+		}
+	}
+	return &RuleProfileInfo{
+		RuleID:    s.RuleID,
+		Runs:      s.Runs,
+		TotalMs:   milliseconds(s.Total),
+		AverageMs: milliseconds(s.Average),
+		MaxMs:     milliseconds(s.Max),
+		VMMs:      milliseconds(s.VM),
+		Calls:     calls,
+		LastRunAt: s.LastRunAt,
+	}
 }
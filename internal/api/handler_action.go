@@ -12,22 +12,26 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/malyshevhen/rule-engine/internal/action"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
+	"github.com/malyshevhen/rule-engine/internal/notify"
 	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
 )
 
 // createAction creates a new action
 //
 //	@Summary		Create a new action
-//	@Description	Create a new action with the given name and Lua script.
+//	@Description	Create a new action with the given name and Lua script, or a publish_event or notify action
+//	@Description	publishing the event its params describe.
 //	@Tags			actions
 //	@Accept			json
 //	@Produce		json
 //	@Param			action	body		CreateActionRequest	true	"Action to create"
 //	@Success		201		{object}	ActionInfo
 //	@Failure		400		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/actions [post]
-func createAction(actionSvc ActionService) http.HandlerFunc {
+func createAction(actionSvc ActionService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateActionRequest
 		if err := ValidateAndParseJSON(r, &req); err != nil {
@@ -44,10 +48,15 @@ func createAction(actionSvc ActionService) http.HandlerFunc {
 			enabled = *req.Enabled
 		}
 
-		action := &action.Action{
+		action := ActionInfoToAction(&ActionInfo{
 			Name:      req.Name,
+			Type:      req.Type,
 			LuaScript: req.LuaScript,
+			Params:    req.Params,
 			Enabled:   enabled,
+		})
+		if !validateAction(w, scriptValidator, action) {
+			return
 		}
 
 		if err := actionSvc.Create(r.Context(), action); err != nil {
@@ -174,9 +183,10 @@ func getAction(actionSvc ActionService) http.HandlerFunc {
 //	@Success		200		{object}	ActionInfo
 //	@Failure		400		{object}	APIErrorResponse
 //	@Failure		404		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/actions/{id} [patch]
-func updateAction(actionSvc ActionService) http.HandlerFunc {
+func updateAction(actionSvc ActionService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		idStr := vars["id"]
@@ -199,8 +209,8 @@ func updateAction(actionSvc ActionService) http.HandlerFunc {
 			return
 		}
 
-		// Apply JSON Patch
-		actionJSON, err := json.Marshal(currentAction)
+		// Apply JSON Patch to the action as the API shows it
+		actionJSON, err := json.Marshal(ActionToActionInfo(currentAction))
 		if err != nil {
 			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to serialize action")
 			return
@@ -212,15 +222,15 @@ func updateAction(actionSvc ActionService) http.HandlerFunc {
 			return
 		}
 
-		var updatedAction action.Action
-		if err := json.Unmarshal(modifiedJSON, &updatedAction); err != nil {
+		var updatedInfo ActionInfo
+		if err := json.Unmarshal(modifiedJSON, &updatedInfo); err != nil {
 			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid patch result")
 			return
 		}
+		updatedAction := ActionInfoToAction(&updatedInfo)
 
 		// Validate the updated action
-		if updatedAction.LuaScript == "" {
-			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "lua_script cannot be empty")
+		if !validateAction(w, scriptValidator, updatedAction) {
 			return
 		}
 
@@ -228,14 +238,42 @@ func updateAction(actionSvc ActionService) http.HandlerFunc {
 		updatedAction.ID = id
 
 		// Update the action
-		if err := actionSvc.Update(r.Context(), &updatedAction); err != nil {
+		if err := actionSvc.Update(r.Context(), updatedAction); err != nil {
 			slog.Error("Failed to update action", "action_id", id, "error", err)
 			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update action")
 			return
 		}
 
 		// Return the updated action
-		SuccessResponse(w, ActionToActionInfo(&updatedAction))
+		SuccessResponse(w, ActionToActionInfo(updatedAction))
+	}
+}
+
+// validateAction checks the script or params of an action according to its type,
+// writing the error response when they are invalid
+func validateAction(w http.ResponseWriter, scriptValidator ScriptValidator, a *action.Action) bool {
+	switch a.Type {
+	case action.TypeLuaScript:
+		if a.LuaScript == "" {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "lua_script cannot be empty")
+			return false
+		}
+		return ValidateScript(w, scriptValidator, "lua_script", a.LuaScript)
+	case action.TypePublishEvent:
+		if _, err := events.ParseActionParams(a.Params); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return false
+		}
+		return true
+	case action.TypeNotify:
+		if _, err := notify.ParseActionParams(a.Params); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return false
+		}
+		return true
+	default:
+		ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("unsupported action type '%s'", a.Type))
+		return false
 	}
 }
 
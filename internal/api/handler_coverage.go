@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
+	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
+)
+
+// getRuleCoverage gets the line coverage of the script of a rule
+//
+//	@Summary		Get the coverage of a rule script
+//	@Description	Get how many times each line of the Lua script of a rule ran, aggregated over the executions run with coverage enabled since the script last changed.
+//	@Tags			coverage
+//	@Produce		json
+//	@Param			id	path		string	true	"Rule ID"
+//	@Success		200	{object}	ScriptCoverageInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/rules/{id}/coverage [get]
+func getRuleCoverage(ruleSvc RuleService, coverageSvc CoverageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := mux.Vars(r)["id"]
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			slog.Error("Invalid rule ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid rule ID format")
+			return
+		}
+
+		rule, err := ruleSvc.GetByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, ruleStorage.ErrNotFound) {
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Rule not found")
+				return
+			}
+			slog.Error("Failed to get rule", "rule_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve rule")
+			return
+		}
+
+		scriptCoverageResponse(w, coverageSvc, rule.LuaScript)
+	}
+}
+
+// getTriggerCoverage gets the line coverage of the condition script of a trigger
+//
+//	@Summary		Get the coverage of a trigger condition script
+//	@Description	Get how many times each line of the condition script of a trigger ran, aggregated over the evaluations run with coverage enabled since the script last changed. Expression conditions have no coverage.
+//	@Tags			coverage
+//	@Produce		json
+//	@Param			id	path		string	true	"Trigger ID"
+//	@Success		200	{object}	ScriptCoverageInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		422	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/triggers/{id}/coverage [get]
+func getTriggerCoverage(triggerSvc TriggerService, coverageSvc CoverageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := mux.Vars(r)["id"]
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			slog.Error("Invalid trigger ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid trigger ID format")
+			return
+		}
+
+		trigger, err := triggerSvc.GetByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, triggerStorage.ErrNotFound) {
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Trigger not found")
+				return
+			}
+			slog.Error("Failed to get trigger", "trigger_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve trigger")
+			return
+		}
+
+		if trigger.Language == executor.LanguageExpr {
+			ErrorResponse(w, http.StatusUnprocessableEntity, "COVERAGE_UNSUPPORTED", "Coverage is only recorded for Lua conditions")
+			return
+		}
+
+		scriptCoverageResponse(w, coverageSvc, trigger.ConditionScript)
+	}
+}
+
+// getActionCoverage gets the line coverage of the script of an action
+//
+//	@Summary		Get the coverage of an action script
+//	@Description	Get how many times each line of the Lua script of an action ran, aggregated over the executions run with coverage enabled since the script last changed.
+//	@Tags			coverage
+//	@Produce		json
+//	@Param			id	path		string	true	"Action ID"
+//	@Success		200	{object}	ScriptCoverageInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/actions/{id}/coverage [get]
+func getActionCoverage(actionSvc ActionService, coverageSvc CoverageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := mux.Vars(r)["id"]
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			slog.Error("Invalid action ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid action ID format")
+			return
+		}
+
+		action, err := actionSvc.GetByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, actionStorage.ErrNotFound) {
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Action not found")
+				return
+			}
+			slog.Error("Failed to get action", "action_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve action")
+			return
+		}
+
+		scriptCoverageResponse(w, coverageSvc, action.LuaScript)
+	}
+}
+
+// resetCoverage drops the recorded coverage
+//
+//	@Summary		Reset coverage
+//	@Description	Drop the line coverage recorded for every script, e.g. before running a test suite.
+//	@Tags			coverage
+//	@Success		204
+//	@Router			/api/v1/coverage [delete]
+func resetCoverage(coverageSvc CoverageService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		coverageSvc.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func scriptCoverageResponse(w http.ResponseWriter, coverageSvc CoverageService, script string) {
+	report, err := coverageSvc.Report(script)
+	if err != nil {
+		slog.Error("Failed to compute script coverage", "error", err)
+		ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to compute script coverage")
+		return
+	}
+	SuccessResponse(w, CoverageReportToScriptCoverageInfo(report))
+}
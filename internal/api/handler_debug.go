@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+)
+
+// debugWaitTimeout bounds how long a request waits for a script to pause or finish.
+// A script still running afterwards is reported with the running status.
+const debugWaitTimeout = 5 * time.Second
+
+// startDebugSession runs a Lua script under the debugger
+//
+//	@Summary		Start a debug session
+//	@Description	Run a Lua script in the sandbox under the debugger. The response describes the session once the script pauses on a breakpoint or on entry, or finishes. The script runs with the execution timeout, paused time aside, and the session expires once it has been paused or finished for its time-to-live.
+//	@Tags			debug
+//	@Accept			json
+//	@Produce		json
+//	@Param			session	body		StartDebugSessionRequest	true	"Script to debug"
+//	@Success		201		{object}	DebugSessionInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		429		{object}	APIErrorResponse
+//	@Router			/api/v1/debug/sessions [post]
+func startDebugSession(debugSvc DebugService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req StartDebugSessionRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil {
+			slog.Error("Failed to validate start debug session request", "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		session, err := debugSvc.Start(debugger.StartRequest{
+			Script:      strings.TrimSpace(req.Script),
+			Context:     req.Context,
+			Breakpoints: req.Breakpoints,
+			StopOnEntry: req.StopOnEntry,
+		})
+		if err != nil {
+			if errors.Is(err, debugger.ErrTooManySessions) {
+				ErrorResponse(w, http.StatusTooManyRequests, "TOO_MANY_DEBUG_SESSIONS", "Too many debug sessions, stop one and retry")
+				return
+			}
+			slog.Error("Failed to start debug session", "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start debug session")
+			return
+		}
+
+		CreatedResponse(w, waitDebugSession(r.Context(), session))
+	}
+}
+
+// getDebugSession gets the state of a debug session
+//
+//	@Summary		Get a debug session
+//	@Description	Get the state of a debug session: its call stack, locals and upvalues while paused, its result once finished.
+//	@Tags			debug
+//	@Produce		json
+//	@Param			id	path		string	true	"Debug session ID"
+//	@Success		200	{object}	DebugSessionInfo
+//	@Failure		404	{object}	APIErrorResponse
+//	@Router			/api/v1/debug/sessions/{id} [get]
+func getDebugSession(debugSvc DebugService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := lookupDebugSession(w, r, debugSvc)
+		if !ok {
+			return
+		}
+		SuccessResponse(w, DebugSnapshotToDebugSessionInfo(session.Snapshot()))
+	}
+}
+
+// setDebugBreakpoints replaces the breakpoints of a debug session
+//
+//	@Summary		Set the breakpoints of a debug session
+//	@Description	Replace the breakpoints of a debug session with the given script lines. They apply from the next line the script runs.
+//	@Tags			debug
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string					true	"Debug session ID"
+//	@Param			breakpoints	body		SetBreakpointsRequest	true	"Breakpoint lines"
+//	@Success		200			{object}	DebugSessionInfo
+//	@Failure		400			{object}	APIErrorResponse
+//	@Failure		404			{object}	APIErrorResponse
+//	@Router			/api/v1/debug/sessions/{id}/breakpoints [put]
+func setDebugBreakpoints(debugSvc DebugService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := lookupDebugSession(w, r, debugSvc)
+		if !ok {
+			return
+		}
+
+		var req SetBreakpointsRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil {
+			slog.Error("Failed to validate set breakpoints request", "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		session.SetBreakpoints(req.Breakpoints)
+		SuccessResponse(w, DebugSnapshotToDebugSessionInfo(session.Snapshot()))
+	}
+}
+
+// resumeDebugSession resumes a paused debug session
+//
+//	@Summary		Resume a debug session
+//	@Description	Resume a paused script. continue runs to the next breakpoint, step to the next line entering called functions, next to the next line without entering them. The response describes the session once the script pauses again or finishes.
+//	@Tags			debug
+//	@Produce		json
+//	@Param			id		path		string	true	"Debug session ID"
+//	@Param			command	path		string	true	"Resume command"	Enums(continue, step, next)
+//	@Success		200		{object}	DebugSessionInfo
+//	@Failure		404		{object}	APIErrorResponse
+//	@Failure		409		{object}	APIErrorResponse
+//	@Router			/api/v1/debug/sessions/{id}/{command} [post]
+func resumeDebugSession(debugSvc DebugService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := lookupDebugSession(w, r, debugSvc)
+		if !ok {
+			return
+		}
+
+		var err error
+		switch command := mux.Vars(r)["command"]; command {
+		case "continue":
+			err = session.Continue()
+		case "step":
+			err = session.Step()
+		case "next":
+			err = session.Next()
+		default:
+			ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Unknown debug command: "+command)
+			return
+		}
+		if err != nil {
+			ErrorResponse(w, http.StatusConflict, "DEBUG_SESSION_NOT_PAUSED", "Debug session is not paused")
+			return
+		}
+
+		SuccessResponse(w, waitDebugSession(r.Context(), session))
+	}
+}
+
+// stopDebugSession aborts a debug session
+//
+//	@Summary		Stop a debug session
+//	@Description	Abort the script of a debug session and discard the session.
+//	@Tags			debug
+//	@Param			id	path	string	true	"Debug session ID"
+//	@Success		204
+//	@Failure		404	{object}	APIErrorResponse
+//	@Router			/api/v1/debug/sessions/{id} [delete]
+func stopDebugSession(debugSvc DebugService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		if err := debugSvc.Stop(id); err != nil {
+			if errors.Is(err, debugger.ErrSessionNotFound) {
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Debug session not found")
+				return
+			}
+			slog.Error("Failed to stop debug session", "session_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to stop debug session")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func lookupDebugSession(w http.ResponseWriter, r *http.Request, debugSvc DebugService) (*debugger.Session, bool) {
+	id := mux.Vars(r)["id"]
+	session, err := debugSvc.Get(id)
+	if err != nil {
+		if errors.Is(err, debugger.ErrSessionNotFound) {
+			ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Debug session not found")
+			return nil, false
+		}
+		slog.Error("Failed to get debug session", "session_id", id, "error", err)
+		ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve debug session")
+		return nil, false
+	}
+	return session, true
+}
+
+// waitDebugSession waits for the script of session to pause or finish and describes the session
+func waitDebugSession(ctx context.Context, session *debugger.Session) *DebugSessionInfo {
+	ctx, cancel := context.WithTimeout(ctx, debugWaitTimeout)
+	defer cancel()
+	if err := session.Wait(ctx); err != nil {
+		slog.Debug("Debug session still running", "session_id", session.ID(), "error", err)
+	}
+	return DebugSnapshotToDebugSessionInfo(session.Snapshot())
+}
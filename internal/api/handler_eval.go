@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 )
@@ -11,7 +12,7 @@ import (
 // evaluateScript evaluates a Lua script and returns the result
 //
 //	@Summary		Evaluate a Lua script
-//	@Description	Evaluate a Lua script in a sandboxed environment.
+//	@Description	Evaluate a Lua script in a sandboxed environment. The response includes every line the script printed or logged and, when coverage is requested, how many times each line ran.
 //	@Tags			evaluation
 //	@Accept			json
 //	@Produce		json
@@ -34,24 +35,20 @@ func evaluateScript(executorSvc ExecutorService) http.HandlerFunc {
 
 		// Create a minimal execution context for evaluation
 		execContext := &execCtx.ExecutionContext{
-			RuleID:    "evaluate", // Use a fixed ID for evaluation
+			RuleID:    "evaluate", // Evaluations share one ID, and so one http quota
 			TriggerID: "evaluate",
-			Data:      req.Context, // Empty data for evaluation
+			Event:     req.Context, // Exposed as ctx.event.payload
+			FiredAt:   time.Now().UTC(),
+			Attempt:   1,
+			Data:      req.Context,
+			Coverage:  req.Coverage,
+			Profile:   req.Profile,
+			Language:  req.Language,
 		}
 
 		// Execute the script
 		result := executorSvc.ExecuteScript(r.Context(), req.Script, execContext)
 
-		// Convert duration to string
-		durationStr := result.Duration.String()
-
-		response := EvaluateScriptResponse{
-			Success:  result.Success,
-			Output:   result.Output,
-			Error:    result.Error,
-			Duration: durationStr,
-		}
-
-		SuccessResponse(w, response)
+		SuccessResponse(w, ExecuteResultToEvaluateScriptResponse(result))
 	}
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+)
+
+// listRuleExecutions lists the recorded executions of a rule
+//
+//	@Summary		List the executions of a rule
+//	@Description	Get the execution history of a rule, most recent first, with the output and logs of every run.
+//	@Tags			executions
+//	@Produce		json
+//	@Param			id		path		string	true	"Rule ID"
+//	@Param			limit	query		int		false	"Limit number of executions returned"
+//	@Param			offset	query		int		false	"Offset for pagination"
+//	@Success		200		{object}	map[string]any
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/rules/{id}/executions [get]
+func listRuleExecutions(executionSvc ExecutionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		idStr := vars["id"]
+		ruleID, err := uuid.Parse(idStr)
+		if err != nil {
+			slog.Error("Invalid rule ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid rule ID format")
+			return
+		}
+
+		// Parse pagination parameters
+		limitStr := GetQueryParam(r, "limit")
+		offsetStr := GetQueryParam(r, "offset")
+
+		limit := apiConfig.DefaultRulesLimit
+		offset := apiConfig.DefaultRulesOffset
+
+		if limitStr != "" {
+			if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= apiConfig.MaxRulesLimit {
+				limit = parsedLimit
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Invalid limit parameter (must be between 1 and %d)", apiConfig.MaxRulesLimit))
+				return
+			}
+		}
+
+		if offsetStr != "" {
+			if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+				offset = parsedOffset
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid offset parameter (must be non-negative)")
+				return
+			}
+		}
+
+		executions, total, err := executionSvc.ListByRule(r.Context(), ruleID, limit, offset)
+		if err != nil {
+			slog.Error("Failed to list rule executions", "rule_id", ruleID, "limit", limit, "offset", offset, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list rule executions")
+			return
+		}
+
+		// Create response with pagination metadata
+		response := map[string]any{
+			"executions": ExecutionsToExecutionInfos(executions),
+			"limit":      limit,
+			"offset":     offset,
+			"count":      len(executions),
+			"total":      total,
+		}
+
+		SuccessResponse(w, response)
+	}
+}
+
+// getExecution gets a recorded execution by its ID
+//
+//	@Summary		Get an execution by ID
+//	@Description	Get a single recorded rule execution with its output and logs.
+//	@Tags			executions
+//	@Produce		json
+//	@Param			id	path		int	true	"Execution ID"
+//	@Success		200	{object}	ExecutionInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/executions/{id} [get]
+func getExecution(executionSvc ExecutionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		idStr := vars["id"]
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil || id <= 0 {
+			slog.Error("Invalid execution ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid execution ID format")
+			return
+		}
+
+		execution, err := executionSvc.GetByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, executionStorage.ErrNotFound) {
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Execution not found")
+				return
+			}
+			slog.Error("Failed to get execution", "execution_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve execution")
+			return
+		}
+
+		SuccessResponse(w, ExecutionToExecutionInfo(execution))
+	}
+}
+
+// replayExecution re-runs a recorded execution against its recorded inputs
+//
+//	@Summary		Replay an execution
+//	@Description	Re-run a recorded execution with the inputs it observed: its trigger context, the current time and the HTTP responses it received. The current script of the rule is run unless a proposed script is given. The response tells whether the replay ended like the recorded execution and which calls the recording could not serve.
+//	@Tags			executions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Execution ID"
+//	@Param			replay	body		ReplayExecutionRequest	false	"Proposed script"
+//	@Success		200		{object}	ReplayExecutionResponse
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		404		{object}	APIErrorResponse
+//	@Failure		409		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/executions/{id}/replay [post]
+func replayExecution(executionSvc ExecutionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		idStr := vars["id"]
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil || id <= 0 {
+			slog.Error("Invalid execution ID format", "id", idStr, "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid execution ID format")
+			return
+		}
+
+		// The body is optional, an empty one replays the current script
+		var req ReplayExecutionRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			slog.Error("Failed to validate replay execution request", "error", err)
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		replay, err := executionSvc.Replay(r.Context(), id, req.Script)
+		if err != nil {
+			switch {
+			case errors.Is(err, executionStorage.ErrNotFound):
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Execution not found")
+			case errors.Is(err, ruleStorage.ErrNotFound):
+				ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Rule of the execution not found")
+			case errors.Is(err, execution.ErrNotReplayable):
+				ErrorResponse(w, http.StatusConflict, "EXECUTION_NOT_REPLAYABLE", "Execution was not recorded for replay")
+			case errors.Is(err, execution.ErrReplayDisabled):
+				ErrorResponse(w, http.StatusConflict, "REPLAY_DISABLED", "Execution replay is not enabled")
+			default:
+				slog.Error("Failed to replay execution", "execution_id", id, "error", err)
+				ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to replay execution")
+			}
+			return
+		}
+
+		SuccessResponse(w, ReplayToReplayExecutionResponse(replay))
+	}
+}
@@ -0,0 +1,387 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/library"
+	libraryStorage "github.com/malyshevhen/rule-engine/internal/storage/library"
+)
+
+// createLibrary creates a new shared Lua library
+//
+//	@Summary		Create a new library
+//	@Description	Create a shared Lua library that scripts load with require(name). The value returned by the code is the module.
+//	@Tags			libraries
+//	@Accept			json
+//	@Produce		json
+//	@Param			library	body		CreateLibraryRequest	true	"Library to create"
+//	@Success		201		{object}	LibraryInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		409		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/libraries [post]
+func createLibrary(librarySvc LibraryService, scriptValidator ScriptValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateLibraryRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		// Sanitize inputs
+		req.Description = strings.TrimSpace(req.Description)
+		req.Code = strings.TrimSpace(req.Code)
+
+		if !ValidateScript(w, scriptValidator, "code", req.Code) {
+			return
+		}
+
+		library := &library.Library{
+			Name:        req.Name,
+			Description: req.Description,
+			Code:        req.Code,
+		}
+
+		if err := librarySvc.Create(r.Context(), library); err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to create library", "name", req.Name, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create library")
+			return
+		}
+
+		CreatedResponse(w, LibraryToLibraryInfo(library))
+	}
+}
+
+// listLibraries lists all existing libraries
+//
+//	@Summary		List all libraries
+//	@Description	Get a list of all shared Lua libraries, ordered by name, with optional pagination.
+//	@Tags			libraries
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit number of libraries returned"
+//	@Param			offset	query		int	false	"Offset for pagination"
+//	@Success		200		{object}	map[string]any
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/libraries [get]
+func listLibraries(librarySvc LibraryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Parse pagination parameters
+		limitStr := GetQueryParam(r, "limit")
+		offsetStr := GetQueryParam(r, "offset")
+
+		limit := apiConfig.DefaultRulesLimit
+		offset := apiConfig.DefaultRulesOffset
+
+		if limitStr != "" {
+			if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= apiConfig.MaxRulesLimit {
+				limit = parsedLimit
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Invalid limit parameter (must be between 1 and %d)", apiConfig.MaxRulesLimit))
+				return
+			}
+		}
+
+		if offsetStr != "" {
+			if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+				offset = parsedOffset
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid offset parameter (must be non-negative)")
+				return
+			}
+		}
+
+		libraries, total, err := librarySvc.List(r.Context(), limit, offset)
+		if err != nil {
+			slog.Error("Failed to list libraries", "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list libraries")
+			return
+		}
+
+		// Convert to DTOs
+		libraryInfos := make([]LibraryInfo, len(libraries))
+		for i, l := range libraries {
+			libraryInfos[i] = *LibraryToLibraryInfo(l)
+		}
+
+		// Create response with pagination metadata
+		response := map[string]any{
+			"libraries": libraryInfos,
+			"limit":     limit,
+			"offset":    offset,
+			"count":     len(libraryInfos),
+			"total":     total,
+		}
+
+		SuccessResponse(w, response)
+	}
+}
+
+// getLibrary gets a library by its ID
+//
+//	@Summary		Get a library by ID
+//	@Description	Get a single shared Lua library by its unique ID.
+//	@Tags			libraries
+//	@Produce		json
+//	@Param			id	path		string	true	"Library ID"
+//	@Success		200	{object}	LibraryInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/libraries/{id} [get]
+func getLibrary(librarySvc LibraryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := libraryID(w, r)
+		if !ok {
+			return
+		}
+
+		library, err := librarySvc.GetByID(r.Context(), id)
+		if err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to get library", "library_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve library")
+			return
+		}
+
+		SuccessResponse(w, LibraryToLibraryInfo(library))
+	}
+}
+
+// updateLibrary updates a library
+//
+//	@Summary		Update a library
+//	@Description	Update an existing library using a JSON Patch. A change of its code creates a new version and is picked up by the next executions.
+//	@Tags			libraries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Library ID"
+//	@Param			patch	body		PatchRequest	true	"JSON Patch operations"
+//	@Success		200		{object}	LibraryInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		404		{object}	APIErrorResponse
+//	@Failure		409		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/libraries/{id} [patch]
+func updateLibrary(librarySvc LibraryService, scriptValidator ScriptValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := libraryID(w, r)
+		if !ok {
+			return
+		}
+
+		// Get the current library
+		currentLibrary, err := librarySvc.GetByID(r.Context(), id)
+		if err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to get library for update", "library_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve library")
+			return
+		}
+
+		// Apply JSON Patch
+		libraryJSON, err := json.Marshal(currentLibrary)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to serialize library")
+			return
+		}
+
+		modifiedJSON, err := ApplyJSONPatch(r, libraryJSON, "library", id.String())
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		var updatedLibrary library.Library
+		if err := json.Unmarshal(modifiedJSON, &updatedLibrary); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid patch result")
+			return
+		}
+
+		// Validate the updated library
+		updatedLibrary.Code = strings.TrimSpace(updatedLibrary.Code)
+		if err := ValidateStruct(CreateLibraryRequest{Name: updatedLibrary.Name, Code: updatedLibrary.Code}); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+		if !ValidateScript(w, scriptValidator, "code", updatedLibrary.Code) {
+			return
+		}
+
+		// Ensure ID is preserved
+		updatedLibrary.ID = id
+
+		if err := librarySvc.Update(r.Context(), &updatedLibrary); err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to update library", "library_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update library")
+			return
+		}
+
+		SuccessResponse(w, LibraryToLibraryInfo(&updatedLibrary))
+	}
+}
+
+// deleteLibrary deletes a library
+//
+//	@Summary		Delete a library
+//	@Description	Delete a library and its versions. Libraries still required by other libraries, or by rule, trigger or action scripts, cannot be deleted.
+//	@Tags			libraries
+//	@Param			id	path	string	true	"Library ID"
+//	@Success		204
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		409	{object}	APIErrorResponse
+//	@Failure		422	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/libraries/{id} [delete]
+func deleteLibrary(librarySvc LibraryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := libraryID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := librarySvc.Delete(r.Context(), id); err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to delete library", "library_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete library")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// listLibraryVersions lists the versions of a library
+//
+//	@Summary		List the versions of a library
+//	@Description	Get every version of the code of a library, most recent first.
+//	@Tags			libraries
+//	@Produce		json
+//	@Param			id	path		string	true	"Library ID"
+//	@Success		200	{object}	map[string]any
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/libraries/{id}/versions [get]
+func listLibraryVersions(librarySvc LibraryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := libraryID(w, r)
+		if !ok {
+			return
+		}
+
+		versions, err := librarySvc.ListVersions(r.Context(), id)
+		if err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to list library versions", "library_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list library versions")
+			return
+		}
+
+		versionInfos := make([]LibraryVersionInfo, len(versions))
+		for i, v := range versions {
+			versionInfos[i] = *LibraryVersionToLibraryVersionInfo(v)
+		}
+
+		SuccessResponse(w, map[string]any{
+			"versions": versionInfos,
+			"count":    len(versionInfos),
+		})
+	}
+}
+
+// getLibraryVersion gets one version of a library
+//
+//	@Summary		Get a version of a library
+//	@Description	Get the code of a library as it was at the given version.
+//	@Tags			libraries
+//	@Produce		json
+//	@Param			id		path		string	true	"Library ID"
+//	@Param			version	path		int		true	"Library version"
+//	@Success		200		{object}	LibraryVersionInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		404		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/libraries/{id}/versions/{version} [get]
+func getLibraryVersion(librarySvc LibraryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := libraryID(w, r)
+		if !ok {
+			return
+		}
+		version, err := strconv.Atoi(mux.Vars(r)["version"])
+		if err != nil || version <= 0 {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid library version (must be a positive integer)")
+			return
+		}
+
+		v, err := librarySvc.GetVersion(r.Context(), id, version)
+		if err != nil {
+			if libraryErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to get library version", "library_id", id, "version", version, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve library version")
+			return
+		}
+
+		SuccessResponse(w, LibraryVersionToLibraryVersionInfo(v))
+	}
+}
+
+// libraryID parses the library ID of the request path
+func libraryID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	idStr := mux.Vars(r)["id"]
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		slog.Error("Invalid library ID format", "id", idStr, "error", err)
+		ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid library ID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// libraryErrorResponse writes the response of the library errors clients can act on
+func libraryErrorResponse(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, libraryStorage.ErrNotFound):
+		ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Library not found")
+	case errors.Is(err, libraryStorage.ErrDuplicateName):
+		ErrorResponse(w, http.StatusConflict, "LIBRARY_NAME_TAKEN", "A library with this name already exists")
+	case errors.Is(err, library.ErrInUse):
+		ErrorResponse(w, http.StatusConflict, "LIBRARY_IN_USE", err.Error())
+	case errors.Is(err, platform.ErrInvalidLibrary),
+		errors.Is(err, platform.ErrUnknownDependency),
+		errors.Is(err, platform.ErrDependencyCycle):
+		ErrorResponse(w, http.StatusUnprocessableEntity, "INVALID_LIBRARY", err.Error())
+	default:
+		return false
+	}
+	return true
+}
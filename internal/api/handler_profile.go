@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+)
+
+// getRuleProfile gets the aggregated execution profile of a rule
+//
+//	@Summary		Get the profile of a rule
+//	@Description	Get where the profiled executions of a rule, its trigger conditions and actions spent their time: running the script or in each platform module function, such as http.get. Executions are profiled when SCRIPT_PROFILING is enabled.
+//	@Tags			rules
+//	@Produce		json
+//	@Param			id	path		string	true	"Rule ID"
+//	@Success		200	{object}	RuleProfileInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/rules/{id}/profile [get]
+func getRuleProfile(ruleSvc RuleService, profileSvc ProfileService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := profiledRuleID(w, r, ruleSvc)
+		if !ok {
+			return
+		}
+
+		SuccessResponse(w, ProfileSummaryToRuleProfileInfo(profileSvc.Summary(id.String())))
+	}
+}
+
+// resetRuleProfile drops the execution profile recorded for a rule
+//
+//	@Summary		Reset the profile of a rule
+//	@Description	Drop the execution profile recorded for a rule, e.g. after changing its scripts.
+//	@Tags			rules
+//	@Param			id	path		string	true	"Rule ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/rules/{id}/profile [delete]
+func resetRuleProfile(ruleSvc RuleService, profileSvc ProfileService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := profiledRuleID(w, r, ruleSvc)
+		if !ok {
+			return
+		}
+
+		profileSvc.Reset(id.String())
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// profiledRuleID returns the ID of the rule in the path once it is known to
+// exist, writing the error response otherwise
+func profiledRuleID(w http.ResponseWriter, r *http.Request, ruleSvc RuleService) (uuid.UUID, bool) {
+	idStr := mux.Vars(r)["id"]
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		slog.Error("Invalid rule ID format", "id", idStr, "error", err)
+		ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid rule ID format")
+		return uuid.Nil, false
+	}
+
+	if _, err := ruleSvc.GetByID(r.Context(), id); err != nil {
+		if errors.Is(err, ruleStorage.ErrNotFound) {
+			ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Rule not found")
+			return uuid.Nil, false
+		}
+		slog.Error("Failed to get rule", "rule_id", id, "error", err)
+		ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve rule")
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -25,9 +25,10 @@ import (
 //	@Param			rule	body		CreateRuleRequest	true	"Rule to create"
 //	@Success		201		{object}	RuleInfo
 //	@Failure		400		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/rules [post]
-func createRule(ruleSvc RuleService) http.HandlerFunc {
+func createRule(ruleSvc RuleService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateRuleRequest
 		if err := ValidateAndParseJSON(r, &req); err != nil {
@@ -40,6 +41,10 @@ func createRule(ruleSvc RuleService) http.HandlerFunc {
 		req.Name = strings.TrimSpace(req.Name)
 		req.LuaScript = strings.TrimSpace(req.LuaScript)
 
+		if !ValidateScript(w, scriptValidator, "lua_script", req.LuaScript) {
+			return
+		}
+
 		enabled := true
 		if req.Enabled != nil {
 			enabled = *req.Enabled
@@ -50,11 +55,20 @@ func createRule(ruleSvc RuleService) http.HandlerFunc {
 			priority = *req.Priority
 		}
 
+		timeoutMs := 0
+		if req.TimeoutMs != nil {
+			timeoutMs = *req.TimeoutMs
+		}
+
 		rule := &rule.Rule{
 			Name:      req.Name,
 			LuaScript: req.LuaScript,
 			Priority:  priority,
 			Enabled:   enabled,
+			TimeoutMs: timeoutMs,
+		}
+		if req.Limits != nil {
+			rule.Limits = ExecutionLimitsToLimits(*req.Limits)
 		}
 
 		if err := ruleSvc.Create(r.Context(), rule); err != nil {
@@ -176,9 +190,10 @@ func getRule(ruleSvc RuleService) http.HandlerFunc {
 //	@Success		200		{object}	RuleInfo
 //	@Failure		400		{object}	APIErrorResponse
 //	@Failure		404		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/rules/{id} [patch]
-func updateRule(ruleSvc RuleService) http.HandlerFunc {
+func updateRule(ruleSvc RuleService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		idStr := vars["id"]
@@ -234,11 +249,23 @@ func updateRule(ruleSvc RuleService) http.HandlerFunc {
 			validationErrors = append(validationErrors, fmt.Sprintf("Lua script too long (max %d characters, got %d)", apiConfig.MaxLuaScriptLength, len(trimmedScript)))
 		}
 
+		if updatedRuleInfo.TimeoutMs < 0 || updatedRuleInfo.TimeoutMs > apiConfig.MaxScriptTimeoutMs {
+			validationErrors = append(validationErrors, fmt.Sprintf("timeout_ms must be between 0 and %d milliseconds", apiConfig.MaxScriptTimeoutMs))
+		}
+
+		if err := ValidateStruct(updatedRuleInfo.Limits); err != nil {
+			validationErrors = append(validationErrors, "limits must be non-negative")
+		}
+
 		if len(validationErrors) > 0 {
 			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Validation failed: %s", strings.Join(validationErrors, "; ")))
 			return
 		}
 
+		if !ValidateScript(w, scriptValidator, "lua_script", trimmedScript) {
+			return
+		}
+
 		// Convert back to domain model
 		updatedRule := &rule.Rule{
 			ID:        existingRule.ID,
@@ -246,6 +273,8 @@ func updateRule(ruleSvc RuleService) http.HandlerFunc {
 			LuaScript: strings.TrimSpace(updatedRuleInfo.LuaScript),
 			Priority:  updatedRuleInfo.Priority,
 			Enabled:   updatedRuleInfo.Enabled,
+			TimeoutMs: updatedRuleInfo.TimeoutMs,
+			Limits:    ExecutionLimitsToLimits(updatedRuleInfo.Limits),
 			CreatedAt: existingRule.CreatedAt,
 			UpdatedAt: existingRule.UpdatedAt,
 		}
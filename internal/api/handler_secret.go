@@ -0,0 +1,266 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/secret"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
+)
+
+// createSecret creates a new secret
+//
+//	@Summary		Create a new secret
+//	@Description	Create a secret that the scripts of the given rules read with secrets.get(name). The value is encrypted at rest and never returned.
+//	@Tags			secrets
+//	@Accept			json
+//	@Produce		json
+//	@Param			secret	body		CreateSecretRequest	true	"Secret to create"
+//	@Success		201		{object}	SecretInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		409		{object}	APIErrorResponse
+//	@Failure		422		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Failure		503		{object}	APIErrorResponse
+//	@Router			/api/v1/secrets [post]
+func createSecret(secretSvc SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateSecretRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		// Sanitize inputs, the value is kept as given
+		req.Description = strings.TrimSpace(req.Description)
+
+		secret := &secret.Secret{
+			Name:        req.Name,
+			Description: req.Description,
+			RuleIDs:     req.RuleIDs,
+		}
+
+		if err := secretSvc.Create(r.Context(), secret, req.Value); err != nil {
+			if secretErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to create secret", "name", req.Name, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create secret")
+			return
+		}
+
+		CreatedResponse(w, SecretToSecretInfo(secret))
+	}
+}
+
+// listSecrets lists all existing secrets
+//
+//	@Summary		List all secrets
+//	@Description	Get a list of the metadata of all secrets, ordered by name, with optional pagination. Values are never returned.
+//	@Tags			secrets
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit number of secrets returned"
+//	@Param			offset	query		int	false	"Offset for pagination"
+//	@Success		200		{object}	map[string]any
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Router			/api/v1/secrets [get]
+func listSecrets(secretSvc SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Parse pagination parameters
+		limitStr := GetQueryParam(r, "limit")
+		offsetStr := GetQueryParam(r, "offset")
+
+		limit := apiConfig.DefaultRulesLimit
+		offset := apiConfig.DefaultRulesOffset
+
+		if limitStr != "" {
+			if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= apiConfig.MaxRulesLimit {
+				limit = parsedLimit
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Invalid limit parameter (must be between 1 and %d)", apiConfig.MaxRulesLimit))
+				return
+			}
+		}
+
+		if offsetStr != "" {
+			if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+				offset = parsedOffset
+			} else {
+				ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid offset parameter (must be non-negative)")
+				return
+			}
+		}
+
+		secrets, total, err := secretSvc.List(r.Context(), limit, offset)
+		if err != nil {
+			slog.Error("Failed to list secrets", "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list secrets")
+			return
+		}
+
+		// Convert to DTOs
+		secretInfos := make([]SecretInfo, len(secrets))
+		for i, s := range secrets {
+			secretInfos[i] = *SecretToSecretInfo(s)
+		}
+
+		// Create response with pagination metadata
+		response := map[string]any{
+			"secrets": secretInfos,
+			"limit":   limit,
+			"offset":  offset,
+			"count":   len(secretInfos),
+			"total":   total,
+		}
+
+		SuccessResponse(w, response)
+	}
+}
+
+// getSecret gets a secret by its ID
+//
+//	@Summary		Get a secret by ID
+//	@Description	Get the metadata of a single secret by its unique ID. The value is never returned.
+//	@Tags			secrets
+//	@Produce		json
+//	@Param			id	path		string	true	"Secret ID"
+//	@Success		200	{object}	SecretInfo
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/secrets/{id} [get]
+func getSecret(secretSvc SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := secretID(w, r)
+		if !ok {
+			return
+		}
+
+		secret, err := secretSvc.GetByID(r.Context(), id)
+		if err != nil {
+			if secretErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to get secret", "secret_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve secret")
+			return
+		}
+
+		SuccessResponse(w, SecretToSecretInfo(secret))
+	}
+}
+
+// updateSecret updates a secret
+//
+//	@Summary		Update a secret
+//	@Description	Replace the description and the rules of a secret, and its value when one is given. The name of a secret cannot change.
+//	@Tags			secrets
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Secret ID"
+//	@Param			secret	body		UpdateSecretRequest	true	"Secret update"
+//	@Success		200		{object}	SecretInfo
+//	@Failure		400		{object}	APIErrorResponse
+//	@Failure		404		{object}	APIErrorResponse
+//	@Failure		422		{object}	APIErrorResponse
+//	@Failure		500		{object}	APIErrorResponse
+//	@Failure		503		{object}	APIErrorResponse
+//	@Router			/api/v1/secrets/{id} [put]
+func updateSecret(secretSvc SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := secretID(w, r)
+		if !ok {
+			return
+		}
+
+		var req UpdateSecretRequest
+		if err := ValidateAndParseJSON(r, &req); err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+
+		secret := &secret.Secret{
+			ID:          id,
+			Description: strings.TrimSpace(req.Description),
+			RuleIDs:     req.RuleIDs,
+		}
+
+		if err := secretSvc.Update(r.Context(), secret, req.Value); err != nil {
+			if secretErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to update secret", "secret_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update secret")
+			return
+		}
+
+		SuccessResponse(w, SecretToSecretInfo(secret))
+	}
+}
+
+// deleteSecret deletes a secret
+//
+//	@Summary		Delete a secret
+//	@Description	Delete a secret. Scripts reading it get an error from then on.
+//	@Tags			secrets
+//	@Param			id	path	string	true	"Secret ID"
+//	@Success		204
+//	@Failure		400	{object}	APIErrorResponse
+//	@Failure		404	{object}	APIErrorResponse
+//	@Failure		500	{object}	APIErrorResponse
+//	@Router			/api/v1/secrets/{id} [delete]
+func deleteSecret(secretSvc SecretService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := secretID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := secretSvc.Delete(r.Context(), id); err != nil {
+			if secretErrorResponse(w, err) {
+				return
+			}
+			slog.Error("Failed to delete secret", "secret_id", id, "error", err)
+			ErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete secret")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// secretID parses the secret ID of the request path
+func secretID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	idStr := mux.Vars(r)["id"]
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		slog.Error("Invalid secret ID format", "id", idStr, "error", err)
+		ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid secret ID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// secretErrorResponse writes the response of the secret errors clients can act on
+func secretErrorResponse(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, secretStorage.ErrNotFound):
+		ErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Secret not found")
+	case errors.Is(err, secretStorage.ErrDuplicateName):
+		ErrorResponse(w, http.StatusConflict, "SECRET_NAME_TAKEN", "A secret with this name already exists")
+	case errors.Is(err, secretStorage.ErrUnknownRule):
+		ErrorResponse(w, http.StatusUnprocessableEntity, "UNKNOWN_RULE", "The secret is granted to a rule that does not exist")
+	case errors.Is(err, secret.ErrNotConfigured):
+		ErrorResponse(w, http.StatusServiceUnavailable, "SECRETS_NOT_CONFIGURED", "Secrets cannot be written: no master key is configured")
+	default:
+		return false
+	}
+	return true
+}
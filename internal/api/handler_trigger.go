@@ -11,6 +11,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
 	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 )
@@ -25,9 +27,10 @@ import (
 //	@Param			trigger	body		CreateTriggerRequest	true	"Trigger to create"
 //	@Success		201		{object}	TriggerInfo
 //	@Failure		400		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/triggers [post]
-func createTrigger(triggerSvc TriggerService) http.HandlerFunc {
+func createTrigger(triggerSvc TriggerService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateTriggerRequest
 		if err := ValidateAndParseJSON(r, &req); err != nil {
@@ -38,17 +41,32 @@ func createTrigger(triggerSvc TriggerService) http.HandlerFunc {
 		// Sanitize inputs
 		req.Type = strings.TrimSpace(req.Type)
 		req.ConditionScript = strings.TrimSpace(req.ConditionScript)
+		if req.Language == "" {
+			req.Language = executor.LanguageLua
+		}
+
+		// CRON triggers keep their schedule in condition_script
+		if trigger.TriggerType(req.Type) == trigger.Conditional && !ValidateScript(w, conditionValidator(scriptValidator, req.Language), "condition_script", req.ConditionScript) {
+			return
+		}
 
 		enabled := true
 		if req.Enabled != nil {
 			enabled = *req.Enabled
 		}
 
+		timeoutMs := 0
+		if req.TimeoutMs != nil {
+			timeoutMs = *req.TimeoutMs
+		}
+
 		trigger := &trigger.Trigger{
 			RuleID:          req.RuleID,
 			Type:            trigger.TriggerType(req.Type),
 			ConditionScript: req.ConditionScript,
+			Language:        req.Language,
 			Enabled:         enabled,
+			TimeoutMs:       timeoutMs,
 		}
 
 		if err := triggerSvc.Create(r.Context(), trigger); err != nil {
@@ -174,9 +192,10 @@ func getTrigger(triggerSvc TriggerService) http.HandlerFunc {
 //	@Success		200		{object}	TriggerInfo
 //	@Failure		400		{object}	APIErrorResponse
 //	@Failure		404		{object}	APIErrorResponse
+//	@Failure		422		{object}	ScriptValidationErrorResponse
 //	@Failure		500		{object}	APIErrorResponse
 //	@Router			/api/v1/triggers/{id} [patch]
-func updateTrigger(triggerSvc TriggerService) http.HandlerFunc {
+func updateTrigger(triggerSvc TriggerService, scriptValidator ScriptValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		idStr := vars["id"]
@@ -223,6 +242,20 @@ func updateTrigger(triggerSvc TriggerService) http.HandlerFunc {
 			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "condition_script cannot be empty")
 			return
 		}
+		if updatedTrigger.TimeoutMs < 0 || updatedTrigger.TimeoutMs > apiConfig.MaxScriptTimeoutMs {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("timeout_ms must be between 0 and %d milliseconds", apiConfig.MaxScriptTimeoutMs))
+			return
+		}
+		if updatedTrigger.Language == "" {
+			updatedTrigger.Language = executor.LanguageLua
+		}
+		if updatedTrigger.Language != executor.LanguageLua && updatedTrigger.Language != executor.LanguageExpr {
+			ErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "language must be one of lua, expr")
+			return
+		}
+		if updatedTrigger.Type == trigger.Conditional && !ValidateScript(w, conditionValidator(scriptValidator, updatedTrigger.Language), "condition_script", updatedTrigger.ConditionScript) {
+			return
+		}
 
 		// Ensure ID and RuleID are preserved
 		updatedTrigger.ID = id
@@ -276,3 +309,12 @@ func deleteTrigger(triggerSvc TriggerService) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// conditionValidator returns the validator of conditions written in language.
+// Expressions only need to compile, Lua conditions go through scriptValidator.
+func conditionValidator(scriptValidator ScriptValidator, language string) ScriptValidator {
+	if language == executor.LanguageExpr {
+		return validation.ExpressionValidator{}
+	}
+	return scriptValidator
+}
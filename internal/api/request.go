@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
 )
 
 // ParseJSONBody parses JSON request body into the provided interface
@@ -43,6 +45,15 @@ func init() {
 	if err := validate.RegisterValidation("rule_name_length", validateRuleNameLength); err != nil {
 		panic("Failed to register rule_name_length validation: " + err.Error())
 	}
+	if err := validate.RegisterValidation("script_timeout", validateScriptTimeout); err != nil {
+		panic("Failed to register script_timeout validation: " + err.Error())
+	}
+	if err := validate.RegisterValidation("library_name", validateLibraryName); err != nil {
+		panic("Failed to register library_name validation: " + err.Error())
+	}
+	if err := validate.RegisterValidation("secret_name", validateSecretName); err != nil {
+		panic("Failed to register secret_name validation: " + err.Error())
+	}
 }
 
 // validateLuaScriptLength validates Lua script length
@@ -57,11 +68,63 @@ func validateRuleNameLength(fl validator.FieldLevel) bool {
 	return len(strings.TrimSpace(name)) > 0 && len(name) <= apiConfig.MaxRuleNameLength
 }
 
+// validateScriptTimeout validates a script timeout in milliseconds
+func validateScriptTimeout(fl validator.FieldLevel) bool {
+	timeout := fl.Field().Int()
+	return timeout >= 0 && timeout <= int64(apiConfig.MaxScriptTimeoutMs)
+}
+
+// libraryNamePattern matches the module names scripts can pass to require
+var libraryNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// validateLibraryName validates a library name
+func validateLibraryName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	return len(name) <= apiConfig.MaxRuleNameLength && libraryNamePattern.MatchString(name)
+}
+
+// secretNamePattern matches the names scripts can pass to secrets.get
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validateSecretName validates the name of a secret
+func validateSecretName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	return len(name) <= apiConfig.MaxRuleNameLength && secretNamePattern.MatchString(name)
+}
+
 // ValidateStruct validates a struct using the validator tags
 func ValidateStruct(s any) error {
 	return validate.Struct(s)
 }
 
+// ValidateScript statically checks the script of the given field and writes a
+// 422 response with its diagnostics if it has errors. Warnings do not reject the script.
+// It returns true when the request may proceed.
+func ValidateScript(w http.ResponseWriter, v ScriptValidator, field, script string) bool {
+	if v == nil {
+		return true
+	}
+
+	diagnostics := v.Validate(script)
+	if !validation.HasErrors(diagnostics) {
+		return true
+	}
+
+	result := make([]ScriptDiagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		result = append(result, ScriptDiagnostic{
+			Field:    field,
+			Severity: d.Severity,
+			Code:     d.Code,
+			Message:  d.Message,
+			Line:     d.Line,
+			Column:   d.Column,
+		})
+	}
+	ScriptErrorResponse(w, result)
+	return false
+}
+
 // ValidateAndParseJSON parses JSON and validates the struct
 func ValidateAndParseJSON(r *http.Request, v any) error {
 	if err := ParseJSONBody(r, v); err != nil {
@@ -89,6 +152,12 @@ func formatValidationErrors(errors validator.ValidationErrors) string {
 			messages = append(messages, fmt.Sprintf("Lua script must be between 1 and %d characters", apiConfig.MaxLuaScriptLength))
 		case "rule_name_length":
 			messages = append(messages, fmt.Sprintf("Rule name must be between 1 and %d characters", apiConfig.MaxRuleNameLength))
+		case "script_timeout":
+			messages = append(messages, fmt.Sprintf("%s must be between 0 and %d milliseconds", err.Field(), apiConfig.MaxScriptTimeoutMs))
+		case "min":
+			messages = append(messages, fmt.Sprintf("%s must be at least %s", err.Field(), err.Param()))
+		case "max":
+			messages = append(messages, fmt.Sprintf("%s must be at most %s", err.Field(), err.Param()))
 		case "oneof":
 			messages = append(messages, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
 		default:
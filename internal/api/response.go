@@ -37,3 +37,14 @@ func SuccessResponse(w http.ResponseWriter, data any) {
 func CreatedResponse(w http.ResponseWriter, data any) {
 	JSONResponse(w, http.StatusCreated, data)
 }
+
+// ScriptErrorResponse sends the diagnostics of a rejected script
+func ScriptErrorResponse(w http.ResponseWriter, diagnostics []ScriptDiagnostic) {
+	JSONResponse(w, http.StatusUnprocessableEntity, map[string]any{
+		"error": ScriptValidationError{
+			Code:        "SCRIPT_VALIDATION_ERROR",
+			Message:     "Script validation failed",
+			Diagnostics: diagnostics,
+		},
+	})
+}
@@ -9,9 +9,16 @@ import (
 func setupRoutes(
 	healthSvc *Health,
 	executorSvc ExecutorService,
+	scriptValidator ScriptValidator,
 	ruleSvc RuleService,
 	triggerSvc TriggerService,
 	actionSvc ActionService,
+	librarySvc LibraryService,
+	secretSvc SecretService,
+	executionSvc ExecutionService,
+	debugSvc DebugService,
+	coverageSvc CoverageService,
+	profileSvc ProfileService,
 ) *mux.Router {
 	router := mux.NewRouter()
 
@@ -24,29 +31,65 @@ func setupRoutes(
 	api.Use(AuthMiddleware)
 
 	// Rules routes
-	api.HandleFunc("/rules", createRule(ruleSvc)).Methods("POST")
+	api.HandleFunc("/rules", createRule(ruleSvc, scriptValidator)).Methods("POST")
 	api.HandleFunc("/rules", listRules(ruleSvc)).Methods("GET")
 	api.HandleFunc("/rules/{id}", getRule(ruleSvc)).Methods("GET")
-	api.HandleFunc("/rules/{id}", updateRule(ruleSvc)).Methods("PATCH")
+	api.HandleFunc("/rules/{id}", updateRule(ruleSvc, scriptValidator)).Methods("PATCH")
 	api.HandleFunc("/rules/{id}", deleteRule(ruleSvc)).Methods("DELETE")
 	api.HandleFunc("/rules/{id}/actions", addActionToRule(ruleSvc)).Methods("POST")
+	api.HandleFunc("/rules/{id}/executions", listRuleExecutions(executionSvc)).Methods("GET")
+	api.HandleFunc("/rules/{id}/coverage", getRuleCoverage(ruleSvc, coverageSvc)).Methods("GET")
+	api.HandleFunc("/rules/{id}/profile", getRuleProfile(ruleSvc, profileSvc)).Methods("GET")
+	api.HandleFunc("/rules/{id}/profile", resetRuleProfile(ruleSvc, profileSvc)).Methods("DELETE")
 
 	// Triggers routes
-	api.HandleFunc("/triggers", createTrigger(triggerSvc)).Methods("POST")
+	api.HandleFunc("/triggers", createTrigger(triggerSvc, scriptValidator)).Methods("POST")
 	api.HandleFunc("/triggers", listTriggers(triggerSvc)).Methods("GET")
 	api.HandleFunc("/triggers/{id}", getTrigger(triggerSvc)).Methods("GET")
-	api.HandleFunc("/triggers/{id}", updateTrigger(triggerSvc)).Methods("PATCH")
+	api.HandleFunc("/triggers/{id}", updateTrigger(triggerSvc, scriptValidator)).Methods("PATCH")
 	api.HandleFunc("/triggers/{id}", deleteTrigger(triggerSvc)).Methods("DELETE")
+	api.HandleFunc("/triggers/{id}/coverage", getTriggerCoverage(triggerSvc, coverageSvc)).Methods("GET")
 
 	// Actions routes
-	api.HandleFunc("/actions", createAction(actionSvc)).Methods("POST")
+	api.HandleFunc("/actions", createAction(actionSvc, scriptValidator)).Methods("POST")
 	api.HandleFunc("/actions", listActions(actionSvc)).Methods("GET")
 	api.HandleFunc("/actions/{id}", getAction(actionSvc)).Methods("GET")
-	api.HandleFunc("/actions/{id}", updateAction(actionSvc)).Methods("PATCH")
+	api.HandleFunc("/actions/{id}", updateAction(actionSvc, scriptValidator)).Methods("PATCH")
 	api.HandleFunc("/actions/{id}", deleteAction(actionSvc)).Methods("DELETE")
+	api.HandleFunc("/actions/{id}/coverage", getActionCoverage(actionSvc, coverageSvc)).Methods("GET")
+
+	// Libraries routes
+	api.HandleFunc("/libraries", createLibrary(librarySvc, scriptValidator)).Methods("POST")
+	api.HandleFunc("/libraries", listLibraries(librarySvc)).Methods("GET")
+	api.HandleFunc("/libraries/{id}", getLibrary(librarySvc)).Methods("GET")
+	api.HandleFunc("/libraries/{id}", updateLibrary(librarySvc, scriptValidator)).Methods("PATCH")
+	api.HandleFunc("/libraries/{id}", deleteLibrary(librarySvc)).Methods("DELETE")
+	api.HandleFunc("/libraries/{id}/versions", listLibraryVersions(librarySvc)).Methods("GET")
+	api.HandleFunc("/libraries/{id}/versions/{version}", getLibraryVersion(librarySvc)).Methods("GET")
+
+	// Secrets routes
+	api.HandleFunc("/secrets", createSecret(secretSvc)).Methods("POST")
+	api.HandleFunc("/secrets", listSecrets(secretSvc)).Methods("GET")
+	api.HandleFunc("/secrets/{id}", getSecret(secretSvc)).Methods("GET")
+	api.HandleFunc("/secrets/{id}", updateSecret(secretSvc)).Methods("PUT")
+	api.HandleFunc("/secrets/{id}", deleteSecret(secretSvc)).Methods("DELETE")
+
+	// Script coverage routes
+	api.HandleFunc("/coverage", resetCoverage(coverageSvc)).Methods("DELETE")
+
+	// Execution history routes
+	api.HandleFunc("/executions/{id}", getExecution(executionSvc)).Methods("GET")
+	api.HandleFunc("/executions/{id}/replay", replayExecution(executionSvc)).Methods("POST")
 
 	// Script evaluation route
 	api.HandleFunc("/evaluate", evaluateScript(executorSvc)).Methods("POST")
 
+	// Script debugging routes
+	api.HandleFunc("/debug/sessions", startDebugSession(debugSvc)).Methods("POST")
+	api.HandleFunc("/debug/sessions/{id}", getDebugSession(debugSvc)).Methods("GET")
+	api.HandleFunc("/debug/sessions/{id}", stopDebugSession(debugSvc)).Methods("DELETE")
+	api.HandleFunc("/debug/sessions/{id}/breakpoints", setDebugBreakpoints(debugSvc)).Methods("PUT")
+	api.HandleFunc("/debug/sessions/{id}/{command:continue|step|next}", resumeDebugSession(debugSvc)).Methods("POST")
+
 	return router
 }
@@ -12,7 +12,14 @@ import (
 	"github.com/malyshevhen/rule-engine/internal/analytics"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/coverage"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/library"
 	"github.com/malyshevhen/rule-engine/internal/rule"
+	"github.com/malyshevhen/rule-engine/internal/secret"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 )
 
@@ -45,6 +52,26 @@ type ActionService interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// LibraryService interface
+type LibraryService interface {
+	Create(ctx context.Context, library *library.Library) error
+	GetByID(ctx context.Context, id uuid.UUID) (*library.Library, error)
+	List(ctx context.Context, limit, offset int) ([]*library.Library, int, error)
+	Update(ctx context.Context, library *library.Library) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListVersions(ctx context.Context, id uuid.UUID) ([]*library.Version, error)
+	GetVersion(ctx context.Context, id uuid.UUID, version int) (*library.Version, error)
+}
+
+// SecretService interface
+type SecretService interface {
+	Create(ctx context.Context, secret *secret.Secret, value string) error
+	GetByID(ctx context.Context, id uuid.UUID) (*secret.Secret, error)
+	List(ctx context.Context, limit, offset int) ([]*secret.Secret, int, error)
+	Update(ctx context.Context, secret *secret.Secret, value *string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
 // AnalyticsService interface
 type AnalyticsService interface {
 	GetDashboardData(ctx context.Context, timeRange string) (*analytics.DashboardData, error)
@@ -55,6 +82,37 @@ type ExecutorService interface {
 	ExecuteScript(ctx context.Context, script string, execCtx *execCtx.ExecutionContext) *executor.ExecuteResult
 }
 
+// ExecutionService interface
+type ExecutionService interface {
+	GetByID(ctx context.Context, id int64) (*execution.Execution, error)
+	ListByRule(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*execution.Execution, int, error)
+	Replay(ctx context.Context, id int64, script string) (*execution.Replay, error)
+}
+
+// DebugService interface
+type DebugService interface {
+	Start(req debugger.StartRequest) (*debugger.Session, error)
+	Get(id string) (*debugger.Session, error)
+	Stop(id string) error
+}
+
+// CoverageService interface
+type CoverageService interface {
+	Report(script string) (*coverage.Report, error)
+	Reset()
+}
+
+// ProfileService interface
+type ProfileService interface {
+	Summary(ruleID string) *profile.Summary
+	Reset(ruleID string)
+}
+
+// ScriptValidator interface
+type ScriptValidator interface {
+	Validate(script string) []validation.Diagnostic
+}
+
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port string
@@ -67,16 +125,30 @@ func NewServer(
 	ruleSvc RuleService,
 	triggerSvc TriggerService,
 	actionSvc ActionService,
+	librarySvc LibraryService,
+	secretSvc SecretService,
 	analyticsSvc AnalyticsService,
 	executorSvc ExecutorService,
+	executionSvc ExecutionService,
+	debugSvc DebugService,
+	coverageSvc CoverageService,
+	profileSvc ProfileService,
+	scriptValidator ScriptValidator,
 	rateLimitingEnabled bool,
 ) *http.Server {
 	router := setupRoutes(
 		healthSvc,
 		executorSvc,
+		scriptValidator,
 		ruleSvc,
 		triggerSvc,
 		actionSvc,
+		librarySvc,
+		secretSvc,
+		executionSvc,
+		debugSvc,
+		coverageSvc,
+		profileSvc,
 	)
 
 	recoveryHandler := handlers.RecoveryHandler()
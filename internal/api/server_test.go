@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -15,9 +16,21 @@ import (
 	"github.com/malyshevhen/rule-engine/internal/action"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/coverage"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/debugger"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/library"
 	"github.com/malyshevhen/rule-engine/internal/rule"
+	"github.com/malyshevhen/rule-engine/internal/secret"
 	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+	libraryStorage "github.com/malyshevhen/rule-engine/internal/storage/library"
 	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
 	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 	"github.com/stretchr/testify/assert"
@@ -124,6 +137,76 @@ func (m *mockActionService) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
+// mockLibraryService is a mock implementation of LibraryService
+type mockLibraryService struct {
+	mock.Mock
+}
+
+func (m *mockLibraryService) Create(ctx context.Context, library *library.Library) error {
+	args := m.Called(ctx, library)
+	return args.Error(0)
+}
+
+func (m *mockLibraryService) GetByID(ctx context.Context, id uuid.UUID) (*library.Library, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*library.Library), args.Error(1)
+}
+
+func (m *mockLibraryService) List(ctx context.Context, limit, offset int) ([]*library.Library, int, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]*library.Library), args.Int(1), args.Error(2)
+}
+
+func (m *mockLibraryService) Update(ctx context.Context, library *library.Library) error {
+	args := m.Called(ctx, library)
+	return args.Error(0)
+}
+
+func (m *mockLibraryService) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockLibraryService) ListVersions(ctx context.Context, id uuid.UUID) ([]*library.Version, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]*library.Version), args.Error(1)
+}
+
+func (m *mockLibraryService) GetVersion(ctx context.Context, id uuid.UUID, version int) (*library.Version, error) {
+	args := m.Called(ctx, id, version)
+	return args.Get(0).(*library.Version), args.Error(1)
+}
+
+// mockSecretService is a mock implementation of SecretService
+type mockSecretService struct {
+	mock.Mock
+}
+
+func (m *mockSecretService) Create(ctx context.Context, secret *secret.Secret, value string) error {
+	args := m.Called(ctx, secret, value)
+	return args.Error(0)
+}
+
+func (m *mockSecretService) GetByID(ctx context.Context, id uuid.UUID) (*secret.Secret, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*secret.Secret), args.Error(1)
+}
+
+func (m *mockSecretService) List(ctx context.Context, limit, offset int) ([]*secret.Secret, int, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]*secret.Secret), args.Int(1), args.Error(2)
+}
+
+func (m *mockSecretService) Update(ctx context.Context, secret *secret.Secret, value *string) error {
+	args := m.Called(ctx, secret, value)
+	return args.Error(0)
+}
+
+func (m *mockSecretService) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 // mockExecutorService is a mock implementation of ExecutorService
 type mockExecutorService struct {
 	mock.Mock
@@ -134,6 +217,29 @@ func (m *mockExecutorService) ExecuteScript(ctx context.Context, script string,
 	return args.Get(0).(*executor.ExecuteResult)
 }
 
+// mockExecutionService is a mock implementation of ExecutionService
+type mockExecutionService struct {
+	mock.Mock
+}
+
+func (m *mockExecutionService) GetByID(ctx context.Context, id int64) (*execution.Execution, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*execution.Execution), args.Error(1)
+}
+
+func (m *mockExecutionService) ListByRule(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*execution.Execution, int, error) {
+	args := m.Called(ctx, ruleID, limit, offset)
+	return args.Get(0).([]*execution.Execution), args.Int(1), args.Error(2)
+}
+
+func (m *mockExecutionService) Replay(ctx context.Context, id int64, script string) (*execution.Replay, error) {
+	args := m.Called(ctx, id, script)
+	return args.Get(0).(*execution.Replay), args.Error(1)
+}
+
+// testScriptValidator accepts the modules provided by the platform
+var testScriptValidator = validation.NewValidator(platform.NewService().ModuleNames()...)
+
 func TestServer_CreateRule(t *testing.T) {
 	mockRuleSvc := &mockRuleService{}
 
@@ -194,6 +300,33 @@ func TestServer_CreateRule(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
 		},
+		{
+			name: "lua syntax error",
+			requestBody: CreateRuleRequest{
+				Name:      "Test Rule",
+				LuaScript: "if event.temperature > 25 return true end",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks:     func() {},
+		},
+		{
+			name: "forbidden global",
+			requestBody: CreateRuleRequest{
+				Name:      "Test Rule",
+				LuaScript: "os.exit(1)",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks:     func() {},
+		},
+		{
+			name: "unknown module",
+			requestBody: CreateRuleRequest{
+				Name:      "Test Rule",
+				LuaScript: "local fs = require('lfs')\nreturn true",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks:     func() {},
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,7 +338,7 @@ func TestServer_CreateRule(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
-			createRule(mockRuleSvc)(w, req)
+			createRule(mockRuleSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockRuleSvc.AssertExpectations(t)
@@ -213,6 +346,35 @@ func TestServer_CreateRule(t *testing.T) {
 	}
 }
 
+func TestServer_CreateRule_ScriptDiagnostics(t *testing.T) {
+	mockRuleSvc := &mockRuleService{}
+
+	body, _ := json.Marshal(CreateRuleRequest{
+		Name:      "Test Rule",
+		LuaScript: "local x = 1\nif x > 0 then\n  return io.read()\nend",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	createRule(mockRuleSvc, testScriptValidator)(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response ScriptValidationErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "SCRIPT_VALIDATION_ERROR", response.Error.Code)
+	if assert.Len(t, response.Error.Diagnostics, 1) {
+		d := response.Error.Diagnostics[0]
+		assert.Equal(t, "lua_script", d.Field)
+		assert.Equal(t, validation.SeverityError, d.Severity)
+		assert.Equal(t, validation.CodeForbiddenGlobal, d.Code)
+		assert.Equal(t, 3, d.Line)
+	}
+	mockRuleSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestServer_ListRules(t *testing.T) {
 	mockRuleSvc := &mockRuleService{}
 
@@ -375,7 +537,7 @@ func TestServer_UpdateRule(t *testing.T) {
 			req = mux.SetURLVars(req, map[string]string{"id": tt.ruleID})
 			w := httptest.NewRecorder()
 
-			updateRule(mockRuleSvc)(w, req)
+			updateRule(mockRuleSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockRuleSvc.AssertExpectations(t)
@@ -592,13 +754,13 @@ func TestServer_CreateTrigger(t *testing.T) {
 			requestBody: CreateTriggerRequest{
 				RuleID:          ruleID,
 				Type:            "CONDITIONAL",
-				ConditionScript: "event.type == 'device_update'",
+				ConditionScript: "return event.type == 'device_update'",
 				Enabled:         &[]bool{true}[0],
 			},
 			expectedStatus: http.StatusCreated,
 			setupMocks: func() {
 				mockTriggerSvc.On("Create", mock.Anything, mock.MatchedBy(func(tr *trigger.Trigger) bool {
-					return tr.RuleID == ruleID && tr.Type == trigger.Conditional && tr.ConditionScript == "event.type == 'device_update'" && tr.Enabled == true
+					return tr.RuleID == ruleID && tr.Type == trigger.Conditional && tr.ConditionScript == "return event.type == 'device_update'" && tr.Enabled == true
 				})).Return(nil)
 			},
 		},
@@ -607,7 +769,7 @@ func TestServer_CreateTrigger(t *testing.T) {
 			requestBody: CreateTriggerRequest{
 				RuleID:          ruleID,
 				Type:            "",
-				ConditionScript: "event.type == 'device_update'",
+				ConditionScript: "return event.type == 'device_update'",
 			},
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
@@ -617,7 +779,7 @@ func TestServer_CreateTrigger(t *testing.T) {
 			requestBody: CreateTriggerRequest{
 				RuleID:          ruleID,
 				Type:            "invalid",
-				ConditionScript: "event.type == 'device_update'",
+				ConditionScript: "return event.type == 'device_update'",
 			},
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
@@ -632,6 +794,67 @@ func TestServer_CreateTrigger(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
 		},
+		{
+			name: "condition script syntax error",
+			requestBody: CreateTriggerRequest{
+				RuleID:          ruleID,
+				Type:            "CONDITIONAL",
+				ConditionScript: "event.type == 'device_update'",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks:     func() {},
+		},
+		{
+			name: "expression condition",
+			requestBody: CreateTriggerRequest{
+				RuleID:          ruleID,
+				Type:            "CONDITIONAL",
+				ConditionScript: "ctx.event.payload.type == 'device_update'",
+				Language:        "expr",
+			},
+			expectedStatus: http.StatusCreated,
+			setupMocks: func() {
+				mockTriggerSvc.On("Create", mock.Anything, mock.MatchedBy(func(tr *trigger.Trigger) bool {
+					return tr.Language == "expr" && tr.ConditionScript == "ctx.event.payload.type == 'device_update'"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "expression condition syntax error",
+			requestBody: CreateTriggerRequest{
+				RuleID:          ruleID,
+				Type:            "CONDITIONAL",
+				ConditionScript: "ctx.event.payload.type = 'device_update'",
+				Language:        "expr",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks:     func() {},
+		},
+		{
+			name: "unknown language",
+			requestBody: CreateTriggerRequest{
+				RuleID:          ruleID,
+				Type:            "CONDITIONAL",
+				ConditionScript: "return true",
+				Language:        "python",
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+		{
+			name: "cron schedule is not validated as lua",
+			requestBody: CreateTriggerRequest{
+				RuleID:          ruleID,
+				Type:            "CRON",
+				ConditionScript: "@every 1m",
+			},
+			expectedStatus: http.StatusCreated,
+			setupMocks: func() {
+				mockTriggerSvc.On("Create", mock.Anything, mock.MatchedBy(func(tr *trigger.Trigger) bool {
+					return tr.Type == trigger.Cron && tr.ConditionScript == "@every 1m"
+				})).Return(nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -643,7 +866,7 @@ func TestServer_CreateTrigger(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
-			createTrigger(mockTriggerSvc)(w, req)
+			createTrigger(mockTriggerSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockTriggerSvc.AssertExpectations(t)
@@ -658,7 +881,7 @@ func TestServer_ListTriggers(t *testing.T) {
 		{
 			ID:              uuid.New(),
 			Type:            trigger.Conditional,
-			ConditionScript: "event.type == 'device_update'",
+			ConditionScript: "return event.type == 'device_update'",
 			Enabled:         true,
 		},
 	}
@@ -691,7 +914,7 @@ func TestServer_GetTrigger(t *testing.T) {
 	expectedTrigger := &trigger.Trigger{
 		ID:              triggerID,
 		Type:            trigger.Conditional,
-		ConditionScript: "event.type == 'device_update'",
+		ConditionScript: "return event.type == 'device_update'",
 		Enabled:         true,
 	}
 
@@ -750,7 +973,7 @@ func TestServer_UpdateTrigger(t *testing.T) {
 		ID:              triggerID,
 		RuleID:          ruleID,
 		Type:            trigger.Conditional,
-		ConditionScript: "event.type == 'old_condition'",
+		ConditionScript: "return event.type == 'old_condition'",
 		Enabled:         true,
 	}
 
@@ -765,28 +988,28 @@ func TestServer_UpdateTrigger(t *testing.T) {
 			name:      "successful update",
 			triggerID: triggerID.String(),
 			requestBody: `[
-				{"op": "replace", "path": "/condition_script", "value": "event.type == 'new_condition'"},
+				{"op": "replace", "path": "/condition_script", "value": "return event.type == 'new_condition'"},
 				{"op": "replace", "path": "/enabled", "value": false}
 			]`,
 			expectedStatus: http.StatusOK,
 			setupMocks: func() {
 				mockTriggerSvc.On("GetByID", mock.Anything, triggerID).Return(existingTrigger, nil)
 				mockTriggerSvc.On("Update", mock.Anything, mock.MatchedBy(func(tr *trigger.Trigger) bool {
-					return tr.ID == triggerID && tr.ConditionScript == "event.type == 'new_condition'" && tr.Enabled == false
+					return tr.ID == triggerID && tr.ConditionScript == "return event.type == 'new_condition'" && tr.Enabled == false
 				})).Return(nil)
 			},
 		},
 		{
 			name:           "invalid uuid",
 			triggerID:      "invalid-uuid",
-			requestBody:    `[{"op": "replace", "path": "/condition_script", "value": "event.type == 'test'"}]`,
+			requestBody:    `[{"op": "replace", "path": "/condition_script", "value": "return event.type == 'test'"}]`,
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
 		},
 		{
 			name:           "trigger not found",
 			triggerID:      uuid.New().String(),
-			requestBody:    `[{"op": "replace", "path": "/condition_script", "value": "event.type == 'test'"}]`,
+			requestBody:    `[{"op": "replace", "path": "/condition_script", "value": "return event.type == 'test'"}]`,
 			expectedStatus: http.StatusNotFound,
 			setupMocks: func() {
 				mockTriggerSvc.On("GetByID", mock.Anything, mock.Anything).Return((*trigger.Trigger)(nil), triggerStorage.ErrNotFound)
@@ -801,6 +1024,43 @@ func TestServer_UpdateTrigger(t *testing.T) {
 				mockTriggerSvc.On("GetByID", mock.Anything, triggerID).Return(existingTrigger, nil)
 			},
 		},
+		{
+			name:      "switch to an expression",
+			triggerID: triggerID.String(),
+			requestBody: `[
+				{"op": "replace", "path": "/language", "value": "expr"},
+				{"op": "replace", "path": "/condition_script", "value": "ctx.event.payload.type == 'new_condition'"}
+			]`,
+			expectedStatus: http.StatusOK,
+			setupMocks: func() {
+				mockTriggerSvc.On("GetByID", mock.Anything, triggerID).Return(existingTrigger, nil)
+				mockTriggerSvc.On("Update", mock.Anything, mock.MatchedBy(func(tr *trigger.Trigger) bool {
+					return tr.ID == triggerID && tr.Language == "expr"
+				})).Return(nil)
+			},
+		},
+		{
+			name:      "lua script as an expression",
+			triggerID: triggerID.String(),
+			requestBody: `[
+				{"op": "replace", "path": "/language", "value": "expr"}
+			]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			setupMocks: func() {
+				mockTriggerSvc.On("GetByID", mock.Anything, triggerID).Return(existingTrigger, nil)
+			},
+		},
+		{
+			name:      "unknown language",
+			triggerID: triggerID.String(),
+			requestBody: `[
+				{"op": "replace", "path": "/language", "value": "python"}
+			]`,
+			expectedStatus: http.StatusBadRequest,
+			setupMocks: func() {
+				mockTriggerSvc.On("GetByID", mock.Anything, triggerID).Return(existingTrigger, nil)
+			},
+		},
 		{
 			name:      "empty condition_script",
 			triggerID: triggerID.String(),
@@ -823,7 +1083,7 @@ func TestServer_UpdateTrigger(t *testing.T) {
 			req = mux.SetURLVars(req, map[string]string{"id": tt.triggerID})
 			w := httptest.NewRecorder()
 
-			updateTrigger(mockTriggerSvc)(w, req)
+			updateTrigger(mockTriggerSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockTriggerSvc.AssertExpectations(t)
@@ -935,6 +1195,13 @@ func TestServer_UpdateAction(t *testing.T) {
 		LuaScript: "print('old action')",
 		Enabled:   true,
 	}
+	publishID := uuid.New()
+	publishAction := &action.Action{
+		ID:      publishID,
+		Type:    action.TypePublishEvent,
+		Params:  `{"subject": "events.derived.hot", "payload": {"rule": "{{rule.name}}"}}`,
+		Enabled: true,
+	}
 
 	tests := []struct {
 		name           string
@@ -958,6 +1225,32 @@ func TestServer_UpdateAction(t *testing.T) {
 				})).Return(nil)
 			},
 		},
+		{
+			name:     "publish event subject",
+			actionID: publishID.String(),
+			requestBody: `[
+				{"op": "replace", "path": "/params/subject", "value": "events.derived.cold"}
+			]`,
+			expectedStatus: http.StatusOK,
+			setupMocks: func() {
+				mockActionSvc.On("GetByID", mock.Anything, publishID).Return(publishAction, nil)
+				mockActionSvc.On("Update", mock.Anything, mock.MatchedBy(func(a *action.Action) bool {
+					return a.ID == publishID && a.Type == action.TypePublishEvent &&
+						strings.Contains(a.Params, `"subject":"events.derived.cold"`)
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "publish event without subject",
+			actionID: publishID.String(),
+			requestBody: `[
+				{"op": "remove", "path": "/params/subject"}
+			]`,
+			expectedStatus: http.StatusBadRequest,
+			setupMocks: func() {
+				mockActionSvc.On("GetByID", mock.Anything, publishID).Return(publishAction, nil)
+			},
+		},
 		{
 			name:           "invalid uuid",
 			actionID:       "invalid-uuid",
@@ -1005,7 +1298,7 @@ func TestServer_UpdateAction(t *testing.T) {
 			req = mux.SetURLVars(req, map[string]string{"id": tt.actionID})
 			w := httptest.NewRecorder()
 
-			updateAction(mockActionSvc)(w, req)
+			updateAction(mockActionSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockActionSvc.AssertExpectations(t)
@@ -1051,6 +1344,68 @@ func TestServer_CreateAction(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			setupMocks:     func() {},
 		},
+		{
+			name: "publish event",
+			requestBody: CreateActionRequest{
+				Type:   action.TypePublishEvent,
+				Params: json.RawMessage(`{"subject": "events.derived.{{event.payload.room}}", "payload": {"rule": "{{rule.name}}"}}`),
+			},
+			expectedStatus: http.StatusCreated,
+			setupMocks: func() {
+				mockActionSvc.On("Create", mock.Anything, mock.MatchedBy(func(a *action.Action) bool {
+					return a.Type == action.TypePublishEvent && strings.Contains(a.Params, "events.derived.") && a.LuaScript == ""
+				})).Return(nil)
+			},
+		},
+		{
+			name: "publish event without subject",
+			requestBody: CreateActionRequest{
+				Type:   action.TypePublishEvent,
+				Params: json.RawMessage(`{"payload": {}}`),
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+		{
+			name: "publish event with invalid template",
+			requestBody: CreateActionRequest{
+				Type:   action.TypePublishEvent,
+				Params: json.RawMessage(`{"subject": "events.{{rule.name"}`),
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+		{
+			name: "notify",
+			requestBody: CreateActionRequest{
+				Type:   action.TypeNotify,
+				Params: json.RawMessage(`{"channel": "email", "to": ["ops@example.com"], "subject": "Leak", "body": "Water in the {{event.payload.room}}"}`),
+			},
+			expectedStatus: http.StatusCreated,
+			setupMocks: func() {
+				mockActionSvc.On("Create", mock.Anything, mock.MatchedBy(func(a *action.Action) bool {
+					return a.Type == action.TypeNotify && strings.Contains(a.Params, `"channel":"email"`) && a.LuaScript == ""
+				})).Return(nil)
+			},
+		},
+		{
+			name: "notify without channel",
+			requestBody: CreateActionRequest{
+				Type:   action.TypeNotify,
+				Params: json.RawMessage(`{"body": "hello"}`),
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+		{
+			name: "unknown type",
+			requestBody: CreateActionRequest{
+				Type:      "send_email",
+				LuaScript: "print('x')",
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1062,7 +1417,7 @@ func TestServer_CreateAction(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
-			createAction(mockActionSvc)(w, req)
+			createAction(mockActionSvc, testScriptValidator)(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			mockActionSvc.AssertExpectations(t)
@@ -1101,42 +1456,111 @@ func TestServer_EvaluateScript(t *testing.T) {
 			},
 		},
 		{
-			name: "script execution error",
+			name: "structured multiple return",
 			requestBody: EvaluateScriptRequest{
-				Script: "invalid lua",
+				Script: "return {level = 'high'}, 'too hot'",
 			},
 			expectedStatus: http.StatusOK,
 			setupMocks: func() {
 				result := &executor.ExecuteResult{
-					Success:  false,
-					Error:    "syntax error",
-					Duration: 50 * time.Millisecond,
+					Success:  true,
+					Output:   []any{map[string]any{"level": "high"}, "too hot"},
+					Duration: 100 * time.Millisecond,
 				}
-				mockExecutorSvc.On("ExecuteScript", mock.Anything, "invalid lua", mock.AnythingOfType("*context.ExecutionContext")).Return(result)
+				mockExecutorSvc.On("ExecuteScript", mock.Anything, "return {level = 'high'}, 'too hot'", mock.AnythingOfType("*context.ExecutionContext")).Return(result)
 			},
 			expectedResponse: EvaluateScriptResponse{
-				Success:  false,
-				Error:    "syntax error",
-				Duration: "50ms",
+				Success:  true,
+				Result:   map[string]any{"level": "high"},
+				Output:   []any{map[string]any{"level": "high"}, "too hot"},
+				Duration: "100ms",
 			},
 		},
 		{
-			name: "empty script",
+			name: "captured logs",
 			requestBody: EvaluateScriptRequest{
-				Script: "",
+				Script: "print('checking') return true",
+			},
+			expectedStatus: http.StatusOK,
+			setupMocks: func() {
+				result := &executor.ExecuteResult{
+					Success:       true,
+					Output:        []any{true},
+					Duration:      100 * time.Millisecond,
+					Logs:          []scriptlog.Entry{{Level: "info", Message: "checking", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}},
+					LogsTruncated: true,
+				}
+				mockExecutorSvc.On("ExecuteScript", mock.Anything, "print('checking') return true", mock.AnythingOfType("*context.ExecutionContext")).Return(result)
+			},
+			expectedResponse: EvaluateScriptResponse{
+				Success:       true,
+				Output:        []any{true},
+				Duration:      "100ms",
+				Logs:          []ScriptLogEntry{{Level: "info", Message: "checking", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}},
+				LogsTruncated: true,
 			},
-			expectedStatus: http.StatusBadRequest,
-			setupMocks:     func() {},
 		},
 		{
-			name: "script too long",
+			name: "expression",
 			requestBody: EvaluateScriptRequest{
-				Script: string(make([]byte, 10001)),
+				Script:   "temperature > 25",
+				Language: "expr",
+				Context:  map[string]any{"temperature": 28.5},
+			},
+			expectedStatus: http.StatusOK,
+			setupMocks: func() {
+				result := &executor.ExecuteResult{
+					Success:  true,
+					Output:   []any{true},
+					Duration: time.Millisecond,
+				}
+				mockExecutorSvc.On("ExecuteScript", mock.Anything, "temperature > 25", mock.MatchedBy(func(ec *execCtx.ExecutionContext) bool {
+					return ec.Language == "expr"
+				})).Return(result)
+			},
+			expectedResponse: EvaluateScriptResponse{
+				Success:  true,
+				Output:   []any{true},
+				Duration: "1ms",
 			},
-			expectedStatus: http.StatusBadRequest,
-			setupMocks:     func() {},
 		},
-	}
+		{
+			name: "script execution error",
+			requestBody: EvaluateScriptRequest{
+				Script: "invalid lua",
+			},
+			expectedStatus: http.StatusOK,
+			setupMocks: func() {
+				result := &executor.ExecuteResult{
+					Success:  false,
+					Error:    "syntax error",
+					Duration: 50 * time.Millisecond,
+				}
+				mockExecutorSvc.On("ExecuteScript", mock.Anything, "invalid lua", mock.AnythingOfType("*context.ExecutionContext")).Return(result)
+			},
+			expectedResponse: EvaluateScriptResponse{
+				Success:  false,
+				Error:    "syntax error",
+				Duration: "50ms",
+			},
+		},
+		{
+			name: "empty script",
+			requestBody: EvaluateScriptRequest{
+				Script: "",
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+		{
+			name: "script too long",
+			requestBody: EvaluateScriptRequest{
+				Script: string(make([]byte, 10001)),
+			},
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func() {},
+		},
+	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1161,9 +1585,738 @@ func TestServer_EvaluateScript(t *testing.T) {
 				if tt.expectedResponse.Output != nil {
 					assert.Equal(t, tt.expectedResponse.Output, response.Output)
 				}
+				if tt.expectedResponse.Result != nil {
+					assert.Equal(t, tt.expectedResponse.Result, response.Result)
+				}
+				assert.Equal(t, tt.expectedResponse.Logs, response.Logs)
+				assert.Equal(t, tt.expectedResponse.LogsTruncated, response.LogsTruncated)
 			}
 
 			mockExecutorSvc.AssertExpectations(t)
 		})
 	}
 }
+
+func TestServer_ListRuleExecutions(t *testing.T) {
+	ruleID := uuid.New()
+	triggerID := uuid.New()
+	executions := []*execution.Execution{
+		{
+			ID:        2,
+			RuleID:    ruleID,
+			TriggerID: triggerID,
+			Status:    "SUCCESS",
+			Duration:  3 * time.Millisecond,
+			Output:    []any{true},
+			Logs:      []scriptlog.Entry{{Level: "info", Message: "checking"}},
+		},
+		{ID: 1, RuleID: ruleID, Status: "FAILURE", Error: "boom"},
+	}
+
+	tests := []struct {
+		name           string
+		ruleID         string
+		query          string
+		expectedStatus int
+		setupMocks     func(m *mockExecutionService)
+	}{
+		{
+			name:           "successful list",
+			ruleID:         ruleID.String(),
+			query:          "?limit=10&offset=0",
+			expectedStatus: http.StatusOK,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("ListByRule", mock.Anything, ruleID, 10, 0).Return(executions, 2, nil)
+			},
+		},
+		{
+			name:           "invalid uuid",
+			ruleID:         "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func(m *mockExecutionService) {},
+		},
+		{
+			name:           "invalid limit",
+			ruleID:         ruleID.String(),
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func(m *mockExecutionService) {},
+		},
+		{
+			name:           "service error",
+			ruleID:         ruleID.String(),
+			expectedStatus: http.StatusInternalServerError,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("ListByRule", mock.Anything, ruleID, 50, 0).Return([]*execution.Execution(nil), 0, assert.AnError)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutionSvc := &mockExecutionService{}
+			tt.setupMocks(mockExecutionSvc)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/"+tt.ruleID+"/executions"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.ruleID})
+			w := httptest.NewRecorder()
+
+			listRuleExecutions(mockExecutionSvc)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Executions []ExecutionInfo `json:"executions"`
+					Total      int             `json:"total"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 2, response.Total)
+				if assert.Len(t, response.Executions, 2) {
+					assert.Equal(t, &triggerID, response.Executions[0].TriggerID)
+					assert.Equal(t, int64(3), response.Executions[0].DurationMs)
+					assert.Equal(t, "checking", response.Executions[0].Logs[0].Message)
+					assert.Nil(t, response.Executions[1].TriggerID)
+					assert.Equal(t, "boom", response.Executions[1].Error)
+				}
+			}
+			mockExecutionSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_GetExecution(t *testing.T) {
+	tests := []struct {
+		name           string
+		executionID    string
+		expectedStatus int
+		setupMocks     func(m *mockExecutionService)
+	}{
+		{
+			name:           "successful get",
+			executionID:    "42",
+			expectedStatus: http.StatusOK,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("GetByID", mock.Anything, int64(42)).Return(&execution.Execution{ID: 42, RuleID: uuid.New(), Status: "SUCCESS"}, nil)
+			},
+		},
+		{
+			name:           "invalid id",
+			executionID:    "abc",
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func(m *mockExecutionService) {},
+		},
+		{
+			name:           "execution not found",
+			executionID:    "7",
+			expectedStatus: http.StatusNotFound,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("GetByID", mock.Anything, int64(7)).Return((*execution.Execution)(nil), executionStorage.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutionSvc := &mockExecutionService{}
+			tt.setupMocks(mockExecutionSvc)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/executions/"+tt.executionID, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.executionID})
+			w := httptest.NewRecorder()
+
+			getExecution(mockExecutionSvc)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockExecutionSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_ReplayExecution(t *testing.T) {
+	replayed := &execution.Replay{
+		Execution:   &execution.Execution{ID: 42, Status: "SUCCESS", Output: []any{true}, Replayable: true},
+		Script:      execution.ReplayScriptProposed,
+		Result:      &executor.ExecuteResult{Success: true, Status: executor.StatusSuccess, Output: []any{false}},
+		Divergences: []string{"GET http://sensors.local was not recorded"},
+	}
+
+	tests := []struct {
+		name           string
+		executionID    string
+		body           string
+		expectedStatus int
+		setupMocks     func(m *mockExecutionService)
+	}{
+		{
+			name:           "proposed script",
+			executionID:    "42",
+			body:           `{"script": "return false"}`,
+			expectedStatus: http.StatusOK,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("Replay", mock.Anything, int64(42), "return false").Return(replayed, nil)
+			},
+		},
+		{
+			name:           "current script without body",
+			executionID:    "42",
+			expectedStatus: http.StatusOK,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("Replay", mock.Anything, int64(42), "").Return(replayed, nil)
+			},
+		},
+		{
+			name:           "invalid id",
+			executionID:    "abc",
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func(m *mockExecutionService) {},
+		},
+		{
+			name:           "invalid body",
+			executionID:    "42",
+			body:           `{"script": `,
+			expectedStatus: http.StatusBadRequest,
+			setupMocks:     func(m *mockExecutionService) {},
+		},
+		{
+			name:           "execution not found",
+			executionID:    "7",
+			expectedStatus: http.StatusNotFound,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("Replay", mock.Anything, int64(7), "").Return((*execution.Replay)(nil), executionStorage.ErrNotFound)
+			},
+		},
+		{
+			name:           "not recorded",
+			executionID:    "7",
+			expectedStatus: http.StatusConflict,
+			setupMocks: func(m *mockExecutionService) {
+				m.On("Replay", mock.Anything, int64(7), "").Return((*execution.Replay)(nil), execution.ErrNotReplayable)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExecutionSvc := &mockExecutionService{}
+			tt.setupMocks(mockExecutionSvc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/executions/"+tt.executionID+"/replay", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = mux.SetURLVars(req, map[string]string{"id": tt.executionID})
+			w := httptest.NewRecorder()
+
+			replayExecution(mockExecutionSvc)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response ReplayExecutionResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, int64(42), response.ExecutionID)
+				assert.Equal(t, execution.ReplayScriptProposed, response.Script)
+				assert.Equal(t, []any{false}, response.Result.Output)
+				assert.Equal(t, []any{true}, response.OriginalOutput)
+				assert.False(t, response.Matches)
+				assert.Equal(t, replayed.Divergences, response.Divergences)
+			}
+			mockExecutionSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_ScriptCoverage(t *testing.T) {
+	collector := coverage.NewCollector(0)
+	executorSvc := executor.NewService(execCtx.NewService(), platform.NewService(), executor.WithCoverageRecorder(collector))
+	script := "if temperature > 30 then\n  return 'hot'\nend\nreturn 'ok'"
+
+	// Evaluating the script of a rule with coverage counts towards the rule
+	body, err := json.Marshal(EvaluateScriptRequest{Script: script, Context: map[string]any{"temperature": 25}, Coverage: true})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	evaluateScript(executorSvc)(w, httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var evaluation EvaluateScriptResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &evaluation))
+	assert.Equal(t, map[int]int{1: 1, 4: 1}, evaluation.Coverage)
+
+	ruleID := uuid.New()
+	ruleSvc := &mockRuleService{}
+	ruleSvc.On("GetByID", mock.Anything, ruleID).Return(&rule.Rule{ID: ruleID, LuaScript: script}, nil)
+	get := func(handler http.HandlerFunc, id string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w = get(getRuleCoverage(ruleSvc, collector), ruleID.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info ScriptCoverageInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, 1, info.Runs)
+	assert.Equal(t, 3, info.ExecutableLines)
+	assert.Equal(t, 2, info.CoveredLines)
+	assert.Equal(t, []LineCoverageInfo{{Line: 1, Hits: 1}, {Line: 2, Hits: 0}, {Line: 4, Hits: 1}}, info.Lines)
+	assert.NotNil(t, info.LastRunAt)
+
+	w = httptest.NewRecorder()
+	resetCoverage(collector)(w, httptest.NewRequest(http.MethodDelete, "/api/v1/coverage", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = get(getRuleCoverage(ruleSvc, collector), ruleID.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Zero(t, info.Runs)
+	assert.Zero(t, info.CoveredLines)
+
+	assert.Equal(t, http.StatusBadRequest, get(getRuleCoverage(ruleSvc, collector), "invalid").Code)
+
+	triggerSvc := &mockTriggerService{}
+	triggerID := uuid.New()
+	triggerSvc.On("GetByID", mock.Anything, triggerID).Return((*trigger.Trigger)(nil), triggerStorage.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, get(getTriggerCoverage(triggerSvc, collector), triggerID.String()).Code)
+	exprTriggerID := uuid.New()
+	triggerSvc.On("GetByID", mock.Anything, exprTriggerID).Return(&trigger.Trigger{ID: exprTriggerID, ConditionScript: "temperature > 30", Language: "expr"}, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, get(getTriggerCoverage(triggerSvc, collector), exprTriggerID.String()).Code)
+
+	actionSvc := &mockActionService{}
+	actionID := uuid.New()
+	actionSvc.On("GetByID", mock.Anything, actionID).Return(&action.Action{ID: actionID, LuaScript: "return 1"}, nil)
+	w = get(getActionCoverage(actionSvc, collector), actionID.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, 1, info.ExecutableLines)
+}
+
+func TestServer_RuleProfile(t *testing.T) {
+	collector := profile.NewCollector(0)
+	executorSvc := executor.NewService(execCtx.NewService(), platform.NewService(), executor.WithProfileRecorder(collector))
+	script := "local logger = require('logger')\nlogger.info('checking')\nreturn true"
+
+	// Evaluating with profile returns the profile of the execution
+	body, err := json.Marshal(EvaluateScriptRequest{Script: script, Profile: true})
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	evaluateScript(executorSvc)(w, httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var evaluation EvaluateScriptResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &evaluation))
+	assert.NotNil(t, evaluation.Profile)
+	assert.Len(t, evaluation.Profile.Calls, 1)
+	assert.Equal(t, "logger.info", evaluation.Profile.Calls[0].Name)
+	assert.Equal(t, int64(1), evaluation.Profile.Calls[0].Count)
+
+	// Executions of a rule are aggregated per rule
+	ruleID := uuid.New()
+	for range 2 {
+		ec := execCtx.NewService(execCtx.WithProfiling(true)).CreateContext(ruleID.String(), "trigger")
+		assert.True(t, executorSvc.ExecuteScript(context.Background(), script, ec).Success)
+	}
+
+	ruleSvc := &mockRuleService{}
+	ruleSvc.On("GetByID", mock.Anything, ruleID).Return(&rule.Rule{ID: ruleID, LuaScript: script}, nil)
+	request := func(handler http.HandlerFunc, id string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w = request(getRuleProfile(ruleSvc, collector), ruleID.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info RuleProfileInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, ruleID.String(), info.RuleID)
+	assert.Equal(t, int64(2), info.Runs)
+	assert.Greater(t, info.TotalMs, 0.0)
+	assert.LessOrEqual(t, info.VMMs, info.TotalMs)
+	assert.Len(t, info.Calls, 1)
+	assert.Equal(t, "logger.info", info.Calls[0].Name)
+	assert.Equal(t, int64(2), info.Calls[0].Count)
+	assert.NotNil(t, info.LastRunAt)
+
+	w = request(resetRuleProfile(ruleSvc, collector), ruleID.String())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(getRuleProfile(ruleSvc, collector), ruleID.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Zero(t, info.Runs)
+	assert.Empty(t, info.Calls)
+
+	assert.Equal(t, http.StatusBadRequest, request(getRuleProfile(ruleSvc, collector), "invalid").Code)
+	missingID := uuid.New()
+	ruleSvc.On("GetByID", mock.Anything, missingID).Return((*rule.Rule)(nil), ruleStorage.ErrNotFound)
+	assert.Equal(t, http.StatusNotFound, request(getRuleProfile(ruleSvc, collector), missingID.String()).Code)
+}
+
+func TestServer_CreateLibrary(t *testing.T) {
+	platformSvc := platform.NewService()
+	assert.NoError(t, platformSvc.SetLibraries([]platform.Library{{Name: "units", Code: "return {}"}}))
+	scriptValidator := validation.NewValidator(platformSvc.ModuleNames()...).WithModuleSource(platformSvc)
+
+	tests := []struct {
+		name           string
+		requestBody    CreateLibraryRequest
+		createErr      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "successful creation",
+			requestBody:    CreateLibraryRequest{Name: "thresholds", Code: "local units = require('units')\nreturn {max = 28, unit = units.celsius}"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid name",
+			requestBody:    CreateLibraryRequest{Name: "my-lib", Code: "return {}"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_ERROR",
+		},
+		{
+			name:           "unknown module",
+			requestBody:    CreateLibraryRequest{Name: "thresholds", Code: `return require("missing")`},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "SCRIPT_VALIDATION_ERROR",
+		},
+		{
+			name:           "name taken",
+			requestBody:    CreateLibraryRequest{Name: "thresholds", Code: "return {}"},
+			createErr:      libraryStorage.ErrDuplicateName,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "LIBRARY_NAME_TAKEN",
+		},
+		{
+			name:           "dependency cycle",
+			requestBody:    CreateLibraryRequest{Name: "thresholds", Code: `return require("units")`},
+			createErr:      fmt.Errorf("%w: thresholds -> units -> thresholds", platform.ErrDependencyCycle),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "INVALID_LIBRARY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			librarySvc := &mockLibraryService{}
+			librarySvc.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				args.Get(1).(*library.Library).Version = 1
+			}).Return(tt.createErr).Maybe()
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/libraries", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			createLibrary(librarySvc, scriptValidator)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			} else {
+				var info LibraryInfo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+				assert.Equal(t, "thresholds", info.Name)
+				assert.Equal(t, 1, info.Version)
+			}
+		})
+	}
+}
+
+func TestServer_UpdateLibrary(t *testing.T) {
+	librarySvc := &mockLibraryService{}
+	id := uuid.New()
+	librarySvc.On("GetByID", mock.Anything, id).Return(&library.Library{ID: id, Name: "thresholds", Code: "return {max = 28}", Version: 1}, nil)
+	librarySvc.On("Update", mock.Anything, mock.MatchedBy(func(l *library.Library) bool {
+		return l.ID == id && l.Code == "return {max = 30}"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*library.Library).Version = 2
+	}).Return(nil)
+
+	patch := `[{"op": "replace", "path": "/code", "value": "return {max = 30}"}]`
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(patch)), map[string]string{"id": id.String()})
+	w := httptest.NewRecorder()
+
+	updateLibrary(librarySvc, testScriptValidator)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info LibraryInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, 2, info.Version)
+	librarySvc.AssertExpectations(t)
+}
+
+func TestServer_LibraryVersions(t *testing.T) {
+	librarySvc := &mockLibraryService{}
+	id := uuid.New()
+	librarySvc.On("ListVersions", mock.Anything, id).Return([]*library.Version{
+		{LibraryID: id, Version: 2, Code: "return 2"},
+		{LibraryID: id, Version: 1, Code: "return 1"},
+	}, nil)
+	librarySvc.On("GetVersion", mock.Anything, id, 1).Return(&library.Version{LibraryID: id, Version: 1, Code: "return 1"}, nil)
+	librarySvc.On("GetVersion", mock.Anything, id, 3).Return((*library.Version)(nil), libraryStorage.ErrNotFound)
+	get := func(handler http.HandlerFunc, vars map[string]string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), vars)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := get(listLibraryVersions(librarySvc), map[string]string{"id": id.String()})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Versions []LibraryVersionInfo `json:"versions"`
+		Count    int                  `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Count)
+	assert.Equal(t, 2, list.Versions[0].Version)
+
+	w = get(getLibraryVersion(librarySvc), map[string]string{"id": id.String(), "version": "1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var version LibraryVersionInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &version))
+	assert.Equal(t, "return 1", version.Code)
+
+	assert.Equal(t, http.StatusNotFound, get(getLibraryVersion(librarySvc), map[string]string{"id": id.String(), "version": "3"}).Code)
+	assert.Equal(t, http.StatusBadRequest, get(getLibraryVersion(librarySvc), map[string]string{"id": id.String(), "version": "0"}).Code)
+}
+
+func TestServer_DeleteLibrary_StillRequired(t *testing.T) {
+	librarySvc := &mockLibraryService{}
+	id := uuid.New()
+	librarySvc.On("Delete", mock.Anything, id).Return(fmt.Errorf("%w: library 'alerts' requires unknown module 'units'", platform.ErrUnknownDependency))
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/", nil), map[string]string{"id": id.String()})
+	w := httptest.NewRecorder()
+	deleteLibrary(librarySvc)(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "requires unknown module 'units'")
+}
+
+func TestServer_DeleteLibrary_InUse(t *testing.T) {
+	librarySvc := &mockLibraryService{}
+	id := uuid.New()
+	librarySvc.On("Delete", mock.Anything, id).Return(fmt.Errorf("%w: rule 'heating' requires 'units'", library.ErrInUse))
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/", nil), map[string]string{"id": id.String()})
+	w := httptest.NewRecorder()
+	deleteLibrary(librarySvc)(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "LIBRARY_IN_USE")
+	assert.Contains(t, w.Body.String(), "rule 'heating' requires 'units'")
+}
+
+func TestServer_CreateSecret(t *testing.T) {
+	ruleID := uuid.New()
+
+	tests := []struct {
+		name           string
+		requestBody    CreateSecretRequest
+		createErr      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "successful creation",
+			requestBody:    CreateSecretRequest{Name: "weather_api_key", Value: "s3cr3t", RuleIDs: []uuid.UUID{ruleID}},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid name",
+			requestBody:    CreateSecretRequest{Name: "weather api key", Value: "s3cr3t"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_ERROR",
+		},
+		{
+			name:           "missing value",
+			requestBody:    CreateSecretRequest{Name: "weather_api_key"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_ERROR",
+		},
+		{
+			name:           "name taken",
+			requestBody:    CreateSecretRequest{Name: "weather_api_key", Value: "s3cr3t"},
+			createErr:      secretStorage.ErrDuplicateName,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "SECRET_NAME_TAKEN",
+		},
+		{
+			name:           "unknown rule",
+			requestBody:    CreateSecretRequest{Name: "weather_api_key", Value: "s3cr3t", RuleIDs: []uuid.UUID{ruleID}},
+			createErr:      secretStorage.ErrUnknownRule,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "UNKNOWN_RULE",
+		},
+		{
+			name:           "no master key",
+			requestBody:    CreateSecretRequest{Name: "weather_api_key", Value: "s3cr3t"},
+			createErr:      secret.ErrNotConfigured,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "SECRETS_NOT_CONFIGURED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretSvc := &mockSecretService{}
+			secretSvc.On("Create", mock.Anything, mock.Anything, "s3cr3t").Run(func(args mock.Arguments) {
+				args.Get(1).(*secret.Secret).ID = uuid.New()
+			}).Return(tt.createErr).Maybe()
+
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/secrets", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			createSecret(secretSvc)(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NotContains(t, w.Body.String(), "s3cr3t")
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			} else {
+				var info SecretInfo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+				assert.Equal(t, "weather_api_key", info.Name)
+				assert.Equal(t, []uuid.UUID{ruleID}, info.RuleIDs)
+			}
+		})
+	}
+}
+
+func TestServer_UpdateSecret(t *testing.T) {
+	secretSvc := &mockSecretService{}
+	id, ruleID := uuid.New(), uuid.New()
+	secretSvc.On("Update", mock.Anything, mock.MatchedBy(func(s *secret.Secret) bool {
+		return s.ID == id && s.Description == "rotated" && len(s.RuleIDs) == 1 && s.RuleIDs[0] == ruleID
+	}), mock.MatchedBy(func(value *string) bool {
+		return value != nil && *value == "n3w"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*secret.Secret).Name = "weather_api_key"
+	}).Return(nil)
+
+	body := fmt.Sprintf(`{"description": " rotated ", "value": "n3w", "rule_ids": [%q]}`, ruleID)
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body)), map[string]string{"id": id.String()})
+	w := httptest.NewRecorder()
+
+	updateSecret(secretSvc)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "n3w")
+	var info SecretInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "weather_api_key", info.Name)
+	secretSvc.AssertExpectations(t)
+}
+
+func TestServer_GetSecret(t *testing.T) {
+	secretSvc := &mockSecretService{}
+	id, missing := uuid.New(), uuid.New()
+	secretSvc.On("GetByID", mock.Anything, id).Return(&secret.Secret{ID: id, Name: "weather_api_key", RuleIDs: []uuid.UUID{}}, nil)
+	secretSvc.On("GetByID", mock.Anything, missing).Return((*secret.Secret)(nil), secretStorage.ErrNotFound)
+	get := func(id string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		getSecret(secretSvc)(w, req)
+		return w
+	}
+
+	w := get(id.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"id": %q, "name": "weather_api_key", "description": "", "rule_ids": [],
+		"created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"}`, id), w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, get(missing.String()).Code)
+	assert.Equal(t, http.StatusBadRequest, get("not-a-uuid").Code)
+}
+
+func TestServer_DebugSession(t *testing.T) {
+	debugSvc := debugger.NewManager(executor.NewService(execCtx.NewService(), platform.NewService()))
+
+	do := func(handler http.HandlerFunc, method, target string, vars map[string]string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			var err error
+			data, err = json.Marshal(body)
+			assert.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, target, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req = mux.SetURLVars(req, vars)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) DebugSessionInfo {
+		var info DebugSessionInfo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		return info
+	}
+
+	w := do(startDebugSession(debugSvc), http.MethodPost, "/api/v1/debug/sessions", nil, StartDebugSessionRequest{
+		Script:      "local doubled = value * 2\nlocal alert = doubled > 10\nreturn alert",
+		Context:     map[string]any{"value": 6},
+		Breakpoints: []int{2},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	info := decode(w)
+	assert.Equal(t, "paused", info.Status)
+	assert.Equal(t, "breakpoint", info.Reason)
+	assert.Equal(t, 2, info.Line)
+	if assert.Len(t, info.Stack, 1) {
+		assert.Equal(t, []DebugVariable{{Name: "doubled", Value: 12.0}}, info.Stack[0].Locals)
+	}
+	vars := map[string]string{"id": info.ID}
+
+	w = do(getDebugSession(debugSvc), http.MethodGet, "/api/v1/debug/sessions/"+info.ID, vars, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, decode(w).Line)
+
+	w = do(setDebugBreakpoints(debugSvc), http.MethodPut, "/api/v1/debug/sessions/"+info.ID+"/breakpoints", vars, SetBreakpointsRequest{Breakpoints: []int{-1}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(setDebugBreakpoints(debugSvc), http.MethodPut, "/api/v1/debug/sessions/"+info.ID+"/breakpoints", vars, SetBreakpointsRequest{Breakpoints: []int{3}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{3}, decode(w).Breakpoints)
+
+	stepVars := map[string]string{"id": info.ID, "command": "step"}
+	w = do(resumeDebugSession(debugSvc), http.MethodPost, "/api/v1/debug/sessions/"+info.ID+"/step", stepVars, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	info = decode(w)
+	assert.Equal(t, "paused", info.Status)
+	assert.Equal(t, 3, info.Line)
+
+	continueVars := map[string]string{"id": info.ID, "command": "continue"}
+	w = do(resumeDebugSession(debugSvc), http.MethodPost, "/api/v1/debug/sessions/"+info.ID+"/continue", continueVars, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	info = decode(w)
+	assert.Equal(t, "finished", info.Status)
+	if assert.NotNil(t, info.Result) {
+		assert.True(t, info.Result.Success)
+		assert.Equal(t, true, info.Result.Result)
+	}
+
+	w = do(resumeDebugSession(debugSvc), http.MethodPost, "/api/v1/debug/sessions/"+info.ID+"/continue", continueVars, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(stopDebugSession(debugSvc), http.MethodDelete, "/api/v1/debug/sessions/"+info.ID, vars, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(getDebugSession(debugSvc), http.MethodGet, "/api/v1/debug/sessions/"+info.ID, vars, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = do(stopDebugSession(debugSvc), http.MethodDelete, "/api/v1/debug/sessions/"+info.ID, vars, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_StartDebugSession_Errors(t *testing.T) {
+	debugSvc := debugger.NewManager(executor.NewService(execCtx.NewService(), platform.NewService()), debugger.WithMaxSessions(1))
+
+	start := func(body StartDebugSessionRequest) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/debug/sessions", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		startDebugSession(debugSvc)(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, start(StartDebugSessionRequest{Script: ""}).Code)
+	assert.Equal(t, http.StatusBadRequest, start(StartDebugSessionRequest{Script: "return 1", Breakpoints: []int{0}}).Code)
+
+	assert.Equal(t, http.StatusCreated, start(StartDebugSessionRequest{Script: "return 1", StopOnEntry: true}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, start(StartDebugSessionRequest{Script: "return 1"}).Code)
+}
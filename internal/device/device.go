@@ -0,0 +1,47 @@
+// Package device gives rules access to the devices of the IoT platform: their
+// reported state, the commands they accept and the inventory of devices.
+// The device service is reached with HTTPClient; Fake keeps devices in memory
+// for tests and for the device simulator of the end-to-end environment.
+package device
+
+import "errors"
+
+var (
+	// ErrNotFound is returned for a device the platform does not know
+	ErrNotFound = errors.New("device not found")
+	// ErrOffline is returned when a command is sent to a device that is offline
+	ErrOffline = errors.New("device is offline")
+)
+
+// Device describes a device of the platform
+type Device struct {
+	ID     string         `json:"id"`
+	Name   string         `json:"name,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Online bool           `json:"online"`
+	State  map[string]any `json:"state,omitempty"`
+}
+
+// Command is a command sent to a device
+type Command struct {
+	DeviceID string         `json:"device_id"`
+	Command  string         `json:"command"`
+	Params   map[string]any `json:"params,omitempty"`
+}
+
+// Filter selects devices by their attributes; zero fields match every device
+type Filter struct {
+	Type   string `json:"type,omitempty"`
+	Online *bool  `json:"online,omitempty"`
+}
+
+// Matches reports whether d is selected by the filter
+func (f Filter) Matches(d Device) bool {
+	if f.Type != "" && d.Type != f.Type {
+		return false
+	}
+	if f.Online != nil && d.Online != *f.Online {
+		return false
+	}
+	return true
+}
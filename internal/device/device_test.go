@@ -0,0 +1,112 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient(t *testing.T) {
+	var command Command
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/devices/thermostat%201/state":
+			_, _ = w.Write([]byte(`{"temperature": 21.5, "mode": "heat"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/devices/lamp/commands":
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&command))
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/devices/fan/commands":
+			w.WriteHeader(http.StatusConflict)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/devices":
+			assert.Equal(t, "online=true&type=lamp", r.URL.RawQuery)
+			_, _ = w.Write([]byte(`{"devices": [{"id": "lamp", "type": "lamp", "online": true, "state": {"on": false}}]}`))
+		case r.URL.Path == "/api/devices/broken/state":
+			http.Error(w, "database unavailable", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL+"/api/", WithToken("secret"))
+	ctx := context.Background()
+
+	state, err := client.GetState(ctx, "thermostat 1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"temperature": 21.5, "mode": "heat"}, state)
+
+	require.NoError(t, client.SendCommand(ctx, "lamp", "turn_on", map[string]any{"brightness": 80.0}))
+	assert.Equal(t, Command{Command: "turn_on", Params: map[string]any{"brightness": 80.0}}, command)
+
+	online := true
+	devices, err := client.List(ctx, Filter{Type: "lamp", Online: &online})
+	require.NoError(t, err)
+	assert.Equal(t, []Device{{ID: "lamp", Type: "lamp", Online: true, State: map[string]any{"on": false}}}, devices)
+
+	_, err = client.GetState(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, client.SendCommand(ctx, "fan", "turn_on", nil), ErrOffline)
+	_, err = client.GetState(ctx, "broken")
+	assert.EqualError(t, err, "device service returned 500: database unavailable")
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake(
+		Device{ID: "lamp", Type: "lamp", Online: true, State: map[string]any{"on": false}},
+		Device{ID: "fan", Type: "fan", Online: false},
+		Device{ID: "desk_lamp", Type: "lamp", Online: true},
+	)
+	ctx := context.Background()
+
+	require.NoError(t, fake.SendCommand(ctx, "lamp", "turn_on", map[string]any{"on": true}))
+	state, err := fake.GetState(ctx, "lamp")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"on": true}, state)
+	assert.Equal(t, []Command{{DeviceID: "lamp", Command: "turn_on", Params: map[string]any{"on": true}}}, fake.Commands())
+
+	// The returned state is a copy
+	state["on"] = false
+	state, _ = fake.GetState(ctx, "lamp")
+	assert.Equal(t, true, state["on"])
+
+	assert.ErrorIs(t, fake.SendCommand(ctx, "fan", "turn_on", nil), ErrOffline)
+	assert.ErrorIs(t, fake.SendCommand(ctx, "missing", "turn_on", nil), ErrNotFound)
+	_, err = fake.GetState(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	devices, err := fake.List(ctx, Filter{Type: "lamp"})
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "desk_lamp", devices[0].ID)
+	assert.Equal(t, "lamp", devices[1].ID)
+
+	offline := false
+	devices, err = fake.List(ctx, Filter{Online: &offline})
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "fan", devices[0].ID)
+}
+
+func TestLoadFake(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id": "sensor", "type": "sensor", "online": true, "state": {"temperature": 19}}]`), 0o600))
+
+	fake, err := LoadFake(path)
+	require.NoError(t, err)
+	state, err := fake.GetState(context.Background(), "sensor")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"temperature": 19.0}, state)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = LoadFake(path)
+	assert.ErrorContains(t, err, "invalid devices file")
+}
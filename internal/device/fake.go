@@ -0,0 +1,105 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Fake keeps devices in memory and simulates them: the params of the commands
+// sent to a device are merged into its state, so that scripts observe the
+// effect of their commands. It is safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	devices  map[string]Device
+	commands []Command
+}
+
+// NewFake creates a fake holding devices
+func NewFake(devices ...Device) *Fake {
+	f := &Fake{devices: make(map[string]Device, len(devices))}
+	for _, d := range devices {
+		f.Put(d)
+	}
+	return f
+}
+
+// LoadFake creates a fake holding the devices of a JSON file, an array of devices
+func LoadFake(path string) (*Fake, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("invalid devices file %s: %w", path, err)
+	}
+	return NewFake(devices...), nil
+}
+
+// Put adds a device or replaces the device with the same ID
+func (f *Fake) Put(d Device) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d.State = maps.Clone(d.State)
+	if d.State == nil {
+		d.State = map[string]any{}
+	}
+	f.devices[d.ID] = d
+}
+
+// GetState returns a copy of the state of a device
+func (f *Fake) GetState(_ context.Context, id string) (map[string]any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.devices[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return maps.Clone(d.State), nil
+}
+
+// SendCommand records a command and merges its params into the state of the device
+func (f *Fake) SendCommand(_ context.Context, id, command string, params map[string]any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.devices[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if !d.Online {
+		return ErrOffline
+	}
+	f.commands = append(f.commands, Command{DeviceID: id, Command: command, Params: maps.Clone(params)})
+	maps.Copy(d.State, params)
+	return nil
+}
+
+// List returns the devices selected by filter, ordered by ID
+func (f *Fake) List(_ context.Context, filter Filter) ([]Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	devices := []Device{}
+	for _, d := range f.devices {
+		if filter.Matches(d) {
+			d.State = maps.Clone(d.State)
+			devices = append(devices, d)
+		}
+	}
+	slices.SortFunc(devices, func(a, b Device) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return devices, nil
+}
+
+// Commands returns the commands sent so far, in order
+func (f *Fake) Commands() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.commands)
+}
@@ -0,0 +1,155 @@
+package device
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRequestTimeout bounds a request to the device service
+const DefaultRequestTimeout = 5 * time.Second
+
+// maxErrorBody bounds the part of an error response quoted in errors
+const maxErrorBody = 256
+
+// HTTPClient talks to the REST API of the device service:
+//
+//	GET  {base}/devices?type=&online=  lists devices as {"devices": [...]}
+//	GET  {base}/devices/{id}/state     returns the state of a device
+//	POST {base}/devices/{id}/commands  sends {"command": ..., "params": {...}}
+//
+// A 404 response means the device is unknown, a 409 that it is offline.
+type HTTPClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// HTTPClientOption allows to configure the HTTP client
+type HTTPClientOption func(c *HTTPClient) *HTTPClient
+
+// WithToken sets the bearer token sent to the device service
+func WithToken(token string) HTTPClientOption {
+	return func(c *HTTPClient) *HTTPClient {
+		c.token = token
+		return c
+	}
+}
+
+// WithHTTPClient sets the client requests are made with
+func WithHTTPClient(client *http.Client) HTTPClientOption {
+	return func(c *HTTPClient) *HTTPClient {
+		c.client = client
+		return c
+	}
+}
+
+// NewHTTPClient creates a client of the device service at baseURL
+func NewHTTPClient(baseURL string, opts ...HTTPClientOption) *HTTPClient {
+	c := &HTTPClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: DefaultRequestTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetState returns the state last reported by a device
+func (c *HTTPClient) GetState(ctx context.Context, id string) (map[string]any, error) {
+	var state map[string]any
+	if err := c.do(ctx, http.MethodGet, "/devices/"+url.PathEscape(id)+"/state", nil, &state); err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = map[string]any{}
+	}
+	return state, nil
+}
+
+// SendCommand sends a command to a device
+func (c *HTTPClient) SendCommand(ctx context.Context, id, command string, params map[string]any) error {
+	body := Command{Command: command, Params: params}
+	return c.do(ctx, http.MethodPost, "/devices/"+url.PathEscape(id)+"/commands", body, nil)
+}
+
+// List returns the devices selected by filter
+func (c *HTTPClient) List(ctx context.Context, filter Filter) ([]Device, error) {
+	query := url.Values{}
+	if filter.Type != "" {
+		query.Set("type", filter.Type)
+	}
+	if filter.Online != nil {
+		query.Set("online", strconv.FormatBool(*filter.Online))
+	}
+	path := "/devices"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var response struct {
+		Devices []Device `json:"devices"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	if response.Devices == nil {
+		response.Devices = []Device{}
+	}
+	return response.Devices, nil
+}
+
+// do sends a request with the JSON encoding of body, if any, and decodes
+// the response into result, if any
+func (c *HTTPClient) do(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("device service unreachable: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusConflict:
+		return ErrOffline
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("device service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from device service: %w", err)
+	}
+	return nil
+}
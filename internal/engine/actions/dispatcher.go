@@ -0,0 +1,135 @@
+// Package actions runs the parts of a rule execution shared by the trigger
+// manager and the queue workers: recording its outcome and the actions that
+// hand the execution over to another service.
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/notify"
+)
+
+// Action types run by the dispatcher
+const (
+	TypePublishEvent = "publish_event"
+	TypeNotify       = "notify"
+)
+
+// ErrUnknownType is returned for action types the dispatcher does not run
+var ErrUnknownType = errors.New("unknown action type")
+
+// ExecutionRecorder interface for storing the execution history of rules
+type ExecutionRecorder interface {
+	Record(ctx context.Context, execution *execution.Execution) error
+}
+
+// EventPublisher publishes the events of publish_event actions
+type EventPublisher interface {
+	PublishAction(ctx context.Context, params *events.ActionParams, ec *execCtx.ExecutionContext) error
+}
+
+// NotificationSender sends the notifications of notify actions
+type NotificationSender interface {
+	SendAction(ctx context.Context, params *notify.ActionParams, ec *execCtx.ExecutionContext) error
+}
+
+// Dispatcher records rule executions and runs their publish_event and notify actions.
+// A nil Dispatcher records nothing and fails the actions as not configured.
+type Dispatcher struct {
+	recorder  ExecutionRecorder
+	publisher EventPublisher
+	notifier  NotificationSender
+}
+
+// DispatcherOption allows to configure the dispatcher
+type DispatcherOption func(d *Dispatcher) *Dispatcher
+
+// WithExecutionRecorder sets where the outcome of every rule execution is recorded
+func WithExecutionRecorder(recorder ExecutionRecorder) DispatcherOption {
+	return func(d *Dispatcher) *Dispatcher {
+		d.recorder = recorder
+		return d
+	}
+}
+
+// WithEventPublisher sets the publisher of the events of publish_event actions
+func WithEventPublisher(publisher EventPublisher) DispatcherOption {
+	return func(d *Dispatcher) *Dispatcher {
+		d.publisher = publisher
+		return d
+	}
+}
+
+// WithNotificationSender sets the sender of the notifications of notify actions
+func WithNotificationSender(notifier NotificationSender) DispatcherOption {
+	return func(d *Dispatcher) *Dispatcher {
+		d.notifier = notifier
+		return d
+	}
+}
+
+// NewDispatcher creates a new action dispatcher
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Record stores the outcome of a rule script in the execution history
+func (d *Dispatcher) Record(ctx context.Context, ruleID, triggerID uuid.UUID, ec *execCtx.ExecutionContext, result *executor.ExecuteResult) error {
+	if d == nil || d.recorder == nil {
+		return nil
+	}
+	return d.recorder.Record(ctx, execution.FromResult(ruleID, triggerID, ec.FiredAt, result))
+}
+
+// Dispatch runs the action of type actionType described by params.
+// It returns ErrUnknownType for the types it does not run.
+func (d *Dispatcher) Dispatch(ctx context.Context, actionType, params string, ec *execCtx.ExecutionContext) error {
+	var err error
+	switch actionType {
+	case TypePublishEvent:
+		err = d.publishEvent(ctx, params, ec)
+	case TypeNotify:
+		err = d.sendNotification(ctx, params, ec)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownType, actionType)
+	}
+	if err != nil {
+		return fmt.Errorf("%s action failed: %w", actionType, err)
+	}
+	return nil
+}
+
+// publishEvent publishes the event described by the params of a publish_event action
+func (d *Dispatcher) publishEvent(ctx context.Context, params string, ec *execCtx.ExecutionContext) error {
+	if d == nil || d.publisher == nil {
+		return errors.New("event publishing is not configured")
+	}
+	p, err := events.ParseActionParams(params)
+	if err != nil {
+		return err
+	}
+	return d.publisher.PublishAction(ctx, p, ec)
+}
+
+// sendNotification sends the notification described by the params of a notify action
+func (d *Dispatcher) sendNotification(ctx context.Context, params string, ec *execCtx.ExecutionContext) error {
+	if d == nil || d.notifier == nil {
+		return errors.New("notifications are not configured")
+	}
+	p, err := notify.ParseActionParams(params)
+	if err != nil {
+		return err
+	}
+	return d.notifier.SendAction(ctx, p, ec)
+}
@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRecorder struct {
+	mock.Mock
+}
+
+func (m *mockRecorder) Record(ctx context.Context, execution *execution.Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+type mockPublisher struct {
+	mock.Mock
+}
+
+func (m *mockPublisher) PublishAction(ctx context.Context, params *events.ActionParams, ec *execCtx.ExecutionContext) error {
+	args := m.Called(ctx, params, ec)
+	return args.Error(0)
+}
+
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) SendAction(ctx context.Context, params *notify.ActionParams, ec *execCtx.ExecutionContext) error {
+	args := m.Called(ctx, params, ec)
+	return args.Error(0)
+}
+
+func TestDispatcher_Record(t *testing.T) {
+	recorder := &mockRecorder{}
+	d := NewDispatcher(WithExecutionRecorder(recorder))
+	ruleID, triggerID := uuid.New(), uuid.New()
+	ec := execCtx.NewService().CreateContext(ruleID.String(), triggerID.String())
+
+	recorder.On("Record", mock.Anything, mock.MatchedBy(func(e *execution.Execution) bool {
+		return e.RuleID == ruleID && e.TriggerID == triggerID && e.Status == "SUCCESS" && e.TriggeredAt.Equal(ec.FiredAt)
+	})).Return(nil)
+
+	require.NoError(t, d.Record(context.Background(), ruleID, triggerID, ec, &executor.ExecuteResult{Success: true, Status: executor.StatusSuccess}))
+	recorder.AssertExpectations(t)
+
+	var unset *Dispatcher
+	assert.NoError(t, unset.Record(context.Background(), ruleID, triggerID, ec, &executor.ExecuteResult{}))
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	publisher := &mockPublisher{}
+	notifier := &mockNotifier{}
+	d := NewDispatcher(WithEventPublisher(publisher), WithNotificationSender(notifier))
+	ec := execCtx.NewService().CreateContext(uuid.NewString(), uuid.NewString())
+
+	publisher.On("PublishAction", mock.Anything, mock.MatchedBy(func(p *events.ActionParams) bool {
+		return p.Subject == "events.derived.overheat"
+	}), ec).Return(nil)
+	notifier.On("SendAction", mock.Anything, mock.MatchedBy(func(p *notify.ActionParams) bool {
+		return p.Channel == "slack"
+	}), ec).Return(errors.New("webhook unreachable"))
+
+	require.NoError(t, d.Dispatch(context.Background(), TypePublishEvent, `{"subject": "events.derived.overheat"}`, ec))
+
+	err := d.Dispatch(context.Background(), TypeNotify, `{"channel": "slack", "body": "fired"}`, ec)
+	assert.EqualError(t, err, "notify action failed: webhook unreachable")
+
+	err = d.Dispatch(context.Background(), "send_fax", `{}`, ec)
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	publisher.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestDispatcher_NotConfigured(t *testing.T) {
+	ec := execCtx.NewService().CreateContext(uuid.NewString(), uuid.NewString())
+
+	for _, d := range []*Dispatcher{nil, NewDispatcher()} {
+		err := d.Dispatch(context.Background(), TypePublishEvent, `{"subject": "events.derived"}`, ec)
+		assert.EqualError(t, err, "publish_event action failed: event publishing is not configured")
+
+		err = d.Dispatch(context.Background(), TypeNotify, `{"channel": "slack", "body": "fired"}`, ec)
+		assert.EqualError(t, err, "notify action failed: notifications are not configured")
+	}
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/template"
+)
+
+// ActionParams are the params of a publish_event action. The subject and
+// every string of the payload may hold placeholders such as
+// {{event.payload.room}}, rendered from the context of the execution.
+type ActionParams struct {
+	Subject string `json:"subject"`
+	Payload any    `json:"payload,omitempty"`
+}
+
+// ParseActionParams parses and validates the params of a publish_event action
+func ParseActionParams(params string) (*ActionParams, error) {
+	var p ActionParams
+	if err := json.Unmarshal([]byte(params), &p); err != nil {
+		return nil, fmt.Errorf("invalid publish_event params: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the subject and the placeholders of the params
+func (p *ActionParams) Validate() error {
+	if p.Subject == "" {
+		return errors.New("subject is required")
+	}
+	if err := template.Validate(p.Subject); err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	if err := template.ValidateValue(p.Payload); err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	return nil
+}
+
+// PublishAction renders the subject and payload of a publish_event action from
+// the context of the execution that runs it and publishes the event
+func (p *Publisher) PublishAction(ctx context.Context, params *ActionParams, ec *execCtx.ExecutionContext) error {
+	fields := ec.Fields()
+	subject, err := template.Render(params.Subject, fields)
+	if err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	payload, err := template.RenderValue(params.Payload, fields)
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	return p.Publish(ctx, subject, payload, ec.ChainDepth)
+}
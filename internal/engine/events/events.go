@@ -0,0 +1,194 @@
+// Package events publishes events from rules back to NATS, either from scripts
+// through the events module or with publish_event actions.
+//
+// Only subjects matching the configured allow-list can be published to. Every
+// published message carries the chain depth of the execution that published
+// it, so that rules whose events trigger themselves stop after a bounded number
+// of hops instead of looping forever.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ChainDepthHeader is the message header carrying the chain depth of an event
+const ChainDepthHeader = "Rule-Engine-Chain-Depth"
+
+// Defaults applied when nothing else is configured
+const (
+	DefaultMaxChainDepth  = 8
+	DefaultRequestTimeout = time.Second
+)
+
+// Errors returned by the publisher
+var (
+	ErrSubjectNotAllowed = errors.New("subject is not in the publish allow-list")
+	ErrChainTooDeep      = errors.New("event chain too deep")
+)
+
+// Conn is the part of a NATS connection the publisher uses
+type Conn interface {
+	PublishMsg(msg *nats.Msg) error
+	RequestMsgWithContext(ctx context.Context, msg *nats.Msg) (*nats.Msg, error)
+}
+
+// AllowList holds the subject patterns that can be published to. Patterns use
+// the NATS wildcards: * matches one token and a trailing > the remaining ones.
+type AllowList []string
+
+// ParseAllowList parses a comma separated list of subject patterns
+func ParseAllowList(patterns string) AllowList {
+	var list AllowList
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			list = append(list, pattern)
+		}
+	}
+	return list
+}
+
+// Allows reports whether subject matches one of the patterns
+func (a AllowList) Allows(subject string) bool {
+	for _, pattern := range a {
+		if matchSubject(pattern, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" && i == len(patternTokens)-1 {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// ValidateSubject reports whether subject can be published to: it has no
+// empty token, no wildcard and no whitespace
+func ValidateSubject(subject string) error {
+	if subject == "" {
+		return errors.New("subject is empty")
+	}
+	if strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("subject %q contains whitespace", subject)
+	}
+	for _, token := range strings.Split(subject, ".") {
+		switch token {
+		case "":
+			return fmt.Errorf("subject %q has an empty token", subject)
+		case "*", ">":
+			return fmt.Errorf("subject %q contains a wildcard", subject)
+		}
+	}
+	return nil
+}
+
+// ChainDepth returns the chain depth carried by msg, 0 for events that do not
+// come from a rule
+func ChainDepth(msg *nats.Msg) int {
+	if msg.Header == nil {
+		return 0
+	}
+	depth, err := strconv.Atoi(msg.Header.Get(ChainDepthHeader))
+	if err != nil || depth < 0 {
+		return 0
+	}
+	return depth
+}
+
+// Publisher publishes JSON events to the subjects of its allow-list
+type Publisher struct {
+	conn          Conn
+	allow         AllowList
+	maxChainDepth int
+}
+
+// PublisherOption allows to configure the publisher
+type PublisherOption func(p *Publisher) *Publisher
+
+// WithMaxChainDepth sets the chain depth past which events are no longer published
+func WithMaxChainDepth(depth int) PublisherOption {
+	return func(p *Publisher) *Publisher {
+		p.maxChainDepth = depth
+		return p
+	}
+}
+
+// NewPublisher creates a publisher of events on conn
+func NewPublisher(conn Conn, allow AllowList, opts ...PublisherOption) *Publisher {
+	p := &Publisher{conn: conn, allow: allow, maxChainDepth: DefaultMaxChainDepth}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish publishes the JSON encoding of payload to subject on behalf of an
+// execution at chain depth depth
+func (p *Publisher) Publish(_ context.Context, subject string, payload any, depth int) error {
+	msg, err := p.message(subject, payload, depth)
+	if err != nil {
+		return err
+	}
+	return p.conn.PublishMsg(msg)
+}
+
+// Request publishes payload to subject like Publish and waits for the reply,
+// at most timeout, DefaultRequestTimeout when not positive
+func (p *Publisher) Request(ctx context.Context, subject string, payload any, depth int, timeout time.Duration) ([]byte, error) {
+	msg, err := p.message(subject, payload, depth)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	reply, err := p.conn.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no reply on %s within %s", subject, timeout)
+		}
+		return nil, err
+	}
+	return reply.Data, nil
+}
+
+// message builds the message of an event published at chain depth depth
+func (p *Publisher) message(subject string, payload any, depth int) (*nats.Msg, error) {
+	if err := ValidateSubject(subject); err != nil {
+		return nil, err
+	}
+	if !p.allow.Allows(subject) {
+		return nil, fmt.Errorf("%w: %s", ErrSubjectNotAllowed, subject)
+	}
+	if depth+1 > p.maxChainDepth {
+		return nil, fmt.Errorf("%w: at most %d events in a chain", ErrChainTooDeep, p.maxChainDepth)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(ChainDepthHeader, strconv.Itoa(depth+1))
+	return msg, nil
+}
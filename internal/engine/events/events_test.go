@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn records published messages and answers requests with reply
+type fakeConn struct {
+	published []*nats.Msg
+	reply     []byte
+	err       error
+}
+
+func (c *fakeConn) PublishMsg(msg *nats.Msg) error {
+	c.published = append(c.published, msg)
+	return c.err
+}
+
+func (c *fakeConn) RequestMsgWithContext(ctx context.Context, msg *nats.Msg) (*nats.Msg, error) {
+	c.published = append(c.published, msg)
+	if c.reply == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &nats.Msg{Subject: "_INBOX.reply", Data: c.reply}, c.err
+}
+
+func TestAllowList(t *testing.T) {
+	allow := ParseAllowList(" events.derived.> , alerts.*.high,commands.lights ")
+	assert.Equal(t, AllowList{"events.derived.>", "alerts.*.high", "commands.lights"}, allow)
+
+	tests := []struct {
+		subject string
+		allowed bool
+	}{
+		{"events.derived.overheat", true},
+		{"events.derived.overheat.kitchen", true},
+		{"events.derived", false},
+		{"events.raw", false},
+		{"alerts.kitchen.high", true},
+		{"alerts.kitchen.low", false},
+		{"alerts.kitchen.high.extra", false},
+		{"commands.lights", true},
+		{"commands.lights.on", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, allow.Allows(tt.subject), tt.subject)
+	}
+	assert.False(t, AllowList(nil).Allows("events.derived.overheat"))
+}
+
+func TestValidateSubject(t *testing.T) {
+	assert.NoError(t, ValidateSubject("events.derived.overheat"))
+	assert.Error(t, ValidateSubject(""))
+	assert.Error(t, ValidateSubject("events..overheat"))
+	assert.Error(t, ValidateSubject("events.*"))
+	assert.Error(t, ValidateSubject("events.>"))
+	assert.Error(t, ValidateSubject("events.over heat"))
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	conn := &fakeConn{}
+	p := NewPublisher(conn, AllowList{"events.derived.>"})
+
+	err := p.Publish(context.Background(), "events.derived.overheat", map[string]any{"room": "kitchen"}, 2)
+	require.NoError(t, err)
+	require.Len(t, conn.published, 1)
+	msg := conn.published[0]
+	assert.Equal(t, "events.derived.overheat", msg.Subject)
+	assert.JSONEq(t, `{"room":"kitchen"}`, string(msg.Data))
+	assert.Equal(t, 3, ChainDepth(msg))
+
+	err = p.Publish(context.Background(), "events.raw", map[string]any{}, 0)
+	assert.ErrorIs(t, err, ErrSubjectNotAllowed)
+
+	err = p.Publish(context.Background(), "events.derived.*", map[string]any{}, 0)
+	assert.ErrorContains(t, err, "wildcard")
+	assert.Len(t, conn.published, 1)
+}
+
+func TestPublisher_ChainDepth(t *testing.T) {
+	conn := &fakeConn{}
+	p := NewPublisher(conn, AllowList{"events.>"}, WithMaxChainDepth(2))
+
+	assert.NoError(t, p.Publish(context.Background(), "events.loop", nil, 1))
+	err := p.Publish(context.Background(), "events.loop", nil, 2)
+	assert.ErrorIs(t, err, ErrChainTooDeep)
+
+	assert.Equal(t, 0, ChainDepth(&nats.Msg{}))
+	assert.Equal(t, 2, ChainDepth(conn.published[0]))
+}
+
+func TestPublisher_Request(t *testing.T) {
+	conn := &fakeConn{reply: []byte(`{"state":"on"}`)}
+	p := NewPublisher(conn, AllowList{"devices.>"})
+
+	reply, err := p.Request(context.Background(), "devices.lamp.get", map[string]any{}, 0, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, `{"state":"on"}`, string(reply))
+
+	conn.reply = nil
+	_, err = p.Request(context.Background(), "devices.lamp.get", map[string]any{}, 0, 10*time.Millisecond)
+	assert.EqualError(t, err, "no reply on devices.lamp.get within 10ms")
+
+	conn.err = errors.New("connection closed")
+	conn.reply = []byte(`{}`)
+	_, err = p.Request(context.Background(), "devices.lamp.get", map[string]any{}, 0, time.Second)
+	assert.EqualError(t, err, "connection closed")
+}
+
+func TestPublisher_PublishAction(t *testing.T) {
+	conn := &fakeConn{}
+	p := NewPublisher(conn, AllowList{"events.derived.>"})
+
+	params, err := ParseActionParams(`{
+		"subject": "events.derived.overheat.{{event.payload.room}}",
+		"payload": {"rule": "{{rule.name}}", "temperature": "{{event.payload.temperature}}", "source": "{{event.subject}}"}
+	}`)
+	require.NoError(t, err)
+
+	ec := &execCtx.ExecutionContext{
+		RuleID:       "rule-1",
+		RuleName:     "Overheat",
+		EventSubject: "events.kitchen",
+		Event:        map[string]any{"room": "kitchen", "temperature": 31.5},
+		ChainDepth:   1,
+	}
+	require.NoError(t, p.PublishAction(context.Background(), params, ec))
+
+	require.Len(t, conn.published, 1)
+	msg := conn.published[0]
+	assert.Equal(t, "events.derived.overheat.kitchen", msg.Subject)
+	assert.JSONEq(t, `{"rule":"Overheat","temperature":31.5,"source":"events.kitchen"}`, string(msg.Data))
+	assert.Equal(t, 2, ChainDepth(msg))
+}
+
+func TestParseActionParams_Errors(t *testing.T) {
+	tests := []struct {
+		params  string
+		message string
+	}{
+		{`not json`, "invalid publish_event params"},
+		{`{"payload": {}}`, "subject is required"},
+		{`{"subject": "events.{{event.payload.room"}`, "subject: unterminated placeholder"},
+		{`{"subject": "events.x", "payload": {"room": "{{}}"}}`, "payload: room: empty placeholder"},
+	}
+	for _, tt := range tests {
+		_, err := ParseActionParams(tt.params)
+		assert.ErrorContains(t, err, tt.message, tt.params)
+	}
+}
@@ -0,0 +1,318 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Budget resources reported in BudgetExceededError
+const (
+	ResourceInstructions = "instructions"
+	ResourceCallStack    = "call_stack"
+	ResourceRegistry     = "registry"
+	ResourceMemory       = "memory"
+)
+
+// memoryCheckInterval is the number of VM instructions between memory estimates
+const memoryCheckInterval = 1000
+
+// DefaultLimits returns the resource budget applied when nothing else is configured
+func DefaultLimits() execCtx.Limits {
+	return execCtx.Limits{
+		MaxInstructions:   10_000_000,
+		MaxCallStackDepth: lua.CallStackSize,
+		MaxRegistrySize:   lua.RegistrySize * 4,
+		MaxMemoryBytes:    32 << 20,
+	}
+}
+
+// BudgetExceededError is returned when a script exhausts one of its resource limits
+type BudgetExceededError struct {
+	Resource string
+	Limit    int64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s limit of %d reached", e.Resource, e.Limit)
+}
+
+// mergeLimits overrides the defaults with every positive field of override
+func mergeLimits(defaults, override execCtx.Limits) execCtx.Limits {
+	if override.MaxInstructions > 0 {
+		defaults.MaxInstructions = override.MaxInstructions
+	}
+	if override.MaxCallStackDepth > 0 {
+		defaults.MaxCallStackDepth = override.MaxCallStackDepth
+	}
+	if override.MaxRegistrySize > 0 {
+		defaults.MaxRegistrySize = override.MaxRegistrySize
+	}
+	if override.MaxMemoryBytes > 0 {
+		defaults.MaxMemoryBytes = override.MaxMemoryBytes
+	}
+	return defaults
+}
+
+// luaOptions translates the stack and registry limits into gopher-lua options
+func luaOptions(limits execCtx.Limits) lua.Options {
+	opts := lua.Options{
+		SkipOpenLibs:  true, // Don't open default libraries
+		CallStackSize: limits.MaxCallStackDepth,
+		RegistrySize:  lua.RegistrySize,
+	}
+	if limits.MaxRegistrySize > 0 {
+		if limits.MaxRegistrySize < opts.RegistrySize {
+			opts.RegistrySize = limits.MaxRegistrySize
+		}
+		opts.RegistryMaxSize = limits.MaxRegistrySize
+	}
+	return opts
+}
+
+// budgetContext enforces instruction and memory budgets.
+// The gopher-lua VM polls Done() before every instruction once a context is attached,
+// which makes it a cheap hook to count instructions without patching the VM.
+type budgetContext struct {
+	context.Context
+	L            *lua.LState
+	limits       execCtx.Limits
+	instructions int64
+	exceeded     *BudgetExceededError
+	closed       chan struct{}
+	lines        *lineTracker // nil unless the execution carries hooks
+
+	// framesChecked and framesUsable record whether currentFrame agrees with
+	// L.GetStack, which is checked on the first instruction
+	framesChecked bool
+	framesUsable  bool
+}
+
+func newBudgetContext(parent context.Context, L *lua.LState, limits execCtx.Limits) *budgetContext {
+	c := &budgetContext{
+		Context: parent,
+		L:       L,
+		limits:  limits,
+		closed:  make(chan struct{}),
+	}
+	if hooks := hooksFromContext(parent); len(hooks) > 0 {
+		c.lines = &lineTracker{hooks: hooks, clock: clockFromContext(parent)}
+	}
+	return c
+}
+
+// Done counts an instruction and reports a closed channel once a budget is exhausted.
+// It also reports line changes to the hooks of the execution.
+func (c *budgetContext) Done() <-chan struct{} {
+	if c.exceeded != nil {
+		return c.closed
+	}
+
+	c.instructions++
+	if c.limits.MaxInstructions > 0 && c.instructions > c.limits.MaxInstructions {
+		return c.exceed(ResourceInstructions, c.limits.MaxInstructions)
+	}
+	if c.limits.MaxMemoryBytes > 0 {
+		if size, ok := c.pendingConcatSize(); ok && size > c.limits.MaxMemoryBytes {
+			return c.exceed(ResourceMemory, c.limits.MaxMemoryBytes)
+		}
+		if c.instructions%memoryCheckInterval == 0 &&
+			estimateMemory(c.L, c.limits.MaxMemoryBytes) > c.limits.MaxMemoryBytes {
+			return c.exceed(ResourceMemory, c.limits.MaxMemoryBytes)
+		}
+	}
+	if c.lines != nil {
+		c.lines.step(c.L)
+	}
+
+	return c.Context.Done()
+}
+
+// Parent returns the context of the execution without the budget accounting.
+// Platform modules use it for the I/O they perform on behalf of the script, so
+// that waiting on a network call does not count as VM instructions.
+func (c *budgetContext) Parent() context.Context {
+	return c.Context
+}
+
+// Err reports the budget violation before falling back to the parent error
+func (c *budgetContext) Err() error {
+	if c.exceeded != nil {
+		return c.exceeded
+	}
+	// The execution timeout cancels the context with the deadline as its cause
+	if c.Context.Err() != nil && errors.Is(context.Cause(c.Context), context.DeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
+
+func (c *budgetContext) exceed(resource string, limit int64) <-chan struct{} {
+	c.exceeded = &BudgetExceededError{Resource: resource, Limit: limit}
+	close(c.closed)
+	return c.closed
+}
+
+// pendingConcatSize returns the length of the string built by the instruction
+// about to run, when it is a concatenation. The VM joins the operands in one
+// step, so a few doublings of a string would otherwise outgrow the memory
+// budget between two estimates.
+func (c *budgetContext) pendingConcatSize() (int64, bool) {
+	state, frame, ok := currentFrame(c.L)
+	if !ok {
+		return 0, false
+	}
+	if !c.framesChecked {
+		dbg, ok := c.L.GetStack(0)
+		if !ok {
+			return 0, false
+		}
+		c.framesChecked = true
+		c.framesUsable = debugFrame(dbg) == frame
+	}
+	if !c.framesUsable || frame.Fn.IsG || frame.Fn.Proto == nil {
+		return 0, false
+	}
+
+	code := frame.Fn.Proto.Code
+	if frame.Pc <= 0 || frame.Pc > len(code) {
+		return 0, false
+	}
+	inst := code[frame.Pc-1]
+	if opcode(inst) != lua.OP_CONCAT {
+		return 0, false
+	}
+	first, last := frame.LocalBase+opArgB(inst), frame.LocalBase+opArgC(inst)
+	if first < 0 || first > last || last >= len(state.reg.array) {
+		return 0, false
+	}
+	var size int64
+	for _, value := range state.reg.array[first : last+1] {
+		size += stringSize(value)
+	}
+	return size, true
+}
+
+// reserve raises a memory budget violation in L when a builtin is about to
+// build a string of size bytes that does not fit in the budget.
+// It does nothing outside of executions, where c is nil.
+func (c *budgetContext) reserve(L *lua.LState, size int64) {
+	if c == nil || c.limits.MaxMemoryBytes <= 0 || size <= c.limits.MaxMemoryBytes {
+		return
+	}
+	if c.exceeded == nil {
+		c.exceed(ResourceMemory, c.limits.MaxMemoryBytes)
+	}
+	L.RaiseError("%s", c.exceeded.Error())
+}
+
+// checkDeadline raises the error of the execution in L once it has been
+// cancelled or has timed out. The VM only notices the deadline between two
+// instructions, so builtins that may run for long check it themselves.
+func (c *budgetContext) checkDeadline(L *lua.LState) {
+	if c == nil {
+		return
+	}
+	if err := c.Err(); err != nil {
+		L.RaiseError("%s", err.Error())
+	}
+}
+
+// budgetOf returns the budget of the execution running in L, nil outside of executions
+func budgetOf(L *lua.LState) *budgetContext {
+	bctx, _ := L.Context().(*budgetContext)
+	return bctx
+}
+
+// stringSize returns the length of value once converted to a string by a
+// concatenation, with numbers counted at their longest
+func stringSize(value lua.LValue) int64 {
+	switch val := value.(type) {
+	case lua.LString:
+		return int64(len(val))
+	case lua.LNumber:
+		return 32
+	}
+	return 0
+}
+
+// classifyBudgetError maps errors raised by the VM itself onto budget violations
+func classifyBudgetError(err error, bctx *budgetContext, limits execCtx.Limits) *BudgetExceededError {
+	if bctx.exceeded != nil {
+		return bctx.exceeded
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "stack overflow"):
+		return &BudgetExceededError{Resource: ResourceCallStack, Limit: int64(limits.MaxCallStackDepth)}
+	case strings.Contains(msg, "registry overflow"):
+		return &BudgetExceededError{Resource: ResourceRegistry, Limit: int64(limits.MaxRegistrySize)}
+	}
+	return nil
+}
+
+// estimateMemory approximates the memory held by values reachable from the globals
+// and the active call frames. It stops walking once the limit has been passed.
+// The estimate is sampled, so it bounds steady-state usage rather than every
+// transient allocation.
+func estimateMemory(L *lua.LState, limit int64) int64 {
+	w := &memoryWalker{seen: make(map[lua.LValue]struct{}), limit: limit}
+	w.walk(L.G.Global)
+	for level := 0; w.total <= limit; level++ {
+		dbg, ok := L.GetStack(level)
+		if !ok {
+			break
+		}
+		for i := 1; ; i++ {
+			name, value := L.GetLocal(dbg, i)
+			if name == "" {
+				break
+			}
+			w.walk(value)
+		}
+	}
+	return w.total
+}
+
+type memoryWalker struct {
+	seen  map[lua.LValue]struct{}
+	total int64
+	limit int64
+}
+
+func (w *memoryWalker) walk(v lua.LValue) {
+	if w.total > w.limit {
+		return
+	}
+	switch val := v.(type) {
+	case lua.LString:
+		w.total += 16 + int64(len(val))
+	case *lua.LTable:
+		if _, ok := w.seen[val]; ok {
+			return
+		}
+		w.seen[val] = struct{}{}
+		w.total += 64
+		val.ForEach(func(k, v lua.LValue) {
+			w.total += 32
+			w.walk(k)
+			w.walk(v)
+		})
+		if val.Metatable != nil {
+			w.walk(val.Metatable)
+		}
+	case *lua.LFunction:
+		if _, ok := w.seen[val]; ok {
+			return
+		}
+		w.seen[val] = struct{}{}
+		w.total += 64
+		for _, upvalue := range val.Upvalues {
+			w.walk(upvalue.Value())
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/pm"
+)
+
+// gsubBatch is the number of matches string.gsub looks up at a time
+const gsubBatch = 1024
+
+// boundStringBuilders replaces the builtins that build strings with versions that
+// refuse results larger than the memory budget of the running execution. A single
+// call can allocate far more than the sampled memory estimate would ever observe,
+// so the size of the result is checked before it is built.
+func boundStringBuilders(L *lua.LState) {
+	boundBuiltin(L, "string", "rep", func(L *lua.LState) int64 {
+		str := L.CheckString(1)
+		n := L.CheckInt(2)
+		if n <= 0 {
+			return 0
+		}
+		return int64(len(str)) * int64(n)
+	})
+	boundBuiltin(L, "string", "format", func(L *lua.LState) int64 {
+		format := L.CheckString(1)
+		args := make([]lua.LValue, 0, L.GetTop()-1)
+		for i := 2; i <= L.GetTop(); i++ {
+			args = append(args, L.Get(i))
+		}
+		return formatSize(format, args)
+	})
+	for _, name := range []string{"upper", "lower", "reverse"} {
+		boundBuiltin(L, "string", name, func(L *lua.LState) int64 {
+			return int64(len(L.CheckString(1)))
+		})
+	}
+	boundBuiltin(L, "table", "concat", func(L *lua.LState) int64 {
+		tbl := L.CheckTable(1)
+		sep := L.OptString(2, "")
+		first := max(L.OptInt(3, 1), 1)
+		last := min(L.OptInt(4, tbl.Len()), tbl.Len())
+		var size int64
+		for i := first; i <= last; i++ {
+			size += stringSize(tbl.RawGetInt(i))
+			if i != last {
+				size += int64(len(sep))
+			}
+		}
+		return size
+	})
+
+	if strTable, ok := L.GetGlobal("string").(*lua.LTable); ok {
+		strTable.RawSetString("gsub", L.NewFunction(strGsub))
+	}
+}
+
+// boundBuiltin wraps the function name of the library table lib so that it raises
+// a memory budget violation when size reports a result larger than the budget
+func boundBuiltin(L *lua.LState, lib, name string, size func(L *lua.LState) int64) {
+	libTable, ok := L.GetGlobal(lib).(*lua.LTable)
+	if !ok {
+		return
+	}
+	builtin, ok := libTable.RawGetString(name).(*lua.LFunction)
+	if !ok {
+		return
+	}
+	libTable.RawSetString(name, L.NewFunction(func(L *lua.LState) int {
+		if bctx := budgetOf(L); bctx != nil && bctx.limits.MaxMemoryBytes > 0 {
+			bctx.reserve(L, size(L))
+		}
+		top := L.GetTop()
+		L.Push(builtin)
+		for i := 1; i <= top; i++ {
+			L.Push(L.Get(i))
+		}
+		L.Call(top, lua.MultRet)
+		return L.GetTop() - top
+	}))
+}
+
+// formatSize bounds the length of the string string.format builds from format and args.
+// Widths and precisions count in full, as do the longest escapes of %q and %x.
+func formatSize(format string, args []lua.LValue) int64 {
+	var longest lua.LValue = lua.LNil
+	for _, arg := range args {
+		if stringSize(arg) > stringSize(longest) {
+			longest = arg
+		}
+	}
+	// Explicit argument indexes may use any argument any number of times
+	indexed := strings.Contains(format, "[")
+
+	size := int64(len(format))
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0123456789.*[]", format[i]) >= 0 {
+			switch {
+			case format[i] == '*':
+				size += fmtMaxWidth
+			case format[i] >= '0' && format[i] <= '9':
+				n := int64(0)
+				for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+					n = min(n*10+int64(format[i]-'0'), fmtMaxWidth)
+				}
+				size += n
+				i--
+			}
+			i++
+		}
+		if i >= len(format) || format[i] == '%' {
+			continue
+		}
+
+		arg := longest
+		if !indexed {
+			if next >= len(args) {
+				continue
+			}
+			arg = args[next]
+			next++
+		}
+		switch format[i] {
+		case 'q':
+			size += 4*stringSize(arg) + 2
+		case 'x', 'X':
+			size += 3 * stringSize(arg)
+		default:
+			size += stringSize(arg) + 32
+		}
+	}
+	return size
+}
+
+// fmtMaxWidth is the largest width or precision the fmt package honours
+const fmtMaxWidth = 1e6
+
+// strGsub is string.gsub, building the result in a single pass that stays within
+// the memory budget and stops at the deadline of the execution. gopher-lua
+// copies the whole string for every match and checks nothing until the result
+// is complete, which holds a worker for minutes on strings of a few megabytes.
+func strGsub(L *lua.LState) int {
+	str := L.CheckString(1)
+	pat := L.CheckString(2)
+	L.CheckTypes(3, lua.LTString, lua.LTTable, lua.LTFunction)
+	repl := L.CheckAny(3)
+	limit := L.OptInt(4, -1)
+
+	bctx := budgetOf(L)
+	out := &boundedBuilder{L: L, bctx: bctx}
+	src := []byte(str)
+	count, last, offset := 0, 0, 0
+	for limit < 0 || count < limit {
+		bctx.checkDeadline(L)
+		batch := gsubBatch
+		if limit >= 0 {
+			batch = min(batch, limit-count)
+		}
+		matches, err := pm.Find(pat, src, offset, batch)
+		if err != nil {
+			L.RaiseError("%s", err.Error())
+		}
+		for _, match := range matches {
+			start, end := match.Capture(0), match.Capture(1)
+			out.write(str[last:start])
+			if !gsubReplace(L, out, str, repl, match) {
+				out.write(str[start:end])
+			}
+			last = end
+		}
+		count += len(matches)
+
+		// Resume where pm.Find would have, an anchored pattern matches once
+		if len(matches) < batch || strings.HasPrefix(pat, "^") {
+			break
+		}
+		match := matches[len(matches)-1]
+		offset = max(match.Capture(0)+1, match.Capture(1))
+		if offset > len(src) {
+			break
+		}
+	}
+	if count == 0 {
+		L.SetTop(1)
+		L.Push(lua.LNumber(0))
+		return 2
+	}
+	out.write(str[last:])
+
+	L.Push(lua.LString(out.String()))
+	L.Push(lua.LNumber(count))
+	return 2
+}
+
+// gsubReplace writes the replacement of match to out, and reports false when
+// the match is kept as is
+func gsubReplace(L *lua.LState, out *boundedBuilder, str string, repl lua.LValue, match *pm.MatchData) bool {
+	switch repl := repl.(type) {
+	case lua.LString:
+		expandReplacement(L, out, str, string(repl), match)
+		return true
+	case *lua.LTable:
+		idx := 0
+		if match.CaptureLength() > 2 { // has captures
+			idx = 2
+		}
+		var value lua.LValue
+		if match.IsPosCapture(idx) {
+			value = L.GetTable(repl, lua.LNumber(match.Capture(idx)))
+		} else {
+			value = L.GetField(repl, str[match.Capture(idx):match.Capture(idx+1)])
+		}
+		if lua.LVIsFalse(value) {
+			return false
+		}
+		out.write(lua.LVAsString(value))
+		return true
+	case *lua.LFunction:
+		L.Push(repl)
+		nargs := 0
+		if match.CaptureLength() > 2 { // has captures
+			for i := 2; i < match.CaptureLength(); i += 2 {
+				if match.IsPosCapture(i) {
+					L.Push(lua.LNumber(match.Capture(i)))
+				} else {
+					L.Push(lua.LString(capturedString(L, match, str, i)))
+				}
+				nargs++
+			}
+		} else {
+			L.Push(lua.LString(capturedString(L, match, str, 0)))
+			nargs++
+		}
+		L.Call(nargs, 1)
+		value := L.Get(-1)
+		L.Pop(1)
+		if lua.LVIsFalse(value) {
+			return false
+		}
+		out.write(lua.LVAsString(value))
+		return true
+	}
+	return false
+}
+
+// expandReplacement writes repl to out with its %0-%9 captures of match
+// expanded, the way gopher-lua does
+func expandReplacement(L *lua.LState, out *boundedBuilder, str, repl string, match *pm.MatchData) {
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if c != '%' || i == len(repl)-1 {
+			out.writeByte(c)
+			continue
+		}
+		i++
+		switch c = repl[i]; {
+		case c == '%':
+			out.writeByte('%')
+		case c >= '0' && c <= '9':
+			out.write(capturedString(L, match, str, 2*int(c-'0')))
+		default:
+			out.writeByte('%')
+			out.writeByte(c)
+		}
+	}
+}
+
+// capturedString returns the capture of match at idx, the whole match standing
+// for the first capture of patterns without any
+func capturedString(L *lua.LState, match *pm.MatchData, str string, idx int) string {
+	if idx > 2 && idx >= match.CaptureLength() {
+		L.RaiseError("invalid capture index")
+	}
+	if idx >= match.CaptureLength() && idx == 2 {
+		idx = 0
+	}
+	if match.IsPosCapture(idx) {
+		return fmt.Sprint(match.Capture(idx))
+	}
+	return str[match.Capture(idx):match.Capture(idx+1)]
+}
+
+// boundedBuilder builds a string that may not outgrow the memory budget of bctx
+type boundedBuilder struct {
+	strings.Builder
+	L    *lua.LState
+	bctx *budgetContext
+}
+
+func (b *boundedBuilder) write(s string) {
+	b.bctx.reserve(b.L, int64(b.Len()+len(s)))
+	b.WriteString(s)
+}
+
+func (b *boundedBuilder) writeByte(c byte) {
+	b.bctx.reserve(b.L, int64(b.Len()+1))
+	b.WriteByte(c)
+}
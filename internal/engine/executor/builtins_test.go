@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// TestStrGsub_MatchesGopherLua checks that the single-pass string.gsub returns
+// what the gopher-lua implementation it replaces returns
+func TestStrGsub_MatchesGopherLua(t *testing.T) {
+	scripts := []string{
+		`return string.gsub("hello world", "o", "0")`,
+		`return string.gsub("hello world", "o", "0", 1)`,
+		`return string.gsub("hello world", "o", "0", 0)`,
+		`return string.gsub("hello world", "(o)(r?)", "[%2%1%0]")`,
+		`return string.gsub("hello world", "%w+", "<%0>")`,
+		`return string.gsub("hello world", "%w+", "%1-%1")`,
+		`return string.gsub("hello", "l", "%%")`,
+		`return string.gsub("hello", "l", "%a")`,
+		`return string.gsub("hello", "l", "50%")`,
+		`return string.gsub("hello", "", "-")`,
+		`return string.gsub("hello", "x*", "-")`,
+		`return string.gsub("hello", "^h", "H")`,
+		`return string.gsub("hello hello", "^hello", "bye")`,
+		`return string.gsub("hello", "()l", "%1")`,
+		`return string.gsub("hello", "l+$", "L")`,
+		`return string.gsub("$name is $age", "%$(%w+)", {name = "ann", age = 7})`,
+		`return string.gsub("$name is $unknown", "%$(%w+)", {name = "ann"})`,
+		`return string.gsub("abc", "%w", function(c) return c:upper() .. "." end)`,
+		`return string.gsub("abc", "(%w)()", function(c, pos) return c .. pos end)`,
+		`return string.gsub("abc", "%w", function(c) if c == "b" then return false end return "x" end)`,
+		`return string.gsub(string.rep("ab", 3000), "b", "cd")`,
+		`return string.gsub(string.rep("ab", 3000), "b", "cd", 2000)`,
+		`return pcall(string.gsub, "hello", "l", "%2")`,
+	}
+
+	original := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer original.Close()
+	lua.OpenBase(original)
+	lua.OpenString(original)
+
+	bounded := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer bounded.Close()
+	lua.OpenBase(bounded)
+	lua.OpenString(bounded)
+	lua.OpenTable(bounded)
+	boundStringBuilders(bounded)
+
+	run := func(L *lua.LState, script string) []lua.LValue {
+		top := L.GetTop()
+		require.NoError(t, L.DoString(script))
+		results := make([]lua.LValue, 0, L.GetTop()-top)
+		for i := top + 1; i <= L.GetTop(); i++ {
+			results = append(results, L.Get(i))
+		}
+		L.SetTop(top)
+		return results
+	}
+
+	for _, script := range scripts {
+		t.Run(script, func(t *testing.T) {
+			assert.Equal(t, run(original, script), run(bounded, script))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package executor
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/malyshevhen/rule-engine/internal/metrics"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// DefaultScriptCacheSize is the number of compiled scripts kept in memory
+const DefaultScriptCacheSize = 1024
+
+// chunkName is the chunk name used when compiling scripts, matching L.DoString
+const chunkName = "<string>"
+
+// ScriptCache keeps compiled function prototypes keyed by the hash of their source.
+// Prototypes are immutable once compiled, so a single entry is safely shared by
+// every Lua state. The least recently used entry is evicted once the cache is full.
+type ScriptCache struct {
+	cache *compiledCache[*lua.FunctionProto]
+}
+
+// NewScriptCache creates a cache holding at most maxSize compiled scripts
+func NewScriptCache(maxSize int) *ScriptCache {
+	return &ScriptCache{cache: newCompiledCache(maxSize, compileScript)}
+}
+
+// ScriptHash returns the cache key of a script
+func ScriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the compiled prototype of script, compiling and caching it on a miss
+func (c *ScriptCache) Get(script string) (*lua.FunctionProto, error) {
+	proto, hit, err := c.cache.get(script)
+	if hit {
+		metrics.LuaScriptCacheLookupsTotal.WithLabelValues("hit").Inc()
+		return proto, nil
+	}
+	metrics.LuaScriptCacheLookupsTotal.WithLabelValues("miss").Inc()
+	metrics.LuaScriptCacheSize.Set(float64(c.cache.len()))
+	return proto, err
+}
+
+// Invalidate drops the compiled prototype of script
+func (c *ScriptCache) Invalidate(script string) {
+	if c.cache.invalidate(script) {
+		metrics.LuaScriptCacheSize.Set(float64(c.cache.len()))
+	}
+}
+
+// Len returns the number of cached scripts
+func (c *ScriptCache) Len() int {
+	return c.cache.len()
+}
+
+// compiledCache keeps the compiled form of scripts keyed by the hash of their
+// source, evicting the least recently used entry once full. Compiled values are
+// shared by every caller, so they must be safe for concurrent use.
+type compiledCache[T any] struct {
+	mu      sync.Mutex
+	maxSize int
+	compile func(script string) (T, error)
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type compiledCacheEntry[T any] struct {
+	hash  string
+	value T
+}
+
+func newCompiledCache[T any](maxSize int, compile func(script string) (T, error)) *compiledCache[T] {
+	return &compiledCache[T]{
+		maxSize: maxSize,
+		compile: compile,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the compiled form of script, compiling and caching it on a miss,
+// and whether it was cached
+func (c *compiledCache[T]) get(script string) (T, bool, error) {
+	hash := ScriptHash(script)
+
+	c.mu.Lock()
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*compiledCacheEntry[T]).value, true, nil
+	}
+	c.mu.Unlock()
+
+	value, err := c.compile(script)
+	if err != nil {
+		return value, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another caller may have compiled the same script meanwhile
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*compiledCacheEntry[T]).value, false, nil
+	}
+	c.entries[hash] = c.order.PushFront(&compiledCacheEntry[T]{hash: hash, value: value})
+	for c.order.Len() > c.maxSize {
+		c.remove(c.order.Back())
+	}
+	return value, false, nil
+}
+
+// invalidate drops the compiled form of script and reports whether it was cached
+func (c *compiledCache[T]) invalidate(script string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[ScriptHash(script)]
+	if ok {
+		c.remove(elem)
+	}
+	return ok
+}
+
+func (c *compiledCache[T]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *compiledCache[T]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*compiledCacheEntry[T]).hash)
+}
+
+// compileScript parses and compiles a script the same way L.LoadString does
+func compileScript(script string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), chunkName)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, chunkName)
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptCache_Get(t *testing.T) {
+	cache := NewScriptCache(2)
+
+	first, err := cache.Get("return 1")
+	assert.NoError(t, err)
+	assert.NotNil(t, first)
+
+	second, err := cache.Get("return 1")
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestScriptCache_SyntaxError(t *testing.T) {
+	cache := NewScriptCache(2)
+
+	proto, err := cache.Get("return +")
+	assert.Error(t, err)
+	assert.Nil(t, proto)
+	assert.Contains(t, err.Error(), "line:1")
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestScriptCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewScriptCache(2)
+
+	one, _ := cache.Get("return 1")
+	_, _ = cache.Get("return 2")
+	_, _ = cache.Get("return 1") // "return 2" is now the least recently used
+	_, _ = cache.Get("return 3")
+
+	assert.Equal(t, 2, cache.Len())
+	again, _ := cache.Get("return 1")
+	assert.Same(t, one, again)
+}
+
+func TestScriptCache_Invalidate(t *testing.T) {
+	cache := NewScriptCache(2)
+
+	first, _ := cache.Get("return 1")
+	cache.Invalidate("return 1")
+	assert.Equal(t, 0, cache.Len())
+
+	second, _ := cache.Get("return 1")
+	assert.NotSame(t, first, second)
+}
+
+func TestExecutorService_ExecuteScript_CachedScript(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	script := "local n = ... return (n or 0) + value"
+	for _, value := range []int{1, 2} {
+		execContext := ctxSvc.CreateContext("rule", "trigger")
+		execContext.Data["value"] = value
+
+		result := svc.ExecuteScript(context.Background(), script, execContext)
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, []any{float64(value)}, result.Output)
+	}
+	assert.Equal(t, 1, svc.lua.scripts.Len())
+
+	svc.InvalidateScript(script)
+	assert.Equal(t, 0, svc.lua.scripts.Len())
+}
+
+func TestExecutorService_ExecuteScript_CachedExpression(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	script := "value > 1"
+	for _, value := range []int{1, 2} {
+		execContext := ctxSvc.CreateContext("rule", "trigger")
+		execContext.Language = LanguageExpr
+		execContext.Data["value"] = value
+
+		result := svc.ExecuteScript(context.Background(), script, execContext)
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, []any{value > 1}, result.Output)
+	}
+	assert.Equal(t, 1, svc.expr.programs.len())
+	assert.Zero(t, svc.lua.scripts.Len())
+
+	svc.InvalidateScript(script)
+	assert.Zero(t, svc.expr.programs.len())
+}
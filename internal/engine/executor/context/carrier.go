@@ -0,0 +1,17 @@
+package context
+
+import stdcontext "context"
+
+type executionKey struct{}
+
+// WithExecution returns a context carrying ec, which tells the platform modules
+// which rule the running script belongs to
+func WithExecution(ctx stdcontext.Context, ec *ExecutionContext) stdcontext.Context {
+	return stdcontext.WithValue(ctx, executionKey{}, ec)
+}
+
+// FromContext returns the execution context carried by ctx
+func FromContext(ctx stdcontext.Context) (*ExecutionContext, bool) {
+	ec, ok := ctx.Value(executionKey{}).(*ExecutionContext)
+	return ec, ok && ec != nil
+}
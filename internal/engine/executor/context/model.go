@@ -1,10 +1,65 @@
 // Package context provides execution context for Lua scripts
 package context
 
+import "time"
+
 // ExecutionContext holds data available to Lua scripts during execution
 type ExecutionContext struct {
-	// TODO: Add fields like device state, user data, etc.
-	RuleID    string         `json:"rule_id"`
-	TriggerID string         `json:"trigger_id"`
-	Data      map[string]any `json:"data"`
+	RuleID    string `json:"rule_id"`
+	RuleName  string `json:"rule_name,omitempty"`
+	TriggerID string `json:"trigger_id"`
+	// TriggerType is the type of the trigger that fired, e.g. CONDITIONAL or CRON
+	TriggerType string `json:"trigger_type,omitempty"`
+	// EventSubject is the subject of the triggering event, empty for scheduled triggers
+	EventSubject string `json:"event_subject,omitempty"`
+	// Event is the payload of the triggering event
+	Event map[string]any `json:"event,omitempty"`
+	// FiredAt is the time the trigger fired
+	FiredAt time.Time `json:"fired_at"`
+	// Attempt is the 1-based number of this execution attempt
+	Attempt int `json:"attempt"`
+	// ChainDepth counts the execute_rule actions and published events that led to
+	// this execution, 0 for a direct trigger
+	ChainDepth int `json:"chain_depth"`
+	// Data is exposed as individual globals when legacy globals are enabled
+	Data map[string]any `json:"data"`
+	// Timeout overrides the executor's default wall-clock limit when positive
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Limits overrides the executor's default resource budget field by field
+	Limits Limits `json:"limits,omitempty"`
+	// Coverage makes the executor count the lines the script runs
+	Coverage bool `json:"coverage,omitempty"`
+	// Profile makes the executor time the script and its platform module calls
+	Profile bool `json:"profile,omitempty"`
+	// Language selects the runtime of the script, Lua when empty
+	Language string `json:"language,omitempty"`
+}
+
+// Limits bounds the resources a single script execution may consume.
+// Zero fields fall back to the executor defaults.
+type Limits struct {
+	MaxInstructions   int64 `json:"max_instructions,omitempty"`
+	MaxCallStackDepth int   `json:"max_call_stack_depth,omitempty"`
+	MaxRegistrySize   int   `json:"max_registry_size,omitempty"`
+	MaxMemoryBytes    int64 `json:"max_memory_bytes,omitempty"`
+}
+
+// Fields returns the execution context in the shape of the ctx table scripts see:
+// rule, trigger and event maps, fired_at (RFC 3339), attempt and chain_depth
+func (ec *ExecutionContext) Fields() map[string]any {
+	payload := ec.Event
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	fields := map[string]any{
+		"rule":        map[string]any{"id": ec.RuleID, "name": ec.RuleName},
+		"trigger":     map[string]any{"id": ec.TriggerID, "type": ec.TriggerType},
+		"event":       map[string]any{"subject": ec.EventSubject, "payload": payload},
+		"attempt":     max(ec.Attempt, 1),
+		"chain_depth": ec.ChainDepth,
+	}
+	if !ec.FiredAt.IsZero() {
+		fields["fired_at"] = ec.FiredAt.UTC().Format(time.RFC3339Nano)
+	}
+	return fields
 }
@@ -1,20 +1,50 @@
 package context
 
+import "time"
+
 // Service manages execution contexts
 type Service struct {
-	// TODO: Add dependencies like cache, etc.
+	coverage bool
+	profile  bool
+}
+
+// ServiceOption allows to configure the execution context service
+type ServiceOption func(s *Service) *Service
+
+// WithCoverage makes every created context enable line coverage
+func WithCoverage(enabled bool) ServiceOption {
+	return func(s *Service) *Service {
+		s.coverage = enabled
+		return s
+	}
+}
+
+// WithProfiling makes every created context enable profiling
+func WithProfiling(enabled bool) ServiceOption {
+	return func(s *Service) *Service {
+		s.profile = enabled
+		return s
+	}
 }
 
 // NewService creates a new execution context service
-func NewService() *Service {
-	return &Service{}
+func NewService(opts ...ServiceOption) *Service {
+	s := &Service{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// CreateContext creates a new execution context for a rule execution
+// CreateContext creates a new execution context for the first attempt of a rule execution fired now
 func (s *Service) CreateContext(ruleID, triggerID string) *ExecutionContext {
 	return &ExecutionContext{
 		RuleID:    ruleID,
 		TriggerID: triggerID,
+		FiredAt:   time.Now().UTC(),
+		Attempt:   1,
 		Data:      make(map[string]any),
+		Coverage:  s.coverage,
+		Profile:   s.profile,
 	}
 }
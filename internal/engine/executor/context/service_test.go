@@ -1,7 +1,9 @@
 package context
 
 import (
+	stdcontext "context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,6 +21,9 @@ func TestService_CreateContext(t *testing.T) {
 	assert.Equal(t, triggerID, ctx.TriggerID)
 	assert.NotNil(t, ctx.Data)
 	assert.Empty(t, ctx.Data)
+	assert.Equal(t, 1, ctx.Attempt)
+	assert.Zero(t, ctx.ChainDepth)
+	assert.WithinDuration(t, time.Now(), ctx.FiredAt, time.Second)
 }
 
 func TestExecutionContext_Structure(t *testing.T) {
@@ -35,3 +40,18 @@ func TestExecutionContext_Structure(t *testing.T) {
 	assert.Equal(t, "device-789", ctx.Data["device_id"])
 	assert.Equal(t, 25.5, ctx.Data["temperature"])
 }
+
+func TestService_CreateContext_Coverage(t *testing.T) {
+	assert.False(t, NewService().CreateContext("rule", "trigger").Coverage)
+	assert.True(t, NewService(WithCoverage(true)).CreateContext("rule", "trigger").Coverage)
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(stdcontext.Background())
+	assert.False(t, ok)
+
+	ec := NewService().CreateContext("rule-123", "trigger-456")
+	got, ok := FromContext(WithExecution(stdcontext.Background(), ec))
+	assert.True(t, ok)
+	assert.Same(t, ec, got)
+}
@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"log/slog"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// setContextTable exposes the execution context to the script as the read-only ctx global:
+//
+//	ctx.rule.id, ctx.rule.name
+//	ctx.trigger.id, ctx.trigger.type
+//	ctx.event.subject, ctx.event.payload
+//	ctx.fired_at (RFC 3339), ctx.attempt, ctx.chain_depth
+//
+// ctx and its rule, trigger and event values reject assignments and iterate over
+// their fields with pairs. The payload is a plain table converted afresh for every
+// execution, so changing it only affects the running script.
+func setContextTable(L *lua.LState, ec *execCtx.ExecutionContext) {
+	rule := L.NewTable()
+	rule.RawSetString("id", lua.LString(ec.RuleID))
+	rule.RawSetString("name", lua.LString(ec.RuleName))
+
+	trigger := L.NewTable()
+	trigger.RawSetString("id", lua.LString(ec.TriggerID))
+	trigger.RawSetString("type", lua.LString(ec.TriggerType))
+
+	payload := lua.LValue(L.NewTable())
+	if ec.Event != nil {
+		payload = convert.FromGo(L, ec.Event)
+	}
+	event := L.NewTable()
+	event.RawSetString("subject", lua.LString(ec.EventSubject))
+	event.RawSetString("payload", payload)
+
+	fields := L.NewTable()
+	fields.RawSetString("rule", readOnlyProxy(L, "ctx.rule", rule))
+	fields.RawSetString("trigger", readOnlyProxy(L, "ctx.trigger", trigger))
+	fields.RawSetString("event", readOnlyProxy(L, "ctx.event", event))
+	if !ec.FiredAt.IsZero() {
+		fields.RawSetString("fired_at", lua.LString(ec.FiredAt.UTC().Format(time.RFC3339Nano)))
+	}
+	fields.RawSetString("attempt", lua.LNumber(max(ec.Attempt, 1)))
+	fields.RawSetString("chain_depth", lua.LNumber(ec.ChainDepth))
+
+	L.SetGlobal("ctx", readOnlyProxy(L, "ctx", fields))
+}
+
+// readOnlyProxy returns a proxy that reads from fields, raises an error on assignment
+// and iterates over fields with pairs. The proxy is a userdata rather than an empty
+// table, so that rawset cannot add keys to it that would shadow the fields.
+func readOnlyProxy(L *lua.LState, name string, fields *lua.LTable) *lua.LUserData {
+	next := L.GetGlobal("next")
+
+	mt := L.NewTable()
+	mt.RawSetString("__index", fields)
+	mt.RawSetString("__newindex", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("%s is read-only", name)
+		return 0
+	}))
+	mt.RawSetString("__pairs", L.NewFunction(func(L *lua.LState) int {
+		L.Push(next)
+		L.Push(fields)
+		L.Push(lua.LNil)
+		return 3
+	}))
+	mt.RawSetString("__metatable", lua.LFalse)
+
+	proxy := L.NewUserData()
+	proxy.Metatable = mt
+	return proxy
+}
+
+// setLegacyGlobals exposes rule_id, trigger_id and every key of Data as globals.
+// Keys that would shadow an existing global, such as require, string or ctx, are skipped.
+func setLegacyGlobals(L *lua.LState, ec *execCtx.ExecutionContext) {
+	L.SetGlobal("rule_id", lua.LString(ec.RuleID))
+	L.SetGlobal("trigger_id", lua.LString(ec.TriggerID))
+
+	for key, value := range ec.Data {
+		if L.GetGlobal(key) != lua.LNil {
+			slog.Debug("Skipping context key that shadows a global", "rule_id", ec.RuleID, "key", key)
+			continue
+		}
+		L.SetGlobal(key, convert.FromGo(L, value))
+	}
+}
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorService_ExecuteScript_ContextTable(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("rule-1", "trigger-1")
+	ec.RuleName = "Temperature Alert"
+	ec.TriggerType = "CONDITIONAL"
+	ec.EventSubject = "events.sensor_1"
+	ec.Event = map[string]any{"temperature": 28.5, "tags": []any{"a", "b"}}
+	ec.FiredAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ec.Attempt = 2
+	ec.ChainDepth = 1
+
+	result := svc.ExecuteScript(context.Background(), `
+		return ctx.rule.id, ctx.rule.name, ctx.trigger.id, ctx.trigger.type,
+			ctx.event.subject, ctx.event.payload.temperature, ctx.event.payload.tags[2],
+			ctx.fired_at, ctx.attempt, ctx.chain_depth
+	`, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{
+		"rule-1", "Temperature Alert", "trigger-1", "CONDITIONAL",
+		"events.sensor_1", 28.5, "b",
+		"2024-05-01T12:00:00Z", 2.0, 1.0,
+	}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_ContextTableIsReadOnly(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	tests := []struct {
+		name   string
+		script string
+		errMsg string
+	}{
+		{"assign field", `ctx.attempt = 5`, "ctx is read-only"},
+		{"assign nested field", `ctx.rule.id = "other"`, "ctx.rule is read-only"},
+		{"replace payload", `ctx.event.payload = {}`, "ctx.event is read-only"},
+		{"change metatable", `setmetatable(ctx, nil)`, "table expected, got userdata"},
+		{"raw assignment", `rawset(ctx, "attempt", 5)`, "table expected, got userdata"},
+		{"raw nested assignment", `rawset(ctx.rule, "id", "other")`, "table expected, got userdata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := svc.ExecuteScript(context.Background(), tt.script, ctxSvc.CreateContext("rule", "trigger"))
+			assert.False(t, result.Success)
+			assert.Contains(t, result.Error, tt.errMsg)
+		})
+	}
+}
+
+func TestExecutorService_ExecuteScript_LegacyGlobals(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("rule-1", "trigger-1")
+	ec.Data["temperature"] = 28.5
+	ec.Data["rule_id"] = "spoofed"
+	ec.Data["require"] = "clobbered"
+	ec.Data["ctx"] = "clobbered"
+
+	result := svc.ExecuteScript(context.Background(), `
+		return temperature, rule_id, trigger_id, type(require), type(ctx)
+	`, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{28.5, "rule-1", "trigger-1", "function", "userdata"}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_ContextTablePairs(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("rule-1", "trigger-1")
+	ec.RuleName = "Temperature Alert"
+
+	result := svc.ExecuteScript(context.Background(), `
+		local keys = {}
+		for key in pairs(ctx) do table.insert(keys, key) end
+		table.sort(keys)
+		local rule = {}
+		for key, value in pairs(ctx.rule) do rule[key] = value end
+		return table.concat(keys, ","), rule
+	`, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{
+		"attempt,chain_depth,event,fired_at,rule,trigger",
+		map[string]any{"id": "rule-1", "name": "Temperature Alert"},
+	}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_LegacyGlobalsDisabled(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithLegacyGlobals(false))
+
+	ec := ctxSvc.CreateContext("rule-1", "trigger-1")
+	ec.Data["temperature"] = 28.5
+
+	result := svc.ExecuteScript(context.Background(), `return temperature == nil, rule_id == nil, ctx.rule.id`, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{true, true, "rule-1"}, result.Output)
+}
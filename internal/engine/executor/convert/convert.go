@@ -0,0 +1,376 @@
+// Package convert translates values between Go and the Lua VM.
+// It is shared by the executor and the platform modules so that scripts see
+// the same shapes whether data comes from an event, a module call or is
+// returned to the engine.
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Errors returned by ToGo
+var (
+	ErrCyclicTable     = errors.New("cannot convert cyclic table")
+	ErrUnsupportedType = errors.New("cannot convert")
+)
+
+// options customize the conversions in both directions
+type options struct {
+	null      lua.LValue
+	arrayMeta *lua.LTable
+	strict    bool
+}
+
+// Option customizes a conversion
+type Option func(o *options) *options
+
+// WithNull sets the Lua value standing for a Go nil, so that nil elements of
+// slices and maps survive the conversion instead of leaving holes in tables
+func WithNull(null lua.LValue) Option {
+	return func(o *options) *options {
+		o.null = null
+		return o
+	}
+}
+
+// WithArrayMetatable marks sequences: FromGo sets meta on the tables it creates
+// from slices, and ToGo converts the empty tables carrying meta to empty slices
+// rather than empty maps
+func WithArrayMetatable(meta *lua.LTable) Option {
+	return func(o *options) *options {
+		o.arrayMeta = meta
+		return o
+	}
+}
+
+// WithStrictTypes makes ToGo fail on functions, userdata and threads instead
+// of converting them to their string representation
+func WithStrictTypes() Option {
+	return func(o *options) *options {
+		o.strict = true
+		return o
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ToGo converts a Lua value to a Go value.
+//
+//   - nil, booleans, numbers (as float64) and strings map to their Go counterparts
+//   - tables whose keys are exactly 1..n become []any, preserving order
+//   - every other table becomes map[string]any, keys are converted with tostring
+//   - functions, userdata and other values are returned as their string representation
+//
+// Tables shared between several keys are converted once per reference.
+// A table nested inside itself yields an error wrapping ErrCyclicTable.
+func ToGo(v lua.LValue, opts ...Option) (any, error) {
+	c := &toGoConverter{options: newOptions(opts), visiting: make(map[*lua.LTable]struct{})}
+	return c.convert(v, "")
+}
+
+// ToGoValues converts every value of a multiple return
+func ToGoValues(values ...lua.LValue) ([]any, error) {
+	results := make([]any, 0, len(values))
+	for i, v := range values {
+		result, err := ToGo(v)
+		if err != nil {
+			return nil, fmt.Errorf("return value %d: %w", i+1, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+type toGoConverter struct {
+	options
+	// visiting holds the tables on the current conversion path
+	visiting map[*lua.LTable]struct{}
+}
+
+func (c *toGoConverter) convert(v lua.LValue, path string) (any, error) {
+	if c.null != nil && v == c.null {
+		return nil, nil
+	}
+	switch val := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(val), nil
+	case lua.LNumber:
+		return float64(val), nil
+	case lua.LString:
+		return string(val), nil
+	case *lua.LTable:
+		return c.convertTable(val, path)
+	default:
+		if c.strict {
+			if path == "" {
+				return nil, fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+			}
+			return nil, fmt.Errorf("%w %s at %s", ErrUnsupportedType, v.Type(), path)
+		}
+		return v.String(), nil
+	}
+}
+
+func (c *toGoConverter) convertTable(tbl *lua.LTable, path string) (any, error) {
+	if _, ok := c.visiting[tbl]; ok {
+		if path == "" {
+			return nil, ErrCyclicTable
+		}
+		return nil, fmt.Errorf("%w at %s", ErrCyclicTable, path)
+	}
+	c.visiting[tbl] = struct{}{}
+	defer delete(c.visiting, tbl)
+
+	if n, ok := arrayLength(tbl); ok {
+		result := make([]any, n)
+		for i := 1; i <= n; i++ {
+			item, err := c.convert(tbl.RawGetInt(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i-1] = item
+		}
+		return result, nil
+	}
+	if c.arrayMeta != nil && tbl.Metatable == c.arrayMeta {
+		if key, _ := tbl.Next(lua.LNil); key == lua.LNil {
+			return []any{}, nil
+		}
+	}
+
+	result := make(map[string]any)
+	var err error
+	tbl.ForEach(func(k, v lua.LValue) {
+		if err != nil {
+			return
+		}
+		key := k.String()
+		var item any
+		item, err = c.convert(v, joinPath(path, key))
+		result[key] = item
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// arrayLength reports whether the keys of tbl are exactly the integers 1..n
+func arrayLength(tbl *lua.LTable) (int, bool) {
+	count, maxIndex := 0, 0
+	isArray := true
+	tbl.ForEach(func(k, _ lua.LValue) {
+		if !isArray {
+			return
+		}
+		num, ok := k.(lua.LNumber)
+		if !ok || float64(num) < 1 || float64(num) != math.Trunc(float64(num)) {
+			isArray = false
+			return
+		}
+		count++
+		if int(num) > maxIndex {
+			maxIndex = int(num)
+		}
+	})
+	if !isArray || count == 0 || count != maxIndex {
+		return 0, false
+	}
+	return count, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// FromGo converts a Go value to a Lua value.
+//
+//   - nil, booleans, every numeric kind, strings and byte slices map to Lua primitives
+//   - time.Time becomes an RFC 3339 string and time.Duration its number of seconds
+//   - slices and arrays become sequences, maps become tables keyed by fmt.Sprint(key)
+//   - structs and other values are converted through their JSON encoding
+//
+// A Go map or slice nested inside itself is converted to nil at the point of the cycle.
+func FromGo(L *lua.LState, v any, opts ...Option) lua.LValue {
+	c := &fromGoConverter{options: newOptions(opts), L: L, visiting: make(map[uintptr]struct{})}
+	return c.convert(v)
+}
+
+type fromGoConverter struct {
+	options
+	L        *lua.LState
+	visiting map[uintptr]struct{}
+}
+
+func (c *fromGoConverter) convert(v any) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return c.nilValue()
+	case lua.LValue:
+		return val
+	case bool:
+		return lua.LBool(val)
+	case string:
+		return lua.LString(val)
+	case []byte:
+		return lua.LString(val)
+	case float64:
+		return lua.LNumber(val)
+	case int:
+		return lua.LNumber(val)
+	case int64:
+		return lua.LNumber(val)
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return lua.LNumber(f)
+		}
+		return lua.LString(val)
+	case time.Time:
+		return lua.LString(val.Format(time.RFC3339Nano))
+	case time.Duration:
+		return lua.LNumber(val.Seconds())
+	case map[string]any:
+		if val == nil {
+			return c.nilValue()
+		}
+		ptr := reflect.ValueOf(val).Pointer()
+		if !c.enter(ptr) {
+			return lua.LNil
+		}
+		defer c.leave(ptr)
+		table := c.L.CreateTable(0, len(val))
+		for k, item := range val {
+			table.RawSetString(k, c.convert(item))
+		}
+		return table
+	case []any:
+		if val == nil {
+			return c.nilValue()
+		}
+		if len(val) > 0 {
+			ptr := reflect.ValueOf(val).Pointer()
+			if !c.enter(ptr) {
+				return lua.LNil
+			}
+			defer c.leave(ptr)
+		}
+		table := c.newArray(len(val))
+		for i, item := range val {
+			table.RawSetInt(i+1, c.convert(item)) // Lua is 1-indexed
+		}
+		return table
+	}
+
+	return c.convertReflect(reflect.ValueOf(v))
+}
+
+func (c *fromGoConverter) convertReflect(rv reflect.Value) lua.LValue {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return lua.LNumber(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(rv.Float())
+	case reflect.Bool:
+		return lua.LBool(rv.Bool())
+	case reflect.String:
+		return lua.LString(rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return c.nilValue()
+		}
+		if rv.Kind() == reflect.Pointer && rv.Elem().Kind() != reflect.Struct {
+			return c.convert(rv.Elem().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return c.nilValue()
+			}
+			if rv.Len() > 0 {
+				if !c.enter(rv.Pointer()) {
+					return lua.LNil
+				}
+				defer c.leave(rv.Pointer())
+			}
+		}
+		table := c.newArray(rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			table.RawSetInt(i+1, c.convert(rv.Index(i).Interface()))
+		}
+		return table
+	case reflect.Map:
+		if rv.IsNil() {
+			return c.nilValue()
+		}
+		if !c.enter(rv.Pointer()) {
+			return lua.LNil
+		}
+		defer c.leave(rv.Pointer())
+		table := c.L.CreateTable(0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			table.RawSetString(fmt.Sprint(iter.Key().Interface()), c.convert(iter.Value().Interface()))
+		}
+		return table
+	}
+
+	// Structs and anything else go through their JSON representation
+	data, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return lua.LString(fmt.Sprintf("%v", rv.Interface()))
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return lua.LString(data)
+	}
+	return c.convert(decoded)
+}
+
+// nilValue is the Lua value of a Go nil
+func (c *fromGoConverter) nilValue() lua.LValue {
+	if c.null != nil {
+		return c.null
+	}
+	return lua.LNil
+}
+
+// newArray creates the table of a sequence of n elements
+func (c *fromGoConverter) newArray(n int) *lua.LTable {
+	table := c.L.CreateTable(n, 0)
+	if c.arrayMeta != nil {
+		table.Metatable = c.arrayMeta
+	}
+	return table
+}
+
+func (c *fromGoConverter) enter(ptr uintptr) bool {
+	if _, ok := c.visiting[ptr]; ok {
+		return false
+	}
+	c.visiting[ptr] = struct{}{}
+	return true
+}
+
+func (c *fromGoConverter) leave(ptr uintptr) {
+	delete(c.visiting, ptr)
+}
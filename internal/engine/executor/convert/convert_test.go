@@ -0,0 +1,234 @@
+package convert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func evalTable(t *testing.T, L *lua.LState, script string) lua.LValue {
+	t.Helper()
+	err := L.DoString(script)
+	assert.NoError(t, err)
+	return L.Get(-1)
+}
+
+func TestToGo_Primitives(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    lua.LValue
+		expected any
+	}{
+		{name: "nil", value: lua.LNil, expected: nil},
+		{name: "bool", value: lua.LTrue, expected: true},
+		{name: "number", value: lua.LNumber(4.5), expected: 4.5},
+		{name: "string", value: lua.LString("hello"), expected: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ToGo(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestToGo_Tables(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	tests := []struct {
+		name     string
+		script   string
+		expected any
+	}{
+		{
+			name:     "array",
+			script:   `return {"a", "b", "c"}`,
+			expected: []any{"a", "b", "c"},
+		},
+		{
+			name:     "map",
+			script:   `return {name = "sensor", value = 21.5}`,
+			expected: map[string]any{"name": "sensor", "value": 21.5},
+		},
+		{
+			name:     "sparse array becomes map",
+			script:   `return {[1] = "a", [3] = "c"}`,
+			expected: map[string]any{"1": "a", "3": "c"},
+		},
+		{
+			name:     "mixed keys become map",
+			script:   `return {"a", key = "b"}`,
+			expected: map[string]any{"1": "a", "key": "b"},
+		},
+		{
+			name:     "empty table becomes map",
+			script:   `return {}`,
+			expected: map[string]any{},
+		},
+		{
+			name:   "nested",
+			script: `return {device = {id = "d1", readings = {1, 2, 3}}, ok = true}`,
+			expected: map[string]any{
+				"device": map[string]any{"id": "d1", "readings": []any{1.0, 2.0, 3.0}},
+				"ok":     true,
+			},
+		},
+		{
+			name:   "shared reference is not a cycle",
+			script: `local shared = {x = 1} return {a = shared, b = shared}`,
+			expected: map[string]any{
+				"a": map[string]any{"x": 1.0},
+				"b": map[string]any{"x": 1.0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ToGo(evalTable(t, L, tt.script))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestToGo_CyclicTable(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	value := evalTable(t, L, `local t = {name = "loop", child = {}} t.child.parent = t return t`)
+
+	result, err := ToGo(value)
+	assert.ErrorIs(t, err, ErrCyclicTable)
+	assert.Contains(t, err.Error(), "child.parent")
+	assert.Nil(t, result)
+}
+
+func TestToGoValues(t *testing.T) {
+	results, err := ToGoValues(lua.LTrue, lua.LString("reason"), lua.LNil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{true, "reason", nil}, results)
+}
+
+func TestFromGo(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	type reading struct {
+		Sensor string  `json:"sensor"`
+		Value  float64 `json:"value"`
+	}
+
+	L.SetGlobal("data", FromGo(L, map[string]any{
+		"count":    int32(3),
+		"ratio":    float32(0.5),
+		"tags":     []string{"a", "b"},
+		"labels":   map[string]string{"room": "kitchen"},
+		"reading":  reading{Sensor: "t1", Value: 21.5},
+		"pointer":  &reading{Sensor: "t2", Value: 1},
+		"at":       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"interval": 1500 * time.Millisecond,
+		"raw":      []byte("bytes"),
+		"missing":  nil,
+	}))
+
+	err := L.DoString(`
+		assert(data.count == 3)
+		assert(data.ratio == 0.5)
+		assert(#data.tags == 2 and data.tags[1] == "a" and data.tags[2] == "b")
+		assert(data.labels.room == "kitchen")
+		assert(data.reading.sensor == "t1" and data.reading.value == 21.5)
+		assert(data.pointer.sensor == "t2")
+		assert(data.at == "2024-01-02T03:04:05Z")
+		assert(data.interval == 1.5)
+		assert(data.raw == "bytes")
+		assert(data.missing == nil)
+	`)
+	assert.NoError(t, err)
+}
+
+func TestFromGo_CyclicMap(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	cyclic := map[string]any{"name": "loop"}
+	cyclic["self"] = cyclic
+
+	L.SetGlobal("data", FromGo(L, cyclic))
+	err := L.DoString(`assert(data.name == "loop" and data.self == nil)`)
+	assert.NoError(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	original := map[string]any{
+		"id":     "evt-1",
+		"values": []any{1.0, 2.0},
+		"meta":   map[string]any{"ok": true},
+	}
+
+	result, err := ToGo(FromGo(L, original))
+	assert.NoError(t, err)
+	assert.Equal(t, original, result)
+}
+
+func TestRoundTrip_WithOptions(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	null := L.NewUserData()
+	arrayMeta := L.NewTable()
+	opts := []Option{WithNull(null), WithArrayMetatable(arrayMeta)}
+
+	original := map[string]any{
+		"values":  []any{1.0, nil, 3.0},
+		"empty":   []any{},
+		"nothing": nil,
+		"nested":  map[string]any{"items": []any{}},
+	}
+
+	value := FromGo(L, original, opts...)
+	L.SetGlobal("data", value)
+	L.SetGlobal("null", null)
+	err := L.DoString(`
+		assert(#data.values == 3 and data.values[2] == null)
+		assert(data.nothing == null)
+		assert(next(data.empty) == nil)
+	`)
+	assert.NoError(t, err)
+
+	result, err := ToGo(value, opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, original, result)
+
+	// Without the options the holes and the empty arrays are lost
+	result, err = ToGo(FromGo(L, original))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"values": map[string]any{"1": 1.0, "3": 3.0},
+		"empty":  map[string]any{},
+		"nested": map[string]any{"items": map[string]any{}},
+	}, result)
+}
+
+func TestToGo_StrictTypes(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	value := evalTable(t, L, `return {callback = print}`)
+
+	result, err := ToGo(value)
+	assert.NoError(t, err)
+	assert.Contains(t, result.(map[string]any)["callback"], "function")
+
+	_, err = ToGo(value, WithStrictTypes())
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.EqualError(t, err, "cannot convert function at callback")
+}
@@ -0,0 +1,25 @@
+package executor
+
+import lua "github.com/yuin/gopher-lua"
+
+// CoverageRecorder aggregates the line hit counts of executions run with coverage
+type CoverageRecorder interface {
+	Record(script string, hits map[int]int)
+}
+
+// lineCounter is a hook counting how many times each line of the script is
+// entered, every iteration of a loop written on one line included
+type lineCounter struct {
+	hits map[int]int
+}
+
+func newLineCounter() *lineCounter {
+	return &lineCounter{hits: make(map[int]int)}
+}
+
+// Line implements Hook
+func (c *lineCounter) Line(_ *lua.LState, dbg *lua.Debug) {
+	if dbg.Source == ScriptSource {
+		c.hits[dbg.CurrentLine]++
+	}
+}
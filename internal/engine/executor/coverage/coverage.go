@@ -0,0 +1,173 @@
+// Package coverage aggregates the per-line hit counts of script executions.
+// Counts are keyed by the hash of the script source, so the coverage of a rule,
+// trigger or action script resets as soon as the script is changed, and a script
+// evaluated on its own counts towards every resource running the same source.
+package coverage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// DefaultMaxScripts is the number of scripts whose coverage is kept in memory
+const DefaultMaxScripts = 1024
+
+// Line is the hit count of one source line
+type Line struct {
+	Line int   `json:"line"`
+	Hits int64 `json:"hits"`
+}
+
+// Report describes how much of a script its recorded executions ran
+type Report struct {
+	ScriptHash string `json:"script_hash"`
+	// Runs is the number of executions recorded with coverage
+	Runs            int        `json:"runs"`
+	ExecutableLines int        `json:"executable_lines"`
+	CoveredLines    int        `json:"covered_lines"`
+	Percent         float64    `json:"percent"`
+	Lines           []Line     `json:"lines"` // every executable line, in source order
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+}
+
+type scriptCoverage struct {
+	runs      int
+	hits      map[int]int64
+	lastRunAt time.Time
+}
+
+// Collector aggregates hit counts across executions.
+// Once it holds maxScripts scripts, the one run least recently is dropped.
+type Collector struct {
+	mu         sync.Mutex
+	maxScripts int
+	scripts    map[string]*scriptCoverage
+}
+
+// NewCollector creates a collector keeping the coverage of at most maxScripts scripts
+func NewCollector(maxScripts int) *Collector {
+	if maxScripts <= 0 {
+		maxScripts = DefaultMaxScripts
+	}
+	return &Collector{maxScripts: maxScripts, scripts: make(map[string]*scriptCoverage)}
+}
+
+// Record adds the hit counts of one execution of script
+func (c *Collector) Record(script string, hits map[int]int) {
+	hash := scriptHash(script)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sc, ok := c.scripts[hash]
+	if !ok {
+		if len(c.scripts) >= c.maxScripts {
+			c.evictOldest()
+		}
+		sc = &scriptCoverage{hits: make(map[int]int64)}
+		c.scripts[hash] = sc
+	}
+	sc.runs++
+	sc.lastRunAt = time.Now().UTC()
+	for line, n := range hits {
+		sc.hits[line] += int64(n)
+	}
+}
+
+// Report returns the coverage of script.
+// Scripts without recorded executions are reported with every line uncovered.
+func (c *Collector) Report(script string) (*Report, error) {
+	executable, err := ExecutableLines(script)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{ScriptHash: scriptHash(script), ExecutableLines: len(executable), Lines: make([]Line, len(executable))}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sc := c.scripts[report.ScriptHash]
+	for i, line := range executable {
+		report.Lines[i] = Line{Line: line}
+		if sc != nil {
+			report.Lines[i].Hits = sc.hits[line]
+		}
+		if report.Lines[i].Hits > 0 {
+			report.CoveredLines++
+		}
+	}
+	if sc != nil {
+		report.Runs = sc.runs
+		lastRunAt := sc.lastRunAt
+		report.LastRunAt = &lastRunAt
+	}
+	if report.ExecutableLines > 0 {
+		report.Percent = float64(report.CoveredLines) * 100 / float64(report.ExecutableLines)
+	}
+	return report, nil
+}
+
+// Reset drops the coverage recorded for every script
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scripts = make(map[string]*scriptCoverage)
+}
+
+func (c *Collector) evictOldest() {
+	var oldest string
+	for hash, sc := range c.scripts {
+		if oldest == "" || sc.lastRunAt.Before(c.scripts[oldest].lastRunAt) {
+			oldest = hash
+		}
+	}
+	delete(c.scripts, oldest)
+}
+
+// ExecutableLines returns the source lines of script that carry instructions,
+// in the functions it defines as well as in its main chunk
+func ExecutableLines(script string) ([]int, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), "<string>")
+	if err != nil {
+		return nil, err
+	}
+	proto, err := lua.Compile(chunk, "<string>")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	var walk func(proto *lua.FunctionProto, positions []int)
+	walk = func(proto *lua.FunctionProto, positions []int) {
+		for _, line := range positions {
+			if line > 0 {
+				seen[line] = true
+			}
+		}
+		for _, child := range proto.FunctionPrototypes {
+			walk(child, child.DbgSourcePositions)
+		}
+	}
+	// The implicit return closing the main chunk is placed after its last line
+	positions := proto.DbgSourcePositions
+	walk(proto, positions[:max(len(positions)-1, 0)])
+
+	lines := make([]int, 0, len(seen))
+	for line := range seen {
+		lines = append(lines, line)
+	}
+	slices.Sort(lines)
+	return lines, nil
+}
+
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
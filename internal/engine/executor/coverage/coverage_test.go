@@ -0,0 +1,68 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const script = `local function check(x)
+  if x > 10 then
+    return "high"
+  end
+  return "low"
+end
+return check(value)`
+
+func TestExecutableLines(t *testing.T) {
+	lines, err := ExecutableLines(script)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 5, 6, 7}, lines)
+
+	_, err = ExecutableLines("return (")
+	assert.Error(t, err)
+}
+
+func TestCollector_Report(t *testing.T) {
+	c := NewCollector(0)
+
+	report, err := c.Report(script)
+	require.NoError(t, err)
+	assert.Zero(t, report.Runs)
+	assert.Zero(t, report.CoveredLines)
+	assert.Nil(t, report.LastRunAt)
+	assert.Len(t, report.Lines, 6)
+
+	c.Record(script, map[int]int{1: 1, 7: 1, 2: 1, 5: 1, 6: 1})
+	c.Record(script, map[int]int{1: 1, 7: 1, 2: 1, 5: 1, 6: 1})
+	c.Record("return 1", map[int]int{1: 1})
+
+	report, err = c.Report(script)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Runs)
+	assert.Equal(t, 6, report.ExecutableLines)
+	assert.Equal(t, 5, report.CoveredLines)
+	assert.InDelta(t, 83.33, report.Percent, 0.01)
+	assert.Equal(t, []Line{{1, 2}, {2, 2}, {3, 0}, {5, 2}, {6, 2}, {7, 2}}, report.Lines)
+	assert.NotNil(t, report.LastRunAt)
+
+	c.Reset()
+	report, err = c.Report(script)
+	require.NoError(t, err)
+	assert.Zero(t, report.Runs)
+}
+
+func TestCollector_Eviction(t *testing.T) {
+	c := NewCollector(2)
+	c.Record("return 1", map[int]int{1: 1})
+	c.Record("return 2", map[int]int{1: 1})
+	c.Record("return 1", map[int]int{1: 1})
+	c.Record("return 3", map[int]int{1: 1})
+
+	for script, runs := range map[string]int{"return 1": 2, "return 2": 0, "return 3": 1} {
+		report, err := c.Report(script)
+		require.NoError(t, err)
+		assert.Equal(t, runs, report.Runs, script)
+	}
+}
@@ -0,0 +1,155 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+)
+
+// Default session limits
+const (
+	DefaultSessionTTL  = 5 * time.Minute
+	DefaultMaxSessions = 16
+)
+
+// Errors returned by the manager
+var (
+	ErrSessionNotFound = errors.New("debug session not found")
+	ErrTooManySessions = errors.New("too many debug sessions")
+)
+
+// Executor runs scripts in the sandbox
+type Executor interface {
+	ExecuteScript(ctx context.Context, script string, execCtx *execCtx.ExecutionContext) *executor.ExecuteResult
+}
+
+// StartRequest describes a script to debug
+type StartRequest struct {
+	Script string
+	// Context is exposed to the script as the event payload, as for evaluations
+	Context     map[string]any
+	Breakpoints []int
+	// StopOnEntry pauses the script before its first line
+	StopOnEntry bool
+}
+
+// Manager keeps track of the running debug sessions.
+// Scripts run with the timeout and budgets of normal executions, the time they
+// spend paused aside. A session lives until it is stopped or has been paused
+// or finished for its time-to-live; an expired script is aborted like a timed
+// out execution.
+type Manager struct {
+	executor    Executor
+	ttl         time.Duration
+	maxSessions int
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// ManagerOption allows to configure the debug session manager
+type ManagerOption func(m *Manager) *Manager
+
+// WithSessionTTL sets how long a session is kept while paused or once finished
+func WithSessionTTL(ttl time.Duration) ManagerOption {
+	return func(m *Manager) *Manager {
+		if ttl > 0 {
+			m.ttl = ttl
+		}
+		return m
+	}
+}
+
+// WithMaxSessions sets how many sessions may exist at the same time
+func WithMaxSessions(maxSessions int) ManagerOption {
+	return func(m *Manager) *Manager {
+		if maxSessions > 0 {
+			m.maxSessions = maxSessions
+		}
+		return m
+	}
+}
+
+// NewManager creates a debug session manager running scripts with executor
+func NewManager(executor Executor, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		executor:    executor,
+		ttl:         DefaultSessionTTL,
+		maxSessions: DefaultMaxSessions,
+		sessions:    make(map[string]*Session),
+	}
+	for _, opt := range opts {
+		m = opt(m)
+	}
+	return m
+}
+
+// Start runs a script under a new debug session.
+// The script starts right away; use Session.Wait to observe its first pause.
+func (m *Manager) Start(req StartRequest) (*Session, error) {
+	m.mu.Lock()
+	if len(m.sessions) >= m.maxSessions {
+		m.mu.Unlock()
+		return nil, ErrTooManySessions
+	}
+	session := newSession(context.Background(), uuid.NewString(), m.ttl, req.Breakpoints, req.StopOnEntry)
+	m.sessions[session.id] = session
+	m.mu.Unlock()
+
+	execContext := &execCtx.ExecutionContext{
+		RuleID:    "debug",
+		TriggerID: "debug",
+		Event:     req.Context,
+		FiredAt:   time.Now().UTC(),
+		Attempt:   1,
+		Data:      req.Context,
+	}
+
+	go func() {
+		result := m.executor.ExecuteScript(executor.WithHook(session.ctx, session), req.Script, execContext)
+		session.finish(result)
+		slog.Debug("Debug session finished", "session_id", session.id, "status", result.Status)
+
+		// Keep the result available until the session expires or is stopped
+		<-session.ctx.Done()
+		m.remove(session)
+	}()
+
+	return session, nil
+}
+
+// Get returns the session with the given ID
+func (m *Manager) Get(id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// Stop aborts the script of a session and discards it
+func (m *Manager) Stop(id string) error {
+	session, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	m.remove(session)
+	session.stop()
+	return nil
+}
+
+func (m *Manager) remove(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[session.id] == session {
+		delete(m.sessions, session.id)
+	}
+}
@@ -0,0 +1,227 @@
+package debugger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `local limit = 10
+local function scale(x)
+  local factor = limit / 5
+  return x * factor
+end
+local value = scale(event_value)
+local alert = value > limit
+return alert, value`
+
+func newTestManager(opts ...ManagerOption) *Manager {
+	return NewManager(executor.NewService(execCtx.NewService(), platform.NewService()), opts...)
+}
+
+func waitPaused(t *testing.T, session *Session) *Snapshot {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, session.Wait(ctx))
+	return session.Snapshot()
+}
+
+func TestManager_Breakpoints(t *testing.T) {
+	m := newTestManager()
+
+	session, err := m.Start(StartRequest{
+		Script:      testScript,
+		Context:     map[string]any{"event_value": 6},
+		Breakpoints: []int{4, 7},
+	})
+	require.NoError(t, err)
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusPaused, snapshot.Status)
+	assert.Equal(t, ReasonBreakpoint, snapshot.Reason)
+	assert.Equal(t, 4, snapshot.Line)
+	assert.Equal(t, []int{4, 7}, snapshot.Breakpoints)
+	require.Len(t, snapshot.Stack, 2)
+	assert.Equal(t, "scale", snapshot.Stack[0].Function)
+	assert.Equal(t, executor.ScriptSource, snapshot.Stack[0].Source)
+	assert.Equal(t, []Variable{{Name: "x", Value: 6.0}, {Name: "factor", Value: 2.0}}, snapshot.Stack[0].Locals)
+	assert.Equal(t, []Variable{{Name: "limit", Value: 10.0}}, snapshot.Stack[0].Upvalues)
+	assert.Equal(t, "main chunk", snapshot.Stack[1].Function)
+	assert.Equal(t, 6, snapshot.Stack[1].Line)
+
+	require.NoError(t, session.Continue())
+	snapshot = waitPaused(t, session)
+	assert.Equal(t, 7, snapshot.Line)
+	require.Len(t, snapshot.Stack, 1)
+	assert.Equal(t, []Variable{
+		{Name: "limit", Value: 10.0},
+		{Name: "scale", Value: snapshot.Stack[0].Locals[1].Value},
+		{Name: "value", Value: 12.0},
+	}, snapshot.Stack[0].Locals)
+
+	require.NoError(t, session.Continue())
+	snapshot = waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	require.NotNil(t, snapshot.Result)
+	assert.True(t, snapshot.Result.Success, snapshot.Result.Error)
+	assert.Equal(t, []any{true, 12.0}, snapshot.Result.Output)
+	assert.Empty(t, snapshot.Stack)
+
+	assert.ErrorIs(t, session.Continue(), ErrNotPaused)
+}
+
+func TestManager_Stepping(t *testing.T) {
+	m := newTestManager()
+
+	session, err := m.Start(StartRequest{
+		Script:      testScript,
+		Context:     map[string]any{"event_value": 1},
+		StopOnEntry: true,
+	})
+	require.NoError(t, err)
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, ReasonEntry, snapshot.Reason)
+	assert.Equal(t, 1, snapshot.Line)
+
+	lines := func(step func() error, n int) []int {
+		var visited []int
+		for range n {
+			require.NoError(t, step())
+			snapshot := waitPaused(t, session)
+			require.Equal(t, StatusPaused, snapshot.Status)
+			assert.Equal(t, ReasonStep, snapshot.Reason)
+			visited = append(visited, snapshot.Line)
+		}
+		return visited
+	}
+
+	// Step enters scale, next returns to the main chunk without entering any call
+	assert.Equal(t, []int{2, 6, 3, 4}, lines(session.Step, 4))
+	assert.Equal(t, []int{7, 8}, lines(session.Next, 2))
+
+	require.NoError(t, session.Continue())
+	snapshot = waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	assert.Equal(t, []any{false, 2.0}, snapshot.Result.Output)
+}
+
+func TestManager_SetBreakpoints(t *testing.T) {
+	m := newTestManager()
+
+	session, err := m.Start(StartRequest{Script: testScript, Context: map[string]any{"event_value": 1}, StopOnEntry: true})
+	require.NoError(t, err)
+	waitPaused(t, session)
+
+	session.SetBreakpoints([]int{8, 3})
+	require.NoError(t, session.Continue())
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, ReasonBreakpoint, snapshot.Reason)
+	assert.Equal(t, 3, snapshot.Line)
+	assert.Equal(t, []int{3, 8}, snapshot.Breakpoints)
+}
+
+func TestManager_Stop(t *testing.T) {
+	m := newTestManager()
+
+	session, err := m.Start(StartRequest{Script: testScript, Breakpoints: []int{1}})
+	require.NoError(t, err)
+	waitPaused(t, session)
+
+	got, err := m.Get(session.ID())
+	require.NoError(t, err)
+	assert.Same(t, session, got)
+
+	require.NoError(t, m.Stop(session.ID()))
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	assert.False(t, snapshot.Result.Success)
+
+	_, err = m.Get(session.ID())
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.ErrorIs(t, m.Stop(session.ID()), ErrSessionNotFound)
+}
+
+func TestManager_SessionExpires(t *testing.T) {
+	m := newTestManager(WithSessionTTL(50 * time.Millisecond))
+
+	session, err := m.Start(StartRequest{Script: testScript, StopOnEntry: true})
+	require.NoError(t, err)
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusPaused, snapshot.Status)
+	assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), snapshot.ExpiresAt, time.Second)
+
+	assert.Eventually(t, func() bool {
+		return session.Snapshot().Status == StatusFinished
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, executor.StatusTimeout, session.Snapshot().Result.Status)
+
+	assert.Eventually(t, func() bool {
+		_, err := m.Get(session.ID())
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestManager_PausedTimeIsNotTimed(t *testing.T) {
+	m := NewManager(executor.NewService(execCtx.NewService(), platform.NewService(),
+		executor.WithTimeout(50*time.Millisecond)))
+
+	session, err := m.Start(StartRequest{Script: testScript, Context: map[string]any{"event_value": 6}, StopOnEntry: true})
+	require.NoError(t, err)
+	waitPaused(t, session)
+
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, session.Continue())
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	assert.True(t, snapshot.Result.Success, snapshot.Result.Error)
+}
+
+func TestManager_RunningScriptTimesOut(t *testing.T) {
+	m := NewManager(executor.NewService(execCtx.NewService(), platform.NewService(),
+		executor.WithTimeout(100*time.Millisecond)))
+
+	session, err := m.Start(StartRequest{Script: "while true do end"})
+	require.NoError(t, err)
+	assert.True(t, session.Snapshot().ExpiresAt.IsZero(), "running sessions do not expire")
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	assert.Equal(t, executor.StatusTimeout, snapshot.Result.Status)
+}
+
+func TestManager_MaxSessions(t *testing.T) {
+	m := newTestManager(WithMaxSessions(1))
+
+	session, err := m.Start(StartRequest{Script: testScript, StopOnEntry: true})
+	require.NoError(t, err)
+
+	_, err = m.Start(StartRequest{Script: testScript})
+	assert.ErrorIs(t, err, ErrTooManySessions)
+
+	require.NoError(t, m.Stop(session.ID()))
+	session, err = m.Start(StartRequest{Script: "return 1"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusFinished, waitPaused(t, session).Status)
+}
+
+func TestManager_RuntimeError(t *testing.T) {
+	m := newTestManager()
+
+	session, err := m.Start(StartRequest{Script: "local x = nil\nreturn x.field"})
+	require.NoError(t, err)
+
+	snapshot := waitPaused(t, session)
+	assert.Equal(t, StatusFinished, snapshot.Status)
+	assert.False(t, snapshot.Result.Success)
+	assert.Equal(t, executor.StatusFailure, snapshot.Result.Status)
+}
@@ -0,0 +1,365 @@
+// Package debugger runs scripts step by step.
+// A session executes a script in the regular sandbox with a line hook attached,
+// pauses it on breakpoints or after a step and exposes the call stack, locals
+// and upvalues of the paused script until it is resumed.
+package debugger
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ErrNotPaused is returned when a session is resumed while it is not paused
+var ErrNotPaused = errors.New("debug session is not paused")
+
+// Status is the state of a debug session
+type Status string
+
+const (
+	StatusRunning  Status = "running"
+	StatusPaused   Status = "paused"
+	StatusFinished Status = "finished"
+)
+
+// Reasons a session paused
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+)
+
+// stepMode decides where a running session pauses next
+type stepMode int
+
+const (
+	// modeContinue pauses on the next breakpoint
+	modeContinue stepMode = iota
+	// modeStep pauses on the next line, entering called functions
+	modeStep
+	// modeNext pauses on the next line of the current function or of its callers
+	modeNext
+)
+
+// Variable is a local or upvalue of a paused script
+type Variable struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// Frame is one level of the call stack of a paused script, innermost first.
+// Locals and upvalues are only reported for script functions.
+type Frame struct {
+	Function string     `json:"function"`
+	Source   string     `json:"source"`
+	Line     int        `json:"line,omitempty"`
+	Locals   []Variable `json:"locals,omitempty"`
+	Upvalues []Variable `json:"upvalues,omitempty"`
+}
+
+// Snapshot is the observable state of a session
+type Snapshot struct {
+	ID          string                  `json:"id"`
+	Status      Status                  `json:"status"`
+	Reason      string                  `json:"reason,omitempty"`
+	Line        int                     `json:"line,omitempty"`
+	Breakpoints []int                   `json:"breakpoints"`
+	Stack       []Frame                 `json:"stack,omitempty"`
+	Result      *executor.ExecuteResult `json:"result,omitempty"`
+	ExpiresAt   time.Time               `json:"expires_at,omitzero"` // unset while the script runs
+}
+
+// Session is a script running under the debugger.
+// It implements executor.Hook and must only be attached to one execution.
+type Session struct {
+	id     string
+	ttl    time.Duration
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	resume chan struct{}
+
+	mu          sync.Mutex
+	status      Status
+	reason      string
+	line        int
+	stack       []Frame
+	result      *executor.ExecuteResult
+	breakpoints map[int]struct{}
+	stopOnEntry bool
+	mode        stepMode
+	// depth is the stack depth of the last pause, used by modeNext
+	depth int
+	// changed is closed and replaced on every status change
+	changed chan struct{}
+	// idle expires the session once it has been paused or finished for ttl
+	idle      *time.Timer
+	expiresAt time.Time
+}
+
+func newSession(ctx context.Context, id string, ttl time.Duration, breakpoints []int, stopOnEntry bool) *Session {
+	ctx, cancel := context.WithCancelCause(ctx)
+	s := &Session{
+		id:          id,
+		ttl:         ttl,
+		ctx:         ctx,
+		cancel:      cancel,
+		resume:      make(chan struct{}, 1),
+		status:      StatusRunning,
+		changed:     make(chan struct{}),
+		stopOnEntry: stopOnEntry,
+	}
+	s.setBreakpoints(breakpoints)
+	return s
+}
+
+// ID returns the session identifier
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Snapshot returns the current state of the session
+func (s *Session) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Snapshot{
+		ID:          s.id,
+		Status:      s.status,
+		Reason:      s.reason,
+		Line:        s.line,
+		Breakpoints: s.breakpointLines(),
+		Stack:       s.stack,
+		Result:      s.result,
+		ExpiresAt:   s.expiresAt,
+	}
+}
+
+// SetBreakpoints replaces the breakpoints of the session with the given script lines
+func (s *Session) SetBreakpoints(lines []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setBreakpoints(lines)
+}
+
+func (s *Session) setBreakpoints(lines []int) {
+	s.breakpoints = make(map[int]struct{}, len(lines))
+	for _, line := range lines {
+		s.breakpoints[line] = struct{}{}
+	}
+}
+
+func (s *Session) breakpointLines() []int {
+	lines := make([]int, 0, len(s.breakpoints))
+	for line := range s.breakpoints {
+		lines = append(lines, line)
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// Continue resumes a paused session until the next breakpoint
+func (s *Session) Continue() error {
+	return s.resumeWith(modeContinue)
+}
+
+// Step resumes a paused session until the next line, entering called functions
+func (s *Session) Step() error {
+	return s.resumeWith(modeStep)
+}
+
+// Next resumes a paused session until the next line, stepping over called functions
+func (s *Session) Next() error {
+	return s.resumeWith(modeNext)
+}
+
+func (s *Session) resumeWith(mode stepMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != StatusPaused {
+		return ErrNotPaused
+	}
+	s.mode = mode
+	s.setStatus(StatusRunning)
+	s.resume <- struct{}{}
+	return nil
+}
+
+// Wait blocks until the session is paused or finished, or ctx is done
+func (s *Session) Wait(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		status, changed := s.status, s.changed
+		s.mu.Unlock()
+		if status != StatusRunning {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setStatus must be called with s.mu held. The session expires once it has
+// been paused or finished for its time-to-live; running scripts are bounded
+// by the execution timeout instead.
+func (s *Session) setStatus(status Status) {
+	s.status = status
+	if status == StatusRunning {
+		s.reason, s.line, s.stack = "", 0, nil
+		if s.idle != nil {
+			s.idle.Stop()
+		}
+		s.expiresAt = time.Time{}
+	} else {
+		s.expiresAt = time.Now().Add(s.ttl)
+		if s.idle == nil {
+			// An expired script is aborted like a timed out execution
+			s.idle = time.AfterFunc(s.ttl, func() { s.cancel(context.DeadlineExceeded) })
+		} else {
+			s.idle.Reset(s.ttl)
+		}
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// stop aborts the script; a paused script runs again until it observes the cancellation
+func (s *Session) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel(context.Canceled)
+	if s.status == StatusPaused {
+		s.setStatus(StatusRunning)
+	}
+}
+
+func (s *Session) finish(result *executor.ExecuteResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.result = result
+	s.setStatus(StatusFinished)
+}
+
+// Line implements executor.Hook. It blocks the script while the session is paused.
+func (s *Session) Line(L *lua.LState, dbg *lua.Debug) {
+	if dbg.Source != executor.ScriptSource {
+		return
+	}
+
+	s.mu.Lock()
+	reason := s.pauseReason(L, dbg.CurrentLine)
+	if reason == "" {
+		s.mu.Unlock()
+		return
+	}
+	s.stack = captureStack(L)
+	s.depth = len(s.stack)
+	s.reason, s.line = reason, dbg.CurrentLine
+	s.setStatus(StatusPaused)
+	s.mu.Unlock()
+
+	select {
+	case <-s.resume:
+	case <-s.ctx.Done():
+		// The script is aborted by the cancelled execution context
+		s.mu.Lock()
+		if s.status == StatusPaused {
+			s.setStatus(StatusRunning)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// pauseReason must be called with s.mu held
+func (s *Session) pauseReason(L *lua.LState, line int) string {
+	entry := s.stopOnEntry
+	s.stopOnEntry = false
+	if _, ok := s.breakpoints[line]; ok {
+		return ReasonBreakpoint
+	}
+	if entry {
+		return ReasonEntry
+	}
+	switch s.mode {
+	case modeStep:
+		return ReasonStep
+	case modeNext:
+		if stackDepth(L) <= s.depth {
+			return ReasonStep
+		}
+	}
+	return ""
+}
+
+func stackDepth(L *lua.LState) int {
+	depth := 0
+	for {
+		if _, ok := L.GetStack(depth); !ok {
+			return depth
+		}
+		depth++
+	}
+}
+
+// captureStack describes every frame of the running script, innermost first
+func captureStack(L *lua.LState) []Frame {
+	var stack []Frame
+	for level := 0; ; level++ {
+		dbg, ok := L.GetStack(level)
+		if !ok {
+			return stack
+		}
+		value, err := L.GetInfo("fnSl", dbg, nil)
+		if err != nil {
+			continue
+		}
+		fn, ok := value.(*lua.LFunction)
+		if !ok {
+			continue
+		}
+
+		frame := Frame{Function: dbg.Name, Source: dbg.Source}
+		if fn.IsG {
+			if frame.Function == "" {
+				frame.Function = "Go function"
+			}
+			stack = append(stack, frame)
+			continue
+		}
+		switch {
+		case dbg.What == "main":
+			frame.Function = "main chunk"
+		case frame.Function == "":
+			frame.Function = "anonymous function"
+		}
+		frame.Line = dbg.CurrentLine
+		frame.Locals = variables(executor.Locals(L, dbg))
+		frame.Upvalues = variables(executor.Upvalues(L, dbg))
+		stack = append(stack, frame)
+	}
+}
+
+// variables converts the values of vars so that they can be serialized.
+// Values that cannot be converted, such as cyclic tables, keep their string form.
+func variables(vars []executor.Variable) []Variable {
+	if len(vars) == 0 {
+		return nil
+	}
+	result := make([]Variable, 0, len(vars))
+	for _, v := range vars {
+		value, err := convert.ToGo(v.Value)
+		if err != nil {
+			value = v.Value.String()
+		}
+		result = append(result, Variable{Name: v.Name, Value: value})
+	}
+	return result
+}
@@ -0,0 +1,283 @@
+// Package egress decides which hosts the requests scripts make may reach.
+// A Policy combines allow- and deny-lists of host names and networks with the
+// blocking of private addresses. Addresses are checked when connections are
+// dialed, on the address actually connected to, so that a name resolving to a
+// public address when the URL is checked and to a private one when it is
+// dialed (DNS rebinding) is still refused.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects bounds the redirects followed by a request
+const maxRedirects = 10
+
+// ErrDenied is returned for requests the policy refuses
+var ErrDenied = errors.New("egress denied")
+
+// specialNetworks are the networks besides the private, loopback, link-local
+// and multicast ones that requests may not reach unless explicitly allowed
+var specialNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use IPv4/IPv6 translation
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("255.255.255.255/32"),
+}
+
+// Rules lists host names and networks. A host name matches itself, and a
+// name starting with "*." matches the names below it.
+type Rules struct {
+	Hosts    []string
+	Networks []netip.Prefix
+}
+
+// ParseRules parses a comma-separated list of host names, wildcard host names,
+// IP addresses and networks in CIDR notation
+func ParseRules(list string) (Rules, error) {
+	var rules Rules
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			rules.Networks = append(rules.Networks, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			rules.Networks = append(rules.Networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		name := strings.TrimPrefix(entry, "*.")
+		if name == "" || strings.ContainsAny(name, "/*:") {
+			return Rules{}, fmt.Errorf("invalid host or network '%s'", entry)
+		}
+		rules.Hosts = append(rules.Hosts, strings.TrimSuffix(entry, "."))
+	}
+	return rules, nil
+}
+
+// empty reports whether the rules list nothing
+func (r Rules) empty() bool {
+	return len(r.Hosts) == 0 && len(r.Networks) == 0
+}
+
+// matchesHost reports whether host is listed
+func (r Rules) matchesHost(host string) bool {
+	for _, pattern := range r.Hosts {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAddr reports whether addr is in a listed network
+func (r Rules) matchesAddr(addr netip.Addr) bool {
+	for _, prefix := range r.Networks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy decides which hosts requests may reach:
+//
+//   - hosts and addresses of the deny-list are always refused
+//   - when the allow-list is not empty, only its hosts and networks are allowed
+//   - private, loopback, link-local and other special addresses are refused
+//     unless private networks are allowed or the address is in an allowed network
+type Policy struct {
+	allow        Rules
+	deny         Rules
+	allowPrivate bool
+	dialer       *net.Dialer
+}
+
+// PolicyOption allows to configure the policy
+type PolicyOption func(p *Policy) *Policy
+
+// WithAllow sets the hosts and networks requests are restricted to
+func WithAllow(rules Rules) PolicyOption {
+	return func(p *Policy) *Policy {
+		p.allow = rules
+		return p
+	}
+}
+
+// WithDeny sets the hosts and networks requests may never reach
+func WithDeny(rules Rules) PolicyOption {
+	return func(p *Policy) *Policy {
+		p.deny = rules
+		return p
+	}
+}
+
+// WithPrivateNetworks allows requests to private and other special addresses
+func WithPrivateNetworks(allowed bool) PolicyOption {
+	return func(p *Policy) *Policy {
+		p.allowPrivate = allowed
+		return p
+	}
+}
+
+// NewPolicy creates a policy, which blocks private addresses by default
+func NewPolicy(opts ...PolicyOption) *Policy {
+	p := &Policy{
+		dialer: &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// CheckURL checks the scheme and the host of a request URL.
+// The addresses the host resolves to are checked when dialing.
+func (p *Policy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme '%s' is not allowed", ErrDenied, u.Scheme)
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrDenied)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(host, addr.Unmap())
+	}
+	if p.deny.matchesHost(host) {
+		return fmt.Errorf("%w: host %s is denied", ErrDenied, host)
+	}
+	// Hosts outside the allow-list may still resolve into an allowed network
+	if !p.allow.matchesHost(host) && len(p.allow.Networks) == 0 && !p.allow.empty() {
+		return fmt.Errorf("%w: host %s is not allowed", ErrDenied, host)
+	}
+	return nil
+}
+
+// checkAddr checks an address host resolved to
+func (p *Policy) checkAddr(host string, addr netip.Addr) error {
+	if p.deny.matchesAddr(addr) || p.deny.matchesHost(host) {
+		return fmt.Errorf("%w: address %s of %s is denied", ErrDenied, addr, host)
+	}
+	explicit := p.allow.matchesAddr(addr)
+	if !p.allow.empty() && !explicit && !p.allow.matchesHost(host) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrDenied, host)
+	}
+	if !p.allowPrivate && !explicit && isPrivate(addr) {
+		return fmt.Errorf("%w: address %s of %s is private", ErrDenied, addr, host)
+	}
+	return nil
+}
+
+// DialContext dials addr, refusing the connection when the policy does not
+// allow the address the host name resolved to
+func (p *Policy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	host = normalizeHost(host)
+	dialer := *p.dialer
+	dialer.Control = func(_, address string, _ syscall.RawConn) error {
+		ipport, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: unexpected address %s", ErrDenied, address)
+		}
+		return p.checkAddr(host, ipport.Addr().Unmap())
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// CheckRedirect checks the target of a redirect, to be used as the
+// CheckRedirect function of an http.Client
+func (p *Policy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return p.CheckURL(req.URL)
+}
+
+// Transport returns a transport dialing through the policy. Proxies are not
+// used, since the policy would check the address of the proxy only.
+func (p *Policy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = p.DialContext
+	return transport
+}
+
+// Client returns a client whose requests follow the policy
+func (p *Policy) Client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     p.Transport(),
+		CheckRedirect: p.CheckRedirect,
+	}
+}
+
+// Networks of IPv6 addresses that embed an IPv4 address, which the hosts
+// translating them reach on the behalf of the client
+var (
+	nat64Network = netip.MustParsePrefix("64:ff9b::/96") // well-known NAT64 prefix
+	sixToFour    = netip.MustParsePrefix("2002::/16")    // 6to4
+)
+
+// embeddedIPv4 returns the IPv4 address embedded in an IPv4-mapped, NAT64 or
+// 6to4 address
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case addr.Is4In6():
+		return addr.Unmap(), true
+	case nat64Network.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// isPrivate reports whether addr is private, loopback, link-local or otherwise
+// not a public unicast address. Addresses embedding an IPv4 address are
+// classified by that address.
+func isPrivate(addr netip.Addr) bool {
+	if v4, ok := embeddedIPv4(addr); ok {
+		addr = v4
+	}
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range specialNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,129 @@
+package egress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustRules(t *testing.T, list string) Rules {
+	t.Helper()
+	rules, err := ParseRules(list)
+	require.NoError(t, err)
+	return rules
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" API.example.com, *.hooks.example.com,10.1.2.3, 192.168.1.7/16,, ::ffff:203.0.113.9")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "*.hooks.example.com"}, rules.Hosts)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.1.2.3/32"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("203.0.113.9/32"),
+	}, rules.Networks)
+
+	for _, invalid := range []string{"*.", "example.com/path", "*.*.example.com", "10.0.0.0/33"} {
+		_, err := ParseRules(invalid)
+		assert.ErrorContains(t, err, "invalid host or network", invalid)
+	}
+}
+
+func TestPolicy_CheckURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		url    string
+		denied string
+	}{
+		{"public host", NewPolicy(), "https://api.example.com/v1", ""},
+		{"public address", NewPolicy(), "http://203.0.113.10:8080/", ""},
+		{"scheme", NewPolicy(), "file:///etc/passwd", "scheme 'file' is not allowed"},
+		{"loopback", NewPolicy(), "http://127.0.0.1/", "address 127.0.0.1 of 127.0.0.1 is private"},
+		{"metadata", NewPolicy(), "http://169.254.169.254/latest/meta-data", "is private"},
+		{"private", NewPolicy(), "http://10.0.0.5/", "is private"},
+		{"ipv6 loopback", NewPolicy(), "http://[::1]/", "is private"},
+		{"ipv4-mapped", NewPolicy(), "http://[::ffff:192.168.1.1]/", "address 192.168.1.1"},
+		{"carrier-grade nat", NewPolicy(), "http://100.64.0.1/", "is private"},
+		{"nat64 private", NewPolicy(), "http://[64:ff9b::10.0.0.5]/", "is private"},
+		{"nat64 loopback", NewPolicy(), "http://[64:ff9b::7f00:1]/", "is private"},
+		{"nat64 public", NewPolicy(), "http://[64:ff9b::203.0.113.10]/", ""},
+		{"6to4 private", NewPolicy(), "http://[2002:a9fe:a9fe::1]/", "is private"},
+		{"6to4 public", NewPolicy(), "http://[2002:cb00:710a::1]/", ""},
+		{"private allowed", NewPolicy(WithPrivateNetworks(true)), "http://10.0.0.5/", ""},
+		{"allowed network", NewPolicy(WithAllow(mustRules(t, "10.0.0.0/8"))), "http://10.0.0.5/", ""},
+		{"outside allowed network", NewPolicy(WithAllow(mustRules(t, "10.0.0.0/8"))), "http://203.0.113.10/", "not allowed"},
+		{"allowed host", NewPolicy(WithAllow(mustRules(t, "api.example.com"))), "https://API.example.com./", ""},
+		{"not allowed host", NewPolicy(WithAllow(mustRules(t, "api.example.com"))), "https://evil.com/", "host evil.com is not allowed"},
+		{"wildcard", NewPolicy(WithAllow(mustRules(t, "*.example.com"))), "https://hooks.eu.example.com/", ""},
+		{"wildcard apex", NewPolicy(WithAllow(mustRules(t, "*.example.com"))), "https://example.com/", "not allowed"},
+		{"denied host", NewPolicy(WithDeny(mustRules(t, "*.internal.example.com"))), "https://db.internal.example.com/", "host db.internal.example.com is denied"},
+		{"deny wins", NewPolicy(WithAllow(mustRules(t, "203.0.113.0/24")), WithDeny(mustRules(t, "203.0.113.66"))), "http://203.0.113.66/", "is denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			err = tt.policy.CheckURL(u)
+			if tt.denied == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrDenied)
+			assert.ErrorContains(t, err, tt.denied)
+		})
+	}
+}
+
+func TestPolicy_Dial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+
+	// The name passes the URL check, but resolves to a private address when dialed
+	client := NewPolicy(WithAllow(mustRules(t, "localhost, 203.0.113.0/24"))).Client(time.Second)
+	_, err := client.Get("http://localhost" + port)
+	assert.ErrorIs(t, err, ErrDenied)
+	assert.ErrorContains(t, err, "is private")
+
+	client = NewPolicy(WithAllow(mustRules(t, "127.0.0.1"))).Client(time.Second)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Redirects are checked as well
+	_, err = client.Get(server.URL + "/redirect")
+	assert.ErrorIs(t, err, ErrDenied)
+	assert.ErrorContains(t, err, "169.254.169.254")
+}
+
+func TestQuota(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	quota := NewQuota(2, time.Minute)
+	quota.now = func() time.Time { return now }
+
+	assert.NoError(t, quota.Allow("rule-1"))
+	assert.NoError(t, quota.Allow("rule-1"))
+	err := quota.Allow("rule-1")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.EqualError(t, err, "request quota exceeded: at most 2 requests per 1m0s")
+	assert.NoError(t, quota.Allow("rule-2"))
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, quota.Allow("rule-1"))
+	assert.Len(t, quota.counts, 1)
+}
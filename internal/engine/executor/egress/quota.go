@@ -0,0 +1,71 @@
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a rule made all the requests its quota allows
+var ErrQuotaExceeded = errors.New("request quota exceeded")
+
+// Quota limits the requests each rule makes within a fixed window of time.
+// It is kept in memory, per instance of the engine, and is safe for concurrent use.
+type Quota struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	counts    map[string]*quotaWindow
+	lastSweep time.Time
+}
+
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+// NewQuota creates a quota of limit requests per window
+func NewQuota(limit int, window time.Duration) *Quota {
+	return &Quota{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		counts: make(map[string]*quotaWindow),
+	}
+}
+
+// Allow counts a request of the rule key, or returns ErrQuotaExceeded when
+// the rule already made all its requests of the current window
+func (q *Quota) Allow(key string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.sweep(now)
+	w, ok := q.counts[key]
+	if !ok || now.Sub(w.start) >= q.window {
+		w = &quotaWindow{start: now}
+		q.counts[key] = w
+	}
+	if w.count >= q.limit {
+		return fmt.Errorf("%w: at most %d requests per %s", ErrQuotaExceeded, q.limit, q.window)
+	}
+	w.count++
+	return nil
+}
+
+// sweep forgets the windows that ended, at most once per window
+func (q *Quota) sweep(now time.Time) {
+	if now.Sub(q.lastSweep) < q.window {
+		return
+	}
+	q.lastSweep = now
+	for key, w := range q.counts {
+		if now.Sub(w.start) >= q.window {
+			delete(q.counts, key)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package expr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// ctxCheckInterval is the number of steps between checks of the evaluation context
+const ctxCheckInterval = 256
+
+type evaluator struct {
+	ctx      context.Context
+	env      map[string]any
+	steps    int64
+	maxSteps int64
+}
+
+// step counts n units of work against the budget
+func (e *evaluator) step(n int64) error {
+	before := e.steps
+	e.steps += n
+	if e.maxSteps > 0 && e.steps > e.maxSteps {
+		return ErrStepLimit
+	}
+	if before/ctxCheckInterval != e.steps/ctxCheckInterval {
+		return e.ctx.Err()
+	}
+	return nil
+}
+
+func (e *evaluator) eval(n node) (any, error) {
+	if err := e.step(1); err != nil {
+		return nil, err
+	}
+
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		value, ok := e.env[n.name]
+		if !ok {
+			return nil, n.pos.errorf("unknown variable '%s'", n.name)
+		}
+		return normalize(value), nil
+
+	case *memberNode:
+		target, err := e.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := target.(map[string]any)
+		if !ok {
+			return nil, n.pos.errorf("cannot read field '%s' of %s", n.name, typeName(target))
+		}
+		return normalize(m[n.name]), nil
+
+	case *indexNode:
+		return e.evalIndex(n)
+
+	case *callNode:
+		args := make([]any, len(n.args))
+		for i, arg := range n.args {
+			value, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		if err := e.step(n.fn.cost(args)); err != nil {
+			return nil, err
+		}
+		value, err := n.fn.call(args)
+		if err != nil {
+			return nil, n.pos.errorf("%s: %s", n.name, err)
+		}
+		return value, nil
+
+	case *unaryNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !Truthy(operand), nil
+		}
+		f, ok := operand.(float64)
+		if !ok {
+			return nil, n.pos.errorf("cannot negate %s", typeName(operand))
+		}
+		return -f, nil
+
+	case *binaryNode:
+		return e.evalBinary(n)
+
+	case *conditionalNode:
+		cond, err := e.eval(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		if Truthy(cond) {
+			return e.eval(n.then)
+		}
+		return e.eval(n.otherwise)
+
+	case *listNode:
+		items := make([]any, len(n.items))
+		for i, item := range n.items {
+			value, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+	}
+	return nil, n.position().errorf("unsupported expression")
+}
+
+// evalIndex reads a list element by its 0-based position or a map field by its name.
+// Positions out of range and missing fields yield null.
+func (e *evaluator) evalIndex(n *indexNode) (any, error) {
+	target, err := e.eval(n.target)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.eval(n.index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target := target.(type) {
+	case []any:
+		f, ok := index.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, n.pos.errorf("list index must be an integer, got %s", typeName(index))
+		}
+		if f < 0 || f >= float64(len(target)) {
+			return nil, nil
+		}
+		return normalize(target[int(f)]), nil
+	case map[string]any:
+		key, ok := index.(string)
+		if !ok {
+			return nil, n.pos.errorf("map key must be a string, got %s", typeName(index))
+		}
+		return normalize(target[key]), nil
+	}
+	return nil, n.pos.errorf("cannot index %s", typeName(target))
+}
+
+func (e *evaluator) evalBinary(n *binaryNode) (any, error) {
+	left, err := e.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	// The logical operators only evaluate their right operand when needed
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	}
+
+	right, err := e.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return e.evalIn(n, left, right)
+	case "<", "<=", ">", ">=":
+		return compare(n, left, right)
+	case "+":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, n.pos.errorf("cannot apply '%s' to %s and %s", n.op, typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, n.pos.errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, n.pos.errorf("division by zero")
+		}
+		// Floored like Lua, so the result has the sign of the divisor
+		return l - math.Floor(l/r)*r, nil
+	}
+	return nil, n.pos.errorf("unsupported operator '%s'", n.op)
+}
+
+// evalIn tests whether right, a list, map or string, contains left
+func (e *evaluator) evalIn(n *binaryNode, left, right any) (any, error) {
+	switch right := right.(type) {
+	case []any:
+		if err := e.step(int64(len(right))); err != nil {
+			return nil, err
+		}
+		for _, item := range right {
+			if equal(left, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]any:
+		key, ok := left.(string)
+		if !ok {
+			return nil, n.pos.errorf("map key must be a string, got %s", typeName(left))
+		}
+		_, found := right[key]
+		return found, nil
+	case string:
+		sub, ok := left.(string)
+		if !ok {
+			return nil, n.pos.errorf("cannot search %s in a string", typeName(left))
+		}
+		if err := e.step(int64(len(right) / ctxCheckInterval)); err != nil {
+			return nil, err
+		}
+		return strings.Contains(right, sub), nil
+	}
+	return nil, n.pos.errorf("cannot search in %s", typeName(right))
+}
+
+// compare orders two numbers or two strings
+func compare(n *binaryNode, left, right any) (any, error) {
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, n.pos.errorf("cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		switch {
+		case l < r:
+			c = -1
+		case l > r:
+			c = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, n.pos.errorf("cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		c = strings.Compare(l, r)
+	default:
+		return nil, n.pos.errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// equal compares two values. Numbers of any Go type compare by value and
+// lists and maps compare element by element.
+func equal(a, b any) bool {
+	a, b = normalize(a), normalize(b)
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// normalize converts Go numbers to float64, like the values scripts see
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, bool, float64, string, []any, map[string]any:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return items
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	}
+	return fmt.Sprint(v)
+}
+
+// typeName names the type of a value in error messages
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
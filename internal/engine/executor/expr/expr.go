@@ -0,0 +1,74 @@
+// Package expr implements a small expression language for trigger conditions.
+// An expression computes one value from the variables it is given, such as
+//
+//	event.temperature > 25 && event.room in ["kitchen", "hall"]
+//
+// The language has literals (numbers, strings, true, false, null and lists),
+// variable, field and index access, arithmetic, comparison and logical operators,
+// a conditional operator and a fixed set of built-in functions. It has no
+// assignment, loops or user-defined functions, so every expression terminates
+// after a number of steps bounded by its size and the size of its inputs.
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStepLimit is returned when an evaluation exceeds its step budget
+var ErrStepLimit = errors.New("expression step limit reached")
+
+// Error is a syntax or evaluation error located in the source of an expression
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program struct {
+	root node
+}
+
+// Compile parses source into a program.
+// Unknown functions and calls with the wrong number of arguments are reported here.
+func Compile(source string) (*Program, error) {
+	p, err := newParser(source)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Program{root: root}, nil
+}
+
+// Eval computes the value of the program for the variables of env.
+// Numbers are returned as float64, lists as []any and maps as map[string]any.
+// Evaluation stops with ErrStepLimit once it takes more than maxSteps steps
+// (0 means unbounded) and with the error of ctx once ctx is done.
+func (p *Program) Eval(ctx context.Context, env map[string]any, maxSteps int64) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e := &evaluator{ctx: ctx, env: env, maxSteps: maxSteps}
+	return e.eval(p.root)
+}
+
+// Truthy reports whether v counts as true in a condition: every value but null and false
+func Truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
@@ -0,0 +1,177 @@
+package expr
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eval(t *testing.T, source string, env map[string]any) (any, error) {
+	t.Helper()
+	program, err := Compile(source)
+	require.NoError(t, err)
+	return program.Eval(context.Background(), env, 0)
+}
+
+func TestEval(t *testing.T) {
+	env := map[string]any{
+		"event": map[string]any{
+			"temperature": 28.5,
+			"room":        "kitchen",
+			"tags":        []any{"indoor", "ground"},
+			"count":       3, // Go numbers of any type compare as numbers
+			"sensor":      map[string]any{"battery": 15.0},
+			"note":        nil,
+		},
+		"threshold": 25,
+	}
+
+	tests := []struct {
+		source   string
+		expected any
+	}{
+		{`event.temperature > 25`, true},
+		{`event.temperature > threshold && event.room == "kitchen"`, true},
+		{`event.room in ["kitchen", "hall"]`, true},
+		{`"outdoor" in event.tags`, false},
+		{`"sensor" in event`, true},
+		{`"itch" in event.room`, true},
+		{`event.count == 3 and not (event.count > 3)`, true},
+		{`event.sensor.battery < 20 || false`, true},
+		{`event["room"] + "/" + event.tags[1]`, "kitchen/ground"},
+		{`event.tags[5]`, nil},
+		{`event.missing == null`, true},
+		{`event.note == nil`, true},
+		{`1 + 2 * 3 - 4 / 2`, 5.0},
+		{`-7 % 3`, 2.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`1.5e1`, 15.0},
+		{`event.temperature > 30 ? "hot" : event.temperature > 20 ? "warm" : "cold"`, "warm"},
+		{`[1, 2] == [1, 2]`, true},
+		{`"a" < "b"`, true},
+		{`!event.note`, true},
+		{`len(event.tags) == 2 && len("héllo") == 5`, true},
+		{`has(event, "note") && !has(event, "missing")`, true},
+		{`contains(event.tags, "indoor") && contains(event.room, "kit")`, true},
+		{`starts_with(event.room, "kit") && ends_with(event.room, "chen")`, true},
+		{`matches(event.room, "^k.*n$")`, true},
+		{`upper(trim("  a ")) + lower("B")`, "Ab"},
+		{`abs(-2) + floor(1.7) + ceil(1.2) + round(2.5)`, 8.0},
+		{`min(3, 1, 2) + max(3, 1, 2)`, 4.0},
+		{`number("42") + 1`, 43.0},
+		{`string(event.temperature) + string(true)`, "28.5true"},
+		{`'it\'s' + "\t"`, "it's\t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			value, err := eval(t, tt.source, env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestEval_ShortCircuit(t *testing.T) {
+	// The right operand would fail if it were evaluated
+	value, err := eval(t, `false && missing.field`, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, false, value)
+
+	value, err = eval(t, `true || 1 / 0`, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+}
+
+func TestEval_Errors(t *testing.T) {
+	env := map[string]any{"event": map[string]any{"temperature": 28.5, "note": nil}}
+
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{`unknown > 1`, "line 1, column 1: unknown variable 'unknown'"},
+		{`event.note.text`, "line 1, column 12: cannot read field 'text' of null"},
+		{`event.temperature > "25"`, "line 1, column 19: cannot compare number and string"},
+		{`event.temperature + "C"`, "cannot apply '+' to number and string"},
+		{`1 / 0`, "division by zero"},
+		{`-"a"`, "cannot negate string"},
+		{`len(1)`, "len: expected a string, list or map, got number"},
+		{`matches("a", "(")`, "matches: invalid pattern"},
+		{`[1][0.5]`, "list index must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := eval(t, tt.source, env)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+			var exprErr *Error
+			assert.ErrorAs(t, err, &exprErr)
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{``, "line 1, column 1: empty expression"},
+		{`event.temperature = 25`, "line 1, column 19: unexpected '=', use '==' to compare values"},
+		{`a ~= b`, "use '!=' to compare values"},
+		{`a >`, "line 1, column 4: unexpected end of expression"},
+		{`a b`, "line 1, column 3: unexpected 'b'"},
+		{`(a`, "expected ')' but found end of expression"},
+		{`[1, 2`, "expected ',' or ']' but found end of expression"},
+		{`a."b"`, "expected a field name after '.'"},
+		{`"open`, "unterminated string"},
+		{`"\q"`, "invalid escape sequence"},
+		{`a # b`, "unexpected character '#'"},
+		{`exec("rm")`, "unknown function 'exec'"},
+		{`len()`, "len expects 1 argument, got 0"},
+		{`has(a)`, "has expects 2 arguments, got 1"},
+		{"a &&\n  b ||\n  c ?", "line 3, column 6: unexpected end of expression"},
+		{strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1), "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := Compile(tt.source)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestEval_Budget(t *testing.T) {
+	list := make([]any, 1000)
+	for i := range list {
+		list[i] = float64(i)
+	}
+	program, err := Compile(`-1 in items`)
+	require.NoError(t, err)
+
+	_, err = program.Eval(context.Background(), map[string]any{"items": list}, 100)
+	assert.ErrorIs(t, err, ErrStepLimit)
+
+	value, err := program.Eval(context.Background(), map[string]any{"items": list}, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, false, value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = program.Eval(ctx, map[string]any{"items": list}, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTruthy(t *testing.T) {
+	assert.False(t, Truthy(nil))
+	assert.False(t, Truthy(false))
+	assert.True(t, Truthy(true))
+	assert.True(t, Truthy(0.0))
+	assert.True(t, Truthy(""))
+}
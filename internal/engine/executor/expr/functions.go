@@ -0,0 +1,230 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// function is a built-in function. maxArgs is -1 for variadic functions.
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(args []any) (any, error)
+	// linear marks functions whose work grows with the size of their arguments
+	linear bool
+}
+
+// arity describes the number of arguments fn accepts in error messages
+func (fn *function) arity() string {
+	switch {
+	case fn.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", fn.minArgs)
+	case fn.minArgs == fn.maxArgs && fn.minArgs == 1:
+		return "1 argument"
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("%d arguments", fn.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", fn.minArgs, fn.maxArgs)
+	}
+}
+
+// cost is the number of steps a call with args counts against the budget
+func (fn *function) cost(args []any) int64 {
+	if !fn.linear {
+		return 1
+	}
+	var n int64 = 1
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			n += int64(len(arg) / ctxCheckInterval)
+		case []any:
+			n += int64(len(arg))
+		}
+	}
+	return n
+}
+
+// Functions lists the names of the built-in functions
+func Functions() []string {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// maxPatternLength bounds the regular expressions accepted by matches
+const maxPatternLength = 1024
+
+// functions are the built-in functions by name
+var functions = map[string]*function{
+	"len":         {minArgs: 1, maxArgs: 1, call: length},
+	"has":         {minArgs: 2, maxArgs: 2, call: has},
+	"contains":    {minArgs: 2, maxArgs: 2, call: contains, linear: true},
+	"starts_with": {minArgs: 2, maxArgs: 2, call: stringPredicate(strings.HasPrefix)},
+	"ends_with":   {minArgs: 2, maxArgs: 2, call: stringPredicate(strings.HasSuffix)},
+	"matches":     {minArgs: 2, maxArgs: 2, call: matches, linear: true},
+	"lower":       {minArgs: 1, maxArgs: 1, call: stringFunction(strings.ToLower), linear: true},
+	"upper":       {minArgs: 1, maxArgs: 1, call: stringFunction(strings.ToUpper), linear: true},
+	"trim":        {minArgs: 1, maxArgs: 1, call: stringFunction(strings.TrimSpace), linear: true},
+	"abs":         {minArgs: 1, maxArgs: 1, call: numberFunction(math.Abs)},
+	"floor":       {minArgs: 1, maxArgs: 1, call: numberFunction(math.Floor)},
+	"ceil":        {minArgs: 1, maxArgs: 1, call: numberFunction(math.Ceil)},
+	"round":       {minArgs: 1, maxArgs: 1, call: numberFunction(math.Round)},
+	"min":         {minArgs: 1, maxArgs: -1, call: extremum(func(a, b float64) bool { return a < b })},
+	"max":         {minArgs: 1, maxArgs: -1, call: extremum(func(a, b float64) bool { return a > b })},
+	"number":      {minArgs: 1, maxArgs: 1, call: toNumber},
+	"string":      {minArgs: 1, maxArgs: 1, call: toString},
+}
+
+// length returns the number of characters of a string or elements of a list or map
+func length(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []any:
+		return float64(len(v)), nil
+	case map[string]any:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("expected a string, list or map, got %s", typeName(args[0]))
+}
+
+// has reports whether a map has a field, which may be null
+func has(args []any) (any, error) {
+	m, ok := args[0].(map[string]any)
+	if !ok {
+		if args[0] == nil {
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected a map, got %s", typeName(args[0]))
+	}
+	key, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string key, got %s", typeName(args[1]))
+	}
+	_, found := m[key]
+	return found, nil
+}
+
+// contains reports whether a string contains a substring or a list an element
+func contains(args []any) (any, error) {
+	switch haystack := args[0].(type) {
+	case string:
+		needle, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot search %s in a string", typeName(args[1]))
+		}
+		return strings.Contains(haystack, needle), nil
+	case []any:
+		for _, item := range haystack {
+			if equal(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("expected a string or list, got %s", typeName(args[0]))
+}
+
+func matches(args []any) (any, error) {
+	s, ok1 := args[0].(string)
+	pattern, ok2 := args[1].(string)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("expected two strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+	}
+	if len(pattern) > maxPatternLength {
+		return nil, fmt.Errorf("pattern longer than %d bytes", maxPatternLength)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.MatchString(s), nil
+}
+
+func stringPredicate(fn func(s, arg string) bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok1 := args[0].(string)
+		arg, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("expected two strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+		}
+		return fn(s, arg), nil
+	}
+}
+
+func stringFunction(fn func(s string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", typeName(args[0]))
+		}
+		return fn(s), nil
+	}
+}
+
+func numberFunction(fn func(f float64) float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		f, ok := args[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %s", typeName(args[0]))
+		}
+		return fn(f), nil
+	}
+}
+
+// extremum returns the argument that is better than all the others
+func extremum(better func(a, b float64) bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		var result float64
+		for i, arg := range args {
+			f, ok := arg.(float64)
+			if !ok {
+				return nil, fmt.Errorf("expected numbers, got %s", typeName(arg))
+			}
+			if i == 0 || better(f, result) {
+				result = f
+			}
+		}
+		return result, nil
+	}
+}
+
+// toNumber converts a number or a numeric string to a number
+func toNumber(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, errors.New("string is not a number")
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a number", typeName(args[0]))
+}
+
+// toString converts a scalar to its string representation
+func toString(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "null", nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a string", typeName(args[0]))
+}
@@ -0,0 +1,194 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+// position locates a token in the source, both 1-based
+type position struct {
+	line   int
+	column int
+}
+
+func (p position) errorf(format string, args ...any) *Error {
+	return &Error{Line: p.line, Column: p.column, Message: fmt.Sprintf(format, args...)}
+}
+
+type token struct {
+	kind  tokenKind
+	text  string // the operator, identifier or source of the token
+	value any    // the value of number and string literals
+	pos   position
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// operators lists the operator tokens, longest first
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"(", ")", "[", "]", ",", ".", "?", ":",
+	"!", "<", ">", "+", "-", "*", "/", "%",
+}
+
+// lex splits source into tokens, ending with a tokenEOF
+func lex(source string) ([]token, error) {
+	var tokens []token
+	line, column := 1, 1
+	advance := func(s string) {
+		for _, r := range s {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+	}
+
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		pos := position{line: line, column: column}
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(source[i : i+size])
+			i += size
+			continue
+
+		case r >= '0' && r <= '9', r == '.' && i+1 < len(source) && source[i+1] >= '0' && source[i+1] <= '9':
+			end := scanNumber(source, i)
+			text := source[i:end]
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, pos.errorf("malformed number '%s'", text)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, pos: pos})
+			advance(text)
+			i = end
+			continue
+
+		case r == '"' || r == '\'':
+			value, end, err := scanString(source, i, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[i:end], value: value, pos: pos})
+			advance(source[i:end])
+			i = end
+			continue
+
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(source) {
+				r, size := utf8.DecodeRuneInString(source[end:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[i:end], pos: pos})
+			advance(source[i:end])
+			i = end
+			continue
+		}
+
+		matched := ""
+		for _, op := range operators {
+			if strings.HasPrefix(source[i:], op) {
+				matched = op
+				break
+			}
+		}
+		switch {
+		case matched != "":
+			tokens = append(tokens, token{kind: tokenOperator, text: matched, pos: pos})
+			advance(matched)
+			i += len(matched)
+		case r == '=':
+			return nil, pos.errorf("unexpected '=', use '==' to compare values")
+		case r == '~' && strings.HasPrefix(source[i:], "~="):
+			return nil, pos.errorf("unexpected '~=', use '!=' to compare values")
+		default:
+			return nil, pos.errorf("unexpected character '%c'", r)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: position{line: line, column: column}}), nil
+}
+
+// scanNumber returns the end of the number literal starting at start
+func scanNumber(source string, start int) int {
+	end := start
+	digits := func() {
+		for end < len(source) && source[end] >= '0' && source[end] <= '9' {
+			end++
+		}
+	}
+	digits()
+	if end < len(source) && source[end] == '.' {
+		end++
+		digits()
+	}
+	if end < len(source) && (source[end] == 'e' || source[end] == 'E') {
+		exp := end + 1
+		if exp < len(source) && (source[exp] == '+' || source[exp] == '-') {
+			exp++
+		}
+		if exp < len(source) && source[exp] >= '0' && source[exp] <= '9' {
+			end = exp
+			digits()
+		}
+	}
+	return end
+}
+
+// scanString decodes the string literal starting at start and returns its end
+func scanString(source string, start int, pos position) (string, int, error) {
+	quote := source[start]
+	var b strings.Builder
+	for i := start + 1; i < len(source); {
+		c := source[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, pos.errorf("unterminated string")
+		case c == '\\' && i+1 < len(source):
+			switch esc := source[i+1]; esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'':
+				b.WriteByte(esc)
+			default:
+				return "", 0, pos.errorf("invalid escape sequence '\\%c' in string", esc)
+			}
+			i += 2
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, pos.errorf("unterminated string")
+}
@@ -0,0 +1,369 @@
+package expr
+
+// maxDepth bounds the nesting of an expression, which bounds the recursion of
+// the parser and of the evaluator
+const maxDepth = 100
+
+type node interface {
+	position() position
+}
+
+type literalNode struct {
+	pos   position
+	value any
+}
+
+type identNode struct {
+	pos  position
+	name string
+}
+
+type memberNode struct {
+	pos    position
+	target node
+	name   string
+}
+
+type indexNode struct {
+	pos    position
+	target node
+	index  node
+}
+
+type callNode struct {
+	pos  position
+	name string
+	fn   *function
+	args []node
+}
+
+type unaryNode struct {
+	pos     position
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos         position
+	op          string
+	left, right node
+}
+
+type conditionalNode struct {
+	pos                   position
+	cond, then, otherwise node
+}
+
+type listNode struct {
+	pos   position
+	items []node
+}
+
+func (n *literalNode) position() position     { return n.pos }
+func (n *identNode) position() position       { return n.pos }
+func (n *memberNode) position() position      { return n.pos }
+func (n *indexNode) position() position       { return n.pos }
+func (n *callNode) position() position        { return n.pos }
+func (n *unaryNode) position() position       { return n.pos }
+func (n *binaryNode) position() position      { return n.pos }
+func (n *conditionalNode) position() position { return n.pos }
+func (n *listNode) position() position        { return n.pos }
+
+// binaryPrecedence gives the precedence of the binary operators, higher binds tighter.
+// The keywords and, or and in are operators too.
+var binaryPrecedence = map[string]int{
+	"||": 1, "or": 1,
+	"&&": 2, "and": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// keywordOperators maps the keyword spelling of operators to their symbol
+var keywordOperators = map[string]string{"and": "&&", "or": "||", "not": "!"}
+
+// parser is a precedence climbing parser over the tokens of an expression
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func newParser(source string) (*parser, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) parse() (node, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, p.peek().pos.errorf("empty expression")
+	}
+	root, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, t.pos.errorf("unexpected %s", t)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+// isOperator reports whether t is the operator op
+func isOperator(t token, op string) bool {
+	return t.kind == tokenOperator && t.text == op
+}
+
+func (p *parser) expect(op string) (token, error) {
+	t := p.advance()
+	if !isOperator(t, op) {
+		return t, t.pos.errorf("expected '%s' but found %s", op, t)
+	}
+	return t, nil
+}
+
+// enter guards the recursion of the parser
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.peek().pos.errorf("expression is nested too deeply")
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+// parseConditional parses cond ? then : otherwise, which is right associative
+func (p *parser) parseConditional() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	cond, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if !isOperator(p.peek(), "?") {
+		return cond, nil
+	}
+	t := p.advance()
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalNode{pos: t.pos, cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// binaryOperator returns the binary operator t stands for, if any
+func binaryOperator(t token) (string, int, bool) {
+	if t.kind != tokenOperator && t.kind != tokenIdent {
+		return "", 0, false
+	}
+	precedence, ok := binaryPrecedence[t.text]
+	if !ok {
+		return "", 0, false
+	}
+	op := t.text
+	if symbol, ok := keywordOperators[op]; ok {
+		op = symbol
+	}
+	return op, precedence, true
+}
+
+// parseBinary parses the binary operators binding at least as tight as minPrecedence.
+// They are all left associative.
+func (p *parser) parseBinary(minPrecedence int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, precedence, ok := binaryOperator(p.peek())
+		if !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		t := p.advance()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseBinary(precedence + 1)
+		p.leave()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: t.pos, op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	op := ""
+	switch {
+	case isOperator(t, "!"), isOperator(t, "-"):
+		op = t.text
+	case t.kind == tokenIdent && t.text == "not":
+		op = "!"
+	default:
+		return p.parsePostfix()
+	}
+	p.advance()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &unaryNode{pos: t.pos, op: op, operand: operand}, nil
+}
+
+// parsePostfix parses a primary expression followed by field accesses and indexes
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case isOperator(t, "."):
+			p.advance()
+			name := p.advance()
+			if name.kind != tokenIdent {
+				return nil, name.pos.errorf("expected a field name after '.' but found %s", name)
+			}
+			n = &memberNode{pos: name.pos, target: n, name: name.text}
+		case isOperator(t, "["):
+			p.advance()
+			index, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{pos: t.pos, target: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{pos: t.pos, value: t.value}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{pos: t.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: t.pos, value: false}, nil
+		case "null", "nil":
+			return &literalNode{pos: t.pos, value: nil}, nil
+		case "and", "or", "in":
+			return nil, t.pos.errorf("unexpected %s", t)
+		}
+		if isOperator(p.peek(), "(") {
+			return p.parseCall(t)
+		}
+		return &identNode{pos: t.pos, name: t.text}, nil
+
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			n, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{pos: t.pos, items: items}, nil
+		}
+	}
+	return nil, t.pos.errorf("unexpected %s", t)
+}
+
+// parseCall parses the arguments of a call to the built-in function named by name
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, name.pos.errorf("unknown function '%s'", name.text)
+	}
+	p.advance() // (
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, name.pos.errorf("%s expects %s, got %d", name.text, fn.arity(), len(args))
+	}
+	return &callNode{pos: name.pos, name: name.text, fn: fn, args: args}, nil
+}
+
+// parseList parses comma separated expressions up to the closing operator
+func (p *parser) parseList(closing string) ([]node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	var items []node
+	if isOperator(p.peek(), closing) {
+		p.advance()
+		return items, nil
+	}
+	for {
+		item, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		t := p.advance()
+		switch {
+		case isOperator(t, closing):
+			return items, nil
+		case !isOperator(t, ","):
+			return nil, t.pos.errorf("expected ',' or '%s' but found %s", closing, t)
+		}
+	}
+}
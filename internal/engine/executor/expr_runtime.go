@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"errors"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/expr"
+)
+
+// exprRuntime evaluates the expressions of the expr package. Expressions cannot
+// loop or call the platform modules, which makes them cheap trigger conditions.
+// Every evaluation step counts as an instruction against the budget.
+type exprRuntime struct {
+	legacyGlobals bool
+	programs      *compiledCache[*expr.Program] // nil when caching is disabled
+}
+
+// newExprRuntime creates the expression runtime. A cacheSize of 0 disables the
+// program cache.
+func newExprRuntime(cacheSize int, legacyGlobals bool) *exprRuntime {
+	r := &exprRuntime{legacyGlobals: legacyGlobals}
+	if cacheSize > 0 {
+		r.programs = newCompiledCache(cacheSize, expr.Compile)
+	}
+	return r
+}
+
+// Language implements Runtime
+func (r *exprRuntime) Language() string {
+	return LanguageExpr
+}
+
+// Run implements Runtime. The value of the expression is the only output.
+func (r *exprRuntime) Run(ctx context.Context, script string, ec *execCtx.ExecutionContext, limits execCtx.Limits) ([]any, error) {
+	program, err := r.compile(script)
+	if err != nil {
+		return nil, err
+	}
+	value, err := program.Eval(ctx, r.environment(ec), limits.MaxInstructions)
+	if errors.Is(err, expr.ErrStepLimit) {
+		return nil, &BudgetExceededError{Resource: ResourceInstructions, Limit: limits.MaxInstructions}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []any{value}, nil
+}
+
+// compile returns the program of script, from the cache when enabled
+func (r *exprRuntime) compile(script string) (*expr.Program, error) {
+	if r.programs == nil {
+		return expr.Compile(script)
+	}
+	program, _, err := r.programs.get(script)
+	return program, err
+}
+
+// invalidateScript drops the compiled form of script from the cache
+func (r *exprRuntime) invalidateScript(script string) {
+	if r.programs != nil {
+		r.programs.invalidate(script)
+	}
+}
+
+// environment returns the variables of an expression: the ctx map mirrors the
+// ctx table of Lua scripts and legacy globals are exposed the same way
+func (r *exprRuntime) environment(ec *execCtx.ExecutionContext) map[string]any {
+	env := map[string]any{"ctx": ec.Fields()}
+	if r.legacyGlobals {
+		env["rule_id"] = ec.RuleID
+		env["trigger_id"] = ec.TriggerID
+		for key, value := range ec.Data {
+			if _, ok := env[key]; !ok {
+				env[key] = value
+			}
+		}
+	}
+	return env
+}
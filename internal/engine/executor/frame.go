@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"go/token"
+	"reflect"
+	"unsafe"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// gopher-lua keeps the call frames and registers of a state unexported, and
+// L.GetLocal cannot read a local during the first instruction of its scope.
+// The mirrors below give the hooks and the budget checks read-only access to
+// them. They are compared field by field with the layout reflect reports for
+// the library in use, and every read is refused when they differ, so that an
+// upgrade of gopher-lua degrades to missing values rather than wrong ones.
+
+// callFrameHeader mirrors the leading fields of gopher-lua's callFrame,
+// which lua.Debug references as its first field
+type callFrameHeader struct {
+	Idx       int
+	Fn        *lua.LFunction
+	Parent    unsafe.Pointer
+	Pc        int
+	Base      int
+	LocalBase int
+}
+
+// stateHeader mirrors the leading fields of lua.LState
+type stateHeader struct {
+	G       *lua.Global
+	Parent  *lua.LState
+	Env     *lua.LTable
+	Panic   func(*lua.LState)
+	Dead    bool
+	Options lua.Options
+
+	stop         int32
+	reg          *registryHeader
+	stack        interface{}
+	alloc        unsafe.Pointer
+	currentFrame *callFrameHeader
+}
+
+// registryHeader mirrors the leading fields of gopher-lua's registry
+type registryHeader struct {
+	array []lua.LValue
+	top   int
+}
+
+// mirrorsMatch reports whether the mirrors match the layout of gopher-lua
+var mirrorsMatch = checkMirrors()
+
+// checkMirrors compares the mirrors with the private layout of gopher-lua
+func checkMirrors() bool {
+	state := reflect.TypeOf(lua.LState{})
+	reg, ok := state.FieldByName("reg")
+	if !ok || reg.Type.Kind() != reflect.Pointer {
+		return false
+	}
+	frame, ok := reflect.TypeOf(lua.Debug{}).FieldByName("frame")
+	if !ok || frame.Offset != 0 || frame.Type.Kind() != reflect.Pointer {
+		return false
+	}
+	return sameLayout(reflect.TypeOf(stateHeader{}), state) &&
+		sameLayout(reflect.TypeOf(registryHeader{}), reg.Type.Elem()) &&
+		sameLayout(reflect.TypeOf(callFrameHeader{}), frame.Type.Elem())
+}
+
+// sameLayout reports whether the fields of mirror have the names, types,
+// offsets and sizes of the leading fields of original. Pointers to private
+// types are mirrored by unsafe.Pointer or by pointers to mirrors, and
+// interfaces by any interface.
+func sameLayout(mirror, original reflect.Type) bool {
+	if original.Kind() != reflect.Struct || mirror.NumField() > original.NumField() {
+		return false
+	}
+	for i := range mirror.NumField() {
+		m, o := mirror.Field(i), original.Field(i)
+		if m.Name != o.Name || m.Offset != o.Offset || m.Type.Size() != o.Type.Size() || !sameType(m.Type, o.Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameType reports whether a field of type mirror can stand for one of type original
+func sameType(mirror, original reflect.Type) bool {
+	switch {
+	case mirror == original:
+		return true
+	case mirror.Kind() == reflect.Interface:
+		return original.Kind() == reflect.Interface
+	case original.Kind() != reflect.Pointer || original.Elem().PkgPath() == "" || token.IsExported(original.Elem().Name()):
+		return false
+	}
+	return mirror.Kind() == reflect.UnsafePointer || mirror.Kind() == reflect.Pointer
+}
+
+// mirrorState returns the mirror of L, checked against its exported fields
+func mirrorState(L *lua.LState) (*stateHeader, bool) {
+	if !mirrorsMatch {
+		return nil, false
+	}
+	state := (*stateHeader)(unsafe.Pointer(L))
+	if state.G != L.G || state.Env != L.Env || state.Options != L.Options || state.reg == nil {
+		return nil, false
+	}
+	return state, true
+}
+
+// currentFrame returns the frame L is running. Unlike L.GetStack it does not
+// allocate, which matters to callers that run before every instruction; they
+// check it once against debugFrame.
+func currentFrame(L *lua.LState) (*stateHeader, *callFrameHeader, bool) {
+	state, ok := mirrorState(L)
+	if !ok || state.currentFrame == nil || state.currentFrame.Fn == nil {
+		return nil, nil, false
+	}
+	return state, state.currentFrame, true
+}
+
+// debugFrame returns the frame described by dbg
+func debugFrame(dbg *lua.Debug) *callFrameHeader {
+	if !mirrorsMatch {
+		return nil
+	}
+	return *(**callFrameHeader)(unsafe.Pointer(dbg))
+}
+
+// Instruction decoding of gopher-lua, whose helpers are unexported
+func opcode(inst uint32) int { return int(inst >> 26) }
+func opArgB(inst uint32) int { return int(inst & 0x1ff) }
+func opArgC(inst uint32) int { return int(inst>>9) & 0x1ff }
+
+func frameHeader(dbg *lua.Debug, fn *lua.LFunction) (*callFrameHeader, bool) {
+	frame := debugFrame(dbg)
+	if frame == nil || frame.Fn != fn {
+		return nil, false
+	}
+	return frame, true
+}
+
+// framePC returns the index of the instruction the frame described by dbg is about to run.
+// The frame must belong to fn, which guards against a change of the callFrame layout.
+func framePC(dbg *lua.Debug, fn *lua.LFunction) (int, bool) {
+	frame, ok := frameHeader(dbg, fn)
+	if !ok || frame.Pc <= 0 {
+		return 0, false
+	}
+	return frame.Pc - 1, true
+}
+
+// frameRegister returns register no (1-based) of the frame described by dbg
+func frameRegister(L *lua.LState, dbg *lua.Debug, no int) (lua.LValue, bool) {
+	fn, ok := debugFunction(L, dbg)
+	if !ok {
+		return nil, false
+	}
+	frame, ok := frameHeader(dbg, fn)
+	if !ok || no < 1 || no > int(fn.Proto.NumUsedRegisters) {
+		return nil, false
+	}
+
+	state, ok := mirrorState(L)
+	if !ok {
+		return nil, false
+	}
+	idx := frame.LocalBase + no - 1
+	if idx < 0 || idx >= len(state.reg.array) {
+		return nil, false
+	}
+	value := state.reg.array[idx]
+	if value == nil {
+		return lua.LNil, true
+	}
+	return value, true
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorsMatchGopherLua(t *testing.T) {
+	// A failure means gopher-lua changed its private layout: update the
+	// mirrors of frame.go before upgrading it
+	assert.True(t, checkMirrors(), "the mirrors of frame.go do not match gopher-lua")
+	assert.True(t, mirrorsMatch)
+}
+
+// privateFrame stands for a private type of gopher-lua
+type privateFrame struct{ pc int }
+
+func TestSameLayout(t *testing.T) {
+	type original struct {
+		Fn    *int
+		frame *privateFrame
+		Pc    int
+		extra string
+	}
+	tests := []struct {
+		name   string
+		mirror any
+		want   bool
+	}{
+		{"leading fields", struct {
+			Fn    *int
+			frame unsafe.Pointer
+		}{}, true},
+		{"renamed field", struct {
+			Fn     *int
+			parent unsafe.Pointer
+		}{}, false},
+		{"missing field", struct {
+			Fn *int
+			Pc int
+		}{}, false},
+		{"mirrored pointer", struct {
+			Fn    *int
+			frame *struct{ pc int }
+		}{}, true},
+		{"other type", struct {
+			Fn    *string
+			frame unsafe.Pointer
+			Pc    int
+		}{}, false},
+		{"other size", struct {
+			Fn    *int
+			frame unsafe.Pointer
+			Pc    int32
+		}{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sameLayout(reflect.TypeOf(tt.mirror), reflect.TypeOf(original{})))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ScriptSource is the source name the executed script carries in debug information
+const ScriptSource = chunkName
+
+// Hook observes a script while it runs.
+// Line is called on the goroutine running the script each time the VM moves to
+// another source line or function, or jumps back within a line as a loop
+// written on one line does, before the first instruction it runs there.
+// dbg describes the running function with its Source and CurrentLine filled in
+// and can be passed to L.GetLocal. The script is paused until Line returns,
+// and the time it spends paused does not count towards the execution timeout.
+//
+// Line must not use L.Context(): the hook is called from inside it.
+type Hook interface {
+	Line(L *lua.LState, dbg *lua.Debug)
+}
+
+type hooksKey struct{}
+
+// WithHook returns a context that makes ExecuteScript report to hook,
+// in addition to the hooks already carried by ctx
+func WithHook(ctx context.Context, hook Hook) context.Context {
+	hooks := append(slices.Clip(hooksFromContext(ctx)), hook)
+	return context.WithValue(ctx, hooksKey{}, hooks)
+}
+
+func hooksFromContext(ctx context.Context) []Hook {
+	hooks, _ := ctx.Value(hooksKey{}).([]Hook)
+	return hooks
+}
+
+// lineTracker calls the hooks of an execution when the running line changes
+type lineTracker struct {
+	hooks    []Hook
+	clock    *executionClock // nil outside of ExecuteScript
+	lastFn   lua.LValue
+	lastLine int
+	lastPc   int // -1 when the frame cannot be read
+}
+
+func (t *lineTracker) step(L *lua.LState) {
+	dbg, ok := L.GetStack(0)
+	if !ok {
+		return
+	}
+	fn, err := L.GetInfo("fSl", dbg, nil)
+	if err != nil || dbg.CurrentLine <= 0 {
+		return
+	}
+	// The VM polls before every instruction, with the program counter on the
+	// instruction about to run, so a jump backwards starts the line anew
+	pc := -1
+	if frame := debugFrame(dbg); frame != nil {
+		pc = frame.Pc
+	}
+	sameLine := fn == t.lastFn && dbg.CurrentLine == t.lastLine
+	if sameLine && (pc < 0 || pc > t.lastPc) {
+		t.lastPc = pc
+		return
+	}
+	t.lastFn, t.lastLine, t.lastPc = fn, dbg.CurrentLine, pc
+
+	t.clock.pause()
+	for _, hook := range t.hooks {
+		hook.Line(L, dbg)
+	}
+	t.clock.resume()
+}
+
+// Variable is a named value visible to a running script
+type Variable struct {
+	Name  string
+	Value lua.LValue
+}
+
+// Locals returns the local variables of the function described by dbg that are
+// in scope at the line reported to a hook, in declaration order.
+// Compiler temporaries are left out.
+func Locals(L *lua.LState, dbg *lua.Debug) []Variable {
+	fn, ok := debugFunction(L, dbg)
+	if !ok {
+		return nil
+	}
+
+	// L.GetLocal only names a local once the instruction that follows its
+	// activation has started, so at a line boundary the locals declared by the
+	// previous statement would be missing. Scopes are resolved against the
+	// instruction about to run instead, as the reference implementation does.
+	pc, ok := framePC(dbg, fn)
+	if !ok {
+		return namedLocals(L, dbg)
+	}
+
+	var locals []Variable
+	no := 0
+	for _, local := range fn.Proto.DbgLocals {
+		if local.StartPc > pc {
+			break
+		}
+		if pc >= local.EndPc {
+			continue
+		}
+		no++
+		if strings.HasPrefix(local.Name, "(") {
+			continue
+		}
+		locals = append(locals, Variable{Name: local.Name, Value: localValue(L, dbg, no)})
+	}
+	return locals
+}
+
+// namedLocals returns the locals that L.GetLocal can name
+func namedLocals(L *lua.LState, dbg *lua.Debug) []Variable {
+	var locals []Variable
+	for no := 1; ; no++ {
+		name, value := L.GetLocal(dbg, no)
+		if name == "" {
+			return locals
+		}
+		if !strings.HasPrefix(name, "(") {
+			locals = append(locals, Variable{Name: name, Value: value})
+		}
+	}
+}
+
+// localValue returns the value held by the register of local no
+func localValue(L *lua.LState, dbg *lua.Debug, no int) lua.LValue {
+	if name, value := L.GetLocal(dbg, no); name != "" {
+		return value
+	}
+	if value, ok := frameRegister(L, dbg, no); ok {
+		return value
+	}
+	return lua.LNil
+}
+
+// Upvalues returns the upvalues of the function described by dbg
+func Upvalues(L *lua.LState, dbg *lua.Debug) []Variable {
+	fn, ok := debugFunction(L, dbg)
+	if !ok {
+		return nil
+	}
+	upvalues := make([]Variable, 0, len(fn.Upvalues))
+	for no := 1; no <= len(fn.Upvalues); no++ {
+		name, value := L.GetUpvalue(fn, no)
+		upvalues = append(upvalues, Variable{Name: name, Value: value})
+	}
+	return upvalues
+}
+
+func debugFunction(L *lua.LState, dbg *lua.Debug) (*lua.LFunction, bool) {
+	value, err := L.GetInfo("f", dbg, nil)
+	if err != nil {
+		return nil, false
+	}
+	fn, ok := value.(*lua.LFunction)
+	return fn, ok && !fn.IsG
+}
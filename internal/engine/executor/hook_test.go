@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// lineRecorder records the lines of the script reported to it and the locals in scope there
+type lineRecorder struct {
+	lines  []int
+	locals map[int][]string
+}
+
+func (r *lineRecorder) Line(L *lua.LState, dbg *lua.Debug) {
+	if dbg.Source != ScriptSource {
+		return
+	}
+	r.lines = append(r.lines, dbg.CurrentLine)
+	for _, local := range Locals(L, dbg) {
+		if r.locals == nil {
+			r.locals = make(map[int][]string)
+		}
+		r.locals[dbg.CurrentLine] = append(r.locals[dbg.CurrentLine], local.Name+"="+local.Value.String())
+	}
+}
+
+func TestExecutorService_ExecuteScript_Hooks(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	first, second := &lineRecorder{}, &lineRecorder{}
+	ctx := WithHook(WithHook(context.Background(), first), second)
+
+	result := svc.ExecuteScript(ctx, "local function double(x)\n  return x * 2\nend\nlocal a = 1\nlocal b = double(a)\nreturn b", ctxSvc.CreateContext("rule", "trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{2.0}, result.Output)
+	assert.Equal(t, []int{1, 4, 5, 2, 6}, first.lines)
+	assert.Equal(t, first.lines, second.lines)
+	assert.Len(t, first.locals[4], 1)
+	assert.Contains(t, first.locals[4][0], "double=function")
+	assert.Equal(t, []string{"a=1"}, first.locals[5][1:])
+	assert.Equal(t, []string{"x=1"}, first.locals[2])
+	assert.Equal(t, []string{"a=1", "b=2"}, first.locals[6][1:])
+}
+
+func TestExecutorService_ExecuteScript_WithoutHooks(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	recorder := &lineRecorder{}
+	_ = WithHook(context.Background(), recorder)
+
+	result := svc.ExecuteScript(context.Background(), "return 1", ctxSvc.CreateContext("rule", "trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	assert.Empty(t, recorder.lines)
+}
+
+func TestExecutorService_ExecuteScript_HooksWithoutMirrors(t *testing.T) {
+	// A gopher-lua whose layout no longer matches the mirrors of frame.go
+	mirrorsMatch = false
+	t.Cleanup(func() { mirrorsMatch = checkMirrors() })
+
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+	recorder := &lineRecorder{}
+
+	result := svc.ExecuteScript(WithHook(context.Background(), recorder), "local function double(x)\n  return x * 2\nend\nlocal a = 1\nlocal b = double(a)\nreturn b", ctxSvc.CreateContext("rule", "trigger"))
+
+	// Lines are still reported, but locals are the ones L.GetLocal names, which
+	// leaves out the ones declared by the statement just run
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []int{1, 4, 5, 2, 6}, recorder.lines)
+	assert.Empty(t, recorder.locals[2])
+	assert.Equal(t, []string{"a=1"}, recorder.locals[6][1:])
+}
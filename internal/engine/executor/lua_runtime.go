@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaRuntime runs Lua scripts in sandboxed states with the platform modules loaded.
+// States built with the default limits are pooled and compiled scripts are cached.
+type luaRuntime struct {
+	platformAPI   *platform.Service
+	limits        execCtx.Limits
+	pool          *LStatePool
+	scripts       *ScriptCache
+	legacyGlobals bool
+}
+
+// newLuaRuntime creates the Lua runtime. A poolSize or cacheSize of 0 disables
+// the state pool or the script cache.
+func newLuaRuntime(platformAPI *platform.Service, limits execCtx.Limits, poolSize, cacheSize int, legacyGlobals bool) *luaRuntime {
+	r := &luaRuntime{platformAPI: platformAPI, limits: limits, legacyGlobals: legacyGlobals}
+	if poolSize > 0 {
+		r.pool = NewLStatePool(func() *lua.LState { return r.newSandboxState(r.limits) }, poolSize)
+	}
+	if cacheSize > 0 {
+		r.scripts = NewScriptCache(cacheSize)
+	}
+	return r
+}
+
+// Language implements Runtime
+func (r *luaRuntime) Language() string {
+	return LanguageLua
+}
+
+// Run implements Runtime. The script is cancelled once ctx is done or one of
+// its resource budgets is exhausted.
+func (r *luaRuntime) Run(ctx context.Context, script string, ec *execCtx.ExecutionContext, limits execCtx.Limits) ([]any, error) {
+	// Take a sandboxed Lua state and expose the execution context to it
+	L, release := r.acquireState(limits)
+	setContextTable(L, ec)
+	if r.legacyGlobals {
+		setLegacyGlobals(L, ec)
+	}
+
+	// Attach the deadline and budget so the VM aborts runaway scripts
+	budgetCtx := newBudgetContext(ctx, L, limits)
+	L.SetContext(budgetCtx)
+
+	output, err := r.runScript(L, script)
+	// Aborted states may be left mid-call, so they are not reused
+	release(budgetCtx.exceeded != nil || ctx.Err() != nil)
+	if err != nil {
+		if budgetErr := classifyBudgetError(err, budgetCtx, limits); budgetErr != nil {
+			return nil, budgetErr
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// invalidateScript drops the compiled form of script from the cache
+func (r *luaRuntime) invalidateScript(script string) {
+	if r.scripts != nil {
+		r.scripts.Invalidate(script)
+	}
+}
+
+// reset drops the pooled states, which preload the platform modules and libraries
+func (r *luaRuntime) reset() {
+	if r.pool != nil {
+		r.pool.Reset()
+	}
+}
+
+// runScript executes script in L, reusing its compiled prototype when cached,
+// and converts every value it returns
+func (r *luaRuntime) runScript(L *lua.LState, script string) ([]any, error) {
+	base := L.GetTop()
+	if r.scripts == nil {
+		if err := L.DoString(script); err != nil {
+			return nil, err
+		}
+	} else {
+		proto, err := r.scripts.Get(script)
+		if err != nil {
+			return nil, err
+		}
+		L.Push(L.NewFunctionFromProto(proto))
+		if err := L.PCall(0, lua.MultRet, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Everything above base was returned by the chunk
+	values := make([]lua.LValue, L.GetTop()-base)
+	for i := range values {
+		values[i] = L.Get(base + i + 1)
+	}
+	output, err := convert.ToGoValues(values...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script result: %w", err)
+	}
+	return output, nil
+}
+
+// acquireState returns a sandboxed state for the given limits and the function
+// that hands it back once the execution is over. Pooled states are only used
+// when the stack and registry limits match the ones they were built with.
+func (r *luaRuntime) acquireState(limits execCtx.Limits) (*lua.LState, func(discard bool)) {
+	if r.pool == nil ||
+		limits.MaxCallStackDepth != r.limits.MaxCallStackDepth ||
+		limits.MaxRegistrySize != r.limits.MaxRegistrySize {
+		L := r.newSandboxState(limits)
+		return L, func(bool) { L.Close() }
+	}
+
+	L := r.pool.Get()
+	return L, func(discard bool) {
+		if discard {
+			r.pool.Discard(L)
+			return
+		}
+		r.pool.Put(L)
+	}
+}
+
+// newSandboxState creates a Lua state with the safe libraries and platform modules loaded
+func (r *luaRuntime) newSandboxState(limits execCtx.Limits) *lua.LState {
+	// Create a new Lua state with sandboxed options and stack/registry limits
+	L := lua.NewState(luaOptions(limits))
+
+	// Open only essential safe libraries
+	lua.OpenBase(L)    // _G, basic functions
+	lua.OpenTable(L)   // table library
+	lua.OpenString(L)  // string library
+	lua.OpenMath(L)    // math library
+	lua.OpenPackage(L) // package library for require
+	// Explicitly do NOT open: io, os, debug, coroutine (if not needed)
+
+	boundStringBuilders(L)
+
+	// Register platform API functions
+	r.platformAPI.RegisterAPIFunctions(L)
+
+	// Remove unsafe globals and restrict require to the platform modules
+	hardenSandbox(L)
+
+	// Capture print output into the execution logs instead of stdout
+	L.SetGlobal("print", L.NewFunction(scriptPrint))
+
+	// Drop the library tables the openers leave on the stack
+	L.SetTop(0)
+
+	return L
+}
@@ -1,8 +1,7 @@
 package platform
 
 import (
-	"encoding/json"
-	"fmt"
+	"sync"
 	"time"
 
 	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
@@ -17,16 +16,43 @@ type Module interface {
 // Service implements the PlatformAPI interface
 type Service struct {
 	ms []Module
+
+	mu        sync.RWMutex
+	libraries map[string]*compiledLibrary
+}
+
+// ServiceOption allows to configure the platform API service
+type ServiceOption func(s *Service) *Service
+
+// WithModule registers an additional module scripts can require, or replaces
+// the module with the same name, such as a differently configured http module
+func WithModule(module Module) ServiceOption {
+	return func(s *Service) *Service {
+		for i, m := range s.ms {
+			if m.Name() == module.Name() {
+				s.ms[i] = module
+				return s
+			}
+		}
+		s.ms = append(s.ms, module)
+		return s
+	}
 }
 
 // NewService creates a new platform API service
-func NewService() *Service {
+func NewService(opts ...ServiceOption) *Service {
 	ms := []Module{
 		modules.NewLoggerModule(),
 		modules.NewHTTPModule(),
 		modules.NewTimeModule(),
+		modules.NewJSONModule(),
+		modules.NewCryptoModule(),
 	}
-	return &Service{ms: ms}
+	s := &Service{ms: ms}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetCurrentTime returns the current timestamp
@@ -36,72 +62,24 @@ func (s *Service) GetCurrentTime() time.Time {
 
 // RegisterAPIFunctions registers platform API functions in the Lua state
 func (s *Service) RegisterAPIFunctions(L *lua.LState) {
-	// Register modules
+	// Register modules, timing their functions in profiled executions
 	for _, module := range s.ms {
-		L.PreloadModule(module.Name(), module.Loader)
+		L.PreloadModule(module.Name(), profiledLoader(module.Name(), module.Loader))
 	}
-}
 
-// luaValueFromGo converts a Go value to a Lua value
-func luaValueFromGo(L *lua.LState, v any) lua.LValue {
-	switch val := v.(type) {
-	case nil:
-		return lua.LNil
-	case bool:
-		return lua.LBool(val)
-	case int:
-		return lua.LNumber(val)
-	case int32:
-		return lua.LNumber(val)
-	case int64:
-		return lua.LNumber(val)
-	case float32:
-		return lua.LNumber(val)
-	case float64:
-		return lua.LNumber(val)
-	case string:
-		return lua.LString(val)
-	case map[string]any:
-		table := L.NewTable()
-		for k, v := range val {
-			L.SetField(table, k, luaValueFromGo(L, v))
-		}
-		return table
-	case []any:
-		table := L.NewTable()
-		for i, v := range val {
-			table.Insert(i+1, luaValueFromGo(L, v))
-		}
-		return table
-	default:
-		// For complex types, convert to JSON string
-		if jsonBytes, err := json.Marshal(v); err == nil {
-			return lua.LString(jsonBytes)
-		}
-		return lua.LString(fmt.Sprintf("%v", v))
+	// Register the libraries managed through the API
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, lib := range s.libraries {
+		L.PreloadModule(lib.Name, lib.loader)
 	}
 }
 
-// luaValueToGo converts a Lua value to a Go interface{}
-func luaValueToGo(v lua.LValue) any {
-	switch v.Type() {
-	case lua.LTNil:
-		return nil
-	case lua.LTBool:
-		return lua.LVAsBool(v)
-	case lua.LTNumber:
-		return float64(v.(lua.LNumber))
-	case lua.LTString:
-		return string(v.(lua.LString))
-	case lua.LTTable:
-		// Convert table to map
-		table := v.(*lua.LTable)
-		result := make(map[string]any)
-		table.ForEach(func(k, v lua.LValue) {
-			result[k.String()] = luaValueToGo(v)
-		})
-		return result
-	default:
-		return v.String()
+// ModuleNames returns the names of the platform modules scripts can require
+func (s *Service) ModuleNames() []string {
+	names := make([]string, 0, len(s.ms))
+	for _, module := range s.ms {
+		names = append(names, module.Name())
 	}
+	return names
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
 	"github.com/stretchr/testify/assert"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -19,6 +21,14 @@ func TestService_GetCurrentTime(t *testing.T) {
 	assert.True(t, currentTime.Before(after) || currentTime.Equal(after))
 }
 
+func TestWithModule_ReplacesModuleWithSameName(t *testing.T) {
+	http := modules.NewHTTPModule(modules.WithHTTPMaxResponseBytes(10))
+	service := NewService(WithModule(http), WithModule(modules.NewEventsModule(nil)))
+
+	assert.Equal(t, []string{"logger", "http", "time", "json", "crypto", "events"}, service.ModuleNames())
+	assert.Same(t, http, service.ms[1])
+}
+
 func TestRegisterAPIFunctions_LogMessage(t *testing.T) {
 	service := NewService()
 	L := lua.NewState()
@@ -77,26 +87,27 @@ func TestRegisterAPIFunctions_ErrorHandling(t *testing.T) {
 }
 
 func TestLuaValueConversions(t *testing.T) {
-	// Test luaValueFromGo
+	// Test convert.FromGo
 	L := lua.NewState()
 	defer L.Close()
 
 	// Test various Go types to Lua conversion
-	assert.Equal(t, lua.LTString, luaValueFromGo(L, "string").Type())
-	assert.Equal(t, lua.LTNumber, luaValueFromGo(L, 42).Type())
-	assert.Equal(t, lua.LTNumber, luaValueFromGo(L, int64(42)).Type())
-	assert.Equal(t, lua.LTNumber, luaValueFromGo(L, 3.14).Type())
-	assert.Equal(t, lua.LTBool, luaValueFromGo(L, true).Type())
-	assert.Equal(t, lua.LTNil, luaValueFromGo(L, nil).Type())
+	assert.Equal(t, lua.LTString, convert.FromGo(L, "string").Type())
+	assert.Equal(t, lua.LTNumber, convert.FromGo(L, 42).Type())
+	assert.Equal(t, lua.LTNumber, convert.FromGo(L, int64(42)).Type())
+	assert.Equal(t, lua.LTNumber, convert.FromGo(L, 3.14).Type())
+	assert.Equal(t, lua.LTBool, convert.FromGo(L, true).Type())
+	assert.Equal(t, lua.LTNil, convert.FromGo(L, nil).Type())
 
 	// Test table conversion
 	mapData := map[string]any{"key": "value"}
-	table := luaValueFromGo(L, mapData)
+	table := convert.FromGo(L, mapData)
 	assert.Equal(t, lua.LTTable, table.Type())
 
-	// Test luaValueToGo
+	// Test convert.ToGo
 	userData := &lua.LUserData{}
-	result := luaValueToGo(userData)
+	result, err := convert.ToGo(userData)
+	assert.NoError(t, err)
 	assert.Contains(t, result, "userdata") // fallback case returns string representation
 }
 
@@ -137,6 +148,17 @@ func TestPlatformAPI_Integration(t *testing.T) {
 	assert(type(time) == "table")
 	assert(time.now ~= nil)
 	assert(type(time.now) == "function")
+
+	local json = require 'json'
+	assert(type(json) == "table")
+	assert(type(json.encode) == "function")
+	assert(type(json.decode) == "function")
+	assert(json.encode(json.decode('{"a":[1,null]}')) == '{"a":[1,null]}')
+
+	local crypto = require 'crypto'
+	assert(type(crypto) == "table")
+	assert(crypto.sha256("abc") == "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
+	assert(crypto.base64_decode(crypto.base64_encode("payload")) == "payload")
 	`
 
 	err := L.DoString(script)
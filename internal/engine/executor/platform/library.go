@@ -0,0 +1,182 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Errors returned when a set of libraries cannot be loaded
+var (
+	ErrInvalidLibrary    = errors.New("invalid library")
+	ErrUnknownDependency = errors.New("unknown library dependency")
+	ErrDependencyCycle   = errors.New("library dependency cycle")
+)
+
+// Library is Lua code that scripts can load with require(Name).
+// The value returned by the code is the module returned by require.
+type Library struct {
+	Name    string
+	Version int
+	Code    string
+}
+
+// LibrarySource returns the chunk name library code runs under,
+// which prefixes the errors and stack traces it raises
+func LibrarySource(name string) string {
+	return "library:" + name
+}
+
+// compiledLibrary is a library ready to be preloaded
+type compiledLibrary struct {
+	Library
+	proto    *lua.FunctionProto
+	requires []string
+}
+
+// loader runs the library code like require runs a module
+func (l *compiledLibrary) loader(L *lua.LState) int {
+	L.Push(L.NewFunctionFromProto(l.proto))
+	L.Push(lua.LString(l.Name))
+	L.Call(1, 1)
+	return 1
+}
+
+// SetLibraries replaces the libraries preloaded in the states registered from now on.
+// The set is rejected as a whole when a library does not compile, shadows a
+// module, requires a module that is neither a platform module, a standard
+// library nor another library of the set, or takes part in a dependency cycle.
+func (s *Service) SetLibraries(libs []Library) error {
+	compiled := make(map[string]*compiledLibrary, len(libs))
+	for _, lib := range libs {
+		if err := s.checkLibraryName(lib.Name); err != nil {
+			return err
+		}
+		if _, ok := compiled[lib.Name]; ok {
+			return fmt.Errorf("%w: library '%s' is defined twice", ErrInvalidLibrary, lib.Name)
+		}
+
+		chunk, err := parse.Parse(strings.NewReader(lib.Code), LibrarySource(lib.Name))
+		if err != nil {
+			return fmt.Errorf("%w: library '%s': %s", ErrInvalidLibrary, lib.Name, strings.TrimSpace(err.Error()))
+		}
+		proto, err := lua.Compile(chunk, LibrarySource(lib.Name))
+		if err != nil {
+			return fmt.Errorf("%w: library '%s': %s", ErrInvalidLibrary, lib.Name, strings.TrimSpace(err.Error()))
+		}
+		requires, err := validation.Requires(lib.Code)
+		if err != nil {
+			return fmt.Errorf("%w: library '%s': %s", ErrInvalidLibrary, lib.Name, strings.TrimSpace(err.Error()))
+		}
+		compiled[lib.Name] = &compiledLibrary{Library: lib, proto: proto, requires: requires}
+	}
+
+	for _, name := range sortedNames(compiled) {
+		for _, dep := range compiled[name].requires {
+			if _, ok := compiled[dep]; !ok && !s.isBuiltinModule(dep) {
+				return fmt.Errorf("%w: library '%s' requires unknown module '%s'", ErrUnknownDependency, name, dep)
+			}
+		}
+	}
+	if cycle := findCycle(compiled); cycle != nil {
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+
+	s.mu.Lock()
+	s.libraries = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+// Libraries returns the libraries currently preloaded, by name
+func (s *Service) Libraries() []Library {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	libs := make([]Library, 0, len(s.libraries))
+	for _, name := range sortedNames(s.libraries) {
+		libs = append(libs, s.libraries[name].Library)
+	}
+	return libs
+}
+
+// HasModule reports whether scripts can require name: a platform module,
+// a standard library or a library currently preloaded
+func (s *Service) HasModule(name string) bool {
+	if s.isBuiltinModule(name) {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.libraries[name]
+	return ok
+}
+
+func (s *Service) isBuiltinModule(name string) bool {
+	return slices.Contains(s.ModuleNames(), name) || slices.Contains(validation.StandardModules, name)
+}
+
+func (s *Service) checkLibraryName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: library name is empty", ErrInvalidLibrary)
+	case s.isBuiltinModule(name) || slices.Contains(validation.DefaultForbiddenGlobals, name):
+		return fmt.Errorf("%w: library name '%s' is reserved", ErrInvalidLibrary, name)
+	}
+	return nil
+}
+
+// findCycle returns a dependency cycle among libs, starting and ending with the
+// same library, or nil. Libraries are visited by name so the result is stable.
+func findCycle(libs map[string]*compiledLibrary) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(libs))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			start := slices.Index(path, name)
+			return append(slices.Clone(path[start:]), name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range libs[name].requires {
+			if _, ok := libs[dep]; !ok {
+				continue
+			}
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range sortedNames(libs) {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func sortedNames(libs map[string]*compiledLibrary) []string {
+	names := make([]string, 0, len(libs))
+	for name := range libs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,121 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestService_SetLibraries(t *testing.T) {
+	service := NewService()
+	require.NoError(t, service.SetLibraries([]Library{
+		{Name: "units", Version: 2, Code: `
+			local M = {}
+			function M.fahrenheit(c) return c * 9 / 5 + 32 end
+			return M
+		`},
+		{Name: "thresholds", Version: 1, Code: `
+			local units = require("units")
+			local logger = require("logger")
+			return { too_hot = function(c) return units.fahrenheit(c) > 86 end }
+		`},
+	}))
+
+	L := lua.NewState()
+	defer L.Close()
+	service.RegisterAPIFunctions(L)
+
+	require.NoError(t, L.DoString(`
+		local thresholds = require("thresholds")
+		result = thresholds.too_hot(31)
+	`))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("result"))
+
+	assert.True(t, service.HasModule("units"))
+	assert.True(t, service.HasModule("http"))
+	assert.True(t, service.HasModule("math"))
+	assert.False(t, service.HasModule("unknown"))
+	libs := service.Libraries()
+	require.Len(t, libs, 2)
+	assert.Equal(t, "thresholds", libs[0].Name)
+	assert.Equal(t, "units", libs[1].Name)
+	assert.Equal(t, 2, libs[1].Version)
+}
+
+func TestService_SetLibraries_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		libs     []Library
+		expected error
+		message  string
+	}{
+		{
+			name:     "syntax error",
+			libs:     []Library{{Name: "broken", Code: "return {"}},
+			expected: ErrInvalidLibrary,
+			message:  "library 'broken'",
+		},
+		{
+			name:     "reserved name",
+			libs:     []Library{{Name: "http", Code: "return {}"}},
+			expected: ErrInvalidLibrary,
+			message:  "library name 'http' is reserved",
+		},
+		{
+			name:     "duplicate name",
+			libs:     []Library{{Name: "a", Code: "return {}"}, {Name: "a", Code: "return {}"}},
+			expected: ErrInvalidLibrary,
+		},
+		{
+			name:     "unknown dependency",
+			libs:     []Library{{Name: "a", Code: "return require('missing')"}},
+			expected: ErrUnknownDependency,
+			message:  "library 'a' requires unknown module 'missing'",
+		},
+		{
+			name: "cycle",
+			libs: []Library{
+				{Name: "c", Code: "return require('a')"},
+				{Name: "a", Code: "return require('b')"},
+				{Name: "b", Code: "return require('c')"},
+			},
+			expected: ErrDependencyCycle,
+			message:  "a -> b -> c -> a",
+		},
+		{
+			name:     "self require",
+			libs:     []Library{{Name: "a", Code: "return require('a')"}},
+			expected: ErrDependencyCycle,
+			message:  "a -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService()
+			require.NoError(t, service.SetLibraries([]Library{{Name: "kept", Code: "return 1"}}))
+
+			err := service.SetLibraries(tt.libs)
+
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Contains(t, err.Error(), tt.message)
+			// A rejected set leaves the current libraries in place
+			assert.True(t, service.HasModule("kept"))
+		})
+	}
+}
+
+func TestService_LibraryErrorsNameTheLibrary(t *testing.T) {
+	service := NewService()
+	require.NoError(t, service.SetLibraries([]Library{{Name: "units", Code: "return { fail = function() error('boom') end }"}}))
+
+	L := lua.NewState()
+	defer L.Close()
+	service.RegisterAPIFunctions(L)
+
+	err := L.DoString(`require("units").fail()`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "library:units:1: boom")
+}
@@ -0,0 +1,21 @@
+package modules
+
+import (
+	"context"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// callContext returns the context for the I/O a module performs on behalf of
+// the script running in L. The executor wraps the execution context in one that
+// counts every Done() call as an instruction, so the wrapped one is used instead.
+func callContext(L *lua.LState) context.Context {
+	ctx := L.Context()
+	if ctx == nil {
+		return context.Background()
+	}
+	if wrapped, ok := ctx.(interface{ Parent() context.Context }); ok {
+		return wrapped.Parent()
+	}
+	return ctx
+}
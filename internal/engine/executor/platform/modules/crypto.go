@@ -0,0 +1,222 @@
+package modules
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxRandomBytes bounds the number of bytes crypto.random_bytes returns
+const maxRandomBytes = 1024
+
+// Encodings of the binary values returned by the crypto module
+const (
+	encodingHex       = "hex"
+	encodingBase64    = "base64"
+	encodingBase64URL = "base64url"
+	encodingRaw       = "raw"
+)
+
+// CryptoModule provides hashing, message authentication, encoding and
+// identifier generation to Lua scripts, for instance to sign the requests
+// made with the http module. It exposes no key management and no access to
+// the system beyond its random source. The generated identifiers and bytes
+// are recorded for replays, which get the recorded values back.
+type CryptoModule struct {
+}
+
+// NewCryptoModule creates a new CryptoModule
+func NewCryptoModule() *CryptoModule {
+	return &CryptoModule{}
+}
+
+// Name returns the name of the module
+func (s *CryptoModule) Name() string {
+	return "crypto"
+}
+
+// SHA256 returns the SHA-256 digest of a string, hex-encoded unless another
+// encoding is given
+func (s *CryptoModule) SHA256(L *lua.LState) int {
+	data := L.CheckString(1)
+	encoding := checkEncoding(L, 2, encodingHex)
+	sum := sha256.Sum256([]byte(data))
+	L.Push(lua.LString(encodeBytes(sum[:], encoding)))
+	return 1
+}
+
+// HMACSHA256 returns the HMAC-SHA256 of a string with a key, hex-encoded
+// unless another encoding is given
+func (s *CryptoModule) HMACSHA256(L *lua.LState) int {
+	key := L.CheckString(1)
+	data := L.CheckString(2)
+	encoding := checkEncoding(L, 3, encodingHex)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	L.Push(lua.LString(encodeBytes(mac.Sum(nil), encoding)))
+	return 1
+}
+
+// Base64Encode returns the base64 encoding of a string, with the URL-safe
+// alphabet and without padding when url is true
+func (s *CryptoModule) Base64Encode(L *lua.LState) int {
+	data := L.CheckString(1)
+	encoding := encodingBase64
+	if L.OptBool(2, false) {
+		encoding = encodingBase64URL
+	}
+	L.Push(lua.LString(encodeBytes([]byte(data), encoding)))
+	return 1
+}
+
+// Base64Decode decodes a base64 string, padded or not, with the URL-safe
+// alphabet when url is true
+func (s *CryptoModule) Base64Decode(L *lua.LState) int {
+	data := L.CheckString(1)
+	enc := base64.RawStdEncoding
+	if L.OptBool(2, false) {
+		enc = base64.RawURLEncoding
+	}
+	decoded, err := enc.DecodeString(strings.TrimRight(data, "="))
+	return pushDecoded(L, decoded, err)
+}
+
+// HexEncode returns the lowercase hex encoding of a string
+func (s *CryptoModule) HexEncode(L *lua.LState) int {
+	L.Push(lua.LString(hex.EncodeToString([]byte(L.CheckString(1)))))
+	return 1
+}
+
+// HexDecode decodes a hex string of either case
+func (s *CryptoModule) HexDecode(L *lua.LState) int {
+	decoded, err := hex.DecodeString(L.CheckString(1))
+	return pushDecoded(L, decoded, err)
+}
+
+// UUIDv4 returns a random UUID
+func (s *CryptoModule) UUIDv4(L *lua.LState) int {
+	L.Push(lua.LString(s.generate(L, "uuid_v4", "", func() (string, error) {
+		id, err := uuid.NewRandom()
+		return id.String(), err
+	})))
+	return 1
+}
+
+// UUIDv7 returns a UUID starting with the current time, so that the
+// identifiers generated one after the other sort in generation order
+func (s *CryptoModule) UUIDv7(L *lua.LState) int {
+	L.Push(lua.LString(s.generate(L, "uuid_v7", "", func() (string, error) {
+		id, err := uuid.NewV7()
+		return id.String(), err
+	})))
+	return 1
+}
+
+// RandomBytes returns n bytes from a cryptographically secure source, raw
+// unless an encoding is given
+func (s *CryptoModule) RandomBytes(L *lua.LState) int {
+	n := L.CheckInt(1)
+	if n < 1 || n > maxRandomBytes {
+		L.ArgError(1, fmt.Sprintf("must be between 1 and %d", maxRandomBytes))
+	}
+	encoding := checkEncoding(L, 2, encodingRaw)
+	generated := s.generate(L, "random_bytes", strconv.Itoa(n), func() (string, error) {
+		b := make([]byte, n)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	})
+	b, err := hex.DecodeString(generated)
+	if err != nil {
+		L.RaiseError("crypto: corrupt recording: %v", err)
+	}
+	L.Push(lua.LString(encodeBytes(b, encoding)))
+	return 1
+}
+
+// generate returns a generated value as text, or the recorded one when the
+// execution is replayed. Values that were not recorded are generated afresh.
+func (s *CryptoModule) generate(L *lua.LState, op, key string, gen func() (string, error)) string {
+	ctx := callContext(L)
+	if player := replay.PlayerFromContext(ctx); player != nil {
+		if recorded, err := player.Call(s.Name(), op, key); err == nil {
+			var value string
+			if err := json.Unmarshal([]byte(recorded.Result), &value); err == nil {
+				return value
+			}
+		}
+	}
+
+	value, err := gen()
+	if err != nil {
+		L.RaiseError("crypto: %v", err)
+	}
+	if recorder := replay.RecorderFromContext(ctx); recorder != nil {
+		raw, _ := json.Marshal(value)
+		recorder.RecordCall(replay.Call{Module: s.Name(), Op: op, Key: key, Result: string(raw)})
+	}
+	return value
+}
+
+// checkEncoding reads the optional encoding name at position n
+func checkEncoding(L *lua.LState, n int, def string) string {
+	encoding := L.OptString(n, def)
+	switch encoding {
+	case encodingHex, encodingBase64, encodingBase64URL, encodingRaw:
+		return encoding
+	}
+	L.ArgError(n, fmt.Sprintf("unknown encoding '%s', expected hex, base64, base64url or raw", encoding))
+	return ""
+}
+
+// encodeBytes returns b in the given encoding; base64url is unpadded, as in JWTs
+func encodeBytes(b []byte, encoding string) string {
+	switch encoding {
+	case encodingHex:
+		return hex.EncodeToString(b)
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case encodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	return string(b)
+}
+
+func pushDecoded(L *lua.LState, decoded []byte, err error) int {
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(fmt.Sprintf("crypto: invalid input: %v", err)))
+		return 2
+	}
+	L.Push(lua.LString(decoded))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Loader loads the crypto module into the Lua state
+func (s *CryptoModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"sha256":        s.SHA256,
+		"hmac_sha256":   s.HMACSHA256,
+		"base64_encode": s.Base64Encode,
+		"base64_decode": s.Base64Decode,
+		"hex_encode":    s.HexEncode,
+		"hex_decode":    s.HexDecode,
+		"uuid_v4":       s.UUIDv4,
+		"uuid_v7":       s.UUIDv7,
+		"random_bytes":  s.RandomBytes,
+	})
+	L.Push(mod)
+	return 1
+}
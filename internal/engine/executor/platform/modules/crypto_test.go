@@ -0,0 +1,109 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestCryptoModule_Vectors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewCryptoModule())
+
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		// FIPS 180-2
+		{"sha256 empty", `return crypto.sha256("")`, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"sha256 abc", `return crypto.sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha256 base64", `return crypto.sha256("abc", "base64")`, "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0="},
+		{"sha256 raw", `return crypto.hex_encode(crypto.sha256("abc", "raw"))`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		// RFC 4231, test cases 1 and 2
+		{"hmac binary key", `return crypto.hmac_sha256(crypto.hex_decode("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"), "Hi There")`,
+			"b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7"},
+		{"hmac", `return crypto.hmac_sha256("Jefe", "what do ya want for nothing?")`,
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"hmac base64url", `return crypto.hmac_sha256("Jefe", "what do ya want for nothing?", "base64url")`,
+			"W9zBRr9gdU5qBCQmCJV1x1oAPwidJzmDnexYuWTsOEM"},
+		// RFC 4648
+		{"base64", `return crypto.base64_encode("foobar")`, "Zm9vYmFy"},
+		{"base64 padded", `return crypto.base64_encode("f")`, "Zg=="},
+		{"base64 url", `return crypto.base64_encode("\251\255", true)`, "-_8"},
+		{"base64 std alphabet", `return crypto.base64_encode("\251\255")`, "+/8="},
+		{"base64 decode", `return crypto.base64_decode("Zm8=")`, "fo"},
+		{"base64 decode unpadded", `return crypto.base64_decode("Zm8")`, "fo"},
+		{"base64 decode url", `return crypto.hex_encode(crypto.base64_decode("-_8", true))`, "fbff"},
+		{"hex", `return crypto.hex_encode("foo")`, "666f6f"},
+		{"hex decode", `return crypto.hex_decode("666F6f")`, "foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := evalScript(t, L, tt.script)
+			assert.Equal(t, lua.LString(tt.expected), results[0])
+		})
+	}
+}
+
+func TestCryptoModule_DecodeErrors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewCryptoModule())
+
+	results := evalScript(t, L, `return crypto.base64_decode("not base64!")`)
+	assert.Equal(t, lua.LNil, results[0])
+	assert.Contains(t, results[1].String(), "crypto: invalid input")
+
+	results = evalScript(t, L, `return crypto.hex_decode("abc")`)
+	assert.Equal(t, lua.LNil, results[0])
+	assert.Contains(t, results[1].String(), "crypto: invalid input")
+
+	err := L.DoString(`crypto.sha256("abc", "base32")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown encoding 'base32'")
+
+	err = L.DoString(`crypto.random_bytes(4096)`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be between 1 and 1024")
+}
+
+func TestCryptoModule_Generators(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewCryptoModule())
+	uuidPattern := `^[0-9a-f]{8}-[0-9a-f]{4}-%s[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`
+
+	results := evalScript(t, L, `return crypto.uuid_v4(), crypto.uuid_v4()`)
+	assert.Regexp(t, regexp.MustCompile(fmt.Sprintf(uuidPattern, "4")), results[0].String())
+	assert.NotEqual(t, results[0], results[1])
+
+	results = evalScript(t, L, `return crypto.uuid_v7(), crypto.uuid_v7()`)
+	assert.Regexp(t, regexp.MustCompile(fmt.Sprintf(uuidPattern, "7")), results[0].String())
+	assert.Less(t, results[0].String(), results[1].String())
+
+	results = evalScript(t, L, `
+		local raw = crypto.random_bytes(16)
+		return #raw, crypto.random_bytes(16, "hex"), crypto.random_bytes(16, "hex")
+	`)
+	assert.Equal(t, lua.LNumber(16), results[0])
+	assert.Regexp(t, `^[0-9a-f]{32}$`, results[1].String())
+	assert.NotEqual(t, results[1], results[2])
+}
+
+func TestCryptoModule_Replay(t *testing.T) {
+	script := `return crypto.uuid_v4(), crypto.uuid_v7(), crypto.random_bytes(8, "hex")`
+	recorder := replay.NewRecorder(&execCtx.ExecutionContext{}, 0)
+
+	L := newModuleState(t, replay.WithRecorder(context.Background(), recorder), NewCryptoModule())
+	recorded := evalScript(t, L, script)
+
+	// The replay gets the recorded values back rather than new ones
+	player := replay.NewPlayer(recorder.Bundle())
+	L = newModuleState(t, replay.WithPlayer(context.Background(), player), NewCryptoModule())
+	assert.Equal(t, recorded, evalScript(t, L, script))
+	assert.Empty(t, player.Misses())
+}
@@ -0,0 +1,206 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/malyshevhen/rule-engine/internal/device"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// DeviceClient gives access to the devices of the IoT platform
+type DeviceClient interface {
+	GetState(ctx context.Context, id string) (map[string]any, error)
+	SendCommand(ctx context.Context, id, command string, params map[string]any) error
+	List(ctx context.Context, filter device.Filter) ([]device.Device, error)
+}
+
+// DeviceModule lets scripts read the state of devices, send them commands
+// and list them. Calls are recorded for replays, which get the recorded
+// outcomes back instead of reaching the devices.
+type DeviceModule struct {
+	client DeviceClient
+}
+
+// NewDeviceModule creates a new DeviceModule on top of client
+func NewDeviceModule(client DeviceClient) *DeviceModule {
+	return &DeviceModule{client: client}
+}
+
+// Name returns the name of the module
+func (s *DeviceModule) Name() string {
+	return "device"
+}
+
+// GetState returns the state last reported by a device
+func (s *DeviceModule) GetState(L *lua.LState) int {
+	id := L.CheckString(1)
+	return s.push(L, "get_state", id, func(ctx context.Context) (any, error) {
+		state, err := s.client.GetState(ctx, id)
+		if err != nil {
+			return nil, deviceError(id, err)
+		}
+		return state, nil
+	})
+}
+
+// SendCommand sends a command with an optional table of params to a device
+func (s *DeviceModule) SendCommand(L *lua.LState) int {
+	id := L.CheckString(1)
+	command := L.CheckString(2)
+	params := s.checkParams(L, 3)
+	return s.push(L, "send_command", id+" "+command, func(ctx context.Context) (any, error) {
+		if err := s.client.SendCommand(ctx, id, command, params); err != nil {
+			return nil, deviceError(id, err)
+		}
+		return true, nil
+	})
+}
+
+// List returns the devices selected by an optional filter table with the
+// fields type and online
+func (s *DeviceModule) List(L *lua.LState) int {
+	filter := s.checkFilter(L, 1)
+	key, _ := json.Marshal(filter)
+	return s.push(L, "list", string(key), func(ctx context.Context) (any, error) {
+		devices, err := s.client.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("device: %w", err)
+		}
+		result := make([]any, len(devices))
+		for i, d := range devices {
+			state := d.State
+			if state == nil {
+				state = map[string]any{}
+			}
+			result[i] = map[string]any{
+				"id":     d.ID,
+				"name":   d.Name,
+				"type":   d.Type,
+				"online": d.Online,
+				"state":  state,
+			}
+		}
+		return result, nil
+	})
+}
+
+// push runs a call, or serves its recorded outcome when the execution is
+// replayed, and pushes its result, or nil and an error message
+func (s *DeviceModule) push(L *lua.LState, op, key string, call func(ctx context.Context) (any, error)) int {
+	result, err := s.call(callContext(L), op, key, call)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(convert.FromGo(L, result))
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *DeviceModule) call(ctx context.Context, op, key string, call func(ctx context.Context) (any, error)) (any, error) {
+	if player := replay.PlayerFromContext(ctx); player != nil {
+		recorded, err := player.Call(s.Name(), op, key)
+		if err != nil {
+			return nil, fmt.Errorf("device: %w", err)
+		}
+		if recorded.Error != "" {
+			return nil, errors.New(recorded.Error)
+		}
+		var result any
+		if err := json.Unmarshal([]byte(recorded.Result), &result); err != nil {
+			return nil, fmt.Errorf("device: corrupt recording: %w", err)
+		}
+		return result, nil
+	}
+
+	result, err := call(ctx)
+	if recorder := replay.RecorderFromContext(ctx); recorder != nil {
+		recorded := replay.Call{Module: s.Name(), Op: op, Key: key}
+		if err != nil {
+			recorded.Error = err.Error()
+		} else if raw, err := json.Marshal(result); err == nil {
+			recorded.Result = string(raw)
+		}
+		recorder.RecordCall(recorded)
+	}
+	return result, err
+}
+
+// checkParams reads the optional table of command params at position n
+func (s *DeviceModule) checkParams(L *lua.LState, n int) map[string]any {
+	tbl := L.OptTable(n, nil)
+	if tbl == nil {
+		return nil
+	}
+	value, err := convert.ToGo(tbl, convert.WithStrictTypes())
+	if err != nil {
+		L.ArgError(n, err.Error())
+	}
+	switch params := value.(type) {
+	case map[string]any:
+		return params
+	case []any:
+		if len(params) == 0 {
+			return map[string]any{}
+		}
+	}
+	L.ArgError(n, "params must be a table with string keys")
+	return nil
+}
+
+// checkFilter reads the optional filter table at position n
+func (s *DeviceModule) checkFilter(L *lua.LState, n int) device.Filter {
+	var filter device.Filter
+	tbl := L.OptTable(n, nil)
+	if tbl == nil {
+		return filter
+	}
+	tbl.ForEach(func(k, v lua.LValue) {
+		switch k.String() {
+		case "type":
+			str, ok := v.(lua.LString)
+			if !ok {
+				L.ArgError(n, "filter field 'type' must be a string")
+			}
+			filter.Type = string(str)
+		case "online":
+			b, ok := v.(lua.LBool)
+			if !ok {
+				L.ArgError(n, "filter field 'online' must be a boolean")
+			}
+			online := bool(b)
+			filter.Online = &online
+		default:
+			L.ArgError(n, fmt.Sprintf("unknown filter field '%s'", k.String()))
+		}
+	})
+	return filter
+}
+
+// deviceError describes an error of a call about the device id
+func deviceError(id string, err error) error {
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		return fmt.Errorf("device: '%s' not found", id)
+	case errors.Is(err, device.ErrOffline):
+		return fmt.Errorf("device: '%s' is offline", id)
+	}
+	return fmt.Errorf("device: %w", err)
+}
+
+// Loader loads the device module into the Lua state
+func (s *DeviceModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"get_state":    s.GetState,
+		"send_command": s.SendCommand,
+		"list":         s.List,
+	})
+	L.Push(mod)
+	return 1
+}
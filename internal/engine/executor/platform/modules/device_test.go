@@ -0,0 +1,99 @@
+package modules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malyshevhen/rule-engine/internal/device"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func newDeviceFake() *device.Fake {
+	return device.NewFake(
+		device.Device{ID: "thermostat", Type: "thermostat", Online: true, State: map[string]any{"temperature": 26.5}},
+		device.Device{ID: "lamp", Type: "lamp", Name: "Kitchen lamp", Online: true, State: map[string]any{"on": false}},
+		device.Device{ID: "fan", Type: "fan", Online: false},
+	)
+}
+
+func TestDeviceModule(t *testing.T) {
+	fake := newDeviceFake()
+	L := newModuleState(t, context.Background(), NewDeviceModule(fake))
+
+	require.NoError(t, L.DoString(`
+		local device = require("device")
+		local state = device.get_state("thermostat")
+		temperature = state.temperature
+		sent = device.send_command("lamp", "turn_on", {on = true, brightness = 80})
+		lamp_on = device.get_state("lamp").on
+		local lamps = device.list({type = "lamp"})
+		lamp_count, lamp_name = #lamps, lamps[1].name
+		local offline = device.list({online = false})
+		offline_id = offline[1].id
+		all = #device.list()
+	`))
+	assert.Equal(t, lua.LNumber(26.5), L.GetGlobal("temperature"))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("sent"))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("lamp_on"))
+	assert.Equal(t, lua.LNumber(1), L.GetGlobal("lamp_count"))
+	assert.Equal(t, lua.LString("Kitchen lamp"), L.GetGlobal("lamp_name"))
+	assert.Equal(t, lua.LString("fan"), L.GetGlobal("offline_id"))
+	assert.Equal(t, lua.LNumber(3), L.GetGlobal("all"))
+	assert.Equal(t, []device.Command{
+		{DeviceID: "lamp", Command: "turn_on", Params: map[string]any{"on": true, "brightness": 80.0}},
+	}, fake.Commands())
+}
+
+func TestDeviceModule_Errors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewDeviceModule(newDeviceFake()))
+
+	tests := []struct {
+		script  string
+		message string
+	}{
+		{`return require("device").get_state("missing")`, "device: 'missing' not found"},
+		{`return require("device").send_command("fan", "turn_on")`, "device: 'fan' is offline"},
+	}
+	for _, tt := range tests {
+		require.NoError(t, L.DoString(`value, err = (function() `+tt.script+` end)()`))
+		assert.Equal(t, lua.LNil, L.GetGlobal("value"))
+		assert.Equal(t, tt.message, L.GetGlobal("err").String())
+	}
+
+	assert.ErrorContains(t, L.DoString(`require("device").list({room = "kitchen"})`), "unknown filter field 'room'")
+	assert.ErrorContains(t, L.DoString(`require("device").list({online = "yes"})`), "filter field 'online' must be a boolean")
+	assert.ErrorContains(t, L.DoString(`require("device").send_command("lamp", "turn_on", {1, 2})`), "params must be a table with string keys")
+	assert.ErrorContains(t, L.DoString(`require("device").send_command("lamp", "turn_on", {callback = print})`), "cannot convert function")
+}
+
+func TestDeviceModule_Replay(t *testing.T) {
+	recorder := replay.NewRecorder(&execCtx.ExecutionContext{}, 0)
+	fake := newDeviceFake()
+	script := `
+		local device = require("device")
+		temperature = device.get_state("thermostat").temperature
+		sent = device.send_command("lamp", "turn_on", {on = true})
+		_, missing = device.get_state("missing")
+		count = #device.list({type = "lamp"})
+	`
+
+	L := newModuleState(t, replay.WithRecorder(context.Background(), recorder), NewDeviceModule(fake))
+	require.NoError(t, L.DoString(script))
+	bundle := recorder.Bundle()
+	require.Len(t, bundle.Calls, 4)
+
+	// The replay gets the recorded outcomes without reaching the devices
+	player := replay.NewPlayer(bundle)
+	L = newModuleState(t, replay.WithPlayer(context.Background(), player), NewDeviceModule(device.NewFake()))
+	require.NoError(t, L.DoString(script))
+	assert.Equal(t, lua.LNumber(26.5), L.GetGlobal("temperature"))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("sent"))
+	assert.Equal(t, lua.LString("device: 'missing' not found"), L.GetGlobal("missing"))
+	assert.Equal(t, lua.LNumber(1), L.GetGlobal("count"))
+	assert.Empty(t, player.Misses())
+	assert.Len(t, fake.Commands(), 1)
+}
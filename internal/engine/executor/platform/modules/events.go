@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// defaultEventsRequestTimeout is how long events.request waits for a reply when no timeout is given
+const defaultEventsRequestTimeout = time.Second
+
+// EventPublisher publishes the events scripts emit
+type EventPublisher interface {
+	Publish(ctx context.Context, subject string, payload any, depth int) error
+	Request(ctx context.Context, subject string, payload any, depth int, timeout time.Duration) ([]byte, error)
+}
+
+// EventsModule lets scripts publish events to NATS and make request/reply
+// calls. Only subjects in the allow-list of the publisher can be used, and
+// events carry the depth of the chain they belong to so that rules
+// triggering each other cannot loop forever.
+type EventsModule struct {
+	publisher EventPublisher
+}
+
+// NewEventsModule creates a new EventsModule on top of publisher
+func NewEventsModule(publisher EventPublisher) *EventsModule {
+	return &EventsModule{publisher: publisher}
+}
+
+// Name returns the name of the module
+func (s *EventsModule) Name() string {
+	return "events"
+}
+
+// Publish publishes a payload, encoded as JSON, on a subject
+func (s *EventsModule) Publish(L *lua.LState) int {
+	subject := L.CheckString(1)
+	payload := L.Get(2)
+
+	ctx := callContext(L)
+	// Replays must not emit events live rules react to
+	if replay.PlayerFromContext(ctx) != nil {
+		L.Push(lua.LTrue)
+		L.Push(lua.LNil)
+		return 2
+	}
+	value, err := convert.ToGo(payload, convert.WithStrictTypes())
+	if err == nil {
+		err = s.publisher.Publish(ctx, subject, value, chainDepth(ctx))
+	}
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("events: " + err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Request publishes a payload on a subject and waits for the reply for the
+// optional number of seconds, one by default. Replies holding JSON are
+// decoded, others are returned as strings.
+func (s *EventsModule) Request(L *lua.LState) int {
+	subject := L.CheckString(1)
+	payload := L.Get(2)
+	seconds := L.OptNumber(3, lua.LNumber(defaultEventsRequestTimeout.Seconds()))
+	if seconds <= 0 {
+		L.ArgError(3, "timeout must be positive")
+	}
+	timeout := time.Duration(float64(seconds) * float64(time.Second))
+
+	reply, err := s.request(L, subject, payload, timeout)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("events: " + err.Error()))
+		return 2
+	}
+	var decoded any
+	if err := json.Unmarshal(reply, &decoded); err != nil {
+		L.Push(lua.LString(reply))
+	} else {
+		L.Push(convert.FromGo(L, decoded))
+	}
+	L.Push(lua.LNil)
+	return 2
+}
+
+// request makes a request, or serves its recorded reply when the execution is
+// replayed, so that replays do not reach the services answering requests
+func (s *EventsModule) request(L *lua.LState, subject string, payload lua.LValue, timeout time.Duration) ([]byte, error) {
+	ctx := callContext(L)
+	if player := replay.PlayerFromContext(ctx); player != nil {
+		recorded, err := player.Call(s.Name(), "request", subject)
+		if err != nil {
+			return nil, err
+		}
+		if recorded.Error != "" {
+			return nil, errors.New(recorded.Error)
+		}
+		var reply string
+		if err := json.Unmarshal([]byte(recorded.Result), &reply); err != nil {
+			return nil, fmt.Errorf("corrupt recording: %w", err)
+		}
+		return []byte(reply), nil
+	}
+
+	value, err := convert.ToGo(payload, convert.WithStrictTypes())
+	if err != nil {
+		return nil, err
+	}
+	reply, err := s.publisher.Request(ctx, subject, value, chainDepth(ctx), timeout)
+	if recorder := replay.RecorderFromContext(ctx); recorder != nil {
+		recorded := replay.Call{Module: s.Name(), Op: "request", Key: subject}
+		if err != nil {
+			recorded.Error = err.Error()
+		} else if raw, err := json.Marshal(string(reply)); err == nil {
+			recorded.Result = string(raw)
+		}
+		recorder.RecordCall(recorded)
+	}
+	return reply, err
+}
+
+// chainDepth returns the depth in the event chain of the execution running in ctx
+func chainDepth(ctx context.Context) int {
+	if ec, ok := execCtx.FromContext(ctx); ok {
+		return ec.ChainDepth
+	}
+	return 0
+}
+
+// Loader loads the events module into the Lua state
+func (s *EventsModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"publish": s.Publish,
+		"request": s.Request,
+	})
+	L.Push(mod)
+	return 1
+}
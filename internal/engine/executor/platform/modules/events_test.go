@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type publishedEvent struct {
+	subject string
+	payload any
+	depth   int
+	timeout time.Duration
+}
+
+// fakePublisher records events and answers requests with reply
+type fakePublisher struct {
+	events []publishedEvent
+	reply  []byte
+	err    error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, subject string, payload any, depth int) error {
+	p.events = append(p.events, publishedEvent{subject: subject, payload: payload, depth: depth})
+	return p.err
+}
+
+func (p *fakePublisher) Request(_ context.Context, subject string, payload any, depth int, timeout time.Duration) ([]byte, error) {
+	p.events = append(p.events, publishedEvent{subject: subject, payload: payload, depth: depth, timeout: timeout})
+	return p.reply, p.err
+}
+
+func TestEventsModule_Publish(t *testing.T) {
+	publisher := &fakePublisher{}
+	ctx := execCtx.WithExecution(context.Background(), &execCtx.ExecutionContext{RuleID: "rule-1", ChainDepth: 2})
+	L := newModuleState(t, ctx, NewEventsModule(publisher))
+
+	require.NoError(t, L.DoString(`ok, err = require("events").publish("events.derived.overheat", {room = "kitchen", temperature = 31})`))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("ok"))
+	assert.Equal(t, lua.LNil, L.GetGlobal("err"))
+
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, publishedEvent{
+		subject: "events.derived.overheat",
+		payload: map[string]any{"room": "kitchen", "temperature": 31.0},
+		depth:   2,
+	}, publisher.events[0])
+}
+
+func TestEventsModule_PublishErrors(t *testing.T) {
+	publisher := &fakePublisher{err: errors.New("subject is not in the publish allow-list")}
+	L := newModuleState(t, context.Background(), NewEventsModule(publisher))
+
+	require.NoError(t, L.DoString(`ok, err = require("events").publish("events.raw", {})`))
+	assert.Equal(t, lua.LNil, L.GetGlobal("ok"))
+	assert.Equal(t, "events: subject is not in the publish allow-list", L.GetGlobal("err").String())
+
+	require.NoError(t, L.DoString(`ok, err = require("events").publish("events.raw", {callback = print})`))
+	assert.Contains(t, L.GetGlobal("err").String(), "cannot convert function")
+}
+
+func TestEventsModule_Request(t *testing.T) {
+	publisher := &fakePublisher{reply: []byte(`{"state": "on", "level": 80}`)}
+	L := newModuleState(t, context.Background(), NewEventsModule(publisher))
+
+	require.NoError(t, L.DoString(`
+		local events = require("events")
+		reply, err = events.request("devices.lamp.get", {id = "lamp-1"}, 0.5)
+		state, level = reply.state, reply.level
+	`))
+	assert.Equal(t, lua.LNil, L.GetGlobal("err"))
+	assert.Equal(t, lua.LString("on"), L.GetGlobal("state"))
+	assert.Equal(t, lua.LNumber(80), L.GetGlobal("level"))
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, 500*time.Millisecond, publisher.events[0].timeout)
+
+	publisher.reply = []byte("pong")
+	require.NoError(t, L.DoString(`reply = require("events").request("devices.lamp.ping")`))
+	assert.Equal(t, lua.LString("pong"), L.GetGlobal("reply"))
+	assert.Equal(t, time.Second, publisher.events[1].timeout)
+
+	publisher.err = errors.New("no reply on devices.lamp.get within 1s")
+	require.NoError(t, L.DoString(`reply, err = require("events").request("devices.lamp.get", {})`))
+	assert.Equal(t, lua.LNil, L.GetGlobal("reply"))
+	assert.Equal(t, "events: no reply on devices.lamp.get within 1s", L.GetGlobal("err").String())
+
+	assert.Error(t, L.DoString(`require("events").request("devices.lamp.get", {}, 0)`))
+}
+
+func TestEventsModule_Replay(t *testing.T) {
+	recorder := replay.NewRecorder(&execCtx.ExecutionContext{}, 0)
+	publisher := &fakePublisher{reply: []byte(`{"state": "on"}`)}
+	script := `
+		local events = require("events")
+		published = events.publish("events.derived.overheat", {})
+		reply, err = events.request("devices.lamp.get", {})
+	`
+
+	L := newModuleState(t, replay.WithRecorder(context.Background(), recorder), NewEventsModule(publisher))
+	require.NoError(t, L.DoString(script))
+	bundle := recorder.Bundle()
+	require.Len(t, bundle.Calls, 1)
+
+	// The replay publishes nothing and gets the recorded reply
+	replayed := &fakePublisher{}
+	player := replay.NewPlayer(bundle)
+	L = newModuleState(t, replay.WithPlayer(context.Background(), player), NewEventsModule(replayed))
+	require.NoError(t, L.DoString(script))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("published"))
+	assert.Equal(t, lua.LNil, L.GetGlobal("err"))
+	assert.Equal(t, lua.LString("on"), L.GetGlobal("reply").(*lua.LTable).RawGetString("state"))
+	assert.Empty(t, player.Misses())
+	assert.Empty(t, replayed.events)
+
+	// Requests that were not recorded fail
+	require.NoError(t, L.DoString(`reply, err = require("events").request("devices.lamp.set", {})`))
+	assert.Equal(t, lua.LNil, L.GetGlobal("reply"))
+	assert.Contains(t, L.GetGlobal("err").String(), "no recorded response")
+	assert.Empty(t, replayed.events)
+}
@@ -2,7 +2,7 @@ package modules
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,9 +10,21 @@ import (
 	"strings"
 	"time"
 
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/egress"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/pkg/tracing"
 	lua "github.com/yuin/gopher-lua"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+// DefaultHTTPMaxResponseBytes is the size above which response bodies are refused
+const DefaultHTTPMaxResponseBytes = 1 << 20
+
 // HTTPMethod represents an HTTP method
 type HTTPMethod string
 
@@ -27,7 +39,10 @@ const (
 
 // HTTPModule provides functions that Lua scripts can call
 type HTTPModule struct {
-	client *http.Client
+	client           *http.Client
+	policy           *egress.Policy
+	quota            *egress.Quota
+	maxResponseBytes int64
 }
 
 // HTTPModuleOption allows to configure the HTTP module
@@ -41,12 +56,43 @@ func WithHTTPClient(client *http.Client) HTTPModuleOption {
 	}
 }
 
+// WithHTTPPolicy restricts the hosts requests may reach. The transport and the
+// redirect checks of the client are replaced by the ones of the policy.
+func WithHTTPPolicy(policy *egress.Policy) HTTPModuleOption {
+	return func(hm *HTTPModule) *HTTPModule {
+		hm.policy = policy
+		return hm
+	}
+}
+
+// WithHTTPRuleQuota limits the requests each rule makes
+func WithHTTPRuleQuota(quota *egress.Quota) HTTPModuleOption {
+	return func(hm *HTTPModule) *HTTPModule {
+		hm.quota = quota
+		return hm
+	}
+}
+
+// WithHTTPMaxResponseBytes sets the size above which response bodies are refused
+func WithHTTPMaxResponseBytes(limit int64) HTTPModuleOption {
+	return func(hm *HTTPModule) *HTTPModule {
+		hm.maxResponseBytes = limit
+		return hm
+	}
+}
+
 // NewHTTPModule creates a new HTTP API service
 func NewHTTPModule(opts ...HTTPModuleOption) *HTTPModule {
-	hm := &HTTPModule{client: &http.Client{Timeout: 5 * time.Second}}
+	hm := &HTTPModule{
+		client:           &http.Client{Timeout: 5 * time.Second},
+		maxResponseBytes: DefaultHTTPMaxResponseBytes,
+	}
 	for _, opt := range opts {
 		opt(hm)
 	}
+	if hm.policy != nil {
+		hm.client = hm.policy.Client(hm.client.Timeout)
+	}
 	return hm
 }
 
@@ -67,21 +113,14 @@ func (s *HTTPModule) Get(L *lua.LState) int {
 		})
 	}
 
-	ctx := context.Background()
-
-	result, err := s.MakeHTTPRequest(ctx, HTTPMethodGet, url, headers, "")
+	result, err := s.request(L, HTTPMethodGet, url, headers, "")
 	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
 
-	table := L.NewTable()
-	for k, v := range result {
-		L.SetField(table, k, luaValueFromGo(L, v))
-	}
-
-	L.Push(table)
+	L.Push(convert.FromGo(L, result))
 	L.Push(lua.LNil)
 	return 2
 }
@@ -99,21 +138,14 @@ func (s *HTTPModule) Post(L *lua.LState) int {
 		})
 	}
 
-	ctx := context.Background()
-
-	result, err := s.MakeHTTPRequest(ctx, HTTPMethodPost, url, headers, body)
+	result, err := s.request(L, HTTPMethodPost, url, headers, body)
 	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
 
-	table := L.NewTable()
-	for k, v := range result {
-		L.SetField(table, k, luaValueFromGo(L, v))
-	}
-
-	L.Push(table)
+	L.Push(convert.FromGo(L, result))
 	L.Push(lua.LNil)
 	return 2
 }
@@ -130,21 +162,14 @@ func (s *HTTPModule) Delete(L *lua.LState) int {
 		})
 	}
 
-	ctx := context.Background()
-
-	result, err := s.MakeHTTPRequest(ctx, HTTPMethodDelete, url, headers, "")
+	result, err := s.request(L, HTTPMethodDelete, url, headers, "")
 	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
 
-	table := L.NewTable()
-	for k, v := range result {
-		L.SetField(table, k, luaValueFromGo(L, v))
-	}
-
-	L.Push(table)
+	L.Push(convert.FromGo(L, result))
 	L.Push(lua.LNil)
 	return 2
 }
@@ -162,21 +187,14 @@ func (s *HTTPModule) Put(L *lua.LState) int {
 		})
 	}
 
-	ctx := context.Background()
-
-	result, err := s.MakeHTTPRequest(ctx, HTTPMethodPut, url, headers, body)
+	result, err := s.request(L, HTTPMethodPut, url, headers, body)
 	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
 
-	table := L.NewTable()
-	for k, v := range result {
-		L.SetField(table, k, luaValueFromGo(L, v))
-	}
-
-	L.Push(table)
+	L.Push(convert.FromGo(L, result))
 	L.Push(lua.LNil)
 	return 2
 }
@@ -194,32 +212,95 @@ func (s *HTTPModule) Patch(L *lua.LState) int {
 		})
 	}
 
-	ctx := context.Background()
-
-	result, err := s.MakeHTTPRequest(ctx, HTTPMethodPatch, url, headers, body)
+	result, err := s.request(L, HTTPMethodPatch, url, headers, body)
 	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
 	}
 
-	table := L.NewTable()
-	for k, v := range result {
-		L.SetField(table, k, luaValueFromGo(L, v))
-	}
-
-	L.Push(table)
+	L.Push(convert.FromGo(L, result))
 	L.Push(lua.LNil)
 	return 2
 }
 
+// request makes an HTTP request on behalf of the script running in L, counted
+// against the quota of its rule. When the execution is replayed the recorded
+// outcome is returned instead, and when it is recorded the outcome is added
+// to the recording.
+func (s *HTTPModule) request(
+	L *lua.LState,
+	method HTTPMethod,
+	url string,
+	headers map[string]string,
+	body string,
+) (map[string]any, error) {
+	ctx := callContext(L)
+	if player := replay.PlayerFromContext(ctx); player != nil {
+		exchange, err := player.HTTP(string(method), url)
+		if err != nil {
+			return nil, err
+		}
+		if exchange.Error != "" {
+			return nil, errors.New(exchange.Error)
+		}
+		return map[string]any{"status": exchange.Status, "body": exchange.Body}, nil
+	}
+
+	var result map[string]any
+	err := s.allow(ctx)
+	if err == nil {
+		result, err = s.MakeHTTPRequest(ctx, method, url, headers, body)
+	}
+	if recorder := replay.RecorderFromContext(ctx); recorder != nil {
+		exchange := replay.HTTPExchange{Method: string(method), URL: url}
+		if err != nil {
+			exchange.Error = err.Error()
+		} else {
+			exchange.Status, _ = result["status"].(int)
+			exchange.Body, _ = result["body"].(string)
+		}
+		recorder.RecordHTTP(exchange)
+	}
+	return result, err
+}
+
+// allow counts a request against the quota of the rule carried by ctx.
+// Ad-hoc evaluations and debug sessions run as the "evaluate" and "debug"
+// rules, so each of them shares one quota. Requests made outside of an
+// execution are not counted.
+func (s *HTTPModule) allow(ctx context.Context) error {
+	if s.quota == nil {
+		return nil
+	}
+	ec, ok := execCtx.FromContext(ctx)
+	if !ok || ec.RuleID == "" {
+		return nil
+	}
+	return s.quota.Allow(ec.RuleID)
+}
+
+// MakeHTTPRequest performs an HTTP request and returns its status and body.
+// The request is canceled with ctx and traced as a child of its span, whose
+// trace context is propagated to the server.
 func (s *HTTPModule) MakeHTTPRequest(
 	ctx context.Context,
 	method HTTPMethod,
 	url string,
 	headers map[string]string,
 	body string,
-) (map[string]any, error) {
+) (result map[string]any, err error) {
+	ctx, span := tracing.StartSpan(ctx, "http.request")
+	defer func() {
+		if err != nil {
+			// Errors quote the URL, which may hold a secret
+			message := redact.String(ctx, err.Error())
+			span.RecordError(errors.New(message))
+			span.SetStatus(codes.Error, message)
+		}
+		span.End()
+	}()
+
 	var bodyReader io.Reader
 	if body != "" {
 		bodyReader = strings.NewReader(body)
@@ -228,9 +309,20 @@ func (s *HTTPModule) MakeHTTPRequest(
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(
+		attribute.String("http.request.method", string(method)),
+		attribute.String("server.address", req.URL.Hostname()),
+	)
+	if s.policy != nil {
+		if err := s.policy.CheckURL(req.URL); err != nil {
+			return nil, err
+		}
+	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -240,10 +332,19 @@ func (s *HTTPModule) MakeHTTPRequest(
 			slog.Error("Failed to close HTTP response body", "error", err)
 		}
 	}()
-	respBody, err := io.ReadAll(resp.Body)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	var reader io.Reader = resp.Body
+	if s.maxResponseBytes > 0 {
+		reader = io.LimitReader(resp.Body, s.maxResponseBytes+1)
+	}
+	respBody, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
+	if s.maxResponseBytes > 0 && int64(len(respBody)) > s.maxResponseBytes {
+		return nil, fmt.Errorf("response body exceeds %d bytes", s.maxResponseBytes)
+	}
 	return map[string]any{
 		"status": resp.StatusCode,
 		"body":   string(respBody),
@@ -264,43 +365,3 @@ func (s *HTTPModule) Loader(L *lua.LState) int {
 	L.Push(mod)
 	return 1
 }
-
-// luaValueFromGo converts a Go value to a Lua value
-func luaValueFromGo(L *lua.LState, v any) lua.LValue {
-	switch val := v.(type) {
-	case nil:
-		return lua.LNil
-	case bool:
-		return lua.LBool(val)
-	case int:
-		return lua.LNumber(val)
-	case int32:
-		return lua.LNumber(val)
-	case int64:
-		return lua.LNumber(val)
-	case float32:
-		return lua.LNumber(val)
-	case float64:
-		return lua.LNumber(val)
-	case string:
-		return lua.LString(val)
-	case map[string]any:
-		table := L.NewTable()
-		for k, v := range val {
-			L.SetField(table, k, luaValueFromGo(L, v))
-		}
-		return table
-	case []any:
-		table := L.NewTable()
-		for i, v := range val {
-			table.Insert(i+1, luaValueFromGo(L, v))
-		}
-		return table
-	default:
-		// For complex types, convert to JSON string
-		if jsonBytes, err := json.Marshal(v); err == nil {
-			return lua.LString(jsonBytes)
-		}
-		return lua.LString(fmt.Sprintf("%v", v))
-	}
-}
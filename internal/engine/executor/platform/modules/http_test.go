@@ -2,12 +2,17 @@ package modules
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/egress"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -346,3 +351,137 @@ func TestHTTPModuleLoader(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	mod := NewHTTPModule(WithHTTPPolicy(egress.NewPolicy()))
+	_, err := mod.MakeHTTPRequest(context.Background(), HTTPMethodGet, server.URL, nil, "")
+	if !errors.Is(err, egress.ErrDenied) {
+		t.Fatalf("Expected the loopback server to be denied, got %v", err)
+	}
+
+	_, err = mod.MakeHTTPRequest(context.Background(), HTTPMethodGet, "ftp://example.com/file", nil, "")
+	if !errors.Is(err, egress.ErrDenied) {
+		t.Fatalf("Expected the ftp scheme to be denied, got %v", err)
+	}
+}
+
+func TestHTTPMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 11)))
+	}))
+	defer server.Close()
+
+	mod := NewHTTPModule(WithHTTPMaxResponseBytes(10))
+	_, err := mod.MakeHTTPRequest(context.Background(), HTTPMethodGet, server.URL, nil, "")
+	if err == nil || err.Error() != "response body exceeds 10 bytes" {
+		t.Fatalf("Expected the response to be refused, got %v", err)
+	}
+
+	mod = NewHTTPModule(WithHTTPMaxResponseBytes(11))
+	result, err := mod.MakeHTTPRequest(context.Background(), HTTPMethodGet, server.URL, nil, "")
+	if err != nil {
+		t.Fatalf("MakeHTTPRequest failed: %v", err)
+	}
+	if result["body"] != strings.Repeat("x", 11) {
+		t.Errorf("Expected the whole body, got %v", result["body"])
+	}
+}
+
+func TestHTTPRuleQuota(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	mod := NewHTTPModule(WithHTTPRuleQuota(egress.NewQuota(1, time.Minute)))
+	L := lua.NewState()
+	defer L.Close()
+	L.SetContext(execCtx.WithExecution(context.Background(), &execCtx.ExecutionContext{RuleID: "rule-1"}))
+	L.PreloadModule(mod.Name(), mod.Loader)
+
+	err := L.DoString(`
+		local http = require("http")
+		first, first_err = http.get("` + server.URL + `")
+		second, second_err = http.get("` + server.URL + `")
+	`)
+	if err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if L.GetGlobal("first_err") != lua.LNil {
+		t.Errorf("Expected the first request to succeed, got %v", L.GetGlobal("first_err"))
+	}
+	if msg := L.GetGlobal("second_err").String(); !strings.HasPrefix(msg, "request quota exceeded") {
+		t.Errorf("Expected the second request to exceed the quota, got %s", msg)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request to reach the server, got %d", requests)
+	}
+}
+
+func TestHTTPRuleQuota_SharedByEvaluations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Evaluations run as one rule, so they draw from a single quota
+	mod := NewHTTPModule(WithHTTPRuleQuota(egress.NewQuota(1, time.Minute)))
+	evaluation := execCtx.WithExecution(context.Background(), &execCtx.ExecutionContext{RuleID: "evaluate"})
+	script := `_, err = require("http").get("` + server.URL + `")`
+
+	first := newModuleState(t, evaluation, mod)
+	if err := first.DoString(script); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if first.GetGlobal("err") != lua.LNil {
+		t.Errorf("Expected the first evaluation to reach the server, got %v", first.GetGlobal("err"))
+	}
+	second := newModuleState(t, evaluation, mod)
+	if err := second.DoString(script); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if msg := second.GetGlobal("err").String(); !strings.HasPrefix(msg, "request quota exceeded") {
+		t.Errorf("Expected the second evaluation to exceed the quota, got %s", msg)
+	}
+
+	// Requests made outside of an execution are not counted
+	outside := newModuleState(t, context.Background(), mod)
+	if err := outside.DoString(script); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if outside.GetGlobal("err") != lua.LNil {
+		t.Errorf("Expected requests outside of executions not to be counted, got %v", outside.GetGlobal("err"))
+	}
+}
+
+func TestHTTPContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	// Canceling the execution cancels the requests of its script
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	mod := NewHTTPModule()
+	L := lua.NewState()
+	defer L.Close()
+	L.SetContext(ctx)
+	L.PreloadModule(mod.Name(), mod.Loader)
+
+	start := time.Now()
+	err := L.DoString(`_, err = require("http").get("` + server.URL + `")`)
+	if err == nil {
+		t.Fatal("Expected the script to be interrupted")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to be canceled, took %s", elapsed)
+	}
+}
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// defaultJSONIndent is the indentation of json.pretty when none is given
+const defaultJSONIndent = "  "
+
+// JSONModule provides functions that Lua scripts can call to encode and
+// decode JSON. Values go through the same conversions as the data the
+// engine exchanges with scripts, extended with two markers:
+//
+//   - json.null stands for a JSON null, so that nulls inside arrays and
+//     objects survive a decode and encode round trip
+//   - tables made with json.array, and the arrays json.decode returns,
+//     encode as arrays even when empty
+type JSONModule struct {
+}
+
+// NewJSONModule creates a new JSONModule
+func NewJSONModule() *JSONModule {
+	return &JSONModule{}
+}
+
+// Name returns the name of the module
+func (s *JSONModule) Name() string {
+	return "json"
+}
+
+// jsonCodec holds the markers of the json module loaded into one Lua state
+type jsonCodec struct {
+	null      *lua.LUserData
+	arrayMeta *lua.LTable
+}
+
+func (c *jsonCodec) options() []convert.Option {
+	return []convert.Option{
+		convert.WithNull(c.null),
+		convert.WithArrayMetatable(c.arrayMeta),
+		convert.WithStrictTypes(),
+	}
+}
+
+// Encode returns the compact JSON encoding of a value
+func (c *jsonCodec) Encode(L *lua.LState) int {
+	return c.encode(L, L.Get(1), "")
+}
+
+// Pretty returns the indented JSON encoding of a value, indented with
+// the optional indent string, two spaces by default
+func (c *jsonCodec) Pretty(L *lua.LState) int {
+	value := L.Get(1)
+	indent := L.OptString(2, defaultJSONIndent)
+	return c.encode(L, value, indent)
+}
+
+func (c *jsonCodec) encode(L *lua.LState, value lua.LValue, indent string) int {
+	encoded, err := c.marshal(value, indent)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LString(encoded))
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (c *jsonCodec) marshal(value lua.LValue, indent string) (string, error) {
+	v, err := convert.ToGo(value, c.options()...)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Scripts build payloads for APIs rather than HTML pages
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Decode parses a JSON document. Objects become tables, arrays become
+// sequences and nulls become json.null.
+func (c *jsonCodec) Decode(L *lua.LState) int {
+	data := L.CheckString(1)
+
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(convert.FromGo(L, v, c.options()...))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Array marks a table, a new one when none is given, to be encoded as an array
+func (c *jsonCodec) Array(L *lua.LState) int {
+	tbl := L.OptTable(1, L.NewTable())
+	tbl.Metatable = c.arrayMeta
+	L.Push(tbl)
+	return 1
+}
+
+// Loader loads the JSON module into the Lua state
+func (s *JSONModule) Loader(L *lua.LState) int {
+	codec := &jsonCodec{null: L.NewUserData(), arrayMeta: L.NewTable()}
+	codec.arrayMeta.RawSetString("__name", lua.LString("json.array"))
+	nullMeta := L.NewTable()
+	nullMeta.RawSetString("__tostring", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString("null"))
+		return 1
+	}))
+	codec.null.Metatable = nullMeta
+
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"encode": codec.Encode,
+		"pretty": codec.Pretty,
+		"decode": codec.Decode,
+		"array":  codec.Array,
+	})
+	L.SetField(mod, "null", codec.null)
+
+	L.Push(mod)
+	return 1
+}
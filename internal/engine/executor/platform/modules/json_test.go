@@ -0,0 +1,153 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestJSONModule_Encode(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		{"string", `return json.encode("a \"quoted\" <tag> & é")`, `"a \"quoted\" <tag> & é"`},
+		{"number", `return json.encode(21.5)`, `21.5`},
+		{"integer", `return json.encode(3)`, `3`},
+		{"boolean", `return json.encode(false)`, `false`},
+		{"nil", `return json.encode(nil)`, `null`},
+		{"null", `return json.encode(json.null)`, `null`},
+		{"array", `return json.encode({3, 1, 2})`, `[3,1,2]`},
+		{"object keys sorted", `return json.encode({b = 1, a = 2})`, `{"a":2,"b":1}`},
+		{"empty table", `return json.encode({})`, `{}`},
+		{"empty array", `return json.encode(json.array())`, `[]`},
+		{"marked array", `return json.encode(json.array({"x"}))`, `["x"]`},
+		{"null in array", `return json.encode({1, json.null, 3})`, `[1,null,3]`},
+		{"null field", `return json.encode({value = json.null})`, `{"value":null}`},
+		{"nested", `return json.encode({device = {id = "t1", tags = {"a"}, readings = {{v = 1}, {v = 2}}}})`,
+			`{"device":{"id":"t1","readings":[{"v":1},{"v":2}],"tags":["a"]}}`},
+		{"sparse array becomes object", `return json.encode({[1] = "a", [3] = "c"})`, `{"1":"a","3":"c"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, L.DoString(tt.script))
+			encoded, errValue := L.Get(-2), L.Get(-1)
+			L.Pop(2)
+			assert.Equal(t, lua.LNil, errValue)
+			assert.Equal(t, tt.expected, encoded.String())
+		})
+	}
+}
+
+func TestJSONModule_Pretty(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	require.NoError(t, L.DoString(`
+		compact = json.pretty({name = "t1", values = {1, 2}})
+		tabs = json.pretty({1}, "\t")
+	`))
+	assert.Equal(t, "{\n  \"name\": \"t1\",\n  \"values\": [\n    1,\n    2\n  ]\n}", L.GetGlobal("compact").String())
+	assert.Equal(t, "[\n\t1\n]", L.GetGlobal("tabs").String())
+}
+
+func TestJSONModule_EncodeErrors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	tests := []struct {
+		name    string
+		script  string
+		message string
+	}{
+		{"function", `return json.encode({callback = print})`, "cannot convert function at callback"},
+		{"cyclic", `local t = {} t.self = t return json.encode(t)`, "cannot convert cyclic table at self"},
+		{"infinity", `return json.encode(1/0)`, "unsupported value"},
+		{"nan", `return json.encode({value = 0/0})`, "unsupported value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, L.DoString(tt.script))
+			encoded, errValue := L.Get(-2), L.Get(-1)
+			L.Pop(2)
+			assert.Equal(t, lua.LNil, encoded)
+			assert.Contains(t, errValue.String(), tt.message)
+		})
+	}
+}
+
+func TestJSONModule_Decode(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	err := L.DoString(`
+		local payload = json.decode([[{
+			"device": {"id": "t1", "online": true, "battery": null},
+			"readings": [21.5, null, 23],
+			"tags": [],
+			"meta": {},
+			"name": "café 😀"
+		}]])
+		assert(payload.device.id == "t1" and payload.device.online == true)
+		assert(payload.device.battery == json.null)
+		assert(tostring(json.null) == "null")
+		assert(#payload.readings == 3 and payload.readings[2] == json.null and payload.readings[3] == 23)
+		assert(next(payload.tags) == nil and next(payload.meta) == nil)
+		assert(payload.name == "café 😀")
+
+		-- Arrays stay arrays, even empty, and objects stay objects
+		assert(json.encode(payload.tags) == "[]")
+		assert(json.encode(payload.meta) == "{}")
+		assert(json.encode(payload.readings) == "[21.5,null,23]")
+
+		assert(json.decode("null") == json.null)
+		assert(json.decode("  42 ") == 42)
+		assert(json.decode('"text"') == "text")
+	`)
+	require.NoError(t, err)
+}
+
+func TestJSONModule_DecodeErrors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	for _, input := range []string{``, `{`, `{"a": 1,}`, `[1] [2]`, `{'a': 1}`, `nul`} {
+		t.Run(input, func(t *testing.T) {
+			L.SetGlobal("input", lua.LString(input))
+			require.NoError(t, L.DoString(`value, err = json.decode(input)`))
+			assert.Equal(t, lua.LNil, L.GetGlobal("value"))
+			assert.NotEqual(t, lua.LNil, L.GetGlobal("err"))
+		})
+	}
+}
+
+// The module agrees with the conversions the engine applies to event payloads
+// and script results, apart from the null and array markers
+func TestJSONModule_RoundTripsWithConvert(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewJSONModule())
+
+	document := `{"event":{"id":"evt-1","values":[1,2.5,-3],"nested":{"ok":true,"labels":["a","b"]}},"count":0}`
+	var payload any
+	require.NoError(t, json.Unmarshal([]byte(document), &payload))
+
+	// A payload converted by the engine encodes to the same document
+	L.SetGlobal("payload", convert.FromGo(L, payload))
+	require.NoError(t, L.DoString(`encoded = json.encode(payload)`))
+	assert.JSONEq(t, document, L.GetGlobal("encoded").String())
+
+	// A decoded document converts back to the same payload
+	require.NoError(t, L.DoString(`decoded = json.decode(encoded)`))
+	result, err := convert.ToGo(L.GetGlobal("decoded"))
+	require.NoError(t, err)
+	assert.Equal(t, payload, result)
+
+	// Decoding then encoding preserves the document
+	require.NoError(t, L.DoString(`again = json.encode(json.decode(encoded))`))
+	assert.Equal(t, L.GetGlobal("encoded").String(), L.GetGlobal("again").String())
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -62,8 +64,7 @@ func (s *LoggerModule) Info(L *lua.LState) int {
 	message := L.ToString(1)
 	L.Pop(1)
 
-	ctx := context.Background()
-	s.LogMessage(ctx, "info", message)
+	s.LogMessage(L.Context(), "info", message)
 
 	return 0
 }
@@ -73,8 +74,7 @@ func (s *LoggerModule) Debug(L *lua.LState) int {
 	message := L.ToString(1)
 	L.Pop(1)
 
-	ctx := context.Background()
-	s.LogMessage(ctx, "debug", message)
+	s.LogMessage(L.Context(), "debug", message)
 
 	return 0
 }
@@ -84,8 +84,7 @@ func (s *LoggerModule) Warn(L *lua.LState) int {
 	message := L.ToString(1)
 	L.Pop(1)
 
-	ctx := context.Background()
-	s.LogMessage(ctx, "warn", message)
+	s.LogMessage(L.Context(), "warn", message)
 
 	return 0
 }
@@ -95,14 +94,16 @@ func (s *LoggerModule) Error(L *lua.LState) int {
 	message := L.ToString(1)
 	L.Pop(1)
 
-	ctx := context.Background()
-	s.LogMessage(ctx, "error", message)
+	s.LogMessage(L.Context(), "error", message)
 
 	return 0
 }
 
-// LogMessage logs a message
+// LogMessage logs a message and captures it into the execution logs carried by ctx
 func (s *LoggerModule) LogMessage(ctx context.Context, level LogLevel, message string) {
+	message = redact.String(ctx, message)
+	scriptlog.Add(ctx, string(level), message)
+
 	switch level {
 	case LogLevelDebug:
 		s.logger.Debug("Lua script message", "message", message)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
 	"github.com/stretchr/testify/require"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -238,3 +239,29 @@ func TestError(t *testing.T) {
 		t.Errorf("Expected error level with message '%s', got level='%s' message='%s'", message, call.level, call.args[1])
 	}
 }
+
+func TestLoggerCapturesIntoCollector(t *testing.T) {
+	mock := &mockLogger{}
+	mod := NewLoggerModule(WithLogger(mock))
+	L := lua.NewState()
+	defer L.Close()
+
+	collector := scriptlog.NewCollector(0, 0)
+	L.SetContext(scriptlog.WithCollector(context.Background(), collector))
+	L.PreloadModule("logger", mod.Loader)
+
+	err := L.DoString(`
+		local logger = require 'logger'
+		logger.info('first')
+		logger.error('second')
+	`)
+	require.NoError(t, err)
+
+	entries := collector.Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "info", entries[0].Level)
+	require.Equal(t, "first", entries[0].Message)
+	require.Equal(t, "error", entries[1].Level)
+	require.Equal(t, "second", entries[1].Message)
+	require.Len(t, mock.calls, 2)
+}
@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"context"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// module is a platform module as the platform service preloads it
+type module interface {
+	Name() string
+	Loader(L *lua.LState) int
+}
+
+// ruleContext returns a context running as the rule ruleID
+func ruleContext(ruleID string) context.Context {
+	return execCtx.WithExecution(context.Background(), &execCtx.ExecutionContext{RuleID: ruleID})
+}
+
+// newModuleState returns a Lua state running in ctx with mod preloaded and
+// also loaded as the global of its name. The state is closed with the test.
+func newModuleState(t *testing.T, ctx context.Context, mod module) *lua.LState {
+	t.Helper()
+	L := lua.NewState()
+	t.Cleanup(L.Close)
+	L.SetContext(ctx)
+	L.PreloadModule(mod.Name(), mod.Loader)
+	require.NoError(t, L.DoString(mod.Name()+` = require("`+mod.Name()+`")`))
+	return L
+}
+
+// evalScript runs script in L and returns the values it returns
+func evalScript(t *testing.T, L *lua.LState, script string) []lua.LValue {
+	t.Helper()
+	top := L.GetTop()
+	require.NoError(t, L.DoString(script))
+	results := make([]lua.LValue, 0, L.GetTop()-top)
+	for i := top + 1; i <= L.GetTop(); i++ {
+		results = append(results, L.Get(i))
+	}
+	L.SetTop(top)
+	return results
+}
+
+// runModuleScript runs script in a fresh Lua state running in ctx with mod
+// preloaded, and returns the values it returns
+func runModuleScript(t *testing.T, ctx context.Context, mod module, script string) []lua.LValue {
+	t.Helper()
+	return evalScript(t, newModuleState(t, ctx, mod), script)
+}
@@ -0,0 +1,142 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/notify"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Notifier sends notifications through the channels configured by the operator
+type Notifier interface {
+	Send(ctx context.Context, channel string, msg notify.Message) error
+}
+
+// NotifyModule lets scripts send notifications by email, chat or SMS through
+// named channels, without access to their credentials. The recipients, the
+// subject and the body may hold placeholders such as {{event.payload.room}},
+// rendered from the context of the execution and the optional data table.
+type NotifyModule struct {
+	notifier Notifier
+}
+
+// NewNotifyModule creates a new NotifyModule on top of notifier
+func NewNotifyModule(notifier Notifier) *NotifyModule {
+	return &NotifyModule{notifier: notifier}
+}
+
+// Name returns the name of the module
+func (s *NotifyModule) Name() string {
+	return "notify"
+}
+
+// Send sends a message through a channel. The message is either its body or a
+// table with a body and optionally to, a recipient or a list of them, and a
+// subject. Placeholders are rendered from the execution and the optional data
+// table, whose keys are added to the top level.
+func (s *NotifyModule) Send(L *lua.LState) int {
+	channel := L.CheckString(1)
+	params := &notify.ActionParams{Channel: channel}
+	switch message := L.CheckAny(2).(type) {
+	case lua.LString:
+		params.Body = string(message)
+	case *lua.LTable:
+		params.To = checkRecipients(L, message.RawGetString("to"))
+		params.Subject = checkOptString(L, message, "subject")
+		params.Body = checkOptString(L, message, "body")
+	default:
+		L.ArgError(2, "message must be a string or a table")
+	}
+	data := L.OptTable(3, nil)
+
+	err := s.send(L, params, data)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("notify: " + err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *NotifyModule) send(L *lua.LState, params *notify.ActionParams, data *lua.LTable) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	ctx := callContext(L)
+	fields := map[string]any{}
+	if ec, ok := execCtx.FromContext(ctx); ok {
+		fields = ec.Fields()
+	}
+	if data != nil {
+		value, err := convert.ToGo(data, convert.WithStrictTypes())
+		if err != nil {
+			return fmt.Errorf("data: %w", err)
+		}
+		extra, ok := value.(map[string]any)
+		if !ok {
+			return errors.New("data must be a table with named fields")
+		}
+		for key, v := range extra {
+			fields[key] = v
+		}
+	}
+	msg, err := params.Render(fields)
+	if err != nil {
+		return err
+	}
+	// Replays must not notify anyone again
+	if replay.PlayerFromContext(ctx) != nil {
+		return nil
+	}
+	return s.notifier.Send(ctx, params.Channel, msg)
+}
+
+// checkRecipients reads to, a recipient or a list of them
+func checkRecipients(L *lua.LState, to lua.LValue) []string {
+	switch to := to.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LString:
+		return []string{string(to)}
+	case *lua.LTable:
+		recipients := make([]string, 0, to.Len())
+		for i := 1; i <= to.Len(); i++ {
+			recipient, ok := to.RawGetInt(i).(lua.LString)
+			if !ok {
+				L.ArgError(2, "to must be a string or a list of strings")
+			}
+			recipients = append(recipients, string(recipient))
+		}
+		return recipients
+	}
+	L.ArgError(2, "to must be a string or a list of strings")
+	return nil
+}
+
+// checkOptString reads the optional string field name of t
+func checkOptString(L *lua.LState, t *lua.LTable, name string) string {
+	switch value := t.RawGetString(name).(type) {
+	case *lua.LNilType:
+		return ""
+	case lua.LString:
+		return string(value)
+	}
+	L.ArgError(2, name+" must be a string")
+	return ""
+}
+
+// Loader loads the notify module into the Lua state
+func (s *NotifyModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"send": s.Send,
+	})
+	L.Push(mod)
+	return 1
+}
@@ -0,0 +1,98 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type sentNotification struct {
+	channel string
+	msg     notify.Message
+}
+
+// fakeNotifier records the notifications it sends
+type fakeNotifier struct {
+	sent []sentNotification
+	err  error
+}
+
+func (n *fakeNotifier) Send(_ context.Context, channel string, msg notify.Message) error {
+	n.sent = append(n.sent, sentNotification{channel: channel, msg: msg})
+	return n.err
+}
+
+func TestNotifyModule_Send(t *testing.T) {
+	notifier := &fakeNotifier{}
+	ctx := execCtx.WithExecution(context.Background(), &execCtx.ExecutionContext{
+		RuleID: "rule-1",
+		Event:  map[string]any{"room": "kitchen"},
+	})
+	L := newModuleState(t, ctx, NewNotifyModule(notifier))
+
+	require.NoError(t, L.DoString(`
+		local notify = require("notify")
+		ok1, err1 = notify.send("slack", "Rule {{rule.id}} fired")
+		ok2, err2 = notify.send("email", {
+			to = {"ops@example.com", "{{owner}}"},
+			subject = "Too hot in the {{event.payload.room}}",
+			body = "It is {{temperature}}°C",
+		}, {owner = "ann@example.com", temperature = 31})
+		ok3, err3 = notify.send("sms", {to = "+15550100", body = "hello"})
+	`))
+	for _, name := range []string{"ok1", "ok2", "ok3"} {
+		assert.Equal(t, lua.LTrue, L.GetGlobal(name), name)
+	}
+
+	assert.Equal(t, []sentNotification{
+		{channel: "slack", msg: notify.Message{Body: "Rule rule-1 fired"}},
+		{channel: "email", msg: notify.Message{
+			To:      []string{"ops@example.com", "ann@example.com"},
+			Subject: "Too hot in the kitchen",
+			Body:    "It is 31°C",
+		}},
+		{channel: "sms", msg: notify.Message{To: []string{"+15550100"}, Body: "hello"}},
+	}, notifier.sent)
+}
+
+func TestNotifyModule_Errors(t *testing.T) {
+	notifier := &fakeNotifier{err: errors.New("slack: unexpected status 500")}
+	L := newModuleState(t, context.Background(), NewNotifyModule(notifier))
+
+	require.NoError(t, L.DoString(`
+		local notify = require("notify")
+		ok1, err1 = notify.send("slack", "hello")
+		ok2, err2 = notify.send("slack", {subject = "no body"})
+		ok3, err3 = notify.send("slack", "{{broken")
+	`))
+	assert.Equal(t, lua.LNil, L.GetGlobal("ok1"))
+	assert.Equal(t, lua.LString("notify: slack: unexpected status 500"), L.GetGlobal("err1"))
+	assert.Equal(t, lua.LString("notify: body is required"), L.GetGlobal("err2"))
+	assert.Contains(t, L.GetGlobal("err3").String(), "notify: body:")
+	assert.Len(t, notifier.sent, 1)
+
+	err := L.DoString(`require("notify").send("slack", {to = {1, 2}, body = "hello"})`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "to must be a string or a list of strings")
+
+	err = L.DoString(`require("notify").send("slack", 42)`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "message must be a string or a table")
+}
+
+func TestNotifyModule_Replay(t *testing.T) {
+	notifier := &fakeNotifier{}
+	player := replay.NewPlayer(&replay.Bundle{})
+	L := newModuleState(t, replay.WithPlayer(context.Background(), player), NewNotifyModule(notifier))
+
+	require.NoError(t, L.DoString(`ok, err = require("notify").send("slack", "hello")`))
+	assert.Equal(t, lua.LTrue, L.GetGlobal("ok"))
+	assert.Empty(t, notifier.sent)
+}
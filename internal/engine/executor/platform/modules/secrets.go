@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// SecretResolver returns the value of a secret for a script of a rule
+type SecretResolver interface {
+	Resolve(ctx context.Context, ruleID, name string) (string, error)
+}
+
+// SecretsModule lets scripts read the secrets granted to their rule.
+// The values handed out are redacted from the logs, errors, output and
+// replay recordings of the execution. They are never recorded, and replays
+// cannot read them: a replayed script may transform a value past redaction
+// and hand it back in the replay response.
+type SecretsModule struct {
+	resolver SecretResolver
+}
+
+// NewSecretsModule creates a new SecretsModule on top of resolver
+func NewSecretsModule(resolver SecretResolver) *SecretsModule {
+	return &SecretsModule{resolver: resolver}
+}
+
+// Name returns the name of the module
+func (s *SecretsModule) Name() string {
+	return "secrets"
+}
+
+// Get returns the value of a secret
+func (s *SecretsModule) Get(L *lua.LState) int {
+	name := L.CheckString(1)
+	value, err := s.get(callContext(L), name)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LString(value))
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *SecretsModule) get(ctx context.Context, name string) (string, error) {
+	if replay.PlayerFromContext(ctx) != nil {
+		return "", errors.New("secrets: not available in replayed executions")
+	}
+	ec, ok := execCtx.FromContext(ctx)
+	if !ok || ec.RuleID == "" {
+		return "", errors.New("secrets: only available to rule executions")
+	}
+	value, err := s.resolver.Resolve(ctx, ec.RuleID, name)
+	switch {
+	case errors.Is(err, secretStorage.ErrNotFound):
+		return "", fmt.Errorf("secrets: '%s' not found", name)
+	case err != nil:
+		return "", fmt.Errorf("secrets: %w", err)
+	}
+	if set := redact.FromContext(ctx); set != nil {
+		set.Add(value)
+	}
+	return value, nil
+}
+
+// Loader loads the secrets module into the Lua state
+func (s *SecretsModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"get": s.Get,
+	})
+	L.Push(mod)
+	return 1
+}
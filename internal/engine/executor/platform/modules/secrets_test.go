@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// fakeSecretResolver grants secrets per rule
+type fakeSecretResolver map[string]map[string]string
+
+func (f fakeSecretResolver) Resolve(_ context.Context, ruleID, name string) (string, error) {
+	if name == "broken" {
+		return "", errors.New("secret cannot be decrypted")
+	}
+	value, ok := f[ruleID][name]
+	if !ok {
+		return "", secretStorage.ErrNotFound
+	}
+	return value, nil
+}
+
+// secretsModule returns a secrets module granting api_token to rule-1
+func secretsModule() *SecretsModule {
+	return NewSecretsModule(fakeSecretResolver{
+		"rule-1": {"api_token": "tok-123456"},
+	})
+}
+
+func TestSecretsModule(t *testing.T) {
+	set := redact.NewSet()
+	ctx := redact.WithSet(ruleContext("rule-1"), set)
+
+	results := runModuleScript(t, ctx, secretsModule(), `return require("secrets").get("api_token")`)
+	assert.Equal(t, []lua.LValue{lua.LString("tok-123456"), lua.LNil}, results)
+
+	// The value is redacted from what is kept of the execution
+	assert.Equal(t, "Bearer [REDACTED]", set.String("Bearer tok-123456"))
+}
+
+func TestSecretsModule_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ruleID string
+		secret string
+		want   string
+	}{
+		{"not granted", "rule-2", "api_token", "secrets: 'api_token' not found"},
+		{"unknown", "rule-1", "missing", "secrets: 'missing' not found"},
+		{"resolver error", "rule-1", "broken", "secrets: secret cannot be decrypted"},
+		{"outside of a rule", "", "api_token", "secrets: only available to rule executions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.ruleID != "" {
+				ctx = ruleContext(tt.ruleID)
+			}
+			results := runModuleScript(t, ctx, secretsModule(), `return require("secrets").get("`+tt.secret+`")`)
+			assert.Equal(t, []lua.LValue{lua.LNil, lua.LString(tt.want)}, results)
+		})
+	}
+}
+
+func TestSecretsModule_Replay(t *testing.T) {
+	// Redaction only catches the value itself, so replays must not read it at all
+	ctx := replay.WithPlayer(ruleContext("rule-1"), replay.NewPlayer(&replay.Bundle{}))
+
+	results := runModuleScript(t, ctx, secretsModule(), `
+		local value, err = require("secrets").get("api_token")
+		return value and value:reverse(), err
+	`)
+	assert.Equal(t, []lua.LValue{lua.LNil, lua.LString("secrets: not available in replayed executions")}, results)
+}
@@ -0,0 +1,262 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/convert"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/storage/kv"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Store quotas applied when none is configured
+const (
+	DefaultStoreMaxKeys       = 1000
+	DefaultStoreMaxValueBytes = 64 << 10
+	// maxStoreKeyLength bounds the length of a key in bytes
+	maxStoreKeyLength = 256
+)
+
+// StoreBackend persists the state of the store module, namespaced per rule
+type StoreBackend interface {
+	Get(ctx context.Context, namespace, key string) (string, bool, error)
+	Set(ctx context.Context, namespace, key, value string, ttl time.Duration, maxKeys int) error
+	Incr(ctx context.Context, namespace, key string, delta int64, maxKeys int) (int64, error)
+	Delete(ctx context.Context, namespace, key string) (bool, error)
+	CompareAndSwap(
+		ctx context.Context,
+		namespace, key string,
+		expected *string,
+		value string,
+		ttl time.Duration,
+		maxKeys int,
+	) (bool, error)
+}
+
+// StoreModuleOption allows to configure the store module
+type StoreModuleOption func(sm *StoreModule) *StoreModule
+
+// WithStoreMaxKeys sets the number of keys a rule may hold, 0 disables the limit
+func WithStoreMaxKeys(maxKeys int) StoreModuleOption {
+	return func(sm *StoreModule) *StoreModule {
+		sm.maxKeys = maxKeys
+		return sm
+	}
+}
+
+// WithStoreMaxValueBytes sets the size of the largest value a rule may store
+func WithStoreMaxValueBytes(maxBytes int) StoreModuleOption {
+	return func(sm *StoreModule) *StoreModule {
+		sm.maxValueBytes = maxBytes
+		return sm
+	}
+}
+
+// StoreModule gives scripts a persistent key-value state that survives
+// between executions. Every rule sees its own keys only.
+// Values are kept as JSON, so tables come back as tables.
+type StoreModule struct {
+	backend       StoreBackend
+	maxKeys       int
+	maxValueBytes int
+}
+
+// NewStoreModule creates a new StoreModule on top of backend
+func NewStoreModule(backend StoreBackend, opts ...StoreModuleOption) *StoreModule {
+	sm := &StoreModule{
+		backend:       backend,
+		maxKeys:       DefaultStoreMaxKeys,
+		maxValueBytes: DefaultStoreMaxValueBytes,
+	}
+	for _, opt := range opts {
+		opt(sm)
+	}
+	return sm
+}
+
+// Name returns the name of the module
+func (s *StoreModule) Name() string {
+	return "store"
+}
+
+// Get returns the value stored under a key, or nil when there is none
+func (s *StoreModule) Get(L *lua.LState) int {
+	key := L.CheckString(1)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		raw, ok, err := s.backend.Get(ctx, namespace, key)
+		if err != nil || !ok {
+			return lua.LNil, err
+		}
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("corrupt value: %w", err)
+		}
+		return convert.FromGo(L, value), nil
+	})
+}
+
+// Set stores a value under a key, expiring it after the optional number of seconds
+func (s *StoreModule) Set(L *lua.LState) int {
+	key := L.CheckString(1)
+	value := L.CheckAny(2)
+	ttl := s.checkTTL(L, 3)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		raw, err := s.encode(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.backend.Set(ctx, namespace, key, raw, ttl, s.maxKeys); err != nil {
+			return nil, err
+		}
+		return lua.LTrue, nil
+	})
+}
+
+// Incr adds the optional integer delta, 1 by default, to the integer stored
+// under a key and returns the new value. Missing keys start at 0.
+func (s *StoreModule) Incr(L *lua.LState) int {
+	key := L.CheckString(1)
+	delta := L.OptInt64(2, 1)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		value, err := s.backend.Incr(ctx, namespace, key, delta, s.maxKeys)
+		if err != nil {
+			return nil, err
+		}
+		return lua.LNumber(value), nil
+	})
+}
+
+// Delete removes a key and returns whether it existed
+func (s *StoreModule) Delete(L *lua.LState) int {
+	key := L.CheckString(1)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		removed, err := s.backend.Delete(ctx, namespace, key)
+		if err != nil {
+			return nil, err
+		}
+		return lua.LBool(removed), nil
+	})
+}
+
+// CompareAndSwap stores a new value under a key only if the current one equals
+// the expected value, or if the key does not exist when the expected value is
+// nil. It returns whether the value was stored.
+func (s *StoreModule) CompareAndSwap(L *lua.LState) int {
+	key := L.CheckString(1)
+	expected := L.Get(2)
+	value := L.CheckAny(3)
+	ttl := s.checkTTL(L, 4)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		var expectedRaw *string
+		if expected != lua.LNil {
+			raw, err := encode(expected)
+			if err != nil {
+				return nil, err
+			}
+			expectedRaw = &raw
+		}
+		raw, err := s.encode(value)
+		if err != nil {
+			return nil, err
+		}
+		swapped, err := s.backend.CompareAndSwap(ctx, namespace, key, expectedRaw, raw, ttl, s.maxKeys)
+		if err != nil {
+			return nil, err
+		}
+		return lua.LBool(swapped), nil
+	})
+}
+
+// call runs op in the namespace of the rule running in L and pushes its
+// result, or nil and an error message
+func (s *StoreModule) call(L *lua.LState, key string, op func(ctx context.Context, namespace string) (lua.LValue, error)) int {
+	result, err := s.run(L, key, op)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(result)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *StoreModule) run(L *lua.LState, key string, op func(ctx context.Context, namespace string) (lua.LValue, error)) (lua.LValue, error) {
+	if key == "" || len(key) > maxStoreKeyLength {
+		return nil, fmt.Errorf("store: key must be 1 to %d bytes long", maxStoreKeyLength)
+	}
+	ctx := callContext(L)
+	// Replays must not change the state live executions see
+	if replay.PlayerFromContext(ctx) != nil {
+		return nil, errors.New("store: not available in replayed executions")
+	}
+	ec, ok := execCtx.FromContext(ctx)
+	if !ok || ec.RuleID == "" {
+		return nil, errors.New("store: only available to rule executions")
+	}
+
+	result, err := op(ctx, ec.RuleID)
+	switch {
+	case errors.Is(err, kv.ErrKeyLimit):
+		return nil, fmt.Errorf("store quota exceeded: at most %d keys per rule", s.maxKeys)
+	case errors.Is(err, kv.ErrNotInteger):
+		return nil, fmt.Errorf("store: value of '%s' is not an integer", key)
+	case err != nil:
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	return result, nil
+}
+
+// checkTTL reads an optional number of seconds at position n
+func (s *StoreModule) checkTTL(L *lua.LState, n int) time.Duration {
+	seconds := L.OptNumber(n, 0)
+	if seconds < 0 {
+		L.ArgError(n, "ttl must not be negative")
+	}
+	return time.Duration(float64(seconds) * float64(time.Second))
+}
+
+// encode converts a value to the JSON kept by the backend, enforcing the size quota
+func (s *StoreModule) encode(value lua.LValue) (string, error) {
+	if value == lua.LNil {
+		return "", errors.New("cannot store nil, use store.delete")
+	}
+	raw, err := encode(value)
+	if err != nil {
+		return "", err
+	}
+	if s.maxValueBytes > 0 && len(raw) > s.maxValueBytes {
+		return "", fmt.Errorf("store quota exceeded: values are limited to %d bytes", s.maxValueBytes)
+	}
+	return raw, nil
+}
+
+func encode(value lua.LValue) (string, error) {
+	v, err := convert.ToGo(value, convert.WithStrictTypes())
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// Loader loads the store module into the Lua state
+func (s *StoreModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"get":    s.Get,
+		"set":    s.Set,
+		"incr":   s.Incr,
+		"delete": s.Delete,
+		"cas":    s.CompareAndSwap,
+	})
+	L.Push(mod)
+	return 1
+}
@@ -0,0 +1,101 @@
+package modules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/storage/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestStoreModule(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore())
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local store = require("store")
+		assert(store.get("missing") == nil)
+		assert(store.set("config", {threshold = 25, rooms = {"kitchen", "hall"}}))
+		assert(store.set("name", "sensor"))
+		local config = store.get("config")
+		return config.threshold, config.rooms[2], store.get("name")
+	`)
+	assert.Equal(t, lua.LNumber(25), results[0])
+	assert.Equal(t, lua.LString("hall"), results[1])
+	assert.Equal(t, lua.LString("sensor"), results[2])
+
+	results = runModuleScript(t, ruleContext("rule-1"), mod, `
+		local store = require("store")
+		store.incr("count")
+		local count = store.incr("count", 4)
+		local removed = store.delete("name")
+		local again = store.delete("name")
+		return count, removed, again
+	`)
+	assert.Equal(t, []lua.LValue{lua.LNumber(5), lua.LTrue, lua.LFalse}, results)
+}
+
+func TestStoreModule_NamespacedPerRule(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore())
+
+	runModuleScript(t, ruleContext("rule-1"), mod, `require("store").set("key", "one")`)
+	results := runModuleScript(t, ruleContext("rule-2"), mod, `return require("store").get("key")`)
+	assert.Equal(t, lua.LNil, results[0])
+}
+
+func TestStoreModule_CompareAndSwap(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore())
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local store = require("store")
+		local created = store.cas("leader", nil, "a")
+		local taken = store.cas("leader", nil, "b")
+		local stale = store.cas("leader", "b", "c")
+		local swapped = store.cas("leader", "a", "c")
+		return created, taken, stale, swapped, (store.get("leader"))
+	`)
+	assert.Equal(t, []lua.LValue{lua.LTrue, lua.LFalse, lua.LFalse, lua.LTrue, lua.LString("c")}, results)
+}
+
+func TestStoreModule_Errors(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore(), WithStoreMaxKeys(2), WithStoreMaxValueBytes(16))
+
+	tests := []struct {
+		name    string
+		script  string
+		message string
+	}{
+		{"key limit", `local s = require("store"); s.set("a", 1); s.set("b", 2); return s.set("c", 3)`, "store quota exceeded: at most 2 keys per rule"},
+		{"value size", `return require("store").set("a", string.rep("x", 32))`, "store quota exceeded: values are limited to 16 bytes"},
+		{"nil value", `return require("store").set("a", nil)`, "cannot store nil"},
+		{"function value", `return require("store").set("a", print)`, "cannot convert function"},
+		{"not an integer", `local s = require("store"); s.set("a", "text"); return s.incr("a")`, "value of 'a' is not an integer"},
+		{"empty key", `return require("store").get("")`, "key must be 1 to 256 bytes long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := runModuleScript(t, ruleContext(tt.name), mod, tt.script)
+			require.Len(t, results, 2)
+			assert.Equal(t, lua.LNil, results[0])
+			assert.Contains(t, results[1].String(), tt.message)
+		})
+	}
+}
+
+func TestStoreModule_RequiresRule(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore())
+	L := newModuleState(t, context.Background(), mod)
+
+	require.NoError(t, L.DoString(`value, err = require("store").get("key")`))
+	assert.Equal(t, "store: only available to rule executions", L.GetGlobal("err").String())
+}
+
+func TestStoreModule_Replay(t *testing.T) {
+	mod := NewStoreModule(kv.NewMemoryStore())
+	L := newModuleState(t, replay.WithPlayer(ruleContext("rule-1"), replay.NewPlayer(&replay.Bundle{})), mod)
+
+	require.NoError(t, L.DoString(`ok, err = require("store").set("key", 1)`))
+	assert.Equal(t, "store: not available in replayed executions", L.GetGlobal("err").String())
+}
@@ -0,0 +1,52 @@
+package modules
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Julian dates of the Unix epoch and of the J2000 epoch
+const (
+	julianUnixEpoch = 2440587.5
+	julianJ2000     = 2451545.0
+)
+
+// sunTimes returns the sunrise and the sunset at a latitude and longitude, in
+// degrees with north and east positive, on the local solar day of t. It
+// follows the sunrise equation, accurate to about a minute away from the poles.
+func sunTimes(lat, lon float64, t time.Time) (sunrise, sunset time.Time, err error) {
+	// The day number since J2000 of the solar day at the longitude
+	day := math.Floor((float64(t.Unix()) + lon*240) / 86400)
+	n := day + julianUnixEpoch + 0.5 - julianJ2000
+
+	meanNoon := n - lon/360
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	m := radians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.0200*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	longitude := radians(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := julianJ2000 + meanNoon + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*longitude)
+
+	declination := math.Asin(math.Sin(longitude) * math.Sin(radians(23.4397)))
+	phi := radians(lat)
+	cosHourAngle := (math.Sin(radians(-0.833)) - math.Sin(phi)*math.Sin(declination)) /
+		(math.Cos(phi) * math.Cos(declination))
+	switch {
+	case cosHourAngle > 1:
+		return time.Time{}, time.Time{}, errors.New("the sun does not rise on that day")
+	case cosHourAngle < -1:
+		return time.Time{}, time.Time{}, errors.New("the sun does not set on that day")
+	}
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+
+	return fromJulian(transit - hourAngle/360), fromJulian(transit + hourAngle/360), nil
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// fromJulian returns the time of a Julian date, rounded to the second
+func fromJulian(jd float64) time.Time {
+	return time.Unix(int64(math.Round((jd-julianUnixEpoch)*86400)), 0).UTC()
+}
@@ -1,18 +1,42 @@
 package modules
 
 import (
+	"fmt"
+	"math"
+	"sync"
 	"time"
+	_ "time/tzdata" // scripts may name any zone, even where the system has no zone database
 
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
 	lua "github.com/yuin/gopher-lua"
 )
 
-// TimeModule provides functions that Lua scripts can call
+// TimeModule provides functions that Lua scripts can call.
+// Points in time are exchanged with scripts as Unix timestamps in seconds,
+// with a fractional part, and durations as seconds.
 type TimeModule struct {
+	clock func() time.Time
+	zones sync.Map // name -> *time.Location
+}
+
+// TimeModuleOption allows to configure the time module
+type TimeModuleOption func(tm *TimeModule) *TimeModule
+
+// WithClock sets the clock the current time is read from
+func WithClock(clock func() time.Time) TimeModuleOption {
+	return func(tm *TimeModule) *TimeModule {
+		tm.clock = clock
+		return tm
+	}
 }
 
 // NewTimeModule creates a new TimeModule
-func NewTimeModule() *TimeModule {
-	return &TimeModule{}
+func NewTimeModule(opts ...TimeModuleOption) *TimeModule {
+	tm := &TimeModule{clock: time.Now}
+	for _, opt := range opts {
+		opt(tm)
+	}
+	return tm
 }
 
 // Name returns the name of the module
@@ -20,11 +44,18 @@ func (s *TimeModule) Name() string {
 	return "time"
 }
 
+// now returns the current time of the execution running in L.
+// Replayed executions get the time observed by the recorded one.
+func (s *TimeModule) now(L *lua.LState) time.Time {
+	return replay.NowFrom(callContext(L), s.clock)
+}
+
 // GetCurrentTime accepts the optional Go-sryle formatter, and returns the current time string
-// If no formatter is provided, the default Go time format is used
+// If no formatter is provided, the default Go time format is used.
+// Replayed executions get the time observed by the recorded one.
 func (s *TimeModule) GetCurrentTime(L *lua.LState) int {
 	format := L.ToString(1)
-	now := time.Now()
+	now := s.now(L)
 	if format == "" {
 		L.Push(lua.LString(now.String()))
 	} else {
@@ -33,10 +64,217 @@ func (s *TimeModule) GetCurrentTime(L *lua.LState) int {
 	return 1
 }
 
+// Unix returns the current time as a Unix timestamp
+func (s *TimeModule) Unix(L *lua.LState) int {
+	L.Push(lua.LNumber(toUnix(s.now(L))))
+	return 1
+}
+
+// Parse parses a time with a Go-style layout, in the optional zone (UTC by
+// default) when the layout has none, and returns its Unix timestamp
+func (s *TimeModule) Parse(L *lua.LState) int {
+	layout := L.CheckString(1)
+	value := L.CheckString(2)
+	loc := s.checkZone(L, 3)
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LNumber(toUnix(t)))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Format formats a Unix timestamp with a Go-style layout in the optional zone
+func (s *TimeModule) Format(L *lua.LState) int {
+	t := fromUnix(float64(L.CheckNumber(1)))
+	layout := L.CheckString(2)
+	loc := s.checkZone(L, 3)
+	L.Push(lua.LString(t.In(loc).Format(layout)))
+	return 1
+}
+
+// Add adds a duration, in seconds or as a Go-style duration string, to a Unix timestamp
+func (s *TimeModule) Add(L *lua.LState) int {
+	ts := float64(L.CheckNumber(1))
+	d := checkDuration(L, 2)
+	L.Push(lua.LNumber(toUnix(fromUnix(ts).Add(d))))
+	return 1
+}
+
+// Diff returns the seconds from the second Unix timestamp to the first one
+func (s *TimeModule) Diff(L *lua.LState) int {
+	a := fromUnix(float64(L.CheckNumber(1)))
+	b := fromUnix(float64(L.CheckNumber(2)))
+	L.Push(lua.LNumber(a.Sub(b).Seconds()))
+	return 1
+}
+
+// Duration parses a Go-style duration string, such as "1h30m", into seconds
+func (s *TimeModule) Duration(L *lua.LState) int {
+	d, err := time.ParseDuration(L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LNumber(d.Seconds()))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// InZone returns the fields of a Unix timestamp, the current time by default,
+// in a zone such as "Europe/Kyiv"
+func (s *TimeModule) InZone(L *lua.LState) int {
+	loc := s.checkZone(L, 1)
+	t := s.optTime(L, 2).In(loc)
+	name, offset := t.Zone()
+
+	tbl := L.NewTable()
+	L.SetField(tbl, "year", lua.LNumber(t.Year()))
+	L.SetField(tbl, "month", lua.LNumber(t.Month()))
+	L.SetField(tbl, "day", lua.LNumber(t.Day()))
+	L.SetField(tbl, "hour", lua.LNumber(t.Hour()))
+	L.SetField(tbl, "minute", lua.LNumber(t.Minute()))
+	L.SetField(tbl, "second", lua.LNumber(t.Second()))
+	L.SetField(tbl, "weekday", lua.LNumber(isoWeekday(t)))
+	L.SetField(tbl, "weekday_name", lua.LString(t.Weekday().String()))
+	L.SetField(tbl, "yearday", lua.LNumber(t.YearDay()))
+	L.SetField(tbl, "zone", lua.LString(name))
+	L.SetField(tbl, "offset", lua.LNumber(offset))
+	L.SetField(tbl, "unix", lua.LNumber(toUnix(t)))
+	L.Push(tbl)
+	return 1
+}
+
+// Weekday returns the day of the week, 1 for Monday to 7 for Sunday, of a
+// Unix timestamp, the current time by default, in the optional zone
+func (s *TimeModule) Weekday(L *lua.LState) int {
+	t := s.optTime(L, 1).In(s.checkZone(L, 2))
+	L.Push(lua.LNumber(isoWeekday(t)))
+	return 1
+}
+
+// Hour returns the hour of a Unix timestamp, the current time by default, in the optional zone
+func (s *TimeModule) Hour(L *lua.LState) int {
+	t := s.optTime(L, 1).In(s.checkZone(L, 2))
+	L.Push(lua.LNumber(t.Hour()))
+	return 1
+}
+
+// Sunrise returns the Unix timestamp of the sunrise at a latitude and longitude
+// on the day of a Unix timestamp, the current time by default
+func (s *TimeModule) Sunrise(L *lua.LState) int {
+	return s.pushSunEvent(L, true)
+}
+
+// Sunset returns the Unix timestamp of the sunset at a latitude and longitude
+// on the day of a Unix timestamp, the current time by default
+func (s *TimeModule) Sunset(L *lua.LState) int {
+	return s.pushSunEvent(L, false)
+}
+
+func (s *TimeModule) pushSunEvent(L *lua.LState, rise bool) int {
+	lat := float64(L.CheckNumber(1))
+	lon := float64(L.CheckNumber(2))
+	if lat < -90 || lat > 90 {
+		L.ArgError(1, "latitude must be between -90 and 90")
+	}
+	if lon < -180 || lon > 180 {
+		L.ArgError(2, "longitude must be between -180 and 180")
+	}
+	sunrise, sunset, err := sunTimes(lat, lon, s.optTime(L, 3))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	t := sunset
+	if rise {
+		t = sunrise
+	}
+	L.Push(lua.LNumber(toUnix(t)))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// optTime reads the optional Unix timestamp at position n, the current time by default
+func (s *TimeModule) optTime(L *lua.LState, n int) time.Time {
+	if L.Get(n) == lua.LNil {
+		return s.now(L)
+	}
+	return fromUnix(float64(L.CheckNumber(n)))
+}
+
+// checkZone reads the optional zone name at position n, UTC by default
+func (s *TimeModule) checkZone(L *lua.LState, n int) *time.Location {
+	name := L.OptString(n, "")
+	if name == "" {
+		return time.UTC
+	}
+	if loc, ok := s.zones.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		L.ArgError(n, fmt.Sprintf("unknown time zone '%s'", name))
+	}
+	s.zones.Store(name, loc)
+	return loc
+}
+
+// checkDuration reads the duration at position n, in seconds or as a Go-style duration string
+func checkDuration(L *lua.LState, n int) time.Duration {
+	switch v := L.Get(n).(type) {
+	case lua.LNumber:
+		return time.Duration(float64(v) * float64(time.Second))
+	case lua.LString:
+		d, err := time.ParseDuration(string(v))
+		if err != nil {
+			L.ArgError(n, err.Error())
+		}
+		return d
+	}
+	L.TypeError(n, lua.LTNumber)
+	return 0
+}
+
+// toUnix returns the Unix timestamp of t in seconds
+func toUnix(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
+
+// fromUnix returns the time of a Unix timestamp in seconds, in UTC
+func fromUnix(ts float64) time.Time {
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// isoWeekday returns the day of the week of t, 1 for Monday to 7 for Sunday
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
 // Loader loads the Time module into the Lua state
 func (s *TimeModule) Loader(L *lua.LState) int {
 	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
-		"now": s.GetCurrentTime,
+		"now":      s.GetCurrentTime,
+		"unix":     s.Unix,
+		"parse":    s.Parse,
+		"format":   s.Format,
+		"add":      s.Add,
+		"diff":     s.Diff,
+		"duration": s.Duration,
+		"in_zone":  s.InZone,
+		"weekday":  s.Weekday,
+		"hour":     s.Hour,
+		"sunrise":  s.Sunrise,
+		"sunset":   s.Sunset,
 	})
 
 	fields := map[string]lua.LValue{
@@ -1,11 +1,14 @@
 package modules
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -251,3 +254,174 @@ func TestScriptExecution_Constants(t *testing.T) {
 	// Since Kitchen is time-only format, we can't check the date
 	// Just ensure parsing succeeds
 }
+
+func TestTimeFunctions(t *testing.T) {
+	// Friday 21 June 2024, 12:30 in Kyiv
+	now := time.Date(2024, 6, 21, 9, 30, 0, 0, time.UTC)
+	L := newModuleState(t, context.Background(), NewTimeModule(WithClock(func() time.Time { return now })))
+
+	err := L.DoString(`
+		local time = require("time")
+		now = time.unix()
+		parsed = time.parse(time.RFC3339, "2024-06-21T12:30:00+03:00")
+		parsed_in_zone = time.parse(time.DateTime, "2024-06-21 12:30:00", "Europe/Kyiv")
+		_, parse_err = time.parse(time.DateOnly, "21/06/2024")
+		formatted = time.format(now, time.DateTime, "Europe/Kyiv")
+		later = time.add(now, "1h30m")
+		earlier = time.add(now, -60)
+		diff = time.diff(later, now)
+		duration = time.duration("2h15m")
+		_, duration_err = time.duration("soon")
+
+		local kyiv = time.in_zone("Europe/Kyiv")
+		kyiv_hour, kyiv_weekday, kyiv_name, kyiv_offset = kyiv.hour, kyiv.weekday, kyiv.weekday_name, kyiv.offset
+		business_hours = kyiv.weekday <= 5 and kyiv.hour >= 9 and kyiv.hour < 18
+
+		weekday = time.weekday()
+		sunday = time.weekday(time.parse(time.DateOnly, "2024-06-23"))
+		hour = time.hour()
+		ny_hour = time.hour(now, "America/New_York")
+	`)
+	if err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+
+	expected := map[string]lua.LValue{
+		"now":            lua.LNumber(now.Unix()),
+		"parsed":         lua.LNumber(now.Unix()),
+		"parsed_in_zone": lua.LNumber(now.Unix()),
+		"formatted":      lua.LString("2024-06-21 12:30:00"),
+		"later":          lua.LNumber(now.Unix() + 5400),
+		"earlier":        lua.LNumber(now.Unix() - 60),
+		"diff":           lua.LNumber(5400),
+		"duration":       lua.LNumber(8100),
+		"kyiv_hour":      lua.LNumber(12),
+		"kyiv_weekday":   lua.LNumber(5),
+		"kyiv_name":      lua.LString("Friday"),
+		"kyiv_offset":    lua.LNumber(3 * 3600),
+		"business_hours": lua.LTrue,
+		"weekday":        lua.LNumber(5),
+		"sunday":         lua.LNumber(7),
+		"hour":           lua.LNumber(9),
+		"ny_hour":        lua.LNumber(5),
+	}
+	for name, value := range expected {
+		if got := L.GetGlobal(name); got != value {
+			t.Errorf("%s: expected %v, got %v", name, value, got)
+		}
+	}
+	if msg := L.GetGlobal("parse_err").String(); !strings.Contains(msg, "cannot parse") {
+		t.Errorf("Expected a parse error, got %s", msg)
+	}
+	if msg := L.GetGlobal("duration_err").String(); !strings.Contains(msg, "invalid duration") {
+		t.Errorf("Expected a duration error, got %s", msg)
+	}
+}
+
+func TestTimeArgumentErrors(t *testing.T) {
+	L := newModuleState(t, context.Background(), NewTimeModule())
+
+	scripts := map[string]string{
+		`require("time").in_zone("Mars/Olympus_Mons")`: "unknown time zone 'Mars/Olympus_Mons'",
+		`require("time").add(0, "tomorrow")`:           "invalid duration",
+		`require("time").add(0, {})`:                   "number expected",
+		`require("time").sunrise(91, 0)`:               "latitude must be between -90 and 90",
+	}
+	for script, message := range scripts {
+		err := L.DoString(script)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", script, message, err)
+		}
+	}
+}
+
+func TestSunriseSunset(t *testing.T) {
+	tests := []struct {
+		name            string
+		lat, lon        float64
+		day             time.Time
+		sunrise, sunset time.Time
+	}{
+		{
+			name: "Kyiv, summer solstice",
+			lat:  50.45, lon: 30.52,
+			day:     time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC),
+			sunrise: time.Date(2024, 6, 21, 1, 47, 0, 0, time.UTC),
+			sunset:  time.Date(2024, 6, 21, 18, 12, 0, 0, time.UTC),
+		},
+		{
+			name: "New York, winter solstice",
+			lat:  40.7128, lon: -74.006,
+			day:     time.Date(2024, 12, 21, 23, 0, 0, 0, time.UTC),
+			sunrise: time.Date(2024, 12, 21, 12, 16, 0, 0, time.UTC),
+			sunset:  time.Date(2024, 12, 21, 21, 32, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sunrise, sunset, err := sunTimes(tt.lat, tt.lon, tt.day)
+			if err != nil {
+				t.Fatalf("sunTimes failed: %v", err)
+			}
+			if d := sunrise.Sub(tt.sunrise).Abs(); d > 3*time.Minute {
+				t.Errorf("Expected sunrise near %v, got %v", tt.sunrise, sunrise)
+			}
+			if d := sunset.Sub(tt.sunset).Abs(); d > 3*time.Minute {
+				t.Errorf("Expected sunset near %v, got %v", tt.sunset, sunset)
+			}
+		})
+	}
+
+	// Polar night in Tromsø
+	_, _, err := sunTimes(69.65, 18.96, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC))
+	if err == nil || err.Error() != "the sun does not rise on that day" {
+		t.Errorf("Expected no sunrise, got %v", err)
+	}
+
+	L := newModuleState(t, context.Background(), NewTimeModule(WithClock(func() time.Time { return tests[0].day })))
+	if err := L.DoString(`
+		local time = require("time")
+		sunrise = time.sunrise(50.45, 30.52)
+		daylight = time.diff(time.sunset(50.45, 30.52), sunrise)
+		_, polar = time.sunset(78.22, 15.65, time.parse(time.DateOnly, "2024-06-21"))
+	`); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if daylight := float64(L.GetGlobal("daylight").(lua.LNumber)); daylight < 16*3600 || daylight > 17*3600 {
+		t.Errorf("Expected about 16.5 hours of daylight in Kyiv, got %v seconds", daylight)
+	}
+	if polar := L.GetGlobal("polar").String(); polar != "the sun does not set on that day" {
+		t.Errorf("Expected no sunset, got %s", polar)
+	}
+}
+
+func TestTimeReplay(t *testing.T) {
+	recorded := time.Date(2024, 6, 21, 9, 30, 0, 0, time.UTC)
+	recorder := replay.NewRecorder(&execCtx.ExecutionContext{}, 0)
+	script := `
+		local time = require("time")
+		now = time.unix()
+		hour = time.hour()
+	`
+
+	L := newModuleState(t, replay.WithRecorder(context.Background(), recorder), NewTimeModule(WithClock(func() time.Time { return recorded })))
+	if err := L.DoString(script); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+
+	// The replay observes the recorded times rather than its own clock
+	player := replay.NewPlayer(recorder.Bundle())
+	L = newModuleState(t, replay.WithPlayer(context.Background(), player), NewTimeModule())
+	if err := L.DoString(script); err != nil {
+		t.Fatalf("Script execution failed: %v", err)
+	}
+	if now := L.GetGlobal("now"); now != lua.LNumber(recorded.Unix()) {
+		t.Errorf("Expected the recorded time, got %v", now)
+	}
+	if hour := L.GetGlobal("hour"); hour != lua.LNumber(9) {
+		t.Errorf("Expected the recorded hour, got %v", hour)
+	}
+	if misses := player.Misses(); len(misses) != 0 {
+		t.Errorf("Expected no misses, got %v", misses)
+	}
+}
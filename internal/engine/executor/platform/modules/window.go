@@ -0,0 +1,287 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/storage/series"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Window quotas applied when none is configured
+const (
+	DefaultWindowRetention = 24 * time.Hour
+	DefaultWindowMaxPoints = 10000
+	DefaultWindowMaxSeries = 100
+	// maxWindowKeyLength bounds the length of a series key in bytes
+	maxWindowKeyLength = 256
+)
+
+// WindowBackend persists the series of the window module, namespaced per rule
+type WindowBackend interface {
+	Push(ctx context.Context, namespace, key string, value float64, retention time.Duration, maxPoints, maxSeries int) error
+	Range(ctx context.Context, namespace, key string, window time.Duration) ([]series.Point, error)
+	Delete(ctx context.Context, namespace, key string) (bool, error)
+}
+
+// WindowModuleOption allows to configure the window module
+type WindowModuleOption func(wm *WindowModule) *WindowModule
+
+// WithWindowRetention sets how long the points of a series are kept, which
+// bounds the duration of the windows scripts can aggregate
+func WithWindowRetention(retention time.Duration) WindowModuleOption {
+	return func(wm *WindowModule) *WindowModule {
+		wm.retention = retention
+		return wm
+	}
+}
+
+// WithWindowMaxPoints sets the number of points a series keeps, 0 disables the limit
+func WithWindowMaxPoints(maxPoints int) WindowModuleOption {
+	return func(wm *WindowModule) *WindowModule {
+		wm.maxPoints = maxPoints
+		return wm
+	}
+}
+
+// WithWindowMaxSeries sets the number of series a rule may hold, 0 disables the limit
+func WithWindowMaxSeries(maxSeries int) WindowModuleOption {
+	return func(wm *WindowModule) *WindowModule {
+		wm.maxSeries = maxSeries
+		return wm
+	}
+}
+
+// WindowModule gives scripts time series of numbers aggregated over sliding
+// windows, such as the average temperature of the last 10 minutes or the
+// number of failures in the last 5. Every rule sees its own series only, and
+// points are kept for the retention of the module.
+type WindowModule struct {
+	backend   WindowBackend
+	retention time.Duration
+	maxPoints int
+	maxSeries int
+}
+
+// NewWindowModule creates a new WindowModule on top of backend
+func NewWindowModule(backend WindowBackend, opts ...WindowModuleOption) *WindowModule {
+	wm := &WindowModule{
+		backend:   backend,
+		retention: DefaultWindowRetention,
+		maxPoints: DefaultWindowMaxPoints,
+		maxSeries: DefaultWindowMaxSeries,
+	}
+	for _, opt := range opts {
+		opt(wm)
+	}
+	return wm
+}
+
+// Name returns the name of the module
+func (s *WindowModule) Name() string {
+	return "window"
+}
+
+// Push adds a number to a series, timestamped with the current time
+func (s *WindowModule) Push(L *lua.LState) int {
+	key := L.CheckString(1)
+	value := float64(L.CheckNumber(2))
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		L.ArgError(2, "value must be a finite number")
+	}
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		if err := s.backend.Push(ctx, namespace, key, value, s.retention, s.maxPoints, s.maxSeries); err != nil {
+			return nil, err
+		}
+		return lua.LTrue, nil
+	})
+}
+
+// Clear removes a series and returns whether it existed
+func (s *WindowModule) Clear(L *lua.LState) int {
+	key := L.CheckString(1)
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		removed, err := s.backend.Delete(ctx, namespace, key)
+		if err != nil {
+			return nil, err
+		}
+		return lua.LBool(removed), nil
+	})
+}
+
+// Count returns the number of points of a series in the window
+func (s *WindowModule) Count(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		return lua.LNumber(len(points))
+	})
+}
+
+// Sum returns the sum of the points of a series in the window, 0 when there are none
+func (s *WindowModule) Sum(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		return lua.LNumber(sumPoints(points))
+	})
+}
+
+// Avg returns the mean of the points of a series in the window, nil when there are none
+func (s *WindowModule) Avg(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		if len(points) == 0 {
+			return lua.LNil
+		}
+		return lua.LNumber(sumPoints(points) / float64(len(points)))
+	})
+}
+
+// Min returns the smallest point of a series in the window, nil when there are none
+func (s *WindowModule) Min(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		if len(points) == 0 {
+			return lua.LNil
+		}
+		least := points[0].Value
+		for _, p := range points[1:] {
+			least = math.Min(least, p.Value)
+		}
+		return lua.LNumber(least)
+	})
+}
+
+// Max returns the largest point of a series in the window, nil when there are none
+func (s *WindowModule) Max(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		if len(points) == 0 {
+			return lua.LNil
+		}
+		greatest := points[0].Value
+		for _, p := range points[1:] {
+			greatest = math.Max(greatest, p.Value)
+		}
+		return lua.LNumber(greatest)
+	})
+}
+
+// Rate returns the change per second of a series between its first and its
+// last point in the window, nil when there are fewer than two
+func (s *WindowModule) Rate(L *lua.LState) int {
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		if len(points) < 2 {
+			return lua.LNil
+		}
+		first, last := points[0], points[len(points)-1]
+		elapsed := last.At.Sub(first.At).Seconds()
+		if elapsed <= 0 {
+			return lua.LNil
+		}
+		return lua.LNumber((last.Value - first.Value) / elapsed)
+	})
+}
+
+// Percentile returns the p-th percentile, 0 to 100, of the points of a series
+// in the window, interpolated between the closest ranks; nil when there are none
+func (s *WindowModule) Percentile(L *lua.LState) int {
+	p := float64(L.CheckNumber(3))
+	if p < 0 || p > 100 {
+		L.ArgError(3, "percentile must be between 0 and 100")
+	}
+	return s.aggregate(L, func(points []series.Point) lua.LValue {
+		if len(points) == 0 {
+			return lua.LNil
+		}
+		values := make([]float64, len(points))
+		for i, point := range points {
+			values[i] = point.Value
+		}
+		sort.Float64s(values)
+		rank := p / 100 * float64(len(values)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		return lua.LNumber(values[lower] + (values[upper]-values[lower])*(rank-float64(lower)))
+	})
+}
+
+// aggregate reads the series and the window, in seconds or as a Go-style
+// duration string, and pushes the aggregate of the points of the window
+func (s *WindowModule) aggregate(L *lua.LState, agg func(points []series.Point) lua.LValue) int {
+	key := L.CheckString(1)
+	window := checkDuration(L, 2)
+	if window <= 0 || window > s.retention {
+		L.ArgError(2, fmt.Sprintf("window must be positive and at most the retention of %s", s.retention))
+	}
+	return s.call(L, key, func(ctx context.Context, namespace string) (lua.LValue, error) {
+		points, err := s.backend.Range(ctx, namespace, key, window)
+		if err != nil {
+			return nil, err
+		}
+		return agg(points), nil
+	})
+}
+
+// call runs op in the namespace of the rule running in L and pushes its
+// result, or nil and an error message
+func (s *WindowModule) call(L *lua.LState, key string, op func(ctx context.Context, namespace string) (lua.LValue, error)) int {
+	result, err := s.run(L, key, op)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(result)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *WindowModule) run(L *lua.LState, key string, op func(ctx context.Context, namespace string) (lua.LValue, error)) (lua.LValue, error) {
+	if key == "" || len(key) > maxWindowKeyLength {
+		return nil, fmt.Errorf("window: key must be 1 to %d bytes long", maxWindowKeyLength)
+	}
+	ctx := callContext(L)
+	// Replays must not change the series live executions see, and cannot see them as they were
+	if replay.PlayerFromContext(ctx) != nil {
+		return nil, errors.New("window: not available in replayed executions")
+	}
+	ec, ok := execCtx.FromContext(ctx)
+	if !ok || ec.RuleID == "" {
+		return nil, errors.New("window: only available to rule executions")
+	}
+
+	result, err := op(ctx, ec.RuleID)
+	switch {
+	case errors.Is(err, series.ErrSeriesLimit):
+		return nil, fmt.Errorf("window quota exceeded: at most %d series per rule", s.maxSeries)
+	case err != nil:
+		return nil, fmt.Errorf("window: %w", err)
+	}
+	return result, nil
+}
+
+func sumPoints(points []series.Point) float64 {
+	total := 0.0
+	for _, p := range points {
+		total += p.Value
+	}
+	return total
+}
+
+// Loader loads the window module into the Lua state
+func (s *WindowModule) Loader(L *lua.LState) int {
+	mod := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"push":       s.Push,
+		"clear":      s.Clear,
+		"count":      s.Count,
+		"sum":        s.Sum,
+		"avg":        s.Avg,
+		"min":        s.Min,
+		"max":        s.Max,
+		"rate":       s.Rate,
+		"percentile": s.Percentile,
+	})
+	L.Push(mod)
+	return 1
+}
@@ -0,0 +1,154 @@
+package modules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/storage/series"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// fixedSeries returns the same points for every window
+type fixedSeries struct {
+	points []series.Point
+	window time.Duration
+}
+
+func (f *fixedSeries) Push(context.Context, string, string, float64, time.Duration, int, int) error {
+	return nil
+}
+
+func (f *fixedSeries) Range(_ context.Context, _, _ string, window time.Duration) ([]series.Point, error) {
+	f.window = window
+	return f.points, nil
+}
+
+func (f *fixedSeries) Delete(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+func TestWindowModule(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore())
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local window = require("window")
+		for _, v in ipairs({21, 23, 19, 25}) do
+			assert(window.push("temperature", v))
+		end
+		return window.count("temperature", 600), window.sum("temperature", "10m"),
+			window.avg("temperature", 600), window.min("temperature", 600), window.max("temperature", 600),
+			(window.percentile("temperature", 600, 50))
+	`)
+	assert.Equal(t, []lua.LValue{
+		lua.LNumber(4), lua.LNumber(88), lua.LNumber(22), lua.LNumber(19), lua.LNumber(25), lua.LNumber(22),
+	}, results)
+
+	results = runModuleScript(t, ruleContext("rule-1"), mod, `
+		local window = require("window")
+		local cleared = window.clear("temperature")
+		return window.count("temperature", 600), window.sum("temperature", 600), window.avg("temperature", 600),
+			window.rate("temperature", 600), window.percentile("temperature", 600, 90), cleared
+	`)
+	assert.Equal(t, []lua.LValue{lua.LNumber(0), lua.LNumber(0), lua.LNil, lua.LNil, lua.LNil, lua.LTrue}, results)
+}
+
+func TestWindowModule_NamespacedPerRule(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore())
+
+	runModuleScript(t, ruleContext("rule-1"), mod, `require("window").push("failures", 1)`)
+	results := runModuleScript(t, ruleContext("rule-2"), mod, `return require("window").count("failures", 300)`)
+	assert.Equal(t, lua.LNumber(0), results[0])
+}
+
+func TestWindowModule_RateAndPercentile(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend := &fixedSeries{points: []series.Point{
+		{At: start, Value: 100},
+		{At: start.Add(10 * time.Second), Value: 130},
+		{At: start.Add(20 * time.Second), Value: 110},
+		{At: start.Add(40 * time.Second), Value: 180},
+	}}
+	mod := NewWindowModule(backend)
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local window = require("window")
+		return window.rate("energy", "1m"), window.percentile("energy", 60, 0), window.percentile("energy", 60, 100),
+			(window.percentile("energy", 60, 90))
+	`)
+	assert.Equal(t, lua.LNumber(2), results[0])
+	assert.Equal(t, lua.LNumber(100), results[1])
+	assert.Equal(t, lua.LNumber(180), results[2])
+	assert.InDelta(t, 165.0, float64(results[3].(lua.LNumber)), 1e-9)
+	assert.Equal(t, time.Minute, backend.window)
+}
+
+func TestWindowModule_Retention(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore(), WithWindowRetention(50*time.Millisecond), WithWindowMaxPoints(3))
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local window = require("window")
+		for i = 1, 5 do window.push("level", i) end
+		return window.sum("level", 0.05)
+	`)
+	assert.Equal(t, lua.LNumber(12), results[0], "only the last 3 points are kept")
+
+	time.Sleep(60 * time.Millisecond)
+	results = runModuleScript(t, ruleContext("rule-1"), mod, `return require("window").count("level", 0.05)`)
+	assert.Equal(t, lua.LNumber(0), results[0])
+}
+
+func TestWindowModule_Errors(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore(), WithWindowMaxSeries(2), WithWindowRetention(time.Hour))
+
+	results := runModuleScript(t, ruleContext("rule-1"), mod, `
+		local w = require("window")
+		w.push("a", 1)
+		w.push("b", 2)
+		return w.push("c", 3)
+	`)
+	assert.Equal(t, []lua.LValue{lua.LNil, lua.LString("window quota exceeded: at most 2 series per rule")}, results)
+
+	results = runModuleScript(t, ruleContext("rule-1"), mod, `return require("window").count("", 60)`)
+	assert.Equal(t, lua.LString("window: key must be 1 to 256 bytes long"), results[1])
+
+	tests := []struct {
+		name    string
+		script  string
+		message string
+	}{
+		{"window beyond retention", `require("window").avg("a", "2h")`, "at most the retention of 1h0m0s"},
+		{"negative window", `require("window").avg("a", -1)`, "window must be positive"},
+		{"bad duration", `require("window").avg("a", "soon")`, "invalid duration"},
+		{"not a number", `require("window").push("a", "hot")`, "number expected"},
+		{"not finite", `require("window").push("a", 0/0)`, "value must be a finite number"},
+		{"percentile range", `require("window").percentile("a", 60, 101)`, "percentile must be between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			L := newModuleState(t, ruleContext("rule-1"), mod)
+			err := L.DoString(tt.script)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestWindowModule_RequiresRule(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore())
+	L := newModuleState(t, context.Background(), mod)
+
+	require.NoError(t, L.DoString(`value, err = require("window").push("key", 1)`))
+	assert.Equal(t, "window: only available to rule executions", L.GetGlobal("err").String())
+}
+
+func TestWindowModule_Replay(t *testing.T) {
+	mod := NewWindowModule(series.NewMemoryStore())
+	L := newModuleState(t, replay.WithPlayer(ruleContext("rule-1"), replay.NewPlayer(&replay.Bundle{})), mod)
+
+	require.NoError(t, L.DoString(`ok, err = require("window").push("key", 1)`))
+	assert.Equal(t, "window: not available in replayed executions", L.GetGlobal("err").String())
+}
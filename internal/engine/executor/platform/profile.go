@@ -0,0 +1,46 @@
+package platform
+
+import (
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// profiledLoader wraps the Go functions of the module loaded by loader so that
+// profiled executions record the time spent in each of them as module.function
+func profiledLoader(module string, loader lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		n := loader(L)
+		mod, ok := L.Get(-1).(*lua.LTable)
+		if !ok {
+			return n
+		}
+		mod.ForEach(func(key, value lua.LValue) {
+			name, ok := key.(lua.LString)
+			fn, isFn := value.(*lua.LFunction)
+			if !ok || !isFn || !fn.IsG {
+				return
+			}
+			mod.RawSet(key, L.NewFunction(profiledFunction(module+"."+string(name), fn.GFunction)))
+		})
+		return n
+	}
+}
+
+// profiledFunction times fn when the execution is profiled, including calls that raise an error
+func profiledFunction(name string, fn lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		ctx := L.Context()
+		if ctx == nil {
+			return fn(L)
+		}
+		recorder := profile.RecorderFromContext(ctx)
+		if recorder == nil {
+			return fn(L)
+		}
+		start := time.Now()
+		defer func() { recorder.Observe(name, time.Since(start)) }()
+		return fn(L)
+	}
+}
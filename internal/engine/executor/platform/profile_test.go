@@ -0,0 +1,36 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestRegisterAPIFunctions_Profiling(t *testing.T) {
+	svc := NewService()
+	L := lua.NewState()
+	defer L.Close()
+	svc.RegisterAPIFunctions(L)
+
+	recorder := profile.NewRecorder()
+	L.SetContext(profile.WithRecorder(context.Background(), recorder))
+
+	script := `
+		local logger = require("logger")
+		logger.info("one")
+		logger.debug("two")
+		logger.info("three")
+	`
+	require.NoError(t, L.DoString(script))
+
+	p := recorder.Profile(0)
+	counts := make(map[string]int)
+	for _, call := range p.Calls {
+		counts[call.Name] = call.Count
+	}
+	assert.Equal(t, map[string]int{"logger.info": 2, "logger.debug": 1}, counts)
+}
@@ -0,0 +1,221 @@
+package executor
+
+import (
+	"sync"
+
+	"github.com/malyshevhen/rule-engine/internal/metrics"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// DefaultStatePoolSize is the number of idle Lua states kept for reuse
+const DefaultStatePoolSize = 32
+
+// LStatePool hands out pre-initialized, sandboxed Lua states.
+// Creating a state and preloading every platform module dominates the cost of
+// short scripts, so states are reset and reused instead of rebuilt.
+// Put restores every table reachable from the globals to the snapshot taken
+// when the state was created, so nothing a script sets leaks into the next run.
+type LStatePool struct {
+	mu        sync.Mutex
+	factory   func() *lua.LState
+	maxIdle   int
+	idle      []*lua.LState
+	snapshots map[*lua.LState]*stateSnapshot
+	inUse     int
+	// generation is bumped by Reset; states built before are not reused
+	generation  uint64
+	generations map[*lua.LState]uint64
+}
+
+// NewLStatePool creates a pool that builds states with factory and keeps at most maxIdle of them
+func NewLStatePool(factory func() *lua.LState, maxIdle int) *LStatePool {
+	return &LStatePool{
+		factory:     factory,
+		maxIdle:     maxIdle,
+		snapshots:   make(map[*lua.LState]*stateSnapshot),
+		generations: make(map[*lua.LState]uint64),
+	}
+}
+
+// Get returns an idle state or creates a new one
+func (p *LStatePool) Get() *lua.LState {
+	p.mu.Lock()
+	p.inUse++
+	if n := len(p.idle); n > 0 {
+		L := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.reportSize()
+		p.mu.Unlock()
+		metrics.LuaStatePoolGetsTotal.WithLabelValues("hit").Inc()
+		return L
+	}
+	p.reportSize()
+	p.mu.Unlock()
+
+	// Build outside the lock so concurrent misses don't serialize
+	metrics.LuaStatePoolGetsTotal.WithLabelValues("miss").Inc()
+	p.mu.Lock()
+	generation := p.generation
+	p.mu.Unlock()
+	L := p.factory()
+	snapshot := takeSnapshot(L)
+
+	p.mu.Lock()
+	p.snapshots[L] = snapshot
+	p.generations[L] = generation
+	p.mu.Unlock()
+	return L
+}
+
+// Put resets the state and returns it to the pool, closing it when the pool is full
+func (p *LStatePool) Put(L *lua.LState) {
+	p.mu.Lock()
+	snapshot, ok := p.snapshots[L]
+	p.mu.Unlock()
+
+	// The caller owns L until it is back in the idle list
+	if ok {
+		snapshot.restore(L)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inUse--
+	defer p.reportSize()
+
+	if !ok || len(p.idle) >= p.maxIdle || p.generations[L] != p.generation {
+		p.discard(L)
+		return
+	}
+	p.idle = append(p.idle, L)
+}
+
+// Discard closes a checked-out state instead of returning it to the pool.
+// It is used for states left in an unknown condition, e.g. after an aborted run.
+func (p *LStatePool) Discard(L *lua.LState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inUse--
+	p.discard(L)
+	p.reportSize()
+}
+
+// Size returns the number of idle states
+func (p *LStatePool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Reset closes every idle state and makes the states currently checked out
+// be closed when they are put back, so that only states built by the factory
+// from now on are handed out. It is used when what the factory preloads changes.
+func (p *LStatePool) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.generation++
+	for _, L := range p.idle {
+		p.discard(L)
+	}
+	p.idle = nil
+	p.reportSize()
+}
+
+// Close closes every idle state
+func (p *LStatePool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, L := range p.idle {
+		p.discard(L)
+	}
+	p.idle = nil
+	p.reportSize()
+}
+
+func (p *LStatePool) discard(L *lua.LState) {
+	delete(p.snapshots, L)
+	delete(p.generations, L)
+	L.Close()
+}
+
+func (p *LStatePool) reportSize() {
+	metrics.LuaStatePoolSize.WithLabelValues("idle").Set(float64(len(p.idle)))
+	metrics.LuaStatePoolSize.WithLabelValues("in_use").Set(float64(p.inUse))
+}
+
+// stateSnapshot records the contents and metatables of every table reachable
+// from the globals of a freshly initialized state
+type stateSnapshot struct {
+	env        *lua.LTable
+	tables     map[*lua.LTable]map[lua.LValue]lua.LValue
+	metatables map[*lua.LTable]lua.LValue
+	stringMeta lua.LValue
+}
+
+func takeSnapshot(L *lua.LState) *stateSnapshot {
+	s := &stateSnapshot{
+		env:        L.G.Global,
+		tables:     make(map[*lua.LTable]map[lua.LValue]lua.LValue),
+		metatables: make(map[*lua.LTable]lua.LValue),
+		stringMeta: L.GetMetatable(lua.LString("")),
+	}
+	s.record(L.G.Global)
+	s.record(L.G.Registry)
+	if mt, ok := s.stringMeta.(*lua.LTable); ok {
+		s.record(mt)
+	}
+	return s
+}
+
+func (s *stateSnapshot) record(tbl *lua.LTable) {
+	if _, ok := s.tables[tbl]; ok {
+		return
+	}
+	contents := make(map[lua.LValue]lua.LValue)
+	s.tables[tbl] = contents
+	s.metatables[tbl] = tbl.Metatable
+
+	tbl.ForEach(func(k, v lua.LValue) {
+		contents[k] = v
+		if nested, ok := v.(*lua.LTable); ok {
+			s.record(nested)
+		}
+	})
+	if mt, ok := tbl.Metatable.(*lua.LTable); ok {
+		s.record(mt)
+	}
+}
+
+// restore resets the stack, the environment and every recorded table
+func (s *stateSnapshot) restore(L *lua.LState) {
+	L.SetTop(0)
+	L.RemoveContext()
+	L.Env = s.env
+	L.G.Global = s.env
+	// A protected string metatable is out of reach of scripts and needs no restoring
+	if _, ok := s.stringMeta.(*lua.LTable); ok {
+		L.SetMetatable(lua.LString(""), s.stringMeta)
+	}
+
+	for tbl, contents := range s.tables {
+		var stale []lua.LValue
+		tbl.ForEach(func(k, v lua.LValue) {
+			if original, ok := contents[k]; !ok || original != v {
+				stale = append(stale, k)
+			}
+		})
+		for _, k := range stale {
+			tbl.RawSet(k, lua.LNil)
+		}
+		for k, v := range contents {
+			if tbl.RawGet(k) == lua.LNil {
+				tbl.RawSet(k, v)
+			}
+		}
+		tbl.Metatable = s.metatables[tbl]
+	}
+}
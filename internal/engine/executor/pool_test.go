@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestLStatePool_GetPut(t *testing.T) {
+	created := 0
+	pool := NewLStatePool(func() *lua.LState {
+		created++
+		return lua.NewState()
+	}, 1)
+	defer pool.Close()
+
+	L1 := pool.Get()
+	L2 := pool.Get()
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 0, pool.Size())
+
+	pool.Put(L1)
+	pool.Put(L2) // pool is full, L2 is closed
+	assert.Equal(t, 1, pool.Size())
+
+	assert.Same(t, L1, pool.Get())
+	assert.Equal(t, 2, created)
+}
+
+func TestLStatePool_Discard(t *testing.T) {
+	pool := NewLStatePool(func() *lua.LState { return lua.NewState() }, 1)
+	defer pool.Close()
+
+	L := pool.Get()
+	pool.Discard(L)
+	assert.Equal(t, 0, pool.Size())
+	assert.NotSame(t, L, pool.Get())
+}
+
+func TestLStatePool_Reset(t *testing.T) {
+	pool := NewLStatePool(func() *lua.LState { return lua.NewState() }, 2)
+	defer pool.Close()
+
+	idle := pool.Get()
+	inUse := pool.Get()
+	pool.Put(idle)
+	assert.Equal(t, 1, pool.Size())
+
+	pool.Reset()
+	assert.Equal(t, 0, pool.Size())
+
+	// States built before the reset are not reused
+	pool.Put(inUse)
+	assert.Equal(t, 0, pool.Size())
+
+	L := pool.Get()
+	assert.NotSame(t, idle, L)
+	assert.NotSame(t, inUse, L)
+	pool.Put(L)
+	assert.Equal(t, 1, pool.Size())
+}
+
+func TestExecutorService_SetLibraries(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithStatePoolSize(1))
+	script := `return require("greeting").hello("world")`
+
+	result := svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "module 'greeting' is not available in the sandbox")
+
+	// The pooled state is replaced by one preloading the library
+	assert.NoError(t, svc.SetLibraries([]platform.Library{{Name: "greeting", Version: 1, Code: `
+		local M = {}
+		function M.hello(name) return "hello " .. name end
+		return M
+	`}}))
+	result = svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{"hello world"}, result.Output)
+
+	// Changes made to a library module by a script do not leak into the next run
+	result = svc.ExecuteScript(context.Background(), `require("greeting").hello = nil return 1`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+	result = svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+
+	assert.ErrorIs(t, svc.SetLibraries([]platform.Library{{Name: "a", Code: "return require('a')"}}), platform.ErrDependencyCycle)
+	result = svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+}
+
+func TestLStatePool_RestoresSnapshot(t *testing.T) {
+	pool := NewLStatePool(func() *lua.LState { return lua.NewState() }, 1)
+	defer pool.Close()
+
+	L := pool.Get()
+	err := L.DoString(`
+		leaked = "secret"
+		string.upper = nil
+		table.extra = {}
+		setmetatable(_G, {__index = function() return "shadow" end})
+		getmetatable("").__index = {}
+	`)
+	assert.NoError(t, err)
+	pool.Put(L)
+
+	L = pool.Get()
+	err = L.DoString(`
+		assert(leaked == nil, "global leaked")
+		assert(undefined_global == nil, "globals metatable leaked")
+		assert(string.upper ~= nil, "string.upper not restored")
+		assert(table.extra == nil, "library table not restored")
+		assert(("x"):upper() == "X", "string metatable not restored")
+	`)
+	assert.NoError(t, err)
+}
+
+func TestExecutorService_ExecuteScript_PooledStateIsolation(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithStatePoolSize(1))
+
+	first := &execCtx.ExecutionContext{
+		RuleID:    "rule-a",
+		TriggerID: "trigger-a",
+		Data:      map[string]any{"temperature": 30},
+	}
+	result := svc.ExecuteScript(context.Background(), `
+		secret = rule_id
+		local logger = require("logger")
+		logger.info = nil
+		return temperature
+	`, first)
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, 1, svc.lua.pool.Size())
+
+	second := ctxSvc.CreateContext("rule-b", "trigger-b")
+	result = svc.ExecuteScript(context.Background(), `
+		return secret == nil and temperature == nil and rule_id == "rule-b"
+			and require("logger").info ~= nil
+	`, second)
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{true}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_DiscardsAbortedState(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithStatePoolSize(1), WithTimeout(50*time.Millisecond))
+
+	result := svc.ExecuteScript(context.Background(), "return 1", ctxSvc.CreateContext("rule", "trigger"))
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, svc.lua.pool.Size())
+
+	result = svc.ExecuteScript(context.Background(), "while true do end", ctxSvc.CreateContext("rule", "trigger"))
+	assert.Equal(t, StatusTimeout, result.Status)
+	assert.Equal(t, 0, svc.lua.pool.Size())
+}
+
+func TestExecutorService_ExecuteScript_LimitsBypassPool(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithStatePoolSize(1))
+
+	execContext := ctxSvc.CreateContext("rule", "trigger")
+	execContext.Limits = execCtx.Limits{MaxCallStackDepth: 32}
+
+	result := svc.ExecuteScript(context.Background(), "return 1", execContext)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, svc.lua.pool.Size())
+}
@@ -0,0 +1,26 @@
+package executor
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptPrint replaces the base print, which writes to the process stdout.
+// Its arguments are joined with tabs like the original and captured into the
+// execution logs at info level.
+func scriptPrint(L *lua.LState) int {
+	top := L.GetTop()
+	parts := make([]string, top)
+	for i := 1; i <= top; i++ {
+		parts[i-1] = L.ToStringMeta(L.Get(i)).String()
+	}
+	message := redact.String(L.Context(), strings.Join(parts, "\t"))
+
+	scriptlog.Add(L.Context(), "info", message)
+	slog.Debug("Lua script print", "message", message)
+	return 0
+}
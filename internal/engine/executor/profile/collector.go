@@ -0,0 +1,134 @@
+package profile
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMaxRules is the number of rules whose profiles are kept in memory
+const DefaultMaxRules = 1024
+
+// CallSummary is the time the profiled executions of a rule spent in one module function
+type CallSummary struct {
+	Name     string        `json:"name"`
+	Count    int64         `json:"count"`
+	Duration time.Duration `json:"duration"`
+	Average  time.Duration `json:"average"` // per call
+}
+
+// Summary aggregates the profiled executions of a rule, including its trigger
+// conditions and actions
+type Summary struct {
+	RuleID string `json:"rule_id"`
+	// Runs is the number of executions recorded with profiling
+	Runs      int64         `json:"runs"`
+	Total     time.Duration `json:"total"`
+	Average   time.Duration `json:"average"` // per execution
+	Max       time.Duration `json:"max"`     // slowest execution
+	VM        time.Duration `json:"vm"`
+	Calls     []CallSummary `json:"calls"` // slowest first
+	LastRunAt *time.Time    `json:"last_run_at,omitempty"`
+}
+
+type ruleProfile struct {
+	runs      int64
+	total     time.Duration
+	max       time.Duration
+	vm        time.Duration
+	calls     map[string]*CallSummary
+	lastRunAt time.Time
+}
+
+// Collector aggregates the profiles of executions per rule.
+// Once it holds maxRules rules, the one run least recently is dropped.
+type Collector struct {
+	mu       sync.Mutex
+	maxRules int
+	rules    map[string]*ruleProfile
+}
+
+// NewCollector creates a collector keeping the profiles of at most maxRules rules
+func NewCollector(maxRules int) *Collector {
+	if maxRules <= 0 {
+		maxRules = DefaultMaxRules
+	}
+	return &Collector{maxRules: maxRules, rules: make(map[string]*ruleProfile)}
+}
+
+// Record adds the profile of one execution of the rule ruleID
+func (c *Collector) Record(ruleID string, p *Profile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rp, ok := c.rules[ruleID]
+	if !ok {
+		if len(c.rules) >= c.maxRules {
+			c.evictOldest()
+		}
+		rp = &ruleProfile{calls: make(map[string]*CallSummary)}
+		c.rules[ruleID] = rp
+	}
+	rp.runs++
+	rp.total += p.Total
+	rp.max = max(rp.max, p.Total)
+	rp.vm += p.VM
+	rp.lastRunAt = time.Now().UTC()
+	for _, call := range p.Calls {
+		cs, ok := rp.calls[call.Name]
+		if !ok {
+			cs = &CallSummary{Name: call.Name}
+			rp.calls[call.Name] = cs
+		}
+		cs.Count += int64(call.Count)
+		cs.Duration += call.Duration
+	}
+}
+
+// Summary returns the aggregated profile of the rule ruleID.
+// Rules without profiled executions have zero runs.
+func (c *Collector) Summary(ruleID string) *Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summary := &Summary{RuleID: ruleID, Calls: []CallSummary{}}
+	rp, ok := c.rules[ruleID]
+	if !ok {
+		return summary
+	}
+	summary.Runs = rp.runs
+	summary.Total = rp.total
+	summary.Average = rp.total / time.Duration(rp.runs)
+	summary.Max = rp.max
+	summary.VM = rp.vm
+	lastRunAt := rp.lastRunAt
+	summary.LastRunAt = &lastRunAt
+
+	calls := make([]Call, 0, len(rp.calls))
+	for _, cs := range rp.calls {
+		calls = append(calls, Call{Name: cs.Name, Duration: cs.Duration})
+	}
+	sortCalls(calls)
+	for _, call := range calls {
+		cs := *rp.calls[call.Name]
+		cs.Average = cs.Duration / time.Duration(cs.Count)
+		summary.Calls = append(summary.Calls, cs)
+	}
+	return summary
+}
+
+// Reset drops the profiles recorded for the rule ruleID
+func (c *Collector) Reset(ruleID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rules, ruleID)
+}
+
+func (c *Collector) evictOldest() {
+	var oldest string
+	for ruleID, rp := range c.rules {
+		if oldest == "" || rp.lastRunAt.Before(c.rules[oldest].lastRunAt) {
+			oldest = ruleID
+		}
+	}
+	delete(c.rules, oldest)
+}
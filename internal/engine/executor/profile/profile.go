@@ -0,0 +1,90 @@
+// Package profile breaks script executions down into the time spent running
+// the script itself and the time spent in each platform module function it
+// calls, such as http.get or logger.info, and aggregates these profiles per rule.
+package profile
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Call is the time an execution spent in one platform module function
+type Call struct {
+	Name     string        `json:"name"` // module.function, e.g. http.get
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Profile breaks an execution down by where its time went
+type Profile struct {
+	Total time.Duration `json:"total"`
+	// VM is the time spent running the script, i.e. Total minus the module calls
+	VM    time.Duration `json:"vm"`
+	Calls []Call        `json:"calls,omitempty"` // slowest first
+}
+
+// Recorder times the module calls of a single execution
+type Recorder struct {
+	mu    sync.Mutex
+	calls map[string]*Call
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{calls: make(map[string]*Call)}
+}
+
+// Observe adds a call of the module function name that took d
+func (r *Recorder) Observe(name string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call, ok := r.calls[name]
+	if !ok {
+		call = &Call{Name: name}
+		r.calls[name] = call
+	}
+	call.Count++
+	call.Duration += d
+}
+
+// Profile returns the profile of an execution that took total
+func (r *Recorder) Profile(total time.Duration) *Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &Profile{Total: total, VM: total}
+	for _, call := range r.calls {
+		p.Calls = append(p.Calls, *call)
+		p.VM -= call.Duration
+	}
+	p.VM = max(p.VM, 0)
+	sortCalls(p.Calls)
+	return p
+}
+
+// sortCalls orders calls from the slowest to the fastest, then by name
+func sortCalls(calls []Call) {
+	slices.SortFunc(calls, func(a, b Call) int {
+		if c := cmp.Compare(b.Duration, a.Duration); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context that makes the platform modules report their calls to r
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// RecorderFromContext returns the recorder of ctx, nil when the execution is not profiled
+func RecorderFromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
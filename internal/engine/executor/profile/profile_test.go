@@ -0,0 +1,83 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder_Profile(t *testing.T) {
+	r := NewRecorder()
+	r.Observe("logger.info", time.Millisecond)
+	r.Observe("http.get", 40*time.Millisecond)
+	r.Observe("logger.info", 2*time.Millisecond)
+	r.Observe("http.post", 40*time.Millisecond)
+
+	p := r.Profile(100 * time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, p.Total)
+	assert.Equal(t, 17*time.Millisecond, p.VM)
+	assert.Equal(t, []Call{
+		{Name: "http.get", Count: 1, Duration: 40 * time.Millisecond},
+		{Name: "http.post", Count: 1, Duration: 40 * time.Millisecond},
+		{Name: "logger.info", Count: 2, Duration: 3 * time.Millisecond},
+	}, p.Calls)
+
+	// Calls are never reported as longer than the execution
+	assert.Zero(t, r.Profile(50*time.Millisecond).VM)
+}
+
+func TestRecorderFromContext(t *testing.T) {
+	assert.Nil(t, RecorderFromContext(context.Background()))
+
+	r := NewRecorder()
+	assert.Same(t, r, RecorderFromContext(WithRecorder(context.Background(), r)))
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(2)
+
+	empty := c.Summary("rule-1")
+	assert.Zero(t, empty.Runs)
+	assert.Empty(t, empty.Calls)
+	assert.Nil(t, empty.LastRunAt)
+
+	c.Record("rule-1", &Profile{
+		Total: 10 * time.Millisecond,
+		VM:    4 * time.Millisecond,
+		Calls: []Call{{Name: "http.get", Count: 1, Duration: 6 * time.Millisecond}},
+	})
+	c.Record("rule-1", &Profile{
+		Total: 30 * time.Millisecond,
+		VM:    2 * time.Millisecond,
+		Calls: []Call{
+			{Name: "http.get", Count: 2, Duration: 24 * time.Millisecond},
+			{Name: "logger.info", Count: 4, Duration: 4 * time.Millisecond},
+		},
+	})
+
+	s := c.Summary("rule-1")
+	assert.Equal(t, "rule-1", s.RuleID)
+	assert.Equal(t, int64(2), s.Runs)
+	assert.Equal(t, 40*time.Millisecond, s.Total)
+	assert.Equal(t, 20*time.Millisecond, s.Average)
+	assert.Equal(t, 30*time.Millisecond, s.Max)
+	assert.Equal(t, 6*time.Millisecond, s.VM)
+	assert.Equal(t, []CallSummary{
+		{Name: "http.get", Count: 3, Duration: 30 * time.Millisecond, Average: 10 * time.Millisecond},
+		{Name: "logger.info", Count: 4, Duration: 4 * time.Millisecond, Average: time.Millisecond},
+	}, s.Calls)
+	assert.NotNil(t, s.LastRunAt)
+
+	// The rule run least recently is dropped once the collector is full
+	c.Record("rule-2", &Profile{Total: time.Millisecond, VM: time.Millisecond})
+	c.Record("rule-3", &Profile{Total: time.Millisecond, VM: time.Millisecond})
+	assert.Zero(t, c.Summary("rule-1").Runs)
+	assert.Equal(t, int64(1), c.Summary("rule-3").Runs)
+
+	c.Reset("rule-3")
+	assert.Zero(t, c.Summary("rule-3").Runs)
+	assert.Equal(t, int64(1), c.Summary("rule-2").Runs)
+}
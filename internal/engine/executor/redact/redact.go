@@ -0,0 +1,111 @@
+// Package redact hides the secrets a script reads from what is kept of its
+// execution. The executor attaches a Set to the execution context, the secrets
+// module adds the values it hands out, and the captured logs, errors, output
+// and replay recordings are redacted with it.
+package redact
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces the secret values
+const Placeholder = "[REDACTED]"
+
+// minLength is the length below which values are not redacted, since
+// replacing every occurrence of a couple of characters would garble the output
+const minLength = 4
+
+// Set holds the secret values handed out during one execution.
+// It is safe for concurrent use.
+type Set struct {
+	mu       sync.RWMutex
+	values   []string
+	replacer *strings.Replacer
+}
+
+// NewSet creates an empty set
+func NewSet() *Set {
+	return &Set{}
+}
+
+// Add adds a secret value
+func (s *Set) Add(value string) {
+	if len(value) < minLength {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.values {
+		if v == value {
+			return
+		}
+	}
+	s.values = append(s.values, value)
+	// Longer values first, so that a secret containing another one is hidden whole
+	sort.Slice(s.values, func(i, j int) bool { return len(s.values[i]) > len(s.values[j]) })
+	pairs := make([]string, 0, 2*len(s.values))
+	for _, v := range s.values {
+		pairs = append(pairs, v, Placeholder)
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+}
+
+// String returns str with the secret values replaced by the placeholder
+func (s *Set) String(str string) string {
+	if s == nil {
+		return str
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.replacer == nil {
+		return str
+	}
+	return s.replacer.Replace(str)
+}
+
+// Value returns v with the secret values replaced in its strings, including
+// the strings nested in maps and slices
+func (s *Set) Value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return s.String(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, item := range v {
+			redacted[s.String(k)] = s.Value(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = s.Value(item)
+		}
+		return redacted
+	}
+	return v
+}
+
+type setKey struct{}
+
+// WithSet returns a context carrying s
+func WithSet(ctx context.Context, s *Set) context.Context {
+	return context.WithValue(ctx, setKey{}, s)
+}
+
+// FromContext returns the set carried by ctx, or nil.
+// A nil context is accepted since Lua states only carry one while running.
+func FromContext(ctx context.Context) *Set {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(setKey{}).(*Set)
+	return s
+}
+
+// String redacts str with the set carried by ctx, if any
+func String(ctx context.Context, str string) string {
+	return FromContext(ctx).String(str)
+}
@@ -0,0 +1,38 @@
+package redact
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet()
+	assert.Equal(t, "token abc123", s.String("token abc123"))
+
+	s.Add("abc123")
+	s.Add("abc123xyz")
+	s.Add("abc")
+	assert.Equal(t, "token [REDACTED], long [REDACTED], short abc", s.String("token abc123, long abc123xyz, short abc"))
+
+	assert.Equal(t, map[string]any{
+		"header": "Bearer [REDACTED]",
+		"list":   []any{"[REDACTED]", 42.0, true},
+	}, s.Value(map[string]any{
+		"header": "Bearer abc123",
+		"list":   []any{"abc123", 42.0, true},
+	}))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	assert.Nil(t, FromContext(nil))
+	assert.Equal(t, "secret-value", String(context.Background(), "secret-value"))
+
+	s := NewSet()
+	s.Add("secret-value")
+	ctx := WithSet(context.Background(), s)
+	assert.Same(t, s, FromContext(ctx))
+	assert.Equal(t, "[REDACTED]", String(ctx, "secret-value"))
+}
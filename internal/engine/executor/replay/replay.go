@@ -0,0 +1,284 @@
+// Package replay records the inputs of a script execution that cannot be derived
+// from the script itself, so that the execution can be re-run deterministically.
+// The executor attaches a Recorder to the execution context; the platform modules
+// record the current time, the HTTP responses and the results of other calls
+// they observe into it. A Player
+// attached instead serves the recorded values back in the order they were observed.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+)
+
+// BundleVersion is the format version of the bundles produced by this package
+const BundleVersion = 1
+
+// DefaultMaxBytes bounds the size of the HTTP bodies kept in a bundle
+const DefaultMaxBytes = 1 << 20
+
+// ErrNotRecorded is returned when a replayed script makes a call that was not recorded
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Bundle holds the inputs of one execution
+type Bundle struct {
+	Version int                       `json:"version"`
+	Context *execCtx.ExecutionContext `json:"context"`
+	// Times are the values returned to the script as the current time, in call order
+	Times []time.Time `json:"times,omitempty"`
+	// HTTP are the requests made by the script and their outcome, in call order
+	HTTP []HTTPExchange `json:"http,omitempty"`
+	// Calls are the other calls to external systems made by the script, in call order
+	Calls []Call `json:"calls,omitempty"`
+	// Truncated is set when exchanges were dropped because of the size bound
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// HTTPExchange is an HTTP request made by a script and its outcome
+type HTTPExchange struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+	Body   string `json:"body,omitempty"`
+	// Error is the error the request failed with, if any
+	Error string `json:"error,omitempty"`
+}
+
+// Call is a call a platform module made to an external system and its outcome
+type Call struct {
+	Module string `json:"module"`
+	Op     string `json:"op"`
+	// Key identifies the call among the calls of the same operation
+	Key string `json:"key,omitempty"`
+	// Result is the JSON encoding of the value the call returned
+	Result string `json:"result,omitempty"`
+	// Error is the error the call failed with, if any
+	Error string `json:"error,omitempty"`
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("%s.%s(%s)", c.Module, c.Op, c.Key)
+}
+
+// Recorder collects the inputs of one execution
+type Recorder struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	bundle   Bundle
+}
+
+// NewRecorder creates a recorder for the execution described by ec.
+// HTTP bodies are kept up to a total of maxBytes (0 means unbounded).
+func NewRecorder(ec *execCtx.ExecutionContext, maxBytes int) *Recorder {
+	snapshot := *ec
+	return &Recorder{
+		maxBytes: maxBytes,
+		bundle:   Bundle{Version: BundleVersion, Context: &snapshot},
+	}
+}
+
+// RecordTime records a value returned as the current time
+func (r *Recorder) RecordTime(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bundle.Times = append(r.bundle.Times, t.UTC())
+}
+
+// RecordHTTP records an HTTP exchange
+func (r *Recorder) RecordHTTP(exchange HTTPExchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxBytes > 0 && r.bytes+len(exchange.Body) > r.maxBytes {
+		r.bundle.Truncated = true
+		return
+	}
+	r.bytes += len(exchange.Body)
+	r.bundle.HTTP = append(r.bundle.HTTP, exchange)
+}
+
+// RecordCall records a call to an external system
+func (r *Recorder) RecordCall(call Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxBytes > 0 && r.bytes+len(call.Result) > r.maxBytes {
+		r.bundle.Truncated = true
+		return
+	}
+	r.bytes += len(call.Result)
+	r.bundle.Calls = append(r.bundle.Calls, call)
+}
+
+// Bundle returns the inputs recorded so far
+func (r *Recorder) Bundle() *Bundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bundle := r.bundle
+	bundle.Times = append([]time.Time(nil), r.bundle.Times...)
+	bundle.HTTP = append([]HTTPExchange(nil), r.bundle.HTTP...)
+	bundle.Calls = append([]Call(nil), r.bundle.Calls...)
+	return &bundle
+}
+
+// Redact replaces the URLs, bodies, results and errors of the recorded
+// exchanges and calls with their redacted form
+func (b *Bundle) Redact(redact func(string) string) {
+	for i := range b.HTTP {
+		exchange := &b.HTTP[i]
+		exchange.URL = redact(exchange.URL)
+		exchange.Body = redact(exchange.Body)
+		exchange.Error = redact(exchange.Error)
+	}
+	for i := range b.Calls {
+		call := &b.Calls[i]
+		call.Key = redact(call.Key)
+		call.Result = redact(call.Result)
+		call.Error = redact(call.Error)
+	}
+}
+
+// Player serves the inputs of a bundle to a re-run of its execution.
+// Calls a script makes that do not match the recording are reported by Misses.
+type Player struct {
+	mu     sync.Mutex
+	bundle *Bundle
+	times  int
+	used   []bool
+	called []bool
+	misses []string
+}
+
+// NewPlayer creates a player for bundle
+func NewPlayer(bundle *Bundle) *Player {
+	return &Player{
+		bundle: bundle,
+		used:   make([]bool, len(bundle.HTTP)),
+		called: make([]bool, len(bundle.Calls)),
+	}
+}
+
+// Now returns the next recorded time. Once the recorded times are exhausted
+// the last one is repeated, or the time the trigger fired if none was recorded.
+func (p *Player) Now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var t time.Time
+	switch {
+	case p.times < len(p.bundle.Times):
+		t = p.bundle.Times[p.times]
+		p.times++
+	case len(p.bundle.Times) > 0:
+		t = p.bundle.Times[len(p.bundle.Times)-1]
+		p.misses = append(p.misses, "time.now called more often than recorded")
+	case p.bundle.Context != nil:
+		t = p.bundle.Context.FiredAt
+		p.misses = append(p.misses, "time.now was not called by the recorded execution")
+	}
+	return t.In(time.Local)
+}
+
+// HTTP returns the first unused recorded exchange for method and url
+func (p *Player) HTTP(method, url string) (HTTPExchange, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, exchange := range p.bundle.HTTP {
+		if !p.used[i] && exchange.Method == method && exchange.URL == url {
+			p.used[i] = true
+			return exchange, nil
+		}
+	}
+	p.misses = append(p.misses, fmt.Sprintf("%s %s was not recorded", method, url))
+	return HTTPExchange{}, fmt.Errorf("%w for %s %s", ErrNotRecorded, method, url)
+}
+
+// Call returns the first unused recorded call of the operation op of module with key
+func (p *Player) Call(module, op, key string) (Call, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	want := Call{Module: module, Op: op, Key: key}
+	for i, call := range p.bundle.Calls {
+		if !p.called[i] && call.Module == module && call.Op == op && call.Key == key {
+			p.called[i] = true
+			return call, nil
+		}
+	}
+	p.misses = append(p.misses, fmt.Sprintf("%s was not recorded", want))
+	return Call{}, fmt.Errorf("%w for %s", ErrNotRecorded, want)
+}
+
+// Misses describes the calls of the re-run that the recording could not serve
+// and the recorded requests the re-run did not make
+func (p *Player) Misses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	misses := append([]string(nil), p.misses...)
+	for i, exchange := range p.bundle.HTTP {
+		if !p.used[i] {
+			misses = append(misses, fmt.Sprintf("recorded %s %s was not requested", exchange.Method, exchange.URL))
+		}
+	}
+	for i, call := range p.bundle.Calls {
+		if !p.called[i] {
+			misses = append(misses, fmt.Sprintf("recorded %s was not called", call))
+		}
+	}
+	return misses
+}
+
+type recorderKey struct{}
+
+type playerKey struct{}
+
+// WithRecorder returns a context carrying r
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// RecorderFromContext returns the recorder carried by ctx, or nil.
+// A nil context is accepted since Lua states only carry one while running.
+func RecorderFromContext(ctx context.Context) *Recorder {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// WithPlayer returns a context carrying p
+func WithPlayer(ctx context.Context, p *Player) context.Context {
+	return context.WithValue(ctx, playerKey{}, p)
+}
+
+// PlayerFromContext returns the player carried by ctx, or nil
+func PlayerFromContext(ctx context.Context) *Player {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(playerKey{}).(*Player)
+	return p
+}
+
+// Now returns the current time for the execution carried by ctx.
+// It is served by the player when replaying and recorded when recording.
+func Now(ctx context.Context) time.Time {
+	return NowFrom(ctx, time.Now)
+}
+
+// NowFrom is Now reading the current time from clock
+func NowFrom(ctx context.Context, clock func() time.Time) time.Time {
+	if p := PlayerFromContext(ctx); p != nil {
+		return p.Now()
+	}
+	// The monotonic reading cannot be replayed, so it is not exposed either
+	now := clock().Round(0)
+	if r := RecorderFromContext(ctx); r != nil {
+		r.RecordTime(now)
+	}
+	return now
+}
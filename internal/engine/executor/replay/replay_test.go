@@ -0,0 +1,105 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ec := &execCtx.ExecutionContext{RuleID: "rule-1", Attempt: 1}
+	r := NewRecorder(ec, 8)
+	ec.RuleID = "changed"
+
+	ctx := WithRecorder(context.Background(), r)
+	now := Now(ctx)
+	r.RecordHTTP(HTTPExchange{Method: "GET", URL: "http://a", Status: 200, Body: "12345"})
+	r.RecordHTTP(HTTPExchange{Method: "GET", URL: "http://b", Status: 200, Body: "12345"})
+	r.RecordHTTP(HTTPExchange{Method: "GET", URL: "http://c", Error: "connection refused"})
+
+	bundle := r.Bundle()
+	assert.Equal(t, BundleVersion, bundle.Version)
+	assert.Equal(t, "rule-1", bundle.Context.RuleID)
+	assert.Equal(t, []time.Time{now.UTC()}, bundle.Times)
+	assert.Equal(t, []HTTPExchange{
+		{Method: "GET", URL: "http://a", Status: 200, Body: "12345"},
+		{Method: "GET", URL: "http://c", Error: "connection refused"},
+	}, bundle.HTTP)
+	assert.True(t, bundle.Truncated)
+}
+
+func TestPlayer(t *testing.T) {
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Second)
+	p := NewPlayer(&Bundle{
+		Version: BundleVersion,
+		Times:   []time.Time{first, second},
+		HTTP: []HTTPExchange{
+			{Method: "GET", URL: "http://a", Body: "1"},
+			{Method: "GET", URL: "http://a", Body: "2"},
+			{Method: "POST", URL: "http://b", Body: "3"},
+		},
+	})
+	ctx := WithPlayer(context.Background(), p)
+
+	assert.True(t, first.Equal(Now(ctx)))
+	assert.True(t, second.Equal(Now(ctx)))
+	assert.True(t, second.Equal(Now(ctx)))
+
+	exchange, err := p.HTTP("GET", "http://a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", exchange.Body)
+	exchange, err = p.HTTP("GET", "http://a")
+	require.NoError(t, err)
+	assert.Equal(t, "2", exchange.Body)
+	_, err = p.HTTP("GET", "http://a")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+
+	assert.Equal(t, []string{
+		"time.now called more often than recorded",
+		"GET http://a was not recorded",
+		"recorded POST http://b was not requested",
+	}, p.Misses())
+}
+
+func TestPlayer_Calls(t *testing.T) {
+	r := NewRecorder(&execCtx.ExecutionContext{}, 0)
+	r.RecordCall(Call{Module: "device", Op: "get_state", Key: "lamp", Result: `{"on":true}`})
+	r.RecordCall(Call{Module: "device", Op: "list", Error: "device service unavailable"})
+	r.RecordCall(Call{Module: "device", Op: "get_state", Key: "fan", Result: `{"on":false}`})
+
+	p := NewPlayer(r.Bundle())
+	call, err := p.Call("device", "get_state", "lamp")
+	require.NoError(t, err)
+	assert.Equal(t, `{"on":true}`, call.Result)
+	call, err = p.Call("device", "list", "")
+	require.NoError(t, err)
+	assert.Equal(t, "device service unavailable", call.Error)
+	_, err = p.Call("device", "get_state", "lamp")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+
+	assert.Equal(t, []string{
+		"device.get_state(lamp) was not recorded",
+		"recorded device.get_state(fan) was not called",
+	}, p.Misses())
+}
+
+func TestPlayer_NoTimes(t *testing.T) {
+	firedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPlayer(&Bundle{Context: &execCtx.ExecutionContext{FiredAt: firedAt}})
+
+	assert.True(t, firedAt.Equal(p.Now()))
+	assert.Equal(t, []string{"time.now was not called by the recorded execution"}, p.Misses())
+}
+
+func TestContext_Nil(t *testing.T) {
+	//nolint:staticcheck // Lua states without a running call carry no context
+	assert.Nil(t, RecorderFromContext(nil))
+	//nolint:staticcheck // Lua states without a running call carry no context
+	assert.Nil(t, PlayerFromContext(nil))
+	assert.WithinDuration(t, time.Now(), Now(context.Background()), time.Second)
+}
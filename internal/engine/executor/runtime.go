@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+)
+
+// Script languages, selected by ExecutionContext.Language
+const (
+	LanguageLua  = "lua"
+	LanguageExpr = "expr"
+)
+
+// ErrUnknownLanguage is returned for scripts written in a language no runtime runs
+var ErrUnknownLanguage = errors.New("unknown script language")
+
+// Runtime runs the scripts of one language.
+// ExecuteScript applies the same timeout, log capture, replay recording,
+// metrics and result type to every runtime.
+type Runtime interface {
+	// Language returns the name execution contexts select the runtime with
+	Language() string
+	// Run executes script for the execution described by ec and returns every
+	// value it produced. ctx carries the deadline of the execution. A script
+	// exhausting limits fails with a *BudgetExceededError.
+	Run(ctx context.Context, script string, ec *execCtx.ExecutionContext, limits execCtx.Limits) ([]any, error)
+}
+
+// runtime returns the runtime of language, Lua when it is empty
+func (s *Service) runtime(language string) (Runtime, error) {
+	if language == "" {
+		language = LanguageLua
+	}
+	rt, ok := s.runtimes[language]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownLanguage, language)
+	}
+	return rt, nil
+}
+
+// Languages returns the languages scripts can be written in
+func (s *Service) Languages() []string {
+	languages := make([]string, 0, len(s.runtimes))
+	for language := range s.runtimes {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+	return languages
+}
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorService_ExecuteScript_Expression(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = LanguageExpr
+	ec.TriggerType = "CONDITIONAL"
+	ec.EventSubject = "sensors.kitchen"
+	ec.Event = map[string]any{"temperature": 28.5, "tags": []any{"indoor"}}
+	ec.Data["event"] = ec.Event
+
+	tests := []struct {
+		source   string
+		expected any
+	}{
+		{`ctx.event.payload.temperature > 25 && "indoor" in ctx.event.payload.tags`, true},
+		{`ctx.event.subject + "/" + ctx.trigger.type`, "sensors.kitchen/CONDITIONAL"},
+		{`ctx.rule.id == rule_id && ctx.trigger.id == trigger_id`, true},
+		{`event.temperature`, 28.5},
+		{`ctx.attempt + ctx.chain_depth`, 1.0},
+	}
+	for _, tt := range tests {
+		result := svc.ExecuteScript(context.Background(), tt.source, ec)
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, StatusSuccess, result.Status)
+		assert.Equal(t, []any{tt.expected}, result.Output, tt.source)
+	}
+
+	result := svc.ExecuteScript(context.Background(), `ctx.event.payload.temperature >`, ec)
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorTypeExecution, result.ErrorType)
+	assert.Contains(t, result.Error, "line 1, column 32: unexpected end of expression")
+}
+
+func TestExecutorService_ExecuteScript_ExpressionWithoutLegacyGlobals(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithLegacyGlobals(false))
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = LanguageExpr
+	ec.Data["event"] = map[string]any{"temperature": 28.5}
+
+	result := svc.ExecuteScript(context.Background(), `event.temperature > 25`, ec)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "unknown variable 'event'")
+}
+
+func TestExecutorService_ExecuteScript_ExpressionBudget(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = LanguageExpr
+	ec.Limits.MaxInstructions = 50
+	items := make([]any, 100)
+	ec.Data["items"] = items
+
+	result := svc.ExecuteScript(context.Background(), `"missing" in items`, ec)
+	assert.False(t, result.Success)
+	assert.Equal(t, StatusFailure, result.Status)
+	assert.Equal(t, ErrorTypeBudgetExceeded, result.ErrorType)
+	assert.Equal(t, "budget exceeded: instructions limit of 50 reached", result.Error)
+}
+
+func TestExecutorService_ExecuteScript_ExpressionHasNoCoverage(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	recorder := &recordedCoverage{}
+	svc := NewService(ctxSvc, platform.NewService(), WithCoverageRecorder(recorder))
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = LanguageExpr
+	ec.Coverage = true
+
+	result := svc.ExecuteScript(context.Background(), `1 + 1`, ec)
+	assert.True(t, result.Success, result.Error)
+	assert.Nil(t, result.Coverage)
+	assert.Nil(t, recorder.hits)
+}
+
+func TestExecutorService_ExecuteScript_UnknownLanguage(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = "python"
+
+	result := svc.ExecuteScript(context.Background(), `print("hello")`, ec)
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorTypeExecution, result.ErrorType)
+	assert.Equal(t, "unknown script language 'python'", result.Error)
+}
+
+// upperRuntime is a toy runtime that returns its script in upper case
+type upperRuntime struct{}
+
+func (upperRuntime) Language() string { return "upper" }
+
+func (upperRuntime) Run(_ context.Context, script string, _ *execCtx.ExecutionContext, _ execCtx.Limits) ([]any, error) {
+	return []any{strings.ToUpper(script)}, nil
+}
+
+// shadowRuntime tries to replace the built-in Lua runtime
+type shadowRuntime struct{ upperRuntime }
+
+func (shadowRuntime) Language() string { return LanguageLua }
+
+func TestExecutorService_WithRuntime(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithRuntime(upperRuntime{}), WithRuntime(shadowRuntime{}))
+
+	assert.Equal(t, []string{LanguageExpr, LanguageLua, "upper"}, svc.Languages())
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Language = "upper"
+	result := svc.ExecuteScript(context.Background(), "hello", ec)
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{"HELLO"}, result.Output)
+
+	// The built-in Lua runtime is kept
+	result = svc.ExecuteScript(context.Background(), "return 'hello'", ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{"hello"}, result.Output)
+}
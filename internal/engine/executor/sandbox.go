@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// hardenSandbox closes the escape hatches left open by the base and package libraries.
+// It must run after the platform modules are preloaded, since it takes over package.preload.
+//
+//   - the globals listed in validation.DefaultForbiddenGlobals are removed,
+//     among them every function that loads code from a string or the filesystem
+//     and those that read or replace the environment of a function
+//   - string.dump is removed, gopher-lua has no bytecode to dump anyway
+//   - require only resolves the platform preloads and the opened standard libraries;
+//     package.path, package.loaders and package.loadlib are no longer reachable
+//   - setmetatable only accepts tables, so the metatables shared by every string,
+//     number or function of the state cannot be replaced
+//   - the string metatable is protected from getmetatable and setmetatable
+//   - pairs honours the __pairs metamethod of Lua 5.2, which read-only proxies such as ctx use
+func hardenSandbox(L *lua.LState) {
+	preload := L.NewTable()
+	if pkg, ok := L.GetGlobal("package").(*lua.LTable); ok {
+		if modules, ok := pkg.RawGetString("preload").(*lua.LTable); ok {
+			modules.ForEach(func(name, loader lua.LValue) {
+				preload.RawSet(name, loader)
+			})
+		}
+	}
+
+	registry := L.Get(lua.RegistryIndex).(*lua.LTable)
+	loaded, ok := registry.RawGetString("_LOADED").(*lua.LTable)
+	if !ok {
+		loaded = L.NewTable()
+		registry.RawSetString("_LOADED", loaded)
+	}
+	loaded.RawSetString("package", lua.LNil)
+	registry.RawSetString("_LOADERS", lua.LNil)
+
+	// The standard libraries are opened before package.loaded exists
+	for _, name := range validation.StandardModules {
+		if lib, ok := L.GetGlobal(name).(*lua.LTable); ok {
+			loaded.RawSetString(name, lib)
+		}
+	}
+
+	for _, name := range validation.DefaultForbiddenGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+	// Debugging helper of gopher-lua that dumps the registers to stdout
+	L.SetGlobal("_printregs", lua.LNil)
+	if str, ok := L.GetGlobal("string").(*lua.LTable); ok {
+		str.RawSetString("dump", lua.LNil)
+	}
+
+	L.SetGlobal("require", L.NewFunction(sandboxRequire(preload, loaded)))
+
+	if setmetatable, ok := L.GetGlobal("setmetatable").(*lua.LFunction); ok {
+		L.SetGlobal("setmetatable", L.NewFunction(tableOnlySetMetatable(setmetatable)))
+	}
+
+	if pairs, ok := L.GetGlobal("pairs").(*lua.LFunction); ok {
+		L.SetGlobal("pairs", L.NewFunction(metaPairs(pairs)))
+	}
+
+	if mt, ok := L.GetMetatable(lua.LString("")).(*lua.LTable); ok {
+		mt.RawSetString("__metatable", lua.LFalse)
+	}
+}
+
+// sandboxRequire returns a require that loads modules from preload only,
+// caching them in loaded like the standard implementation
+func sandboxRequire(preload, loaded *lua.LTable) lua.LGFunction {
+	return func(L *lua.LState) int {
+		name := L.CheckString(1)
+		if module := loaded.RawGetString(name); module != lua.LNil {
+			L.Push(module)
+			return 1
+		}
+
+		loader, ok := preload.RawGetString(name).(*lua.LFunction)
+		if !ok {
+			L.RaiseError("module '%s' is not available in the sandbox", name)
+			return 0
+		}
+
+		L.Push(loader)
+		L.Push(lua.LString(name))
+		L.Call(1, 1)
+		module := L.Get(-1)
+		if module == lua.LNil {
+			module = lua.LTrue
+		}
+		loaded.RawSetString(name, module)
+
+		L.Push(module)
+		return 1
+	}
+}
+
+// tableOnlySetMetatable wraps setmetatable so that it rejects every value but tables,
+// as Lua itself does. gopher-lua would otherwise replace the metatable shared by
+// every value of the given type.
+func tableOnlySetMetatable(setmetatable *lua.LFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		L.CheckTable(1)
+		L.Push(setmetatable)
+		L.Push(L.Get(1))
+		L.Push(L.Get(2))
+		L.Call(2, 1)
+		return 1
+	}
+}
+
+// metaPairs wraps pairs so that values with a __pairs metamethod choose how they
+// are iterated, as in Lua 5.2. gopher-lua only iterates over the raw table.
+func metaPairs(pairs *lua.LFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		value := L.CheckAny(1)
+		iterate := pairs
+		if metaPairs, ok := L.GetMetaField(value, "__pairs").(*lua.LFunction); ok {
+			iterate = metaPairs
+		}
+		L.Push(iterate)
+		L.Push(value)
+		L.Call(1, 3)
+		return 3
+	}
+}
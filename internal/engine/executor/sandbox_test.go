@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/stretchr/testify/assert"
+)
+
+// sandboxEscapes are known ways out of a Lua sandbox; every one of them must fail
+var sandboxEscapes = []struct {
+	name   string
+	script string
+}{
+	{"dofile", `dofile("/etc/passwd")`},
+	{"loadfile", `loadfile("/etc/passwd")()`},
+	{"load", `load(function() return nil end)`},
+	{"loadstring", `loadstring("return 1")()`},
+	{"load through _G", `_G.load(function() return nil end)`},
+	{"dofile through _G index", `_G["dofile"]("/etc/passwd")`},
+	{"loadstring through rawget", `rawget(_G, "loadstring")("return 1")()`},
+	{"os through getfenv", `getfenv(0).os.exit(1)`},
+	{"os", `os.execute("id")`},
+	{"io", `io.open("/etc/passwd")`},
+	{"debug", `debug.getregistry()`},
+	{"coroutine", `coroutine.create(print)`},
+	{"module", `module("escape", package.seeall)`},
+	{"printregs", `_printregs()`},
+	{"package global", `return package.path`},
+	{"package.loadlib", `package.loadlib("/lib/libc.so.6", "system")`},
+	{"require package", `require("package").loadlib("/lib/libc.so.6", "system")`},
+	{"require os", `require("os").exit(1)`},
+	{"require io", `require("io").open("/etc/passwd")`},
+	{"require debug", `require("debug").getregistry()`},
+	{"require from filesystem", `require("etc.passwd")`},
+	{"require relative path", `require("../../etc/passwd")`},
+	{"string metatable index", `getmetatable("").__index = {}`},
+	{"string metatable rawset", `rawset(getmetatable(""), "__index", {})`},
+	{"setmetatable on string", `setmetatable("", {__index = {}})`},
+	{"setmetatable on number", `setmetatable(1, {__index = math})`},
+	{"setmetatable on function", `setmetatable(print, {__index = {}})`},
+	{"string.dump", `string.dump(print)`},
+	{"string.dump reachable", `assert(string.dump)`},
+	{"getfenv", `getfenv(1)`},
+	{"setfenv", `setfenv(1, {})`},
+	{"setfenv on a platform function", `setfenv(print, {})`},
+	{"newproxy", `newproxy(true)`},
+}
+
+func TestExecutorService_ExecuteScript_SandboxEscapes(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	for _, tt := range sandboxEscapes {
+		t.Run(tt.name, func(t *testing.T) {
+			result := svc.ExecuteScript(context.Background(), tt.script, ctxSvc.CreateContext("rule", "trigger"))
+			assert.False(t, result.Success, "escape attempt succeeded: %s", tt.script)
+			assert.Equal(t, StatusFailure, result.Status)
+		})
+	}
+}
+
+func TestExecutorService_ExecuteScript_SandboxAllowsPlatformFeatures(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"platform module", `return require("logger") == require("logger")`},
+		{"standard library", `return require("string") == string`},
+		{"string methods", `return ("abc"):upper() == "ABC"`},
+		{"table metatables", `local t = setmetatable({}, {__index = function() return 1 end}) return t.x == 1`},
+		{"protected string metatable", `return getmetatable("") == false`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := svc.ExecuteScript(context.Background(), tt.script, ctxSvc.CreateContext("rule", "trigger"))
+			assert.True(t, result.Success, result.Error)
+			assert.Equal(t, []any{true}, result.Output)
+		})
+	}
+}
+
+func TestExecutorService_ExecuteScript_UnknownModuleError(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	result := svc.ExecuteScript(context.Background(), `require("lfs")`, ctxSvc.CreateContext("rule", "trigger"))
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "module 'lfs' is not available in the sandbox")
+}
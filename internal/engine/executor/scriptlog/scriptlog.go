@@ -0,0 +1,117 @@
+// Package scriptlog collects the lines a script prints or logs during one execution.
+// The executor attaches a Collector to the execution context; the print function
+// and the logger module add their lines to it so that callers can inspect them.
+package scriptlog
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+)
+
+// Default collector bounds
+const (
+	DefaultMaxEntries = 100
+	DefaultMaxBytes   = 64 << 10
+)
+
+// Entry is a single captured line
+type Entry struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Collector accumulates the entries of one execution up to a number of entries
+// and a total message size. Entries past either bound are dropped and the
+// collector reports itself as truncated; a message that does not fit whole is cut.
+type Collector struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	entries    []Entry
+	truncated  bool
+}
+
+// NewCollector creates a collector bounded by maxEntries and maxBytes (0 means unbounded)
+func NewCollector(maxEntries, maxBytes int) *Collector {
+	return &Collector{maxEntries: maxEntries, maxBytes: maxBytes}
+}
+
+// Add records a line
+func (c *Collector) Add(level, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.truncated = true
+		return
+	}
+	if c.maxBytes > 0 {
+		remaining := c.maxBytes - c.bytes
+		if remaining <= 0 {
+			c.truncated = true
+			return
+		}
+		if len(message) > remaining {
+			// Cut on a rune boundary, the log is full either way
+			cut := remaining
+			for cut > 0 && !utf8.RuneStart(message[cut]) {
+				cut--
+			}
+			c.truncated = true
+			c.bytes = c.maxBytes
+			if cut == 0 {
+				return
+			}
+			c.entries = append(c.entries, Entry{Level: level, Message: message[:cut], Timestamp: time.Now().UTC()})
+			return
+		}
+	}
+
+	c.bytes += len(message)
+	c.entries = append(c.entries, Entry{Level: level, Message: message, Timestamp: time.Now().UTC()})
+}
+
+// Entries returns the recorded entries in order
+func (c *Collector) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Truncated reports whether entries were dropped or cut
+func (c *Collector) Truncated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.truncated
+}
+
+type collectorKey struct{}
+
+// WithCollector returns a context carrying c
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+// FromContext returns the collector carried by ctx, or nil.
+// A nil context is accepted since Lua states only carry one while running.
+func FromContext(ctx context.Context) *Collector {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Add records a line in the collector carried by ctx, if any, with the
+// secrets of the execution redacted
+func Add(ctx context.Context, level, message string) {
+	if c := FromContext(ctx); c != nil {
+		c.Add(level, redact.String(ctx, message))
+	}
+}
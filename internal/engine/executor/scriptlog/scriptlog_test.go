@@ -0,0 +1,76 @@
+package scriptlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_Add(t *testing.T) {
+	c := NewCollector(0, 0)
+	c.Add("info", "first")
+	c.Add("error", "second")
+
+	entries := c.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "info", entries[0].Level)
+	assert.Equal(t, "first", entries[0].Message)
+	assert.False(t, entries[0].Timestamp.IsZero())
+	assert.Equal(t, "error", entries[1].Level)
+	assert.False(t, c.Truncated())
+}
+
+func TestCollector_MaxEntries(t *testing.T) {
+	c := NewCollector(2, 0)
+	c.Add("info", "a")
+	c.Add("info", "b")
+	c.Add("info", "c")
+
+	assert.Len(t, c.Entries(), 2)
+	assert.True(t, c.Truncated())
+}
+
+func TestCollector_MaxBytes(t *testing.T) {
+	c := NewCollector(0, 8)
+	c.Add("info", "12345")
+	c.Add("info", "67890")
+	c.Add("info", "dropped")
+
+	entries := c.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "678", entries[1].Message)
+	assert.True(t, c.Truncated())
+}
+
+func TestCollector_MaxBytesRuneBoundary(t *testing.T) {
+	c := NewCollector(0, 6)
+	c.Add("info", "12345")
+	c.Add("info", "é")
+	c.Add("info", "x")
+
+	entries := c.Entries()
+	assert.Len(t, entries, 1)
+	assert.True(t, c.Truncated())
+
+	c = NewCollector(0, 4)
+	c.Add("info", "ab€cd")
+
+	entries = c.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "ab", entries[0].Message)
+	assert.True(t, c.Truncated())
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	assert.Nil(t, FromContext(nil))
+
+	c := NewCollector(0, 0)
+	ctx := WithCollector(context.Background(), c)
+	assert.Same(t, c, FromContext(ctx))
+
+	Add(ctx, "warn", "message")
+	Add(context.Background(), "warn", "ignored")
+	assert.Len(t, c.Entries(), 1)
+}
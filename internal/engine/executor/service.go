@@ -2,27 +2,193 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/redact"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
 	"github.com/malyshevhen/rule-engine/internal/metrics"
-	lua "github.com/yuin/gopher-lua"
 )
 
-// Service handles Lua script execution
+// DefaultTimeout is the wall-clock limit applied to a script when neither the
+// service nor the execution context configures one
+const DefaultTimeout = 5 * time.Second
+
+// ExecutionStatus mirrors the execution_status enum stored with execution logs
+type ExecutionStatus string
+
+// Execution statuses enum
+const (
+	StatusSuccess ExecutionStatus = "SUCCESS"
+	StatusFailure ExecutionStatus = "FAILURE"
+	StatusTimeout ExecutionStatus = "TIMEOUT"
+)
+
+// Service handles script execution. Lua scripts and expressions are supported
+// out of the box and WithRuntime adds other languages.
 type Service struct {
 	contextService *execCtx.Service
 	platformAPI    *platform.Service
+	timeout        time.Duration
+	limits         execCtx.Limits
+	poolSize       int
+	cacheSize      int
+	legacyGlobals  bool
+	lua            *luaRuntime
+	expr           *exprRuntime
+	runtimes       map[string]Runtime
+	maxLogEntries  int
+	maxLogBytes    int
+	replayMaxBytes int // 0 unless executions are recorded for replay
+	coverage       CoverageRecorder
+	profiles       ProfileRecorder
+}
+
+// ServiceOption allows to configure the executor service
+type ServiceOption func(s *Service) *Service
+
+// WithTimeout sets the default wall-clock limit for a single script execution
+func WithTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) *Service {
+		if timeout > 0 {
+			s.timeout = timeout
+		}
+		return s
+	}
+}
+
+// WithLimits sets the default resource budget for a single script execution.
+// Zero fields keep the built-in defaults.
+func WithLimits(limits execCtx.Limits) ServiceOption {
+	return func(s *Service) *Service {
+		s.limits = mergeLimits(s.limits, limits)
+		return s
+	}
+}
+
+// WithStatePoolSize sets the number of idle Lua states kept for reuse.
+// A size of 0 disables pooling and every execution builds a fresh state.
+func WithStatePoolSize(size int) ServiceOption {
+	return func(s *Service) *Service {
+		if size >= 0 {
+			s.poolSize = size
+		}
+		return s
+	}
+}
+
+// WithScriptCacheSize sets the number of compiled scripts kept in memory, per language.
+// A size of 0 disables the cache and every execution compiles its script.
+func WithScriptCacheSize(size int) ServiceOption {
+	return func(s *Service) *Service {
+		if size >= 0 {
+			s.cacheSize = size
+		}
+		return s
+	}
+}
+
+// WithLegacyGlobals controls whether rule_id, trigger_id and every key of
+// ExecutionContext.Data are also exposed as globals, next to the ctx table.
+// They are enabled by default for compatibility with existing scripts.
+func WithLegacyGlobals(enabled bool) ServiceOption {
+	return func(s *Service) *Service {
+		s.legacyGlobals = enabled
+		return s
+	}
+}
+
+// WithLogLimits bounds the print and logger output captured from a single
+// execution by number of entries and total message size. 0 disables a bound.
+func WithLogLimits(maxEntries, maxBytes int) ServiceOption {
+	return func(s *Service) *Service {
+		if maxEntries >= 0 {
+			s.maxLogEntries = maxEntries
+		}
+		if maxBytes >= 0 {
+			s.maxLogBytes = maxBytes
+		}
+		return s
+	}
+}
+
+// WithReplayRecording makes every execution record the inputs needed to replay it:
+// its execution context, the times returned by time.now and the HTTP responses
+// the script received, keeping HTTP bodies up to maxBytes in total.
+// The recording is returned in ExecuteResult.Replay. A maxBytes of 0 disables recording.
+func WithReplayRecording(maxBytes int) ServiceOption {
+	return func(s *Service) *Service {
+		if maxBytes >= 0 {
+			s.replayMaxBytes = maxBytes
+		}
+		return s
+	}
+}
+
+// WithCoverageRecorder sets where the line hit counts of the executions whose
+// context enables coverage are aggregated, in addition to ExecuteResult.Coverage
+func WithCoverageRecorder(recorder CoverageRecorder) ServiceOption {
+	return func(s *Service) *Service {
+		s.coverage = recorder
+		return s
+	}
+}
+
+// ProfileRecorder aggregates the profiles of executions per rule
+type ProfileRecorder interface {
+	Record(ruleID string, p *profile.Profile)
+}
+
+// WithProfileRecorder sets where the profiles of the executions whose context
+// enables profiling are aggregated, in addition to ExecuteResult.Profile
+func WithProfileRecorder(recorder ProfileRecorder) ServiceOption {
+	return func(s *Service) *Service {
+		s.profiles = recorder
+		return s
+	}
+}
+
+// WithRuntime registers a runtime for the scripts written in its language.
+// The built-in lua and expr runtimes cannot be replaced.
+func WithRuntime(rt Runtime) ServiceOption {
+	return func(s *Service) *Service {
+		if s.runtimes == nil {
+			s.runtimes = make(map[string]Runtime)
+		}
+		s.runtimes[rt.Language()] = rt
+		return s
+	}
 }
 
 // NewService creates a new executor service
-func NewService(contextService *execCtx.Service, platformAPI *platform.Service) *Service {
-	return &Service{
+func NewService(contextService *execCtx.Service, platformAPI *platform.Service, opts ...ServiceOption) *Service {
+	s := &Service{
 		contextService: contextService,
 		platformAPI:    platformAPI,
+		timeout:        DefaultTimeout,
+		limits:         DefaultLimits(),
+		poolSize:       DefaultStatePoolSize,
+		cacheSize:      DefaultScriptCacheSize,
+		legacyGlobals:  true,
+		maxLogEntries:  scriptlog.DefaultMaxEntries,
+		maxLogBytes:    scriptlog.DefaultMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.runtimes == nil {
+		s.runtimes = make(map[string]Runtime)
+	}
+	s.lua = newLuaRuntime(platformAPI, s.limits, s.poolSize, s.cacheSize, s.legacyGlobals)
+	s.runtimes[LanguageLua] = s.lua
+	s.expr = newExprRuntime(s.cacheSize, s.legacyGlobals)
+	s.runtimes[LanguageExpr] = s.expr
+	return s
 }
 
 // GetContextService returns the context service
@@ -30,148 +196,184 @@ func (s *Service) GetContextService() *execCtx.Service {
 	return s.contextService
 }
 
-// ExecuteResult represents the result of script execution
+// InvalidateScript drops the compiled form of script from the caches.
+// Services call it when a rule, trigger or action script is replaced or deleted.
+func (s *Service) InvalidateScript(script string) {
+	s.lua.invalidateScript(script)
+	s.expr.invalidateScript(script)
+}
+
+// SetLibraries replaces the libraries scripts can require.
+// Pooled states preload the previous libraries, so they are dropped.
+func (s *Service) SetLibraries(libs []platform.Library) error {
+	if err := s.platformAPI.SetLibraries(libs); err != nil {
+		return err
+	}
+	s.lua.reset()
+	return nil
+}
+
+// Error types reported in ExecuteResult and the LuaExecutionErrorsTotal metric
+const (
+	ErrorTypeExecution      = "execution_error"
+	ErrorTypeTimeout        = "timeout"
+	ErrorTypeBudgetExceeded = "budget_exceeded"
+)
+
+// ExecuteResult represents the result of script execution.
+// Output holds every value returned by the script, in order.
+// Logs holds the lines printed or logged by the script, including on failure;
+// LogsTruncated is set when some of them were dropped to respect the log limits.
 type ExecuteResult struct {
-	Success  bool          `json:"success"`
-	Output   []any         `json:"output"`
-	Error    string        `json:"error,omitempty"`
-	Duration time.Duration `json:"duration"`
+	Success       bool              `json:"success"`
+	Status        ExecutionStatus   `json:"status"`
+	Output        []any             `json:"output"`
+	Error         string            `json:"error,omitempty"`
+	ErrorType     string            `json:"error_type,omitempty"`
+	Duration      time.Duration     `json:"duration"`
+	Logs          []scriptlog.Entry `json:"logs,omitempty"`
+	LogsTruncated bool              `json:"logs_truncated,omitempty"`
+	// Replay holds the recorded inputs of the execution when recording is enabled
+	Replay *replay.Bundle `json:"replay,omitempty"`
+	// Coverage maps the lines of the script to the number of times they were
+	// entered, when the execution context enables coverage
+	Coverage map[int]int `json:"coverage,omitempty"`
+	// Profile breaks the duration down into the time spent running the script and
+	// in each platform module call, when the execution context enables profiling
+	Profile *profile.Profile `json:"profile,omitempty"`
 }
 
-// ExecuteScript executes a Lua script with sandboxing
+// withExecution attaches ec to ctx. ExecuteScript cannot call the context
+// package directly because its parameter shadows the package name.
+func withExecution(ctx context.Context, ec *execCtx.ExecutionContext) context.Context {
+	return execCtx.WithExecution(ctx, ec)
+}
+
+// ExecuteScript executes a script with the runtime of execCtx.Language.
+// The script is cancelled once it has run for the execution timeout, ctx is
+// done or one of its resource budgets is exhausted. The time hooks hold the
+// script does not count towards the timeout.
 func (s *Service) ExecuteScript(ctx context.Context, script string, execCtx *execCtx.ExecutionContext) *ExecuteResult {
 	start := time.Now()
 
-	// Create a new Lua state with sandboxed options
-	L := s.newLuaState(execCtx)
-	defer L.Close()
+	timeout := s.timeout
+	if execCtx.Timeout > 0 {
+		timeout = execCtx.Timeout
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
 
-	// Execute the script
-	err := L.DoString(script)
-	duration := time.Since(start)
+	// Let the platform modules tell which rule the script belongs to
+	ctx = withExecution(ctx, execCtx)
 
-	status := "success"
-	if err != nil {
-		status = "failure"
-		metrics.LuaExecutionErrorsTotal.WithLabelValues(execCtx.RuleID, "execution_error").Inc()
-	}
+	// Hide the secrets the script reads from what is kept of the execution
+	secrets := redact.NewSet()
+	ctx = redact.WithSet(ctx, secrets)
 
-	// Record metrics
-	metrics.RuleExecutionsTotal.WithLabelValues(execCtx.RuleID, status).Inc()
-	metrics.RuleExecutionDuration.WithLabelValues(execCtx.RuleID).Observe(duration.Seconds())
+	// Capture what the script prints or logs
+	logs := scriptlog.NewCollector(s.maxLogEntries, s.maxLogBytes)
+	ctx = scriptlog.WithCollector(ctx, logs)
 
-	if err != nil {
-		return &ExecuteResult{
-			Success:  false,
-			Error:    err.Error(),
-			Duration: duration,
-		}
+	// Record the inputs of the execution, unless it is itself a replay
+	var recorder *replay.Recorder
+	if s.replayMaxBytes > 0 && replay.PlayerFromContext(ctx) == nil {
+		recorder = replay.NewRecorder(execCtx, s.replayMaxBytes)
+		ctx = replay.WithRecorder(ctx, recorder)
 	}
 
-	// Get the last return value (Lua scripts typically return one value)
-	top := L.GetTop()
-	results := make([]any, 0, 1)
-	if top > 0 {
-		result := L.Get(top)
-		results = append(results, luaValueToGo(result))
+	// Count the lines the script runs, which only Lua scripts have
+	var lines *lineCounter
+	if execCtx.Coverage && (execCtx.Language == "" || execCtx.Language == LanguageLua) {
+		lines = newLineCounter()
+		ctx = WithHook(ctx, lines)
 	}
 
-	return &ExecuteResult{
-		Success:  true,
-		Output:   results,
-		Duration: duration,
+	// Time the platform module calls of the script
+	var profiler *profile.Recorder
+	if execCtx.Profile {
+		profiler = profile.NewRecorder()
+		ctx = profile.WithRecorder(ctx, profiler)
 	}
-}
-
-func (s *Service) newLuaState(execCtx *execCtx.ExecutionContext) *lua.LState {
-	// Create a new Lua state with sandboxed options
-	L := lua.NewState(lua.Options{
-		SkipOpenLibs: true, // Don't open default libraries
-	})
-
-	// Open only essential safe libraries
-	lua.OpenBase(L)    // _G, basic functions
-	lua.OpenTable(L)   // table library
-	lua.OpenString(L)  // string library
-	lua.OpenMath(L)    // math library
-	lua.OpenPackage(L) // package library for require
-	// Explicitly do NOT open: io, os, debug, coroutine (if not needed)
 
-	// Remove any potentially unsafe globals that might be set
-	L.SetGlobal("io", lua.LNil)
-	L.SetGlobal("os", lua.LNil)
-	L.SetGlobal("debug", lua.LNil)
-	L.SetGlobal("coroutine", lua.LNil)
+	limits := mergeLimits(s.limits, execCtx.Limits)
 
-	// Set execution context in Lua
-	L.SetGlobal("rule_id", lua.LString(execCtx.RuleID))
-	L.SetGlobal("trigger_id", lua.LString(execCtx.TriggerID))
-
-	// Inject event data into Lua globals
-	for key, value := range execCtx.Data {
-		L.SetGlobal(key, luaValueToLValue(value))
+	// Execute the script with the runtime of its language
+	var output []any
+	rt, err := s.runtime(execCtx.Language)
+	if err == nil {
+		output, err = rt.Run(ctx, script, execCtx, limits)
 	}
+	duration := time.Since(start)
 
-	// Register platform API functions
-	s.platformAPI.RegisterAPIFunctions(L)
+	status := StatusSuccess
+	errorType := ""
+	errorMessage := ""
+	if err != nil {
+		status = StatusFailure
+		errorType = ErrorTypeExecution
+		errorMessage = secrets.String(err.Error())
+		var budgetErr *BudgetExceededError
+		if errors.As(err, &budgetErr) {
+			errorType = ErrorTypeBudgetExceeded
+			errorMessage = budgetErr.Error()
+		} else if errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+			status = StatusTimeout
+			errorType = ErrorTypeTimeout
+			errorMessage = fmt.Sprintf("script execution timed out after %s", timeout)
+			metrics.LuaExecutionTimeoutsTotal.WithLabelValues(execCtx.RuleID).Inc()
+		}
+		metrics.LuaExecutionErrorsTotal.WithLabelValues(execCtx.RuleID, errorType).Inc()
+	}
 
-	return L
-}
+	// Record metrics
+	metrics.RuleExecutionsTotal.WithLabelValues(execCtx.RuleID, statusLabel(status)).Inc()
+	metrics.RuleExecutionDuration.WithLabelValues(execCtx.RuleID).Observe(duration.Seconds())
 
-// luaValueToGo converts a Lua value to a Go interface{}
-func luaValueToGo(v lua.LValue) any {
-	switch v.Type() {
-	case lua.LTNil:
-		return nil
-	case lua.LTBool:
-		return lua.LVAsBool(v)
-	case lua.LTNumber:
-		return float64(v.(lua.LNumber))
-	case lua.LTString:
-		return string(v.(lua.LString))
-	case lua.LTTable:
-		// Avoid infinite recursion by not converting tables
-		return v.String()
-	default:
-		return v.String()
-	}
-}
-
-// luaValueToLValue converts a Go value to a Lua LValue
-func luaValueToLValue(v any) lua.LValue {
-	switch val := v.(type) {
-	case nil:
-		return lua.LNil
-	case bool:
-		return lua.LBool(val)
-	case int:
-		return lua.LNumber(val)
-	case int32:
-		return lua.LNumber(val)
-	case int64:
-		return lua.LNumber(val)
-	case float32:
-		return lua.LNumber(val)
-	case float64:
-		return lua.LNumber(val)
-	case string:
-		return lua.LString(val)
-	case []any:
-		// Convert slice to Lua table
-		table := &lua.LTable{}
-		for i, item := range val {
-			table.RawSetInt(i+1, luaValueToLValue(item)) // Lua is 1-indexed
+	for i, value := range output {
+		output[i] = secrets.Value(value)
+	}
+	result := &ExecuteResult{
+		Success:       true,
+		Status:        StatusSuccess,
+		Output:        output,
+		Duration:      duration,
+		Logs:          logs.Entries(),
+		LogsTruncated: logs.Truncated(),
+	}
+	if err != nil {
+		result.Success = false
+		result.Status = status
+		result.Output = nil
+		result.Error = errorMessage
+		result.ErrorType = errorType
+	}
+	if recorder != nil {
+		result.Replay = recorder.Bundle()
+		result.Replay.Redact(secrets.String)
+	}
+	if lines != nil {
+		result.Coverage = lines.hits
+		if s.coverage != nil {
+			s.coverage.Record(script, lines.hits)
 		}
-		return table
-	case map[string]any:
-		// Convert map to Lua table
-		table := &lua.LTable{}
-		for k, v := range val {
-			table.RawSetString(k, luaValueToLValue(v))
+	}
+	if profiler != nil {
+		result.Profile = profiler.Profile(duration)
+		if s.profiles != nil {
+			s.profiles.Record(execCtx.RuleID, result.Profile)
 		}
-		return table
+	}
+	return result
+}
+
+// statusLabel returns the metric label used for an execution status
+func statusLabel(status ExecutionStatus) string {
+	switch status {
+	case StatusSuccess:
+		return "success"
+	case StatusTimeout:
+		return "timeout"
 	default:
-		// For unsupported types, convert to string
-		return lua.LString(fmt.Sprintf("%v", v))
+		return "failure"
 	}
 }
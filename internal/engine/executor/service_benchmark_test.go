@@ -90,13 +90,10 @@ func BenchmarkLuaExecution_PlatformAPICalls(b *testing.B) {
 	}
 
 	script := `
-		log_message("Benchmarking platform API")
-		local device_data, err = get_device_state(device_id)
-		if err ~= nil then
-			return false
-		end
-		store_data("benchmark_key", device_data)
-		return device_data.online
+		local logger = require("logger")
+		local time = require("time")
+		logger.debug("Benchmarking platform API for " .. device_id)
+		return time.now(time.RFC3339) ~= ""
 	`
 
 	for b.Loop() {
@@ -144,12 +141,115 @@ func BenchmarkLuaExecution_LargeScript(b *testing.B) {
 		}
 		// Verify the result structure
 		resultMap := result.Output[0].(map[string]any)
-		if resultMap["sum"] != 55 {
+		if resultMap["sum"] != 55.0 {
 			b.Fatalf("Expected sum 55, got %v", resultMap["sum"])
 		}
 	}
 }
 
+// BenchmarkLuaExecution_StatePool compares pooled and freshly built Lua states
+// for a short condition script, where state setup dominates the run time
+func BenchmarkLuaExecution_StatePool(b *testing.B) {
+	script := "return event.temperature > threshold"
+	execContext := &execCtx.ExecutionContext{
+		RuleID:    "benchmark-rule",
+		TriggerID: "benchmark-trigger",
+		Data: map[string]any{
+			"event":     map[string]any{"temperature": 25.5},
+			"threshold": 20,
+		},
+	}
+
+	for _, bm := range []struct {
+		name     string
+		poolSize int
+	}{
+		{name: "Pooled", poolSize: DefaultStatePoolSize},
+		{name: "Unpooled", poolSize: 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			executorSvc := NewService(execCtx.NewService(), platform.NewService(), WithStatePoolSize(bm.poolSize))
+
+			for b.Loop() {
+				result := executorSvc.ExecuteScript(context.Background(), script, execContext)
+				if !result.Success {
+					b.Fatalf("Script execution failed: %s", result.Error)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLuaExecution_ScriptCache compares executing a cached compiled script
+// with parsing and compiling the source on every run
+func BenchmarkLuaExecution_ScriptCache(b *testing.B) {
+	script := `
+		if event.temperature > threshold and event.humidity < 80 then
+			return event.temperature - threshold
+		end
+		return 0
+	`
+	execContext := &execCtx.ExecutionContext{
+		RuleID:    "benchmark-rule",
+		TriggerID: "benchmark-trigger",
+		Data: map[string]any{
+			"event":     map[string]any{"temperature": 25.5, "humidity": 60},
+			"threshold": 20,
+		},
+	}
+
+	for _, bm := range []struct {
+		name      string
+		cacheSize int
+	}{
+		{name: "Cached", cacheSize: DefaultScriptCacheSize},
+		{name: "Uncached", cacheSize: 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			executorSvc := NewService(execCtx.NewService(), platform.NewService(), WithScriptCacheSize(bm.cacheSize))
+
+			for b.Loop() {
+				result := executorSvc.ExecuteScript(context.Background(), script, execContext)
+				if !result.Success {
+					b.Fatalf("Script execution failed: %s", result.Error)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLuaExecution_ConcurrentStatePool compares pooled and freshly built Lua
+// states when one executor is shared by concurrent callers
+func BenchmarkLuaExecution_ConcurrentStatePool(b *testing.B) {
+	script := "return math.sin(x) + math.cos(y)"
+
+	for _, bm := range []struct {
+		name     string
+		poolSize int
+	}{
+		{name: "Pooled", poolSize: DefaultStatePoolSize},
+		{name: "Unpooled", poolSize: 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			executorSvc := NewService(execCtx.NewService(), platform.NewService(), WithStatePoolSize(bm.poolSize))
+
+			b.RunParallel(func(pb *testing.PB) {
+				execContext := &execCtx.ExecutionContext{
+					RuleID:    "concurrent-rule",
+					TriggerID: "concurrent-trigger",
+					Data:      map[string]any{"x": 1.5, "y": 2.3},
+				}
+				for pb.Next() {
+					result := executorSvc.ExecuteScript(context.Background(), script, execContext)
+					if !result.Success {
+						b.Fatalf("Concurrent script execution failed: %s", result.Error)
+					}
+				}
+			})
+		})
+	}
+}
+
 // BenchmarkLuaExecution_Concurrent benchmarks concurrent script execution
 func BenchmarkLuaExecution_Concurrent(b *testing.B) {
 	script := "return math.sin(x) + math.cos(y)"
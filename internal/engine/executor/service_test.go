@@ -7,6 +7,9 @@ import (
 
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/profile"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +23,7 @@ func TestExecutorService_ExecuteScript_Success(t *testing.T) {
 	result := svc.ExecuteScript(context.Background(), "return 'success'", ctx)
 
 	assert.True(t, result.Success)
+	assert.Equal(t, StatusSuccess, result.Status)
 	assert.NotEmpty(t, result.Output)
 	assert.Empty(t, result.Error)
 	assert.Greater(t, result.Duration, time.Duration(0))
@@ -81,3 +85,399 @@ func TestExecutorService_GetContextService(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, ctxSvc, result)
 }
+
+func TestExecutorService_ExecuteScript_Timeout(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc, WithTimeout(50*time.Millisecond))
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+
+	result := svc.ExecuteScript(context.Background(), "while true do end", ctx)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, StatusTimeout, result.Status)
+	assert.Contains(t, result.Error, "timed out")
+	assert.Less(t, result.Duration, time.Second)
+}
+
+func TestExecutorService_ExecuteScript_TimeoutInBuiltin(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc, WithTimeout(50*time.Millisecond))
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+
+	// Each gsub runs for longer than the timeout within a single instruction
+	start := time.Now()
+	result := svc.ExecuteScript(context.Background(), `
+		local s = string.rep("x", 4 * 1024 * 1024)
+		while true do s = s:gsub(".", "y") end
+	`, ctx)
+	elapsed := time.Since(start)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, StatusTimeout, result.Status)
+	assert.Less(t, elapsed, 300*time.Millisecond)
+}
+
+func TestExecutorService_ExecuteScript_ContextTimeoutOverride(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc, WithTimeout(time.Minute))
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ctx.Timeout = 20 * time.Millisecond
+
+	result := svc.ExecuteScript(context.Background(), "while true do end", ctx)
+
+	assert.Equal(t, StatusTimeout, result.Status)
+	assert.Less(t, result.Duration, time.Second)
+}
+
+func TestExecutorService_ExecuteScript_Cancelled(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc)
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+
+	parent, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := svc.ExecuteScript(parent, "while true do end", ctx)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, StatusFailure, result.Status)
+	assert.Contains(t, result.Error, "context canceled")
+}
+
+func TestExecutorService_ExecuteScript_InstructionBudget(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc, WithLimits(execCtx.Limits{MaxInstructions: 1000}))
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+
+	result := svc.ExecuteScript(context.Background(), "while true do end", ctx)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, StatusFailure, result.Status)
+	assert.Equal(t, ErrorTypeBudgetExceeded, result.ErrorType)
+	assert.Contains(t, result.Error, ResourceInstructions)
+
+	// Short scripts stay within the same budget
+	result = svc.ExecuteScript(context.Background(), "local x = 0 for i = 1, 10 do x = x + i end return x", ctx)
+	assert.True(t, result.Success)
+	assert.Equal(t, 55.0, result.Output[0])
+}
+
+func TestExecutorService_ExecuteScript_CallStackBudget(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc)
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ctx.Limits = execCtx.Limits{MaxCallStackDepth: 16}
+
+	result := svc.ExecuteScript(context.Background(), "local function f(n) return 1 + f(n + 1) end return f(1)", ctx)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorTypeBudgetExceeded, result.ErrorType)
+	assert.Contains(t, result.Error, ResourceCallStack)
+}
+
+func TestExecutorService_ExecuteScript_MemoryBudget(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService()
+	svc := NewService(ctxSvc, platformSvc, WithLimits(execCtx.Limits{MaxMemoryBytes: 1 << 20}))
+
+	ctx := ctxSvc.CreateContext("test-rule", "test-trigger")
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{
+			name:   "string.rep",
+			script: "return string.rep('x', 10 * 1024 * 1024)",
+		},
+		{
+			name:   "doubling concatenation",
+			script: `local s = "x" for i = 1, 27 do s = s .. s end return #s`,
+		},
+		{
+			name: "table.concat",
+			script: `
+				local chunk = string.rep("x", 512 * 1024)
+				local t = {}
+				for i = 1, 80 do t[i] = chunk end
+				return #table.concat(t)
+			`,
+		},
+		{
+			name:   "string.format",
+			script: `local s = string.rep("x", 512 * 1024) return #string.format("%s%s%s", s, s, s)`,
+		},
+		{
+			name:   "string.gsub",
+			script: `local s = string.rep("x", 512 * 1024) return #s:gsub("x", "yyyy")`,
+		},
+		{
+			name: "growing table",
+			script: `
+				local t = {}
+				for i = 1, 1000000 do
+					t[i] = "value-" .. i
+				end
+				return #t
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := svc.ExecuteScript(context.Background(), tt.script, ctx)
+
+			assert.False(t, result.Success)
+			assert.Equal(t, ErrorTypeBudgetExceeded, result.ErrorType)
+			assert.Contains(t, result.Error, ResourceMemory)
+		})
+	}
+}
+
+func TestExecutorService_ExecuteScript_StructuredResult(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	result := svc.ExecuteScript(context.Background(), `
+		return {status = "alert", readings = {21.5, 22}, device = {id = device_id}}
+	`, &execCtx.ExecutionContext{RuleID: "test-rule", Data: map[string]any{"device_id": "d1"}})
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{map[string]any{
+		"status":   "alert",
+		"readings": []any{21.5, 22.0},
+		"device":   map[string]any{"id": "d1"},
+	}}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_MultipleReturns(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	result := svc.ExecuteScript(context.Background(), `return true, "too hot", nil`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{true, "too hot", nil}, result.Output)
+}
+
+func TestExecutorService_ExecuteScript_CyclicResult(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	result := svc.ExecuteScript(context.Background(), `local t = {} t.self = t return t`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorTypeExecution, result.ErrorType)
+	assert.Contains(t, result.Error, "cyclic table")
+}
+
+func TestExecutorService_ExecuteScript_CapturesLogs(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+
+	result := svc.ExecuteScript(context.Background(), `
+		local logger = require("logger")
+		print("reading", 21.5, true)
+		logger.warn("too hot")
+		error("boom")
+	`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.False(t, result.Success)
+	if assert.Len(t, result.Logs, 2) {
+		assert.Equal(t, "info", result.Logs[0].Level)
+		assert.Equal(t, "reading\t21.5\ttrue", result.Logs[0].Message)
+		assert.Equal(t, "warn", result.Logs[1].Level)
+		assert.Equal(t, "too hot", result.Logs[1].Message)
+		assert.False(t, result.Logs[0].Timestamp.IsZero())
+	}
+	assert.False(t, result.LogsTruncated)
+}
+
+// tokenResolver hands out the same secret to every rule
+type tokenResolver string
+
+func (r tokenResolver) Resolve(context.Context, string, string) (string, error) {
+	return string(r), nil
+}
+
+func TestExecutorService_ExecuteScript_RedactsSecrets(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	platformSvc := platform.NewService(platform.WithModule(modules.NewSecretsModule(tokenResolver("tok-123456"))))
+	svc := NewService(ctxSvc, platformSvc, WithReplayRecording(replay.DefaultMaxBytes))
+
+	result := svc.ExecuteScript(context.Background(), `
+		local token = require("secrets").get("api_token")
+		print("token", token)
+		require("logger").info("Bearer " .. token)
+		return {header = "Bearer " .. token}, token
+	`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{map[string]any{"header": "Bearer [REDACTED]"}, "[REDACTED]"}, result.Output)
+	if assert.Len(t, result.Logs, 2) {
+		assert.Equal(t, "token\t[REDACTED]", result.Logs[0].Message)
+		assert.Equal(t, "Bearer [REDACTED]", result.Logs[1].Message)
+	}
+
+	result = svc.ExecuteScript(context.Background(), `
+		error("rejected " .. require("secrets").get("api_token"))
+	`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "rejected [REDACTED]")
+	assert.NotContains(t, result.Error, "tok-123456")
+}
+
+func TestExecutorService_ExecuteScript_LogLimits(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService(), WithLogLimits(3, 0))
+
+	result := svc.ExecuteScript(context.Background(), `for i = 1, 10 do print(i) end`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	assert.Len(t, result.Logs, 3)
+	assert.True(t, result.LogsTruncated)
+
+	// Logs of one execution do not leak into the next run of a pooled state
+	result = svc.ExecuteScript(context.Background(), `return 1`, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.Empty(t, result.Logs)
+	assert.False(t, result.LogsTruncated)
+}
+
+func TestExecutorService_ExecuteScript_ReplayRecording(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	script := `local time = require("time") return time.now()`
+
+	result := NewService(ctxSvc, platform.NewService()).ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.Nil(t, result.Replay)
+
+	svc := NewService(ctxSvc, platform.NewService(), WithReplayRecording(replay.DefaultMaxBytes))
+	result = svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+
+	assert.True(t, result.Success, result.Error)
+	if assert.NotNil(t, result.Replay) {
+		assert.Equal(t, "test-rule", result.Replay.Context.RuleID)
+		assert.Len(t, result.Replay.Times, 1)
+		assert.Equal(t, result.Replay.Times[0].Local().String(), result.Output[0])
+	}
+}
+
+type recordedCoverage struct {
+	script string
+	hits   map[int]int
+}
+
+func (r *recordedCoverage) Record(script string, hits map[int]int) {
+	r.script, r.hits = script, hits
+}
+
+func TestExecutorService_ExecuteScript_Coverage(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	recorder := &recordedCoverage{}
+	svc := NewService(ctxSvc, platform.NewService(), WithCoverageRecorder(recorder))
+	script := `local total = 0
+for i = 1, 3 do
+  total = total + i
+end
+if total > 10 then
+  total = 0
+end
+return total`
+
+	result := svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+	assert.Nil(t, result.Coverage)
+	assert.Nil(t, recorder.hits)
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Coverage = true
+	result = svc.ExecuteScript(context.Background(), script, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{6.0}, result.Output)
+	assert.Equal(t, 1, result.Coverage[1])
+	assert.Equal(t, 3, result.Coverage[3])
+	assert.Equal(t, 1, result.Coverage[5])
+	assert.Zero(t, result.Coverage[6])
+	assert.Equal(t, 1, result.Coverage[8])
+	assert.Equal(t, script, recorder.script)
+	assert.Equal(t, result.Coverage, recorder.hits)
+}
+
+func TestExecutorService_ExecuteScript_CoverageOfSingleLineLoops(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	svc := NewService(ctxSvc, platform.NewService())
+	script := `local x = 0
+for i = 1, 10 do x = x + i end
+while x > 50 do x = x - 1 end
+return x`
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Coverage = true
+	result := svc.ExecuteScript(context.Background(), script, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []any{50.0}, result.Output)
+	assert.Equal(t, 1, result.Coverage[1])
+	// Entering the line, then every jump back to the start of the loop
+	assert.Equal(t, 11, result.Coverage[2])
+	assert.Equal(t, 6, result.Coverage[3])
+	assert.Equal(t, 1, result.Coverage[4])
+}
+
+type recordedProfile struct {
+	ruleID  string
+	profile *profile.Profile
+}
+
+func (r *recordedProfile) Record(ruleID string, p *profile.Profile) {
+	r.ruleID, r.profile = ruleID, p
+}
+
+func TestExecutorService_ExecuteScript_Profile(t *testing.T) {
+	ctxSvc := execCtx.NewService()
+	recorder := &recordedProfile{}
+	svc := NewService(ctxSvc, platform.NewService(), WithProfileRecorder(recorder))
+	script := `local logger = require("logger")
+local time = require("time")
+for i = 1, 3 do
+  logger.info("tick " .. i)
+end
+return time.now() ~= nil`
+
+	result := svc.ExecuteScript(context.Background(), script, ctxSvc.CreateContext("test-rule", "test-trigger"))
+	assert.True(t, result.Success, result.Error)
+	assert.Nil(t, result.Profile)
+	assert.Nil(t, recorder.profile)
+
+	ec := ctxSvc.CreateContext("test-rule", "test-trigger")
+	ec.Profile = true
+	result = svc.ExecuteScript(context.Background(), script, ec)
+
+	assert.True(t, result.Success, result.Error)
+	assert.NotNil(t, result.Profile)
+	assert.Equal(t, result.Duration, result.Profile.Total)
+	assert.LessOrEqual(t, result.Profile.VM, result.Profile.Total)
+	counts := make(map[string]int)
+	var inCalls time.Duration
+	for _, call := range result.Profile.Calls {
+		counts[call.Name] = call.Count
+		inCalls += call.Duration
+	}
+	assert.Equal(t, map[string]int{"logger.info": 3, "time.now": 1}, counts)
+	assert.Equal(t, result.Profile.Total, result.Profile.VM+inCalls)
+	assert.Equal(t, "test-rule", recorder.ruleID)
+	assert.Same(t, result.Profile, recorder.profile)
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"context"
+	"time"
+)
+
+// executionClock times an execution out once its script has run for the
+// timeout. The time hooks hold the script, such as a debugger paused on a
+// breakpoint, does not count.
+type executionClock struct {
+	timer     *time.Timer
+	remaining time.Duration
+	resumed   time.Time
+	paused    bool
+}
+
+type clockKey struct{}
+
+// withTimeout returns a context cancelled with context.DeadlineExceeded as its
+// cause once the script has run for timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	clock := &executionClock{remaining: timeout, resumed: time.Now()}
+	clock.timer = time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	ctx = context.WithValue(ctx, clockKey{}, clock)
+	return ctx, func() {
+		clock.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+func clockFromContext(ctx context.Context) *executionClock {
+	clock, _ := ctx.Value(clockKey{}).(*executionClock)
+	return clock
+}
+
+// pause stops the clock. It must be called on the goroutine running the script.
+func (c *executionClock) pause() {
+	if c == nil || c.paused {
+		return
+	}
+	// A clock that already fired stays stopped
+	if c.timer.Stop() {
+		c.paused = true
+		c.remaining -= time.Since(c.resumed)
+	}
+}
+
+// resume restarts the clock stopped by pause
+func (c *executionClock) resume() {
+	if c == nil || !c.paused {
+		return
+	}
+	c.paused = false
+	c.resumed = time.Now()
+	c.timer.Reset(c.remaining)
+}
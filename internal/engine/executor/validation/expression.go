@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"errors"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/expr"
+)
+
+// ExpressionValidator checks the syntax of expr trigger conditions.
+// Unknown variables are only reported at evaluation time, since the
+// variables of an expression depend on the event.
+type ExpressionValidator struct{}
+
+// Validate compiles source and reports the compile error, if any
+func (ExpressionValidator) Validate(source string) []Diagnostic {
+	if _, err := expr.Compile(source); err != nil {
+		d := Diagnostic{Severity: SeverityError, Code: CodeSyntaxError, Message: err.Error()}
+		var exprErr *expr.Error
+		if errors.As(err, &exprErr) {
+			d.Message = exprErr.Message
+			d.Line = exprErr.Line
+			d.Column = exprErr.Column
+		}
+		return []Diagnostic{d}
+	}
+	return nil
+}
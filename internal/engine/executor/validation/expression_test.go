@@ -0,0 +1,21 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionValidator_Validate(t *testing.T) {
+	v := ExpressionValidator{}
+
+	assert.Empty(t, v.Validate(`ctx.event.payload.temperature > 25 && ctx.event.subject == "sensors.kitchen"`))
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityError, Code: CodeSyntaxError, Message: "unexpected '=', use '==' to compare values", Line: 2, Column: 15},
+	}, v.Validate("ctx.attempt > 1 &&\n  ctx.attempt = 2"))
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityError, Code: CodeSyntaxError, Message: "unknown function 'exec'", Line: 1, Column: 1},
+	}, v.Validate(`exec("rm -rf /")`))
+}
@@ -0,0 +1,412 @@
+// Package validation statically checks Lua scripts and expr conditions before they are stored.
+// It catches syntax errors, references to globals that the sandbox removes
+// and require calls for modules the platform does not provide, so that
+// broken scripts are rejected at create/update time rather than at the first event.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/ast"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic codes
+const (
+	CodeSyntaxError     = "syntax_error"
+	CodeForbiddenGlobal = "forbidden_global"
+	CodeUnknownModule   = "unknown_module"
+	CodeDynamicRequire  = "dynamic_require"
+)
+
+// chunkName is the chunk name used when parsing, matching the executor
+const chunkName = "<string>"
+
+// DefaultForbiddenGlobals lists the globals removed from the sandbox.
+// The executor removes exactly these globals when building a Lua state.
+var DefaultForbiddenGlobals = []string{
+	"os", "io", "debug", "coroutine", "package", "module",
+	"load", "loadfile", "loadstring", "dofile",
+	"getfenv", "setfenv", "newproxy",
+}
+
+// StandardModules lists the standard libraries that can be required inside the sandbox
+var StandardModules = []string{"string", "table", "math"}
+
+// Diagnostic describes a problem found in a script.
+// Column is only known for syntax errors.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", d.Line, d.Column, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("line %d: %s", d.Line, d.Message)
+	default:
+		return d.Message
+	}
+}
+
+// HasErrors reports whether any diagnostic has error severity
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleSource tells whether a module that may change at runtime, such as a
+// library managed through the API, is available to scripts
+type ModuleSource interface {
+	HasModule(name string) bool
+}
+
+// Validator checks scripts against the set of modules available to them
+type Validator struct {
+	modules   map[string]struct{}
+	forbidden map[string]struct{}
+	sources   []ModuleSource
+}
+
+// NewValidator creates a validator that accepts require calls for the given
+// modules and the standard libraries
+func NewValidator(modules ...string) *Validator {
+	v := &Validator{
+		modules:   make(map[string]struct{}),
+		forbidden: make(map[string]struct{}),
+	}
+	for _, name := range StandardModules {
+		v.modules[name] = struct{}{}
+	}
+	for _, name := range modules {
+		v.modules[name] = struct{}{}
+	}
+	for _, name := range DefaultForbiddenGlobals {
+		v.forbidden[name] = struct{}{}
+	}
+	return v
+}
+
+// WithModuleSource makes the validator also accept the modules of source
+func (v *Validator) WithModuleSource(source ModuleSource) *Validator {
+	v.sources = append(v.sources, source)
+	return v
+}
+
+func (v *Validator) hasModule(name string) bool {
+	if _, ok := v.modules[name]; ok {
+		return true
+	}
+	for _, source := range v.sources {
+		if source.HasModule(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Requires returns the modules script requires by a literal name, in order of
+// first appearance. Dynamic names cannot be resolved and are left out.
+func Requires(script string) ([]string, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), chunkName)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walker{validator: NewValidator()}
+	w.block(chunk)
+	return w.requires, nil
+}
+
+// Validate parses and compiles script and reports every problem found, ordered by line.
+// A script that cannot be parsed only reports its syntax error.
+func (v *Validator) Validate(script string) []Diagnostic {
+	chunk, err := parse.Parse(strings.NewReader(script), chunkName)
+	if err != nil {
+		return []Diagnostic{syntaxDiagnostic(err, script)}
+	}
+	if _, err := lua.Compile(chunk, chunkName); err != nil {
+		return []Diagnostic{syntaxDiagnostic(err, script)}
+	}
+
+	w := &walker{validator: v}
+	w.block(chunk)
+
+	sort.SliceStable(w.diagnostics, func(i, j int) bool {
+		return w.diagnostics[i].Line < w.diagnostics[j].Line
+	})
+	return w.diagnostics
+}
+
+// syntaxDiagnostic converts a parser or compiler error into a diagnostic
+func syntaxDiagnostic(err error, script string) Diagnostic {
+	d := Diagnostic{Severity: SeverityError, Code: CodeSyntaxError, Message: strings.TrimSpace(err.Error())}
+
+	var parseErr *parse.Error
+	var compileErr *lua.CompileError
+	switch {
+	case errors.As(err, &parseErr):
+		d.Message = parseErr.Message
+		if parseErr.Pos.Line == parse.EOF {
+			d.Line = strings.Count(script, "\n") + 1
+			d.Message = fmt.Sprintf("%s at end of script", parseErr.Message)
+		} else {
+			d.Line = parseErr.Pos.Line
+			d.Column = parseErr.Pos.Column
+			if parseErr.Token != "" {
+				d.Message = fmt.Sprintf("%s near '%s'", parseErr.Message, parseErr.Token)
+			}
+		}
+	case errors.As(err, &compileErr):
+		d.Line = compileErr.Line
+		d.Message = compileErr.Message
+	}
+	return d
+}
+
+// walker visits the syntax tree while tracking local scopes,
+// so that locals shadowing a forbidden global are not reported
+type walker struct {
+	validator   *Validator
+	scopes      []map[string]struct{}
+	diagnostics []Diagnostic
+	requires    []string
+}
+
+func (w *walker) push(names ...string) {
+	scope := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		scope[name] = struct{}{}
+	}
+	w.scopes = append(w.scopes, scope)
+}
+
+func (w *walker) pop() {
+	w.scopes = w.scopes[:len(w.scopes)-1]
+}
+
+func (w *walker) declare(names ...string) {
+	scope := w.scopes[len(w.scopes)-1]
+	for _, name := range names {
+		scope[name] = struct{}{}
+	}
+}
+
+func (w *walker) isLocal(name string) bool {
+	for i := len(w.scopes) - 1; i >= 0; i-- {
+		if _, ok := w.scopes[i][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isGlobal reports whether expr refers to the global variable name
+func (w *walker) isGlobal(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.IdentExpr)
+	return ok && ident.Value == name && !w.isLocal(name)
+}
+
+func (w *walker) report(line int, code, format string, args ...any) {
+	w.diagnostics = append(w.diagnostics, Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     line,
+	})
+}
+
+func (w *walker) forbiddenGlobal(line int, name string) {
+	w.report(line, CodeForbiddenGlobal, "access to '%s' is not allowed in the sandbox", name)
+}
+
+func (w *walker) block(stmts []ast.Stmt) {
+	w.push()
+	defer w.pop()
+	for _, stmt := range stmts {
+		w.stmt(stmt)
+	}
+}
+
+func (w *walker) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		w.exprs(s.Lhs)
+		w.exprs(s.Rhs)
+	case *ast.LocalAssignStmt:
+		// "local function f" must see its own name for recursion
+		if len(s.Names) == 1 && len(s.Exprs) == 1 {
+			if _, ok := s.Exprs[0].(*ast.FunctionExpr); ok {
+				w.declare(s.Names...)
+				w.exprs(s.Exprs)
+				return
+			}
+		}
+		w.exprs(s.Exprs)
+		w.declare(s.Names...)
+	case *ast.FuncCallStmt:
+		w.expr(s.Expr)
+	case *ast.DoBlockStmt:
+		w.block(s.Stmts)
+	case *ast.WhileStmt:
+		w.expr(s.Condition)
+		w.block(s.Stmts)
+	case *ast.RepeatStmt:
+		// The condition can see the locals of the body
+		w.push()
+		for _, inner := range s.Stmts {
+			w.stmt(inner)
+		}
+		w.expr(s.Condition)
+		w.pop()
+	case *ast.IfStmt:
+		w.expr(s.Condition)
+		w.block(s.Then)
+		w.block(s.Else)
+	case *ast.NumberForStmt:
+		w.expr(s.Init)
+		w.expr(s.Limit)
+		if s.Step != nil {
+			w.expr(s.Step)
+		}
+		w.push(s.Name)
+		w.block(s.Stmts)
+		w.pop()
+	case *ast.GenericForStmt:
+		w.exprs(s.Exprs)
+		w.push(s.Names...)
+		w.block(s.Stmts)
+		w.pop()
+	case *ast.FuncDefStmt:
+		w.expr(s.Name.Func)
+		if s.Name.Receiver != nil {
+			w.expr(s.Name.Receiver)
+		}
+		params := paramNames(s.Func)
+		if s.Name.Method != "" {
+			params = append([]string{"self"}, params...)
+		}
+		w.function(params, s.Func.Stmts)
+	case *ast.ReturnStmt:
+		w.exprs(s.Exprs)
+	}
+}
+
+func (w *walker) exprs(exprs []ast.Expr) {
+	for _, expr := range exprs {
+		w.expr(expr)
+	}
+}
+
+func (w *walker) expr(expr ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.IdentExpr:
+		if _, ok := w.validator.forbidden[e.Value]; ok && !w.isLocal(e.Value) {
+			w.forbiddenGlobal(e.Line(), e.Value)
+		}
+	case *ast.AttrGetExpr:
+		// _G.os and _G["os"] reach the same globals
+		if key, ok := e.Key.(*ast.StringExpr); ok && w.isGlobal(e.Object, "_G") {
+			if _, forbidden := w.validator.forbidden[key.Value]; forbidden {
+				w.forbiddenGlobal(e.Line(), key.Value)
+			}
+		}
+		w.expr(e.Object)
+		w.expr(e.Key)
+	case *ast.TableExpr:
+		for _, field := range e.Fields {
+			if field.Key != nil {
+				w.expr(field.Key)
+			}
+			w.expr(field.Value)
+		}
+	case *ast.FuncCallExpr:
+		if w.isGlobal(e.Func, "require") {
+			w.require(e)
+		}
+		if e.Func != nil {
+			w.expr(e.Func)
+		}
+		if e.Receiver != nil {
+			w.expr(e.Receiver)
+		}
+		w.exprs(e.Args)
+	case *ast.LogicalOpExpr:
+		w.expr(e.Lhs)
+		w.expr(e.Rhs)
+	case *ast.RelationalOpExpr:
+		w.expr(e.Lhs)
+		w.expr(e.Rhs)
+	case *ast.StringConcatOpExpr:
+		w.expr(e.Lhs)
+		w.expr(e.Rhs)
+	case *ast.ArithmeticOpExpr:
+		w.expr(e.Lhs)
+		w.expr(e.Rhs)
+	case *ast.UnaryMinusOpExpr:
+		w.expr(e.Expr)
+	case *ast.UnaryNotOpExpr:
+		w.expr(e.Expr)
+	case *ast.UnaryLenOpExpr:
+		w.expr(e.Expr)
+	case *ast.FunctionExpr:
+		w.function(paramNames(e), e.Stmts)
+	}
+}
+
+func (w *walker) function(params []string, body []ast.Stmt) {
+	w.push(params...)
+	w.block(body)
+	w.pop()
+}
+
+func paramNames(fn *ast.FunctionExpr) []string {
+	if fn.ParList == nil {
+		return nil
+	}
+	return fn.ParList.Names
+}
+
+// require checks that a literal module name is provided by the platform.
+// Dynamic names cannot be resolved statically and only produce a warning.
+func (w *walker) require(call *ast.FuncCallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	name, ok := call.Args[0].(*ast.StringExpr)
+	if !ok {
+		w.diagnostics = append(w.diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Code:     CodeDynamicRequire,
+			Message:  "module name is not a string literal and cannot be checked",
+			Line:     call.Line(),
+		})
+		return
+	}
+	if !slices.Contains(w.requires, name.Value) {
+		w.requires = append(w.requires, name.Value)
+	}
+	if !w.validator.hasModule(name.Value) {
+		w.report(call.Line(), CodeUnknownModule, "unknown module '%s'", name.Value)
+	}
+}
@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator("logger", "http", "time")
+
+	tests := []struct {
+		name     string
+		script   string
+		expected []Diagnostic
+	}{
+		{
+			name:   "valid script",
+			script: "local logger = require('logger')\nif event.temperature > 25 then\n  logger.info('hot')\n  return true\nend\nreturn false",
+		},
+		{
+			name:   "standard library require",
+			script: "local s = require('string')\nreturn s.upper('x')",
+		},
+		{
+			name:   "syntax error",
+			script: "if x > 1 then\n  return true\nelse return false",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeSyntaxError, Message: "syntax error at end of script", Line: 3},
+			},
+		},
+		{
+			name:   "syntax error with column",
+			script: "local x = 1\nreturn x +* 2",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeSyntaxError, Message: "syntax error near '*'", Line: 2, Column: 11},
+			},
+		},
+		{
+			name:   "compile error",
+			script: "local function f() return ... end",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeSyntaxError, Message: "cannot use '...' outside a vararg function", Line: 1},
+			},
+		},
+		{
+			name:   "forbidden globals",
+			script: "os.exit(1)\nlocal f = io.open('/etc/passwd')\nreturn load('return 1')",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'os' is not allowed in the sandbox", Line: 1},
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'io' is not allowed in the sandbox", Line: 2},
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'load' is not allowed in the sandbox", Line: 3},
+			},
+		},
+		{
+			name:   "forbidden global through _G",
+			script: "return _G.debug, _G['dofile']",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'debug' is not allowed in the sandbox", Line: 1},
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'dofile' is not allowed in the sandbox", Line: 1},
+			},
+		},
+		{
+			name:   "environment access",
+			script: "setfenv(1, {})\nreturn getfenv(1), newproxy(true)",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'setfenv' is not allowed in the sandbox", Line: 1},
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'getfenv' is not allowed in the sandbox", Line: 2},
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'newproxy' is not allowed in the sandbox", Line: 2},
+			},
+		},
+		{
+			name:   "locals shadow forbidden names",
+			script: "local os = {exit = function() end}\nos.exit()\nlocal function load(io) return io end\nfor _, debug in ipairs({}) do end\nreturn load(1)",
+		},
+		{
+			name:   "field named like a forbidden global",
+			script: "local t = {os = 'linux'}\nreturn t.os",
+		},
+		{
+			name:   "forbidden global inside nested function",
+			script: "local function run()\n  return function()\n    return dofile('x')\n  end\nend",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeForbiddenGlobal, Message: "access to 'dofile' is not allowed in the sandbox", Line: 3},
+			},
+		},
+		{
+			name:   "unknown module",
+			script: "local json = require('json')\nlocal lfs = require 'lfs'",
+			expected: []Diagnostic{
+				{Severity: SeverityError, Code: CodeUnknownModule, Message: "unknown module 'json'", Line: 1},
+				{Severity: SeverityError, Code: CodeUnknownModule, Message: "unknown module 'lfs'", Line: 2},
+			},
+		},
+		{
+			name:   "dynamic require",
+			script: "local name = 'http'\nreturn require(name)",
+			expected: []Diagnostic{
+				{Severity: SeverityWarning, Code: CodeDynamicRequire, Message: "module name is not a string literal and cannot be checked", Line: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, v.Validate(tt.script))
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	assert.False(t, HasErrors(nil))
+	assert.False(t, HasErrors([]Diagnostic{{Severity: SeverityWarning}}))
+	assert.True(t, HasErrors([]Diagnostic{{Severity: SeverityWarning}, {Severity: SeverityError}}))
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	assert.Equal(t, "line 2, column 5: syntax error", Diagnostic{Message: "syntax error", Line: 2, Column: 5}.String())
+	assert.Equal(t, "line 3: unknown module 'x'", Diagnostic{Message: "unknown module 'x'", Line: 3}.String())
+	assert.Equal(t, "oops", Diagnostic{Message: "oops"}.String())
+}
+
+type moduleSet map[string]bool
+
+func (m moduleSet) HasModule(name string) bool { return m[name] }
+
+func TestValidator_WithModuleSource(t *testing.T) {
+	libraries := moduleSet{}
+	v := NewValidator("logger").WithModuleSource(libraries)
+	script := "local units = require('units')\nreturn units.celsius(20)"
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: SeverityError, Code: CodeUnknownModule, Message: "unknown module 'units'", Line: 1},
+	}, v.Validate(script))
+
+	libraries["units"] = true
+	assert.Empty(t, v.Validate(script))
+}
+
+func TestRequires(t *testing.T) {
+	requires, err := Requires(`
+		local logger = require("logger")
+		local units = require("units")
+		local function f(require) return require("ignored") end
+		local again = require("units")
+		local dynamic = require(name)
+		return require("math")
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"logger", "units", "math"}, requires)
+
+	_, err = Requires("return (")
+	assert.Error(t, err)
+}
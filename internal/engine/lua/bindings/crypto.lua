@@ -0,0 +1,79 @@
+---@meta
+
+---@module 'crypto' Crypto module that hashes, signs, encodes and generates identifiers.
+--- Generated identifiers and bytes are recorded with the execution, so replays see the same values.
+local crypto
+
+--- Encodings of binary values. base64url has no padding.
+---@alias crypto.Encoding 'hex'|'base64'|'base64url'|'raw'
+
+--- SHA256 returns the SHA-256 digest of a string
+---
+---@param data string Data to hash
+---@param encoding crypto.Encoding? Encoding of the digest, hex by default
+---@return string digest
+function crypto.sha256(data, encoding) end
+
+--- HMACSHA256 returns the HMAC-SHA256 of a string with a key
+---
+--- Example:
+--- ```
+--- local crypto = require 'crypto'
+---
+--- local body = '{"event": "overheat"}'
+--- local signature = crypto.hmac_sha256('webhook key', body)
+--- ```
+---
+---@param key string Key to sign with
+---@param data string Data to sign
+---@param encoding crypto.Encoding? Encoding of the signature, hex by default
+---@return string signature
+function crypto.hmac_sha256(key, data, encoding) end
+
+--- Base64Encode returns the base64 encoding of a string
+---
+---@param data string Data to encode
+---@param url boolean? Whether to use the URL-safe alphabet without padding
+---@return string encoded
+function crypto.base64_encode(data, url) end
+
+--- Base64Decode decodes a padded or unpadded base64 string
+---
+---@param data string Data to decode
+---@param url boolean? Whether the data uses the URL-safe alphabet
+---@return string? decoded
+---@return string? Error message
+function crypto.base64_decode(data, url) end
+
+--- HexEncode returns the lowercase hex encoding of a string
+---
+---@param data string Data to encode
+---@return string encoded
+function crypto.hex_encode(data) end
+
+--- HexDecode decodes a hex string of either case
+---
+---@param data string Data to decode
+---@return string? decoded
+---@return string? Error message
+function crypto.hex_decode(data) end
+
+--- UUIDv4 returns a random UUID
+---
+---@return string uuid
+function crypto.uuid_v4() end
+
+--- UUIDv7 returns a UUID starting with the current time, so that identifiers
+--- generated one after the other sort in generation order
+---
+---@return string uuid
+function crypto.uuid_v7() end
+
+--- RandomBytes returns bytes from a cryptographically secure source
+---
+---@param n integer Number of bytes, 1 to 1024
+---@param encoding crypto.Encoding? Encoding of the bytes, raw by default
+---@return string bytes
+function crypto.random_bytes(n, encoding) end
+
+return crypto
@@ -0,0 +1,49 @@
+---@meta
+
+---@module 'device' Device module that reads the state of devices, sends them commands and lists them
+local device
+
+---@alias device.State table<string, any>
+
+---@class device.Device A device of the IoT platform
+---@field id string Device ID
+---@field name string Device name
+---@field type string Device type, such as lamp
+---@field online boolean Whether the device is online
+---@field state device.State State last reported by the device
+
+---@class device.Filter Fields selecting devices, all of them by default
+---@field type string? Device type
+---@field online boolean? Whether the device is online
+
+--- GetState returns the state last reported by a device
+---
+---@param id string Device ID
+---@return device.State? state State of the device
+---@return string? Error message
+function device.get_state(id) end
+
+--- SendCommand sends a command to a device
+---
+--- Example:
+--- ```
+--- local device = require 'device'
+---
+--- local ok, err = device.send_command('ac_unit', 'set_mode', { mode = 'cool', temperature = 22 })
+--- ```
+---
+---@param id string Device ID
+---@param command string Command to send
+---@param params table<string, any>? Params of the command
+---@return boolean? ok true when the command was sent
+---@return string? Error message
+function device.send_command(id, command, params) end
+
+--- List returns the devices selected by a filter
+---
+---@param filter device.Filter? Fields selecting devices
+---@return device.Device[]? devices
+---@return string? Error message
+function device.list(filter) end
+
+return device
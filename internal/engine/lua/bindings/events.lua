@@ -0,0 +1,33 @@
+---@meta
+
+---@module 'events' Events module that publishes events to NATS and makes request/reply calls.
+--- Only the subjects allowed by the operator can be used.
+local events
+
+---@alias events.Payload string|number|boolean|table
+
+--- Publish publishes a payload, encoded as JSON, on a subject
+---
+--- Example:
+--- ```
+--- local events = require 'events'
+---
+--- local ok, err = events.publish('events.derived.overheat', { room = 'kitchen', temperature = 31 })
+--- ```
+---
+---@param subject string NATS subject to publish on
+---@param payload events.Payload? Payload of the event
+---@return boolean? ok true when the event was published
+---@return string? Error message
+function events.publish(subject, payload) end
+
+--- Request publishes a payload, encoded as JSON, on a subject and waits for the reply
+---
+---@param subject string NATS subject to send the request on
+---@param payload events.Payload? Payload of the request
+---@param timeout number? Seconds to wait for the reply, 1 by default
+---@return events.Payload? reply Decoded reply when it holds JSON, the reply as a string otherwise
+---@return string? Error message
+function events.request(subject, payload, timeout) end
+
+return events
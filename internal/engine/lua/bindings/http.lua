@@ -1,6 +1,10 @@
 ---@meta
 
----@module 'http' HTTP module that provides functions to make HTTP requests
+---@module 'http' HTTP module that provides functions to make HTTP requests.
+--- Requests follow the egress policy of the service: only http and https URLs
+--- to the allowed, public hosts are reached, response bodies are bounded, and
+--- every rule may make a limited number of requests per window. Refused
+--- requests return nil and an error message.
 local http
 
 ---@alias http.Headers table<string, string>
@@ -12,7 +16,7 @@ local http
 --- Get makes an HTTP GET request
 ---
 ---@param url string HTTP URL to make the request to
----@param headers http.Headers HTTP headers to send with the request
+---@param headers http.Headers? HTTP headers to send with the request
 ---@return http.Response? HTTP response
 ---@return string? Error message
 function http.get(url, headers) end
@@ -20,7 +24,7 @@ function http.get(url, headers) end
 --- Post makes an HTTP POST request
 ---
 ---@param url string HTTP URL to make the request to
----@param headers http.Headers HTTP headers to send with the request
+---@param headers http.Headers? HTTP headers to send with the request
 ---@param body string HTTP body to send with the request
 ---@return http.Response? HTTP response
 ---@return string? Error message
@@ -29,7 +33,7 @@ function http.post(url, headers, body) end
 --- Delete makes an HTTP DELETE request
 ---
 ---@param url string HTTP URL to make the request to
----@param headers http.Headers HTTP headers to send with the request
+---@param headers http.Headers? HTTP headers to send with the request
 ---@return http.Response? HTTP response
 ---@return string? Error message
 function http.delete(url, headers) end
@@ -37,7 +41,7 @@ function http.delete(url, headers) end
 --- Put makes an HTTP PUT request
 ---
 ---@param url string HTTP URL to make the request to
----@param headers http.Headers HTTP headers to send with the request
+---@param headers http.Headers? HTTP headers to send with the request
 ---@param body string HTTP body to send with the request
 ---@return http.Response? HTTP response
 ---@return string? Error message
@@ -46,7 +50,7 @@ function http.put(url, headers, body) end
 --- Patch makes an HTTP PATCH request
 ---
 ---@param url string HTTP URL to make the request to
----@param headers http.Headers HTTP headers to send with the request
+---@param headers http.Headers? HTTP headers to send with the request
 ---@param body string HTTP body to send with the request
 ---@return http.Response? HTTP response
 ---@return string? Error message
@@ -0,0 +1,53 @@
+---@meta
+
+---@module 'json' JSON module that provides functions to encode and decode JSON.
+--- Sequences encode as arrays and other tables as objects.
+local json
+
+---@alias json.Value string|number|boolean|table|json.Null
+
+---@class json.Null Stands for a JSON null, so that arrays keep their length and objects their keys
+
+--- JSON null, which decoded nulls are equal to
+---@type json.Null
+json.null = nil
+
+--- Encode returns the compact JSON of a value, with the keys of objects sorted
+---
+---@param value json.Value Value to encode
+---@return string? JSON
+---@return string? Error message
+function json.encode(value) end
+
+--- Pretty returns the indented JSON of a value, with the keys of objects sorted
+---
+---@param value json.Value Value to encode
+---@param indent string? Indentation, two spaces by default
+---@return string? JSON
+---@return string? Error message
+function json.pretty(value, indent) end
+
+--- Decode parses a JSON document. Decoded arrays encode back as arrays even
+--- when empty, and decoded nulls are `json.null`.
+---
+--- Example:
+--- ```
+--- local json = require 'json'
+---
+--- local room, err = json.decode('{"name": "kitchen", "temperature": null}')
+--- assert(room.temperature == json.null)
+--- ```
+---
+---@param text string JSON document
+---@return json.Value? value Decoded value
+---@return string? Error message
+function json.decode(text) end
+
+--- Array marks a table to be encoded as an array even when empty
+---
+---@generic T: table
+---@param tbl T? Table to mark, a new one by default
+---@return T tbl The marked table
+function json.array(tbl) end
+
+return json
@@ -0,0 +1,35 @@
+---@meta
+
+---@module 'notify' Notify module that sends notifications through the channels configured by the operator
+local notify
+
+---@alias notify.Channel 'email'|'webhook'|'slack'|'telegram'
+
+---@class notify.Message A notification
+---@field to (string|string[])? Recipient or list of recipients, the default ones of the channel otherwise
+---@field subject string? Subject of the notification
+---@field body string? Body of the notification
+
+--- Send sends a message through a channel. The recipients, the subject and the
+--- body may hold `{{path}}` placeholders, rendered from the fields of the
+--- execution context and from the keys of the data table.
+---
+--- Example:
+--- ```
+--- local notify = require 'notify'
+---
+--- local ok, err = notify.send('email', {
+---   to = 'facilities@example.com',
+---   subject = 'Leak in the {{event.payload.room}}',
+---   body = '{{level}} mm of water detected at {{fired_at}}',
+--- }, { level = 12 })
+--- ```
+---
+---@param channel notify.Channel Channel to send through
+---@param message string|notify.Message Body of the message, or the message
+---@param data table<string, any>? Fields the placeholders may refer to
+---@return boolean? ok true when the notification was sent
+---@return string? Error message
+function notify.send(channel, message, data) end
+
+return notify
@@ -0,0 +1,26 @@
+---@meta
+
+---@module 'secrets' Secrets module that reads the secrets granted to the rule running the script.
+--- The values read are redacted from the logs, errors and output of the execution.
+--- Secrets cannot be read by replayed executions.
+local secrets
+
+--- Get returns the value of a secret. A secret that does not exist or is not
+--- granted to the rule is reported as not found.
+---
+--- Example:
+--- ```
+--- local secrets = require 'secrets'
+---
+--- local key, err = secrets.get('weather_api_key')
+--- if err then
+---   error(err)
+--- end
+--- ```
+---
+---@param name string Name of the secret
+---@return string? value Value of the secret
+---@return string? Error message
+function secrets.get(name) end
+
+return secrets
@@ -0,0 +1,70 @@
+---@meta
+
+---@module 'store' Store module that keeps state between executions of a rule.
+--- Every rule has its own keys. Values are stored as JSON, so strings, numbers,
+--- booleans and tables can be stored.
+local store
+
+---@alias store.Value string|number|boolean|table
+
+--- Get returns the value stored under a key
+---
+---@param key string Key of 1 to 256 bytes
+---@return store.Value? value Stored value, nil when there is none
+---@return string? Error message
+function store.get(key) end
+
+--- Set stores a value under a key
+---
+--- Example:
+--- ```
+--- local store = require 'store'
+---
+--- local ok, err = store.set('last_seen', { room = 'kitchen', at = 1718973000 }, 3600)
+--- ```
+---
+---@param key string Key of 1 to 256 bytes
+---@param value store.Value Value to store, which cannot be nil
+---@param ttl number? Seconds after which the key expires, never by default
+---@return boolean? ok true when the value was stored
+---@return string? Error message
+function store.set(key, value, ttl) end
+
+--- Incr adds an integer to the integer stored under a key and returns the new value.
+--- Missing keys start at 0, and the expiry of the key is kept.
+---
+---@param key string Key of 1 to 256 bytes
+---@param delta integer? Integer to add, 1 by default
+---@return integer? value New value
+---@return string? Error message
+function store.incr(key, delta) end
+
+--- Delete removes a key
+---
+---@param key string Key of 1 to 256 bytes
+---@return boolean? removed Whether the key existed
+---@return string? Error message
+function store.delete(key) end
+
+--- Cas stores a value under a key only if the current value equals the expected
+--- one, or if the key does not exist when the expected value is nil
+---
+--- Example:
+--- ```
+--- local store = require 'store'
+---
+--- -- Alert at most once every 5 minutes
+--- if not store.cas('cooldown', nil, true, 300) then
+---   return false
+--- end
+--- ```
+---
+---@param key string Key of 1 to 256 bytes
+---@param expected store.Value? Expected current value, nil for a missing key
+---@param value store.Value Value to store, which cannot be nil
+---@param ttl number? Seconds after which the key expires, never by default
+---@return boolean? swapped Whether the value was stored
+---@return string? Error message
+function store.cas(key, expected, value, ttl) end
+
+return store
@@ -1,8 +1,23 @@
 ---@meta
 
----@module 'time' Time module that provides functions to get the current time
+---@module 'time' Time module that provides functions to get, parse, format and compute times.
+--- Points in time are Unix timestamps in seconds, and durations are seconds.
 local time
 
+---@class time.Fields Fields of a point in time in a zone
+---@field year integer
+---@field month integer 1 to 12
+---@field day integer
+---@field hour integer
+---@field minute integer
+---@field second integer
+---@field weekday integer 1 for Monday to 7 for Sunday
+---@field weekday_name string Monday to Sunday
+---@field yearday integer
+---@field zone string Abbreviated name of the zone, such as EEST
+---@field offset integer Offset of the zone from UTC in seconds
+---@field unix number Unix timestamp
+
 --- Time formats.
 ---@enum time.Format
 local Format = {
@@ -91,4 +106,97 @@ time.TimeOnly = Format.TimeOnly
 ---@return string? time Current time as a string
 function time.now(format) end
 
+--- Unix returns the current time as a Unix timestamp
+---
+---@return number timestamp Unix timestamp in seconds
+function time.unix() end
+
+--- Parse parses a time with a Go-style layout
+---
+--- Example:
+--- ```
+--- local ts, err = time.parse(time.DateTime, '2024-06-21 12:30:00', 'Europe/Kyiv')
+--- ```
+---
+---@param layout time.Format|string Go-style layout of the value
+---@param value string Time to parse
+---@param zone string? Zone of the value when the layout has none, UTC by default
+---@return number? timestamp Unix timestamp in seconds
+---@return string? Error message
+function time.parse(layout, value, zone) end
+
+--- Format formats a Unix timestamp with a Go-style layout
+---
+---@param timestamp number Unix timestamp in seconds
+---@param layout time.Format|string Go-style layout
+---@param zone string? Zone to format the time in, UTC by default
+---@return string time Formatted time
+function time.format(timestamp, layout, zone) end
+
+--- Add adds a duration to a Unix timestamp
+---
+---@param timestamp number Unix timestamp in seconds
+---@param duration number|string Seconds, or a Go-style duration such as '1h30m'
+---@return number timestamp Unix timestamp in seconds
+function time.add(timestamp, duration) end
+
+--- Diff returns the seconds from b to a
+---
+---@param a number Unix timestamp in seconds
+---@param b number Unix timestamp in seconds
+---@return number seconds
+function time.diff(a, b) end
+
+--- Duration parses a Go-style duration such as '1h30m'
+---
+---@param duration string
+---@return number? seconds
+---@return string? Error message
+function time.duration(duration) end
+
+--- InZone returns the fields of a point in time in a zone
+---
+--- Example:
+--- ```
+--- local kyiv = time.in_zone('Europe/Kyiv')
+--- local business_hours = kyiv.weekday <= 5 and kyiv.hour >= 9 and kyiv.hour < 18
+--- ```
+---
+---@param zone string Zone name, such as 'Europe/Kyiv'
+---@param timestamp number? Unix timestamp in seconds, the current time by default
+---@return time.Fields fields
+function time.in_zone(zone, timestamp) end
+
+--- Weekday returns the day of the week, 1 for Monday to 7 for Sunday
+---
+---@param timestamp number? Unix timestamp in seconds, the current time by default
+---@param zone string? Zone name, UTC by default
+---@return integer weekday
+function time.weekday(timestamp, zone) end
+
+--- Hour returns the hour of the day
+---
+---@param timestamp number? Unix timestamp in seconds, the current time by default
+---@param zone string? Zone name, UTC by default
+---@return integer hour
+function time.hour(timestamp, zone) end
+
+--- Sunrise returns the time of the sunrise at a place on the day of a timestamp
+---
+---@param lat number Latitude in degrees, north positive
+---@param lon number Longitude in degrees, east positive
+---@param timestamp number? Unix timestamp in seconds, the current time by default
+---@return number? timestamp Unix timestamp in seconds
+---@return string? Error message, when the sun does not rise on that day
+function time.sunrise(lat, lon, timestamp) end
+
+--- Sunset returns the time of the sunset at a place on the day of a timestamp
+---
+---@param lat number Latitude in degrees, north positive
+---@param lon number Longitude in degrees, east positive
+---@param timestamp number? Unix timestamp in seconds, the current time by default
+---@return number? timestamp Unix timestamp in seconds
+---@return string? Error message, when the sun does not set on that day
+function time.sunset(lat, lon, timestamp) end
+
 return time
@@ -0,0 +1,92 @@
+---@meta
+
+---@module 'window' Window module that keeps series of numbers and aggregates them over sliding windows.
+--- Every rule has its own series. Windows cannot be longer than the retention of the series.
+local window
+
+--- Seconds, or a Go-style duration such as '10m'
+---@alias window.Duration number|string
+
+--- Push adds a number to a series, timestamped with the current time
+---
+--- Example:
+--- ```
+--- local window = require 'window'
+---
+--- window.push('temperature', 26.5)
+--- local average = window.avg('temperature', '10m')
+--- ```
+---
+---@param key string Series of 1 to 256 bytes
+---@param value number Finite number to add
+---@return boolean? ok true when the value was added
+---@return string? Error message
+function window.push(key, value) end
+
+--- Clear removes a series
+---
+---@param key string Series of 1 to 256 bytes
+---@return boolean? removed Whether the series existed
+---@return string? Error message
+function window.clear(key) end
+
+--- Count returns the number of values of a series in a window
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return integer? count
+---@return string? Error message
+function window.count(key, duration) end
+
+--- Sum returns the sum of the values of a series in a window, 0 when there are none
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return number? sum
+---@return string? Error message
+function window.sum(key, duration) end
+
+--- Avg returns the mean of the values of a series in a window, nil when there are none
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return number? mean
+---@return string? Error message
+function window.avg(key, duration) end
+
+--- Min returns the smallest value of a series in a window, nil when there are none
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return number? min
+---@return string? Error message
+function window.min(key, duration) end
+
+--- Max returns the largest value of a series in a window, nil when there are none
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return number? max
+---@return string? Error message
+function window.max(key, duration) end
+
+--- Rate returns the change per second between the first and the last value of a
+--- series in a window, nil when there are fewer than two
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@return number? rate
+---@return string? Error message
+function window.rate(key, duration) end
+
+--- Percentile returns a percentile of the values of a series in a window,
+--- interpolated between the closest ranks; nil when there are none
+---
+---@param key string Series of 1 to 256 bytes
+---@param duration window.Duration Length of the window
+---@param p number Percentile, 0 to 100
+---@return number? value
+---@return string? Error message
+function window.percentile(key, duration, p) end
+
+return window
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"maps"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/actions"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/metrics"
@@ -42,7 +43,7 @@ type TriggerService interface {
 
 // TriggerEvaluator interface
 type TriggerEvaluator interface {
-	EvaluateTriggers(ctx context.Context, triggers []*trigger.Trigger, eventData map[string]any) []*trigger.EvaluationResult
+	EvaluateTriggers(ctx context.Context, triggers []*trigger.Trigger, subject string, eventData map[string]any) []*trigger.EvaluationResult
 }
 
 // AlertingService interface for sending alerts
@@ -60,10 +61,23 @@ type Manager struct {
 	executor       Executor
 	alertingSvc    AlertingService
 	queue          queue.Queue
+	actions        *actions.Dispatcher
 	executingRules map[uuid.UUID]bool // To detect cycles in rule chaining
 	rulesMutex     sync.RWMutex       // Protects executingRules map from concurrent access
 }
 
+// ManagerOption allows to configure the trigger manager
+type ManagerOption func(m *Manager) *Manager
+
+// WithActions sets the dispatcher recording synchronous rule executions and
+// running their publish_event and notify actions
+func WithActions(dispatcher *actions.Dispatcher) ManagerOption {
+	return func(m *Manager) *Manager {
+		m.actions = dispatcher
+		return m
+	}
+}
+
 // NewManager creates a new trigger manager
 func NewManager(
 	nc *nats.Conn,
@@ -74,8 +88,9 @@ func NewManager(
 	executor Executor,
 	alertingSvc AlertingService,
 	queue queue.Queue,
+	opts ...ManagerOption,
 ) *Manager {
-	return &Manager{
+	m := &Manager{
 		nc:             nc,
 		cron:           cron,
 		ruleSvc:        ruleSvc,
@@ -86,6 +101,10 @@ func NewManager(
 		queue:          queue,
 		executingRules: make(map[uuid.UUID]bool),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Start begins listening for triggers
@@ -112,6 +131,7 @@ func (m *Manager) Stop() {
 func (m *Manager) handleConditionalTrigger(ctx context.Context, msg *nats.Msg) {
 	// Record metric
 	metrics.TriggerEventsTotal.WithLabelValues("conditional", "processed").Inc()
+	firedAt := time.Now().UTC()
 
 	// Parse event data
 	var eventData map[string]any
@@ -135,7 +155,7 @@ func (m *Manager) handleConditionalTrigger(ctx context.Context, msg *nats.Msg) {
 	}
 
 	// Evaluate all conditional triggers against the event
-	results := m.triggerEval.EvaluateTriggers(ctx, conditionalTriggers, eventData)
+	results := m.triggerEval.EvaluateTriggers(ctx, conditionalTriggers, msg.Subject, eventData)
 
 	// Execute rules for triggers that matched
 	for _, result := range results {
@@ -148,7 +168,15 @@ func (m *Manager) handleConditionalTrigger(ctx context.Context, msg *nats.Msg) {
 			metrics.TriggerEventsTotal.WithLabelValues("conditional", "fired").Inc()
 
 			// Execute the associated rule
-			m.executeRuleInternal(ctx, result.RuleID, eventData, result.TriggerID, true)
+			m.executeRuleInternal(ctx, &queue.ExecutionRequest{
+				RuleID:       result.RuleID,
+				TriggerID:    result.TriggerID,
+				TriggerType:  string(trigger.Conditional),
+				EventSubject: msg.Subject,
+				EventData:    eventData,
+				FiredAt:      firedAt,
+				ChainDepth:   events.ChainDepth(msg),
+			}, true)
 		} else if result.Error != "" {
 			slog.Error("Trigger evaluation failed",
 				"trigger_id", result.TriggerID,
@@ -221,45 +249,52 @@ func (m *Manager) handleScheduledTrigger(ctx context.Context, triggerID uuid.UUI
 	metrics.TriggerEventsTotal.WithLabelValues("scheduled", "fired").Inc()
 
 	// Execute the associated rule
-	m.executeRuleInternal(ctx, trigger.RuleID, nil, triggerID, true)
+	m.executeRuleInternal(ctx, &queue.ExecutionRequest{
+		RuleID:      trigger.RuleID,
+		TriggerID:   triggerID,
+		TriggerType: string(trigger.Type),
+		FiredAt:     time.Now().UTC(),
+	}, true)
 }
 
 // executeRule executes a rule's logic (queues by default)
 func (m *Manager) executeRule(ctx context.Context, ruleID uuid.UUID) {
-	m.executeRuleInternal(ctx, ruleID, nil, uuid.Nil, true)
+	m.executeRuleInternal(ctx, &queue.ExecutionRequest{RuleID: ruleID}, true)
 }
 
-// executeRuleSync executes a rule synchronously (for rule chaining)
-func (m *Manager) executeRuleSync(ctx context.Context, ruleID uuid.UUID) {
-	m.executeRuleInternal(ctx, ruleID, nil, uuid.Nil, false)
+// executeRuleSync executes a rule synchronously (for rule chaining).
+// The chained execution shares the trigger and event of its parent, one level deeper.
+func (m *Manager) executeRuleSync(ctx context.Context, ruleID uuid.UUID, parent *queue.ExecutionRequest) {
+	req := *parent
+	req.ID = uuid.Nil
+	req.RuleID = ruleID
+	req.ChainDepth = parent.ChainDepth + 1
+	m.executeRuleInternal(ctx, &req, false)
 }
 
 // executeRuleInternal executes a rule's logic with queuing option
-func (m *Manager) executeRuleInternal(ctx context.Context, ruleID uuid.UUID, eventData map[string]any, triggerID uuid.UUID, allowQueue bool) {
+func (m *Manager) executeRuleInternal(ctx context.Context, req *queue.ExecutionRequest, allowQueue bool) {
 	// If queuing is allowed and we have a queue, enqueue the request
 	if allowQueue && m.queue != nil {
-		req := &queue.ExecutionRequest{
-			RuleID:    ruleID,
-			TriggerID: triggerID,
-			EventData: eventData,
-		}
-
 		if err := m.queue.Enqueue(ctx, req); err != nil {
-			slog.Error("Failed to enqueue rule execution", "rule_id", ruleID, "error", err)
+			slog.Error("Failed to enqueue rule execution", "rule_id", req.RuleID, "error", err)
 			// Fall back to synchronous execution
-			m.executeRuleSynchronous(ctx, ruleID, eventData, triggerID)
+			m.executeRuleSynchronous(ctx, req)
 		} else {
-			slog.Info("Rule execution enqueued", "rule_id", ruleID, "trigger_id", triggerID)
+			slog.Info("Rule execution enqueued", "rule_id", req.RuleID, "trigger_id", req.TriggerID)
 		}
 		return
 	}
 
 	// Execute synchronously
-	m.executeRuleSynchronous(ctx, ruleID, eventData, triggerID)
+	m.executeRuleSynchronous(ctx, req)
 }
 
 // executeRuleSynchronous executes a rule synchronously
-func (m *Manager) executeRuleSynchronous(ctx context.Context, ruleID uuid.UUID, eventData map[string]any, triggerID uuid.UUID) {
+func (m *Manager) executeRuleSynchronous(ctx context.Context, req *queue.ExecutionRequest) {
+	ruleID := req.RuleID
+	triggerID := req.TriggerID
+
 	ctx, span := tracing.StartSpan(ctx, "manager.execute_rule_sync")
 	defer span.End()
 
@@ -299,17 +334,13 @@ func (m *Manager) executeRuleSynchronous(ctx context.Context, ruleID uuid.UUID,
 	)
 
 	// Create execution context
-	execCtx := m.executor.GetContextService().CreateContext(ruleID.String(), triggerID.String())
-
-	// Add event data to context if available
-	if eventData != nil {
-		maps.Copy(execCtx.Data, eventData)
-	}
+	execCtx := req.ExecutionContext(m.executor.GetContextService(), rule)
 
 	// Execute rule script
 	ruleCtx, ruleSpan := tracing.StartSpan(ctx, "rule.script_execution")
 	result := m.executor.ExecuteScript(ruleCtx, rule.LuaScript, execCtx)
 	ruleSpan.End()
+	m.recordExecution(ctx, req, execCtx, result)
 
 	if result.Error != "" {
 		span.RecordError(fmt.Errorf("rule execution failed: %s", result.Error))
@@ -321,10 +352,15 @@ func (m *Manager) executeRuleSynchronous(ctx context.Context, ruleID uuid.UUID,
 				"rule_id":    ruleID.String(),
 				"rule_name":  rule.Name,
 				"trigger_id": triggerID.String(),
+				"status":     string(result.Status),
 				"error":      result.Error,
 				"timestamp":  time.Now().Format(time.RFC3339),
 			}
-			if err := m.alertingSvc.SendAlert(ctx, "rule_execution_failure", "high",
+			alertType := "rule_execution_failure"
+			if result.Status == executor.StatusTimeout {
+				alertType = "rule_execution_timeout"
+			}
+			if err := m.alertingSvc.SendAlert(ctx, alertType, "high",
 				fmt.Sprintf("Rule execution failed: %s", rule.Name),
 				fmt.Sprintf("Rule '%s' failed to execute: %s", rule.Name, result.Error),
 				details); err != nil {
@@ -390,12 +426,23 @@ func (m *Manager) executeRuleSynchronous(ctx context.Context, ruleID uuid.UUID,
 				continue
 			}
 			slog.Info("Executing chained rule synchronously", "action_id", action.ID, "target_rule_id", targetRuleID)
-			m.executeRuleSync(actionCtx, targetRuleID)
+			m.executeRuleSync(actionCtx, targetRuleID, req)
 		default:
-			actionSpan.RecordError(fmt.Errorf("unknown action type: %s", action.Type))
-			slog.Error("Unknown action type", "action_id", action.ID, "type", action.Type)
+			if err := m.actions.Dispatch(actionCtx, action.Type, action.Params, execCtx); err != nil {
+				actionSpan.RecordError(err)
+				slog.Error("Action failed", "action_id", action.ID, "type", action.Type, "error", err)
+			} else {
+				slog.Info("Action executed", "action_id", action.ID, "type", action.Type)
+			}
 		}
 
 		actionSpan.End()
 	}
 }
+
+// recordExecution stores the outcome of a rule script in the execution history
+func (m *Manager) recordExecution(ctx context.Context, req *queue.ExecutionRequest, ec *execCtx.ExecutionContext, result *executor.ExecuteResult) {
+	if err := m.actions.Record(ctx, req.RuleID, req.TriggerID, ec, result); err != nil {
+		slog.Error("Failed to record rule execution", "rule_id", req.RuleID, "error", err)
+	}
+}
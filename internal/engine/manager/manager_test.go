@@ -7,8 +7,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/malyshevhen/rule-engine/internal/action"
+	"github.com/malyshevhen/rule-engine/internal/engine/actions"
+	"github.com/malyshevhen/rule-engine/internal/engine/events"
 	execPkg "github.com/malyshevhen/rule-engine/internal/engine/executor"
 	ctxPkg "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/execution"
+	"github.com/malyshevhen/rule-engine/internal/notify"
+	"github.com/malyshevhen/rule-engine/internal/queue"
 	"github.com/malyshevhen/rule-engine/internal/rule"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 	"github.com/stretchr/testify/mock"
@@ -59,6 +65,36 @@ func (m *mockTriggerService) GetEnabledScheduledTriggers(ctx context.Context) ([
 	return args.Get(0).([]*trigger.Trigger), args.Error(1)
 }
 
+// mockExecutionRecorder is a mock implementation of actions.ExecutionRecorder
+type mockExecutionRecorder struct {
+	mock.Mock
+}
+
+func (m *mockExecutionRecorder) Record(ctx context.Context, execution *execution.Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+// mockEventPublisher is a mock implementation of actions.EventPublisher
+type mockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *mockEventPublisher) PublishAction(ctx context.Context, params *events.ActionParams, ec *ctxPkg.ExecutionContext) error {
+	args := m.Called(ctx, params, ec)
+	return args.Error(0)
+}
+
+// mockNotificationSender is a mock implementation of actions.NotificationSender
+type mockNotificationSender struct {
+	mock.Mock
+}
+
+func (m *mockNotificationSender) SendAction(ctx context.Context, params *notify.ActionParams, ec *ctxPkg.ExecutionContext) error {
+	args := m.Called(ctx, params, ec)
+	return args.Error(0)
+}
+
 func TestManager_executeRule(t *testing.T) {
 	mockRuleSvc := &mockRuleService{}
 	mockExec := &mockExecutor{}
@@ -365,3 +401,190 @@ func TestManager_handleScheduledTrigger_DisabledTrigger(t *testing.T) {
 	mockTriggerSvc.AssertExpectations(t)
 	// Should not attempt to get or execute the rule
 }
+
+func TestManager_executeRule_ChainedRuleContext(t *testing.T) {
+	mockRuleSvc := &mockRuleService{}
+	mockExec := &mockExecutor{}
+
+	mgr := &Manager{
+		ruleSvc:        mockRuleSvc,
+		executor:       mockExec,
+		executingRules: make(map[uuid.UUID]bool),
+	}
+
+	parentID := uuid.New()
+	childID := uuid.New()
+	triggerID := uuid.New()
+	parentRule := &rule.Rule{
+		ID:        parentID,
+		Name:      "Parent Rule",
+		LuaScript: "return true",
+		Enabled:   true,
+		Actions: []action.Action{
+			{
+				ID:      uuid.New(),
+				Type:    "execute_rule",
+				Params:  `{"rule_id": "` + childID.String() + `"}`,
+				Enabled: true,
+			},
+		},
+	}
+	childRule := &rule.Rule{
+		ID:        childID,
+		Name:      "Child Rule",
+		LuaScript: "return false",
+		Enabled:   true,
+	}
+
+	mockRuleSvc.On("GetByID", mock.Anything, parentID).Return(parentRule, nil)
+	mockRuleSvc.On("GetByID", mock.Anything, childID).Return(childRule, nil)
+	mockExec.On("GetContextService").Return(ctxPkg.NewService())
+	mockExec.On("ExecuteScript", mock.Anything, parentRule.LuaScript, mock.MatchedBy(func(ec *ctxPkg.ExecutionContext) bool {
+		return ec.RuleName == "Parent Rule" && ec.ChainDepth == 0 && ec.EventSubject == "events.sensor_1"
+	})).Return(&execPkg.ExecuteResult{Success: true, Output: []any{true}})
+	mockExec.On("ExecuteScript", mock.Anything, childRule.LuaScript, mock.MatchedBy(func(ec *ctxPkg.ExecutionContext) bool {
+		return ec.RuleID == childID.String() && ec.RuleName == "Child Rule" && ec.ChainDepth == 1 &&
+			ec.TriggerID == triggerID.String() && ec.EventSubject == "events.sensor_1" && ec.Event["temperature"] == 28.5
+	})).Return(&execPkg.ExecuteResult{Success: true, Output: []any{false}})
+
+	mgr.executeRuleInternal(context.Background(), &queue.ExecutionRequest{
+		RuleID:       parentID,
+		TriggerID:    triggerID,
+		TriggerType:  string(trigger.Conditional),
+		EventSubject: "events.sensor_1",
+		EventData:    map[string]any{"temperature": 28.5},
+	}, false)
+
+	mockRuleSvc.AssertExpectations(t)
+	mockExec.AssertExpectations(t)
+}
+
+func TestManager_executeRule_RecordsExecution(t *testing.T) {
+	mockRuleSvc := &mockRuleService{}
+	mockExec := &mockExecutor{}
+	mockRecorder := &mockExecutionRecorder{}
+
+	mgr := &Manager{
+		ruleSvc:        mockRuleSvc,
+		executor:       mockExec,
+		executingRules: make(map[uuid.UUID]bool),
+	}
+	WithActions(actions.NewDispatcher(actions.WithExecutionRecorder(mockRecorder)))(mgr)
+
+	ruleID := uuid.New()
+	triggerID := uuid.New()
+	expectedRule := &rule.Rule{
+		ID:        ruleID,
+		Name:      "Test Rule",
+		LuaScript: "print('checking') error('test error')",
+		Enabled:   true,
+	}
+	logs := []scriptlog.Entry{{Level: "info", Message: "checking"}}
+
+	mockRuleSvc.On("GetByID", mock.Anything, ruleID).Return(expectedRule, nil)
+	mockExec.On("GetContextService").Return(ctxPkg.NewService())
+	mockExec.On("ExecuteScript", mock.Anything, expectedRule.LuaScript, mock.Anything).Return(&execPkg.ExecuteResult{
+		Success: false,
+		Status:  execPkg.StatusFailure,
+		Error:   "test error",
+		Logs:    logs,
+	})
+	mockRecorder.On("Record", mock.Anything, mock.MatchedBy(func(e *execution.Execution) bool {
+		return e.RuleID == ruleID && e.TriggerID == triggerID && e.Status == "FAILURE" &&
+			e.Error == "test error" && len(e.Logs) == 1 && !e.TriggeredAt.IsZero()
+	})).Return(nil)
+
+	mgr.executeRuleInternal(context.Background(), &queue.ExecutionRequest{RuleID: ruleID, TriggerID: triggerID}, false)
+
+	mockRuleSvc.AssertExpectations(t)
+	mockExec.AssertExpectations(t)
+	mockRecorder.AssertExpectations(t)
+}
+
+func TestManager_executeRule_PublishEvent(t *testing.T) {
+	mockRuleSvc := &mockRuleService{}
+	mockExec := &mockExecutor{}
+	mockPublisher := &mockEventPublisher{}
+
+	mgr := &Manager{
+		ruleSvc:        mockRuleSvc,
+		executor:       mockExec,
+		executingRules: make(map[uuid.UUID]bool),
+	}
+	WithActions(actions.NewDispatcher(actions.WithEventPublisher(mockPublisher)))(mgr)
+
+	ruleID := uuid.New()
+	expectedRule := &rule.Rule{
+		ID:        ruleID,
+		Name:      "Overheat",
+		LuaScript: "return true",
+		Enabled:   true,
+		Actions: []action.Action{
+			{
+				ID:      uuid.New(),
+				Type:    "publish_event",
+				Params:  `{"subject": "events.derived.overheat", "payload": {"rule": "{{rule.name}}"}}`,
+				Enabled: true,
+			},
+		},
+	}
+
+	mockRuleSvc.On("GetByID", mock.Anything, ruleID).Return(expectedRule, nil)
+	mockExec.On("GetContextService").Return(ctxPkg.NewService())
+	mockExec.On("ExecuteScript", mock.Anything, expectedRule.LuaScript, mock.Anything).Return(&execPkg.ExecuteResult{Success: true, Output: []any{true}})
+	mockPublisher.On("PublishAction", mock.Anything, mock.MatchedBy(func(p *events.ActionParams) bool {
+		return p.Subject == "events.derived.overheat"
+	}), mock.MatchedBy(func(ec *ctxPkg.ExecutionContext) bool {
+		return ec.RuleName == "Overheat" && ec.ChainDepth == 2
+	})).Return(nil)
+
+	mgr.executeRuleInternal(context.Background(), &queue.ExecutionRequest{RuleID: ruleID, ChainDepth: 2}, false)
+
+	mockRuleSvc.AssertExpectations(t)
+	mockExec.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestManager_executeRule_Notify(t *testing.T) {
+	mockRuleSvc := &mockRuleService{}
+	mockExec := &mockExecutor{}
+	mockNotifier := &mockNotificationSender{}
+
+	mgr := &Manager{
+		ruleSvc:        mockRuleSvc,
+		executor:       mockExec,
+		executingRules: make(map[uuid.UUID]bool),
+	}
+	WithActions(actions.NewDispatcher(actions.WithNotificationSender(mockNotifier)))(mgr)
+
+	ruleID := uuid.New()
+	expectedRule := &rule.Rule{
+		ID:        ruleID,
+		Name:      "Leak",
+		LuaScript: "return true",
+		Enabled:   true,
+		Actions: []action.Action{
+			{
+				ID:      uuid.New(),
+				Type:    "notify",
+				Params:  `{"channel": "slack", "body": "{{rule.name}} fired"}`,
+				Enabled: true,
+			},
+		},
+	}
+
+	mockRuleSvc.On("GetByID", mock.Anything, ruleID).Return(expectedRule, nil)
+	mockExec.On("GetContextService").Return(ctxPkg.NewService())
+	mockExec.On("ExecuteScript", mock.Anything, expectedRule.LuaScript, mock.Anything).Return(&execPkg.ExecuteResult{Success: true, Output: []any{true}})
+	mockNotifier.On("SendAction", mock.Anything, mock.MatchedBy(func(p *notify.ActionParams) bool {
+		return p.Channel == "slack" && p.Body == "{{rule.name}} fired"
+	}), mock.MatchedBy(func(ec *ctxPkg.ExecutionContext) bool {
+		return ec.RuleName == "Leak"
+	})).Return(nil)
+
+	mgr.executeRuleInternal(context.Background(), &queue.ExecutionRequest{RuleID: ruleID}, false)
+
+	mockRuleSvc.AssertExpectations(t)
+	mockExec.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+}
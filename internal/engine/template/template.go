@@ -0,0 +1,171 @@
+// Package template renders the placeholders of action parameters, such as the
+// subject and payload of a published event, from the context of an execution.
+//
+// A placeholder is a path between double braces, for example
+// {{event.payload.room}} or {{rule.name}}. The segments of the path name map
+// fields, or the 0-based index of a list element. A string that is a single
+// placeholder is replaced by the value itself, keeping its type, whereas
+// placeholders inside a longer string are replaced by their text.
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	openDelim  = "{{"
+	closeDelim = "}}"
+)
+
+// segment is a literal text or a placeholder of a template
+type segment struct {
+	text string
+	path []string // nil for literal text
+}
+
+// parse splits text into literal text and placeholders
+func parse(text string) ([]segment, error) {
+	var segments []segment
+	for {
+		start := strings.Index(text, openDelim)
+		if start < 0 {
+			if text != "" {
+				segments = append(segments, segment{text: text})
+			}
+			return segments, nil
+		}
+		end := strings.Index(text[start:], closeDelim)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated placeholder %q", text[start:])
+		}
+		end += start
+		if start > 0 {
+			segments = append(segments, segment{text: text[:start]})
+		}
+		placeholder := text[start : end+len(closeDelim)]
+		name := strings.TrimSpace(text[start+len(openDelim) : end])
+		if name == "" {
+			return nil, fmt.Errorf("empty placeholder %q", placeholder)
+		}
+		path := strings.Split(name, ".")
+		for _, field := range path {
+			if field == "" || strings.ContainsAny(field, " \t{}") {
+				return nil, fmt.Errorf("invalid placeholder %q", placeholder)
+			}
+		}
+		segments = append(segments, segment{text: placeholder, path: path})
+		text = text[end+len(closeDelim):]
+	}
+}
+
+// Validate reports the malformed placeholders of text
+func Validate(text string) error {
+	_, err := parse(text)
+	return err
+}
+
+// ValidateValue reports the malformed placeholders of the strings of a
+// JSON-like value
+func ValidateValue(v any) error {
+	_, err := RenderValue(v, nil)
+	return err
+}
+
+// Render replaces the placeholders of text with the text of the values at
+// their path in data. Missing values render as an empty string.
+func Render(text string, data map[string]any) (string, error) {
+	segments, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, seg := range segments {
+		if seg.path == nil {
+			b.WriteString(seg.text)
+			continue
+		}
+		b.WriteString(format(lookup(data, seg.path)))
+	}
+	return b.String(), nil
+}
+
+// RenderValue renders every string of a JSON-like value made of maps, lists
+// and scalars. A string that is a single placeholder becomes the value at its
+// path, nil when missing.
+func RenderValue(v any, data map[string]any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		segments, err := parse(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) == 1 && segments[0].path != nil {
+			return lookup(data, segments[0].path), nil
+		}
+		return Render(v, data)
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for key, item := range v {
+			value, err := RenderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			rendered[key] = value
+		}
+		return rendered, nil
+	case []any:
+		rendered := make([]any, len(v))
+		for i, item := range v {
+			value, err := RenderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			rendered[i] = value
+		}
+		return rendered, nil
+	}
+	return v, nil
+}
+
+// lookup returns the value at path in data, nil when missing
+func lookup(data map[string]any, path []string) any {
+	var current any = data
+	for _, field := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			current = node[field]
+		case []any:
+			i, err := strconv.Atoi(field)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			current = node[i]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// format returns the text of a value: strings as they are, numbers in their
+// shortest form, nil as an empty string and maps and lists as JSON
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
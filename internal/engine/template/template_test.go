@@ -0,0 +1,83 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var data = map[string]any{
+	"rule": map[string]any{"id": "rule-1", "name": "Overheat"},
+	"event": map[string]any{
+		"subject": "events.kitchen",
+		"payload": map[string]any{
+			"room":        "kitchen",
+			"temperature": 28.5,
+			"alarm":       true,
+			"tags":        []any{"indoor", "ground"},
+			"sensor":      map[string]any{"id": "t1"},
+		},
+	},
+	"attempt": 1,
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"no placeholders", "no placeholders"},
+		{"events.overheat.{{event.payload.room}}", "events.overheat.kitchen"},
+		{"{{ rule.name }}: {{event.payload.temperature}}°C", "Overheat: 28.5°C"},
+		{"attempt {{attempt}}, alarm {{event.payload.alarm}}", "attempt 1, alarm true"},
+		{"first tag {{event.payload.tags.0}}", "first tag indoor"},
+		{"sensor {{event.payload.sensor}}", `sensor {"id":"t1"}`},
+		{"missing [{{event.payload.missing}}] [{{event.payload.tags.5}}]", "missing [] []"},
+		{"braces } alone {", "braces } alone {"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			rendered, err := Render(tt.text, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
+
+func TestRenderValue(t *testing.T) {
+	payload := map[string]any{
+		"type":        "overheat",
+		"room":        "{{event.payload.room}}",
+		"temperature": "{{event.payload.temperature}}",
+		"tags":        "{{event.payload.tags}}",
+		"missing":     "{{event.payload.missing}}",
+		"summary":     "{{rule.name}} in {{event.payload.room}}",
+		"sources":     []any{"{{event.subject}}", 42.0},
+		"nested":      map[string]any{"sensor": "{{event.payload.sensor.id}}"},
+	}
+
+	rendered, err := RenderValue(payload, data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"type":        "overheat",
+		"room":        "kitchen",
+		"temperature": 28.5,
+		"tags":        []any{"indoor", "ground"},
+		"missing":     nil,
+		"summary":     "Overheat in kitchen",
+		"sources":     []any{"events.kitchen", 42.0},
+		"nested":      map[string]any{"sensor": "t1"},
+	}, rendered)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("events.{{event.payload.room}}"))
+	assert.EqualError(t, Validate("events.{{event.payload.room"), `unterminated placeholder "{{event.payload.room"`)
+	assert.EqualError(t, Validate("events.{{ }}"), `empty placeholder "{{ }}"`)
+	assert.EqualError(t, Validate("{{event..room}}"), `invalid placeholder "{{event..room}}"`)
+
+	err := ValidateValue(map[string]any{"items": []any{"ok", "{{broken"}})
+	assert.EqualError(t, err, `items: [1]: unterminated placeholder "{{broken"`)
+}
@@ -0,0 +1,46 @@
+package execution
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+)
+
+// Execution represents a recorded rule execution in the business domain
+type Execution struct {
+	ID            int64             `json:"id"`
+	RuleID        uuid.UUID         `json:"rule_id"`
+	TriggerID     uuid.UUID         `json:"trigger_id"` // uuid.Nil when not started by a trigger
+	Status        string            `json:"status"`
+	TriggeredAt   time.Time         `json:"triggered_at"`
+	Duration      time.Duration     `json:"duration"`
+	Output        []any             `json:"output"`
+	Error         string            `json:"error,omitempty"`
+	Logs          []scriptlog.Entry `json:"logs,omitempty"`
+	LogsTruncated bool              `json:"logs_truncated,omitempty"`
+	// Replay holds the recorded inputs when the execution was recorded; it is
+	// only loaded for replaying, Replayable tells whether one was stored
+	Replay     *replay.Bundle `json:"-"`
+	Replayable bool           `json:"replayable"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// FromResult builds the execution record of a rule script run
+func FromResult(ruleID, triggerID uuid.UUID, triggeredAt time.Time, result *executor.ExecuteResult) *Execution {
+	return &Execution{
+		RuleID:        ruleID,
+		TriggerID:     triggerID,
+		Status:        string(result.Status),
+		TriggeredAt:   triggeredAt,
+		Duration:      result.Duration,
+		Output:        result.Output,
+		Error:         result.Error,
+		Logs:          result.Logs,
+		LogsTruncated: result.LogsTruncated,
+		Replay:        result.Replay,
+		Replayable:    result.Replay != nil,
+	}
+}
@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+)
+
+// Errors returned when replaying an execution
+var (
+	ErrReplayDisabled = errors.New("execution replay is not configured")
+	ErrNotReplayable  = errors.New("execution was not recorded for replay")
+)
+
+// Script sources of a replay
+const (
+	ReplayScriptCurrent  = "current"
+	ReplayScriptProposed = "proposed"
+)
+
+// Replay is the outcome of re-running a recorded execution
+type Replay struct {
+	Execution *Execution
+	// Script tells whether the current script of the rule or a proposed one was run
+	Script string
+	Result *executor.ExecuteResult
+	// Matches reports whether the replay ended with the status and output of the recorded execution
+	Matches bool
+	// Divergences lists the calls of the replay that the recording could not serve
+	// and the recorded requests the replay did not make
+	Divergences []string
+}
+
+// Replay re-runs a recorded execution against the inputs it observed: its
+// execution context, the times returned by time.now and the HTTP responses it
+// received. script is run instead of the current script of the rule when not empty.
+func (s *Service) Replay(ctx context.Context, id int64, script string) (*Replay, error) {
+	if s.executor == nil || s.rules == nil {
+		return nil, ErrReplayDisabled
+	}
+
+	execution, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.store.GetStore().ExecutionRepository.GetReplayBundle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, ErrNotReplayable
+	}
+	var bundle replay.Bundle
+	if err := json.Unmarshal([]byte(data), &bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode replay bundle of execution %d: %w", id, err)
+	}
+	if bundle.Version != replay.BundleVersion || bundle.Context == nil {
+		return nil, fmt.Errorf("%w: unsupported replay bundle version %d", ErrNotReplayable, bundle.Version)
+	}
+	execution.Replay = &bundle
+
+	source := ReplayScriptProposed
+	if script == "" {
+		r, err := s.rules.GetByID(ctx, execution.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		script = r.LuaScript
+		source = ReplayScriptCurrent
+	}
+
+	player := replay.NewPlayer(&bundle)
+	result := s.executor.ExecuteScript(replay.WithPlayer(ctx, player), script, bundle.Context)
+
+	divergences := player.Misses()
+	if bundle.Truncated {
+		divergences = append(divergences, "the recording was truncated, some HTTP responses were not kept")
+	}
+	return &Replay{
+		Execution:   execution,
+		Script:      source,
+		Result:      result,
+		Matches:     string(result.Status) == execution.Status && sameOutput(result.Output, execution.Output),
+		Divergences: divergences,
+	}, nil
+}
+
+// sameOutput compares the output of a run with a stored output,
+// which went through JSON on its way to the database
+func sameOutput(output, stored []any) bool {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return false
+	}
+	var decoded []any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return false
+	}
+	if len(decoded) == 0 && len(stored) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(decoded, stored)
+}
@@ -0,0 +1,160 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform/modules"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/rule"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRuleGetter is a mock implementation of RuleGetter interface
+type mockRuleGetter struct {
+	mock.Mock
+}
+
+func (m *mockRuleGetter) GetByID(ctx context.Context, id uuid.UUID) (*rule.Rule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*rule.Rule), args.Error(1)
+}
+
+const replayedScript = `
+local http = require("http")
+local time = require("time")
+local resp, err = http.get("http://sensors.local/outdoor")
+return ctx.event.payload.temperature - tonumber(resp.body), time.now(time.RFC3339)
+`
+
+func replayBundle(t *testing.T) string {
+	t.Helper()
+	bundle := replay.Bundle{
+		Version: replay.BundleVersion,
+		Context: &execCtx.ExecutionContext{
+			RuleID:    "rule-1",
+			TriggerID: "trigger-1",
+			Event:     map[string]any{"temperature": 28.5},
+			FiredAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Attempt:   1,
+			Data:      map[string]any{},
+		},
+		Times: []time.Time{time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)},
+		HTTP:  []replay.HTTPExchange{{Method: "GET", URL: "http://sensors.local/outdoor", Status: 200, Body: "20"}},
+	}
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func newReplayService(t *testing.T, output string) (*Service, *mockExecutionRepository, *mockRuleGetter, uuid.UUID) {
+	t.Helper()
+	repo := &mockExecutionRepository{}
+	rules := &mockRuleGetter{}
+	executorSvc := executor.NewService(execCtx.NewService(), platform.NewService())
+	service := NewService(&mockStore{repo: repo}, WithReplay(executorSvc, rules))
+
+	ruleID := uuid.New()
+	repo.On("GetByID", mock.Anything, int64(7)).Return(&executionStorage.Execution{
+		ID: 7, RuleID: ruleID, Status: "SUCCESS", Output: output, OutputLog: "[]", Replayable: true,
+	}, nil)
+	return service, repo, rules, ruleID
+}
+
+func TestService_Replay_CurrentScript(t *testing.T) {
+	local := time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC).In(time.Local).Format(time.RFC3339)
+	service, repo, rules, ruleID := newReplayService(t, `[8.5,"`+local+`"]`)
+	repo.On("GetReplayBundle", mock.Anything, int64(7)).Return(replayBundle(t), nil)
+	rules.On("GetByID", mock.Anything, ruleID).Return(&rule.Rule{ID: ruleID, LuaScript: replayedScript}, nil)
+
+	result, err := service.Replay(context.Background(), 7, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayScriptCurrent, result.Script)
+	assert.True(t, result.Result.Success, result.Result.Error)
+	assert.Equal(t, []any{8.5, local}, result.Result.Output)
+	assert.True(t, result.Matches)
+	assert.Empty(t, result.Divergences)
+	assert.True(t, result.Execution.Replayable)
+	rules.AssertExpectations(t)
+}
+
+func TestService_Replay_ProposedScript(t *testing.T) {
+	service, repo, rules, _ := newReplayService(t, `[8.5]`)
+	repo.On("GetReplayBundle", mock.Anything, int64(7)).Return(replayBundle(t), nil)
+
+	result, err := service.Replay(context.Background(), 7, `
+		local http = require("http")
+		local _, err = http.get("http://sensors.local/indoor")
+		return ctx.event.payload.temperature, err
+	`)
+
+	require.NoError(t, err)
+	assert.Equal(t, ReplayScriptProposed, result.Script)
+	assert.True(t, result.Result.Success, result.Result.Error)
+	assert.Equal(t, 28.5, result.Result.Output[0])
+	assert.Contains(t, result.Result.Output[1], "no recorded response for GET http://sensors.local/indoor")
+	assert.False(t, result.Matches)
+	assert.Equal(t, []string{
+		"GET http://sensors.local/indoor was not recorded",
+		"recorded GET http://sensors.local/outdoor was not requested",
+	}, result.Divergences)
+	rules.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+// grantedSecret resolves the same secret for every rule
+type grantedSecret string
+
+func (g grantedSecret) Resolve(context.Context, string, string) (string, error) {
+	return string(g), nil
+}
+
+func TestService_Replay_SecretsAreNotRead(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	platformSvc := platform.NewService(platform.WithModule(modules.NewSecretsModule(grantedSecret("tok-123456"))))
+	service := NewService(&mockStore{repo: repo}, WithReplay(executor.NewService(execCtx.NewService(), platformSvc), &mockRuleGetter{}))
+	repo.On("GetByID", mock.Anything, int64(7)).Return(&executionStorage.Execution{
+		ID: 7, RuleID: uuid.New(), Status: "SUCCESS", Output: `[]`, OutputLog: "[]", Replayable: true,
+	}, nil)
+	repo.On("GetReplayBundle", mock.Anything, int64(7)).Return(replayBundle(t), nil)
+
+	// A transformed value would slip past redaction into the response
+	result, err := service.Replay(context.Background(), 7, `
+		local crypto = require("crypto")
+		local value, err = require("secrets").get("api_token")
+		return value and value:reverse(), value and crypto.base64_encode(value), err
+	`)
+
+	require.NoError(t, err)
+	require.True(t, result.Result.Success, result.Result.Error)
+	assert.Equal(t, []any{nil, nil, "secrets: not available in replayed executions"}, result.Result.Output)
+}
+
+func TestService_Replay_NotReplayable(t *testing.T) {
+	service, repo, _, _ := newReplayService(t, `[]`)
+	repo.On("GetReplayBundle", mock.Anything, int64(7)).Return("", nil)
+
+	_, err := service.Replay(context.Background(), 7, "")
+
+	assert.ErrorIs(t, err, ErrNotReplayable)
+}
+
+func TestService_Replay_Disabled(t *testing.T) {
+	service := NewService(&mockStore{repo: &mockExecutionRepository{}})
+
+	_, err := service.Replay(context.Background(), 7, "")
+
+	assert.ErrorIs(t, err, ErrReplayDisabled)
+}
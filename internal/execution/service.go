@@ -0,0 +1,163 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/rule"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+)
+
+// Store interface for database operations
+type Store interface {
+	GetStore() *storage.Store
+}
+
+// ScriptExecutor runs scripts in the sandbox
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, script string, execCtx *execCtx.ExecutionContext) *executor.ExecuteResult
+}
+
+// RuleGetter looks up the current version of a rule
+type RuleGetter interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*rule.Rule, error)
+}
+
+// Service records rule executions and serves the execution history
+type Service struct {
+	store    Store
+	executor ScriptExecutor
+	rules    RuleGetter
+}
+
+// ServiceOption allows to configure the execution service
+type ServiceOption func(s *Service) *Service
+
+// WithReplay enables replaying recorded executions with executor,
+// looking up the current script of their rule through rules
+func WithReplay(executor ScriptExecutor, rules RuleGetter) ServiceOption {
+	return func(s *Service) *Service {
+		s.executor = executor
+		s.rules = rules
+		return s
+	}
+}
+
+// NewService creates a new execution service
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{store: store}
+	for _, opt := range opts {
+		s = opt(s)
+	}
+	return s
+}
+
+// Record stores an execution, setting its ID and creation time
+func (s *Service) Record(ctx context.Context, execution *Execution) error {
+	output, err := json.Marshal(execution.Output)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution output: %w", err)
+	}
+	logs, err := json.Marshal(execution.Logs)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution logs: %w", err)
+	}
+
+	var bundle *string
+	if execution.Replay != nil {
+		data, err := json.Marshal(execution.Replay)
+		if err != nil {
+			return fmt.Errorf("failed to encode execution replay bundle: %w", err)
+		}
+		encoded := string(data)
+		bundle = &encoded
+	}
+
+	storageExecution := &executionStorage.Execution{
+		RuleID:        execution.RuleID,
+		TriggeredAt:   execution.TriggeredAt,
+		Status:        execution.Status,
+		DurationMs:    int(execution.Duration.Milliseconds()),
+		Output:        string(output),
+		Error:         execution.Error,
+		OutputLog:     string(logs),
+		LogsTruncated: execution.LogsTruncated,
+		ReplayBundle:  bundle,
+	}
+	if execution.TriggerID != uuid.Nil {
+		triggerID := execution.TriggerID
+		storageExecution.TriggerID = &triggerID
+	}
+
+	if err := s.store.GetStore().ExecutionRepository.Create(ctx, storageExecution); err != nil {
+		return err
+	}
+	execution.ID = storageExecution.ID
+	execution.CreatedAt = storageExecution.CreatedAt
+	return nil
+}
+
+// GetByID retrieves an execution by its ID
+func (s *Service) GetByID(ctx context.Context, id int64) (*Execution, error) {
+	storageExecution, err := s.store.GetStore().ExecutionRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return fromStorage(storageExecution)
+}
+
+// ListByRule retrieves the executions of a rule, most recent first, with pagination
+func (s *Service) ListByRule(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*Execution, int, error) {
+	storageExecutions, total, err := s.store.GetStore().ExecutionRepository.ListByRuleID(ctx, ruleID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	executions := make([]*Execution, len(storageExecutions))
+	for i, storageExecution := range storageExecutions {
+		execution, err := fromStorage(storageExecution)
+		if err != nil {
+			return nil, 0, err
+		}
+		executions[i] = execution
+	}
+	return executions, total, nil
+}
+
+// fromStorage converts a stored execution log to the domain model
+func fromStorage(e *executionStorage.Execution) (*Execution, error) {
+	execution := &Execution{
+		ID:            e.ID,
+		RuleID:        e.RuleID,
+		Status:        e.Status,
+		TriggeredAt:   e.TriggeredAt,
+		Duration:      time.Duration(e.DurationMs) * time.Millisecond,
+		Error:         e.Error,
+		LogsTruncated: e.LogsTruncated,
+		Replayable:    e.Replayable,
+		CreatedAt:     e.CreatedAt,
+	}
+	if e.TriggerID != nil {
+		execution.TriggerID = *e.TriggerID
+	}
+	if e.Output != "" {
+		if err := json.Unmarshal([]byte(e.Output), &execution.Output); err != nil {
+			return nil, fmt.Errorf("failed to decode output of execution %d: %w", e.ID, err)
+		}
+	}
+	if e.OutputLog != "" {
+		var logs []scriptlog.Entry
+		if err := json.Unmarshal([]byte(e.OutputLog), &logs); err != nil {
+			return nil, fmt.Errorf("failed to decode logs of execution %d: %w", e.ID, err)
+		}
+		execution.Logs = logs
+	}
+	return execution, nil
+}
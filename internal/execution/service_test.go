@@ -0,0 +1,203 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/replay"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/scriptlog"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockExecutionRepository is a mock implementation of ExecutionRepository interface
+type mockExecutionRepository struct {
+	mock.Mock
+}
+
+func (m *mockExecutionRepository) Create(ctx context.Context, execution *executionStorage.Execution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+func (m *mockExecutionRepository) GetByID(ctx context.Context, id int64) (*executionStorage.Execution, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*executionStorage.Execution), args.Error(1)
+}
+
+func (m *mockExecutionRepository) ListByRuleID(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*executionStorage.Execution, int, error) {
+	args := m.Called(ctx, ruleID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(1)
+	}
+	return args.Get(0).([]*executionStorage.Execution), args.Int(1), args.Error(2)
+}
+
+func (m *mockExecutionRepository) GetReplayBundle(ctx context.Context, id int64) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+// mockStore is a mock implementation of Store interface for testing
+type mockStore struct {
+	repo *mockExecutionRepository
+}
+
+func (m *mockStore) GetStore() *storage.Store {
+	return &storage.Store{ExecutionRepository: m.repo}
+}
+
+func TestService_Record(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	ruleID := uuid.New()
+	triggerID := uuid.New()
+	firedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	loggedAt := firedAt.Add(time.Millisecond)
+	execution := FromResult(ruleID, triggerID, firedAt, &executor.ExecuteResult{
+		Success:  true,
+		Status:   executor.StatusSuccess,
+		Output:   []any{true, "too hot"},
+		Duration: 3 * time.Millisecond,
+		Logs:     []scriptlog.Entry{{Level: "info", Message: "temperature 28.5", Timestamp: loggedAt}},
+	})
+
+	createdAt := time.Now()
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(e *executionStorage.Execution) bool {
+		return e.RuleID == ruleID && e.TriggerID != nil && *e.TriggerID == triggerID &&
+			e.Status == "SUCCESS" && e.DurationMs == 3 && e.TriggeredAt.Equal(firedAt) &&
+			e.Output == `[true,"too hot"]` &&
+			e.OutputLog == `[{"level":"info","message":"temperature 28.5","timestamp":"2024-05-01T12:00:00.001Z"}]`
+	})).Run(func(args mock.Arguments) {
+		e := args.Get(1).(*executionStorage.Execution)
+		e.ID = 42
+		e.CreatedAt = createdAt
+	}).Return(nil)
+
+	err := service.Record(context.Background(), execution)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), execution.ID)
+	assert.Equal(t, createdAt, execution.CreatedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Record_ReplayBundle(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	recorder := replay.NewRecorder(&execCtx.ExecutionContext{RuleID: "rule-1", Attempt: 1}, 0)
+	recorder.RecordTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	execution := FromResult(uuid.New(), uuid.Nil, time.Now(), &executor.ExecuteResult{
+		Status: executor.StatusSuccess,
+		Replay: recorder.Bundle(),
+	})
+	assert.True(t, execution.Replayable)
+
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(e *executionStorage.Execution) bool {
+		return e.ReplayBundle != nil && strings.Contains(*e.ReplayBundle, `"times":["2024-05-01T12:00:00Z"]`) &&
+			strings.Contains(*e.ReplayBundle, `"rule_id":"rule-1"`)
+	})).Return(nil)
+
+	assert.NoError(t, service.Record(context.Background(), execution))
+	repo.AssertExpectations(t)
+}
+
+func TestService_Record_WithoutTrigger(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(e *executionStorage.Execution) bool {
+		return e.TriggerID == nil && e.Status == "FAILURE" && e.Error == "boom" && e.Output == "null" && e.OutputLog == "null" &&
+			e.ReplayBundle == nil
+	})).Return(nil)
+
+	err := service.Record(context.Background(), FromResult(uuid.New(), uuid.Nil, time.Now(), &executor.ExecuteResult{
+		Status: executor.StatusFailure,
+		Error:  "boom",
+	}))
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetByID(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	triggerID := uuid.New()
+	repo.On("GetByID", mock.Anything, int64(7)).Return(&executionStorage.Execution{
+		ID:            7,
+		RuleID:        uuid.New(),
+		TriggerID:     &triggerID,
+		Status:        "SUCCESS",
+		DurationMs:    12,
+		Output:        `[{"level":"high"}]`,
+		OutputLog:     `[{"level":"warn","message":"too hot","timestamp":"2024-05-01T12:00:00Z"}]`,
+		LogsTruncated: true,
+	}, nil)
+
+	execution, err := service.GetByID(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, triggerID, execution.TriggerID)
+	assert.Equal(t, 12*time.Millisecond, execution.Duration)
+	assert.Equal(t, []any{map[string]any{"level": "high"}}, execution.Output)
+	assert.Equal(t, []scriptlog.Entry{{Level: "warn", Message: "too hot", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}}, execution.Logs)
+	assert.True(t, execution.LogsTruncated)
+}
+
+func TestService_GetByID_NotFound(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	repo.On("GetByID", mock.Anything, int64(7)).Return(nil, executionStorage.ErrNotFound)
+
+	_, err := service.GetByID(context.Background(), 7)
+
+	assert.ErrorIs(t, err, executionStorage.ErrNotFound)
+}
+
+func TestService_ListByRule(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	ruleID := uuid.New()
+	repo.On("ListByRuleID", mock.Anything, ruleID, 10, 0).Return([]*executionStorage.Execution{
+		{ID: 2, RuleID: ruleID, Status: "SUCCESS", Output: "[true]", OutputLog: "[]"},
+		{ID: 1, RuleID: ruleID, Status: "TIMEOUT", Output: "null", OutputLog: "null", Error: "timed out"},
+	}, 2, nil)
+
+	executions, total, err := service.ListByRule(context.Background(), ruleID, 10, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, executions, 2)
+	assert.Equal(t, uuid.Nil, executions[0].TriggerID)
+	assert.Equal(t, []any{true}, executions[0].Output)
+	assert.Equal(t, "timed out", executions[1].Error)
+	assert.Nil(t, executions[1].Output)
+}
+
+func TestService_ListByRule_Error(t *testing.T) {
+	repo := &mockExecutionRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	repo.On("ListByRuleID", mock.Anything, mock.Anything, 10, 0).Return(nil, errors.New("db down"))
+
+	_, _, err := service.ListByRule(context.Background(), uuid.New(), 10, 0)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package library
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Library represents a shared Lua library in the business domain.
+// Scripts load it with require(Name).
+type Library struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Code        string    `json:"code"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Version represents a version of the code of a library
+type Version struct {
+	LibraryID uuid.UUID `json:"library_id"`
+	Version   int       `json:"version"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
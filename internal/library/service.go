@@ -0,0 +1,361 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/action"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/validation"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
+	libraryStorage "github.com/malyshevhen/rule-engine/internal/storage/library"
+	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
+)
+
+// DefaultReloadInterval is how often Watch checks the stored libraries
+const DefaultReloadInterval = 30 * time.Second
+
+// ErrInUse is returned when deleting a library that scripts still require
+var ErrInUse = errors.New("library is in use")
+
+// scanPageSize is the number of scripts read at once when looking for the users of a library
+const scanPageSize = 500
+
+// Store interface for database operations
+type Store interface {
+	ExecTx(ctx context.Context, fn func(*storage.Store) error) error
+	GetStore() *storage.Store
+}
+
+// Registry makes libraries available to scripts.
+// It rejects a set of libraries that does not compile or whose dependencies
+// are missing or cyclic, and drops the states that preloaded the previous set.
+type Registry interface {
+	SetLibraries(libs []platform.Library) error
+}
+
+// Service handles business logic for libraries
+type Service struct {
+	store    Store
+	registry Registry
+
+	// registered identifies the set of libraries last accepted by the registry
+	mu         sync.Mutex
+	registered string
+}
+
+// ServiceOption allows to configure the library service
+type ServiceOption func(s *Service) *Service
+
+// WithRegistry sets the registry updated when libraries change
+func WithRegistry(registry Registry) ServiceOption {
+	return func(s *Service) *Service {
+		s.registry = registry
+		return s
+	}
+}
+
+// NewService creates a new library service
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Load registers the stored libraries
+func (s *Service) Load(ctx context.Context) error {
+	if s.registry == nil {
+		return nil
+	}
+	return s.register(ctx, s.store.GetStore())
+}
+
+// Watch registers the stored libraries again every interval once they have
+// changed, so that the changes made through other instances of the engine are
+// picked up. It returns when ctx is done.
+func (s *Service) Watch(ctx context.Context, interval time.Duration) {
+	if s.registry == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reload(ctx); err != nil {
+				slog.Error("Failed to reload shared libraries", "error", err)
+			}
+		}
+	}
+}
+
+// reload registers the stored libraries unless the registry already has them
+func (s *Service) reload(ctx context.Context) error {
+	storageLibraries, err := s.store.GetStore().LibraryRepository.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	unchanged := fingerprint(storageLibraries) == s.registered
+	s.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+	return s.setLibraries(storageLibraries)
+}
+
+// Create creates a new library
+func (s *Service) Create(ctx context.Context, library *Library) error {
+	return s.change(ctx, func(q *storage.Store) error {
+		storageLibrary := &libraryStorage.Library{
+			Name:        library.Name,
+			Description: library.Description,
+			Code:        library.Code,
+		}
+		if err := q.LibraryRepository.Create(ctx, storageLibrary); err != nil {
+			return err
+		}
+		*library = *fromStorage(storageLibrary)
+		return nil
+	})
+}
+
+// GetByID retrieves a library by its ID
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Library, error) {
+	storageLibrary, err := s.store.GetStore().LibraryRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return fromStorage(storageLibrary), nil
+}
+
+// List retrieves libraries with pagination
+func (s *Service) List(ctx context.Context, limit, offset int) ([]*Library, int, error) {
+	storageLibraries, total, err := s.store.GetStore().LibraryRepository.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	libraries := make([]*Library, len(storageLibraries))
+	for i, storageLibrary := range storageLibraries {
+		libraries[i] = fromStorage(storageLibrary)
+	}
+	return libraries, total, nil
+}
+
+// Update modifies an existing library. A change of its code creates a new version.
+func (s *Service) Update(ctx context.Context, library *Library) error {
+	return s.change(ctx, func(q *storage.Store) error {
+		storageLibrary := &libraryStorage.Library{
+			ID:          library.ID,
+			Name:        library.Name,
+			Description: library.Description,
+			Code:        library.Code,
+		}
+		if err := q.LibraryRepository.Update(ctx, storageLibrary); err != nil {
+			return err
+		}
+		library.Version = storageLibrary.Version
+		library.CreatedAt = storageLibrary.CreatedAt
+		library.UpdatedAt = storageLibrary.UpdatedAt
+		return nil
+	})
+}
+
+// Delete removes a library. It is rejected with ErrInUse while a rule, trigger
+// or action script requires it, and while other libraries require it.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.change(ctx, func(q *storage.Store) error {
+		storageLibrary, err := q.LibraryRepository.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user, err := requiredBy(ctx, q, storageLibrary.Name)
+		if err != nil {
+			return err
+		}
+		if user != "" {
+			return fmt.Errorf("%w: %s requires '%s'", ErrInUse, user, storageLibrary.Name)
+		}
+		return q.LibraryRepository.Delete(ctx, id)
+	})
+}
+
+// ListVersions retrieves the versions of a library, most recent first
+func (s *Service) ListVersions(ctx context.Context, id uuid.UUID) ([]*Version, error) {
+	// Report unknown libraries instead of an empty history
+	if _, err := s.store.GetStore().LibraryRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	storageVersions, err := s.store.GetStore().LibraryRepository.ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*Version, len(storageVersions))
+	for i, storageVersion := range storageVersions {
+		versions[i] = versionFromStorage(storageVersion)
+	}
+	return versions, nil
+}
+
+// GetVersion retrieves one version of a library
+func (s *Service) GetVersion(ctx context.Context, id uuid.UUID, version int) (*Version, error) {
+	storageVersion, err := s.store.GetStore().LibraryRepository.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return versionFromStorage(storageVersion), nil
+}
+
+// change applies fn in a transaction and registers the resulting set of libraries
+// before committing it, so a set the registry rejects is never stored
+func (s *Service) change(ctx context.Context, fn func(q *storage.Store) error) error {
+	registered := false
+	err := s.store.ExecTx(ctx, func(q *storage.Store) error {
+		if err := fn(q); err != nil {
+			return err
+		}
+		if s.registry == nil {
+			return nil
+		}
+		if err := s.register(ctx, q); err != nil {
+			return err
+		}
+		registered = true
+		return nil
+	})
+	if err != nil && registered {
+		// The transaction failed after the registry accepted its libraries,
+		// so the registry is brought back in line with what is stored
+		if loadErr := s.Load(ctx); loadErr != nil {
+			return errors.Join(err, loadErr)
+		}
+	}
+	return err
+}
+
+func (s *Service) register(ctx context.Context, q *storage.Store) error {
+	storageLibraries, err := q.LibraryRepository.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	return s.setLibraries(storageLibraries)
+}
+
+func (s *Service) setLibraries(storageLibraries []*libraryStorage.Library) error {
+	libs := make([]platform.Library, len(storageLibraries))
+	for i, storageLibrary := range storageLibraries {
+		libs[i] = platform.Library{Name: storageLibrary.Name, Version: storageLibrary.Version, Code: storageLibrary.Code}
+	}
+	if err := s.registry.SetLibraries(libs); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.registered = fingerprint(storageLibraries)
+	s.mu.Unlock()
+	return nil
+}
+
+// fingerprint identifies a set of libraries by their names and versions.
+// Every change of the code of a library creates a new version.
+func fingerprint(storageLibraries []*libraryStorage.Library) string {
+	keys := make([]string, len(storageLibraries))
+	for i, storageLibrary := range storageLibraries {
+		keys[i] = fmt.Sprintf("%s@%d", storageLibrary.Name, storageLibrary.Version)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+// requiredBy describes the first rule, trigger or action whose Lua script
+// requires the library name, or returns "" when no script does
+func requiredBy(ctx context.Context, q *storage.Store, name string) (string, error) {
+	requires := func(script string) bool {
+		// Scripts that do not parse cannot load the library either
+		modules, err := validation.Requires(script)
+		return err == nil && slices.Contains(modules, name)
+	}
+
+	var user string
+	err := eachPage(ctx, q.RuleRepository.List, func(rule *ruleStorage.Rule) bool {
+		if requires(rule.LuaScript) {
+			user = fmt.Sprintf("rule '%s'", rule.Name)
+		}
+		return user == ""
+	})
+	if err != nil || user != "" {
+		return user, err
+	}
+	err = eachPage(ctx, q.TriggerRepository.List, func(trigger *triggerStorage.Trigger) bool {
+		lua := trigger.Language == "" || trigger.Language == executor.LanguageLua
+		if trigger.Type == triggerStorage.Conditional && lua && requires(trigger.ConditionScript) {
+			user = fmt.Sprintf("trigger %s", trigger.ID)
+		}
+		return user == ""
+	})
+	if err != nil || user != "" {
+		return user, err
+	}
+	err = eachPage(ctx, q.ActionRepository.List, func(storageAction *actionStorage.Action) bool {
+		if storageAction.Type == action.TypeLuaScript && requires(storageAction.Params) {
+			user = fmt.Sprintf("action '%s'", storageAction.Name)
+		}
+		return user == ""
+	})
+	return user, err
+}
+
+// eachPage calls fn on every item listed by list until fn returns false
+func eachPage[T any](ctx context.Context, list func(ctx context.Context, limit, offset int) ([]T, int, error), fn func(T) bool) error {
+	for offset := 0; ; offset += scanPageSize {
+		items, total, err := list(ctx, scanPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if !fn(item) {
+				return nil
+			}
+		}
+		if len(items) == 0 || offset+len(items) >= total {
+			return nil
+		}
+	}
+}
+
+func fromStorage(storageLibrary *libraryStorage.Library) *Library {
+	return &Library{
+		ID:          storageLibrary.ID,
+		Name:        storageLibrary.Name,
+		Description: storageLibrary.Description,
+		Code:        storageLibrary.Code,
+		Version:     storageLibrary.Version,
+		CreatedAt:   storageLibrary.CreatedAt,
+		UpdatedAt:   storageLibrary.UpdatedAt,
+	}
+}
+
+func versionFromStorage(storageVersion *libraryStorage.Version) *Version {
+	return &Version{
+		LibraryID: storageVersion.LibraryID,
+		Version:   storageVersion.Version,
+		Code:      storageVersion.Code,
+		CreatedAt: storageVersion.CreatedAt,
+	}
+}
@@ -0,0 +1,326 @@
+package library
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
+	libraryStorage "github.com/malyshevhen/rule-engine/internal/storage/library"
+	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLibraryRepository is a mock implementation of LibraryRepository interface
+type mockLibraryRepository struct {
+	mock.Mock
+}
+
+func (m *mockLibraryRepository) Create(ctx context.Context, library *libraryStorage.Library) error {
+	args := m.Called(ctx, library)
+	return args.Error(0)
+}
+
+func (m *mockLibraryRepository) GetByID(ctx context.Context, id uuid.UUID) (*libraryStorage.Library, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*libraryStorage.Library), args.Error(1)
+}
+
+func (m *mockLibraryRepository) List(ctx context.Context, limit, offset int) ([]*libraryStorage.Library, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*libraryStorage.Library), args.Int(1), args.Error(2)
+}
+
+func (m *mockLibraryRepository) ListAll(ctx context.Context) ([]*libraryStorage.Library, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*libraryStorage.Library), args.Error(1)
+}
+
+func (m *mockLibraryRepository) Update(ctx context.Context, library *libraryStorage.Library) error {
+	args := m.Called(ctx, library)
+	return args.Error(0)
+}
+
+func (m *mockLibraryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockLibraryRepository) ListVersions(ctx context.Context, id uuid.UUID) ([]*libraryStorage.Version, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*libraryStorage.Version), args.Error(1)
+}
+
+func (m *mockLibraryRepository) GetVersion(ctx context.Context, id uuid.UUID, version int) (*libraryStorage.Version, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*libraryStorage.Version), args.Error(1)
+}
+
+// pagedList serves items by pages like the List methods of the repositories
+func pagedList[T any](items []T, limit, offset int) ([]T, int, error) {
+	end := min(offset+limit, len(items))
+	if offset >= end {
+		return nil, len(items), nil
+	}
+	return items[offset:end], len(items), nil
+}
+
+// listedRules, listedTriggers and listedActions hold the scripts that may
+// require a library. Only List is implemented.
+type listedRules struct {
+	storage.RuleRepository
+	rules []*ruleStorage.Rule
+}
+
+func (l *listedRules) List(_ context.Context, limit, offset int) ([]*ruleStorage.Rule, int, error) {
+	return pagedList(l.rules, limit, offset)
+}
+
+type listedTriggers struct {
+	storage.TriggerRepository
+	triggers []*triggerStorage.Trigger
+}
+
+func (l *listedTriggers) List(_ context.Context, limit, offset int) ([]*triggerStorage.Trigger, int, error) {
+	return pagedList(l.triggers, limit, offset)
+}
+
+type listedActions struct {
+	storage.ActionRepository
+	actions []*actionStorage.Action
+}
+
+func (l *listedActions) List(_ context.Context, limit, offset int) ([]*actionStorage.Action, int, error) {
+	return pagedList(l.actions, limit, offset)
+}
+
+// mockStore is a mock implementation of Store interface.
+// ExecTx returns commitErr once fn succeeded, like a failing commit.
+type mockStore struct {
+	repo      *mockLibraryRepository
+	commitErr error
+	rules     []*ruleStorage.Rule
+	triggers  []*triggerStorage.Trigger
+	actions   []*actionStorage.Action
+}
+
+func (m *mockStore) ExecTx(ctx context.Context, fn func(*storage.Store) error) error {
+	if err := fn(m.GetStore()); err != nil {
+		return err
+	}
+	return m.commitErr
+}
+
+func (m *mockStore) GetStore() *storage.Store {
+	return &storage.Store{
+		LibraryRepository: m.repo,
+		RuleRepository:    &listedRules{rules: m.rules},
+		TriggerRepository: &listedTriggers{triggers: m.triggers},
+		ActionRepository:  &listedActions{actions: m.actions},
+	}
+}
+
+// mockRegistry is a mock implementation of Registry interface
+type mockRegistry struct {
+	mock.Mock
+}
+
+func (m *mockRegistry) SetLibraries(libs []platform.Library) error {
+	args := m.Called(libs)
+	return args.Error(0)
+}
+
+func TestService_Create_RegistersLibraries(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	registry := &mockRegistry{}
+	service := NewService(&mockStore{repo: repo}, WithRegistry(registry))
+
+	id := uuid.New()
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(l *libraryStorage.Library) bool {
+		return l.Name == "utils" && l.Code == "return {}"
+	})).Run(func(args mock.Arguments) {
+		l := args.Get(1).(*libraryStorage.Library)
+		l.ID = id
+		l.Version = 1
+	}).Return(nil)
+	repo.On("ListAll", mock.Anything).Return([]*libraryStorage.Library{
+		{ID: id, Name: "utils", Code: "return {}", Version: 1},
+	}, nil)
+	registry.On("SetLibraries", []platform.Library{{Name: "utils", Version: 1, Code: "return {}"}}).Return(nil)
+
+	library := &Library{Name: "utils", Code: "return {}"}
+	err := service.Create(context.Background(), library)
+
+	require.NoError(t, err)
+	assert.Equal(t, id, library.ID)
+	assert.Equal(t, 1, library.Version)
+	repo.AssertExpectations(t)
+	registry.AssertExpectations(t)
+}
+
+func TestService_Update_RejectedByRegistry(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	registry := &mockRegistry{}
+	service := NewService(&mockStore{repo: repo}, WithRegistry(registry))
+
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	repo.On("ListAll", mock.Anything).Return([]*libraryStorage.Library{{Name: "a", Code: `return require("a")`}}, nil)
+	registry.On("SetLibraries", mock.Anything).Return(platform.ErrDependencyCycle)
+
+	err := service.Update(context.Background(), &Library{ID: uuid.New(), Name: "a", Code: `return require("a")`})
+
+	assert.ErrorIs(t, err, platform.ErrDependencyCycle)
+	registry.AssertNumberOfCalls(t, "SetLibraries", 1)
+}
+
+func TestService_Delete_CommitFailureReloads(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	registry := &mockRegistry{}
+	service := NewService(&mockStore{repo: repo, commitErr: assert.AnError}, WithRegistry(registry))
+
+	id := uuid.New()
+	stored := []*libraryStorage.Library{{ID: id, Name: "utils", Code: "return {}", Version: 2}}
+	repo.On("GetByID", mock.Anything, id).Return(stored[0], nil)
+	repo.On("Delete", mock.Anything, id).Return(nil)
+	repo.On("ListAll", mock.Anything).Return([]*libraryStorage.Library{}, nil).Once()
+	repo.On("ListAll", mock.Anything).Return(stored, nil).Once()
+	registry.On("SetLibraries", []platform.Library{}).Return(nil).Once()
+	registry.On("SetLibraries", []platform.Library{{Name: "utils", Version: 2, Code: "return {}"}}).Return(nil).Once()
+
+	err := service.Delete(context.Background(), id)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	repo.AssertExpectations(t)
+	registry.AssertExpectations(t)
+}
+
+func TestService_Delete_RequiredByScripts(t *testing.T) {
+	uses := `local utils = require("utils")
+return utils.ok()`
+	tests := []struct {
+		name  string
+		store *mockStore
+		user  string
+	}{
+		{"rule", &mockStore{rules: []*ruleStorage.Rule{{Name: "other", LuaScript: "return true"}, {Name: "heating", LuaScript: uses}}}, "rule 'heating'"},
+		{"trigger", &mockStore{triggers: []*triggerStorage.Trigger{{ID: uuid.MustParse("8f14e45f-ceea-467a-a866-051f2d5a2b1c"), Type: triggerStorage.Conditional, Language: "lua", ConditionScript: uses}}}, "trigger 8f14e45f-ceea-467a-a866-051f2d5a2b1c"},
+		{"action", &mockStore{actions: []*actionStorage.Action{{Name: "notify", Type: "lua_script", Params: uses}}}, "action 'notify'"},
+		{"rule beyond the first page", &mockStore{rules: append(make([]*ruleStorage.Rule, scanPageSize), &ruleStorage.Rule{Name: "last", LuaScript: uses})}, "rule 'last'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, rule := range tt.store.rules {
+				if rule == nil {
+					tt.store.rules[i] = &ruleStorage.Rule{Name: "plain", LuaScript: "return true"}
+				}
+			}
+			repo := &mockLibraryRepository{}
+			registry := &mockRegistry{}
+			tt.store.repo = repo
+			service := NewService(tt.store, WithRegistry(registry))
+
+			id := uuid.New()
+			repo.On("GetByID", mock.Anything, id).Return(&libraryStorage.Library{ID: id, Name: "utils"}, nil)
+
+			err := service.Delete(context.Background(), id)
+
+			assert.ErrorIs(t, err, ErrInUse)
+			assert.EqualError(t, err, "library is in use: "+tt.user+" requires 'utils'")
+			repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			registry.AssertNotCalled(t, "SetLibraries", mock.Anything)
+		})
+	}
+}
+
+func TestService_Delete_IgnoresScriptsThatCannotRequire(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	registry := &mockRegistry{}
+	service := NewService(&mockStore{
+		repo:  repo,
+		rules: []*ruleStorage.Rule{{Name: "broken", LuaScript: `require("utils"`}},
+		triggers: []*triggerStorage.Trigger{
+			{Type: triggerStorage.Conditional, Language: "expr", ConditionScript: `require("utils")`},
+			{Type: triggerStorage.Cron, ConditionScript: "*/5 * * * *"},
+		},
+		actions: []*actionStorage.Action{{Name: "publish", Type: "publish_event", Params: `{"subject": "require(\"utils\")"}`}},
+	}, WithRegistry(registry))
+
+	id := uuid.New()
+	repo.On("GetByID", mock.Anything, id).Return(&libraryStorage.Library{ID: id, Name: "utils"}, nil)
+	repo.On("Delete", mock.Anything, id).Return(nil)
+	repo.On("ListAll", mock.Anything).Return([]*libraryStorage.Library{}, nil)
+	registry.On("SetLibraries", []platform.Library{}).Return(nil)
+
+	require.NoError(t, service.Delete(context.Background(), id))
+	repo.AssertExpectations(t)
+	registry.AssertExpectations(t)
+}
+
+func TestService_Watch_ReloadsChangedLibraries(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	registry := &mockRegistry{}
+	service := NewService(&mockStore{repo: repo}, WithRegistry(registry))
+
+	first := []*libraryStorage.Library{{Name: "utils", Code: "return {}", Version: 1}}
+	second := []*libraryStorage.Library{{Name: "utils", Code: "return {ok = true}", Version: 2}}
+	repo.On("ListAll", mock.Anything).Return(first, nil).Twice()
+	repo.On("ListAll", mock.Anything).Return(second, nil)
+	registry.On("SetLibraries", []platform.Library{{Name: "utils", Version: 1, Code: "return {}"}}).Return(nil).Once()
+	reloaded := make(chan struct{})
+	registry.On("SetLibraries", []platform.Library{{Name: "utils", Version: 2, Code: "return {ok = true}"}}).
+		Run(func(mock.Arguments) { close(reloaded) }).Return(nil).Once()
+
+	require.NoError(t, service.Load(context.Background()))
+
+	// Another instance changed the library after the first check
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Watch(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the changed libraries were not registered")
+	}
+	cancel()
+	<-done
+
+	registry.AssertExpectations(t)
+}
+
+func TestService_ListVersions_NotFound(t *testing.T) {
+	repo := &mockLibraryRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	id := uuid.New()
+	repo.On("GetByID", mock.Anything, id).Return(nil, libraryStorage.ErrNotFound)
+
+	versions, err := service.ListVersions(context.Background(), id)
+
+	assert.ErrorIs(t, err, libraryStorage.ErrNotFound)
+	assert.Nil(t, versions)
+	repo.AssertNotCalled(t, "ListVersions", mock.Anything, mock.Anything)
+}
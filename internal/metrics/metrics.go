@@ -12,7 +12,7 @@ var (
 			Name: "rule_engine_rule_executions_total",
 			Help: "Total number of rule executions",
 		},
-		[]string{"rule_id", "result"}, // result: success, failure
+		[]string{"rule_id", "result"}, // result: success, failure, timeout
 	)
 
 	// TriggerEventsTotal counts trigger events processed
@@ -33,6 +33,50 @@ var (
 		[]string{"rule_id", "error_type"},
 	)
 
+	// LuaExecutionTimeoutsTotal counts scripts aborted by the execution deadline
+	LuaExecutionTimeoutsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rule_engine_lua_execution_timeouts_total",
+			Help: "Total number of Lua executions aborted by the execution timeout",
+		},
+		[]string{"rule_id"},
+	)
+
+	// LuaStatePoolSize measures the number of pooled Lua states by state (idle, in_use)
+	LuaStatePoolSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rule_engine_lua_state_pool_size",
+			Help: "Current number of pooled Lua states",
+		},
+		[]string{"state"},
+	)
+
+	// LuaStatePoolGetsTotal counts Lua state checkouts by result (hit, miss)
+	LuaStatePoolGetsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rule_engine_lua_state_pool_gets_total",
+			Help: "Total number of Lua states taken from the pool",
+		},
+		[]string{"result"},
+	)
+
+	// LuaScriptCacheLookupsTotal counts compiled script cache lookups by result (hit, miss)
+	LuaScriptCacheLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rule_engine_lua_script_cache_lookups_total",
+			Help: "Total number of compiled Lua script cache lookups",
+		},
+		[]string{"result"},
+	)
+
+	// LuaScriptCacheSize measures the number of cached compiled scripts
+	LuaScriptCacheSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rule_engine_lua_script_cache_size",
+			Help: "Current number of compiled Lua scripts in the cache",
+		},
+	)
+
 	// RuleExecutionDuration measures execution duration
 	RuleExecutionDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -49,7 +93,7 @@ var (
 			Name: "rule_engine_trigger_evaluation_total",
 			Help: "Total number of trigger condition evaluations",
 		},
-		[]string{"trigger_type", "result"}, // result: matched, not_matched, error
+		[]string{"trigger_type", "result"}, // result: matched, not_matched, error, timeout
 	)
 
 	// TriggerEvaluationDuration measures trigger evaluation duration
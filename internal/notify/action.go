@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/template"
+)
+
+// ActionParams are the params of a notify action. The recipients, the subject
+// and the body may hold placeholders such as {{event.payload.room}}, rendered
+// from the context of the execution.
+type ActionParams struct {
+	Channel string   `json:"channel"`
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject,omitempty"`
+	Body    string   `json:"body"`
+}
+
+// ParseActionParams parses and validates the params of a notify action
+func ParseActionParams(params string) (*ActionParams, error) {
+	var p ActionParams
+	if err := json.Unmarshal([]byte(params), &p); err != nil {
+		return nil, fmt.Errorf("invalid notify params: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the channel, the body and the placeholders of the params
+func (p *ActionParams) Validate() error {
+	if p.Channel == "" {
+		return errors.New("channel is required")
+	}
+	if p.Body == "" {
+		return errors.New("body is required")
+	}
+	for i, to := range p.To {
+		if err := template.Validate(to); err != nil {
+			return fmt.Errorf("to[%d]: %w", i, err)
+		}
+	}
+	if err := template.Validate(p.Subject); err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	if err := template.Validate(p.Body); err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	return nil
+}
+
+// Render renders the message of the params from data
+func (p *ActionParams) Render(data map[string]any) (Message, error) {
+	var msg Message
+	for i, to := range p.To {
+		rendered, err := template.Render(to, data)
+		if err != nil {
+			return Message{}, fmt.Errorf("to[%d]: %w", i, err)
+		}
+		msg.To = append(msg.To, rendered)
+	}
+	var err error
+	if msg.Subject, err = template.Render(p.Subject, data); err != nil {
+		return Message{}, fmt.Errorf("subject: %w", err)
+	}
+	if msg.Body, err = template.Render(p.Body, data); err != nil {
+		return Message{}, fmt.Errorf("body: %w", err)
+	}
+	return msg, nil
+}
+
+// SendAction renders the message of a notify action from the context of the
+// execution that runs it and sends it through the channel of the action
+func (d *Dispatcher) SendAction(ctx context.Context, params *ActionParams, ec *execCtx.ExecutionContext) error {
+	msg, err := params.Render(ec.Fields())
+	if err != nil {
+		return err
+	}
+	return d.Send(ctx, params.Channel, msg)
+}
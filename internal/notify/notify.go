@@ -0,0 +1,101 @@
+// Package notify sends notifications from rules, either from scripts through
+// the notify module or with notify actions.
+//
+// Notifications go through channels configured by the operator, such as an
+// SMTP server, a generic webhook, a Slack-compatible incoming webhook or a
+// Telegram-compatible bot. Scripts and actions only pick a channel by name
+// and, where the channel supports it, the recipients; they never see the
+// credentials or the addresses of the channels.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultTimeout bounds the time a channel takes to deliver a notification
+const DefaultTimeout = 10 * time.Second
+
+// Errors returned by the dispatcher and the notifiers
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	ErrEmptyMessage   = errors.New("notification body is empty")
+	ErrNoRecipient    = errors.New("notification has no recipient")
+)
+
+// Message is a notification. Channels without a subject prepend it to the body;
+// To overrides the default recipients of the channel.
+type Message struct {
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject,omitempty"`
+	Body    string   `json:"body"`
+}
+
+// text returns the subject and the body as one text, for the channels that
+// have no subject
+func (m Message) text() string {
+	if m.Subject == "" {
+		return m.Body
+	}
+	return m.Subject + "\n\n" + m.Body
+}
+
+// Notifier delivers notifications through one channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Dispatcher sends notifications through named channels
+type Dispatcher struct {
+	channels map[string]Notifier
+}
+
+// DispatcherOption allows to configure the dispatcher
+type DispatcherOption func(d *Dispatcher) *Dispatcher
+
+// WithChannel registers a channel under name, replacing any channel of the same name
+func WithChannel(name string, notifier Notifier) DispatcherOption {
+	return func(d *Dispatcher) *Dispatcher {
+		d.channels[name] = notifier
+		return d
+	}
+}
+
+// NewDispatcher creates a dispatcher of the given channels
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{channels: make(map[string]Notifier)}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Channels returns the names of the channels, sorted
+func (d *Dispatcher) Channels() []string {
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send sends msg through the channel name, within DefaultTimeout
+func (d *Dispatcher) Send(ctx context.Context, channel string, msg Message) error {
+	notifier, ok := d.channels[channel]
+	if !ok {
+		return fmt.Errorf("%w '%s'", ErrUnknownChannel, channel)
+	}
+	if msg.Body == "" {
+		return ErrEmptyMessage
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	if err := notifier.Send(ctx, msg); err != nil {
+		return fmt.Errorf("%s: %w", channel, err)
+	}
+	return nil
+}
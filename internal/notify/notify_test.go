@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) Send(ctx context.Context, msg Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func TestDispatcher_Send(t *testing.T) {
+	email := &mockNotifier{}
+	chat := &mockNotifier{}
+	d := NewDispatcher(WithChannel("email", email), WithChannel("chat", chat))
+	assert.Equal(t, []string{"chat", "email"}, d.Channels())
+
+	msg := Message{Subject: "Leak", Body: "Water in the basement"}
+	email.On("Send", mock.Anything, msg).Return(nil)
+	chat.On("Send", mock.Anything, msg).Return(errors.New("unexpected status 500"))
+
+	require.NoError(t, d.Send(context.Background(), "email", msg))
+	assert.EqualError(t, d.Send(context.Background(), "chat", msg), "chat: unexpected status 500")
+	assert.ErrorIs(t, d.Send(context.Background(), "sms", msg), ErrUnknownChannel)
+	assert.ErrorIs(t, d.Send(context.Background(), "email", Message{Subject: "empty"}), ErrEmptyMessage)
+
+	email.AssertExpectations(t)
+	chat.AssertExpectations(t)
+}
+
+func TestParseActionParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantErr string
+	}{
+		{"valid", `{"channel":"email","to":["{{event.payload.owner}}"],"subject":"Leak","body":"Water in {{event.payload.room}}"}`, ""},
+		{"body only", `{"channel":"slack","body":"hello"}`, ""},
+		{"not json", `channel`, "invalid notify params"},
+		{"no channel", `{"body":"hello"}`, "channel is required"},
+		{"no body", `{"channel":"email"}`, "body is required"},
+		{"bad body", `{"channel":"email","body":"{{event"}`, "body:"},
+		{"bad subject", `{"channel":"email","subject":"{{}}","body":"hello"}`, "subject:"},
+		{"bad recipient", `{"channel":"email","to":["{{event"],"body":"hello"}`, "to[0]:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := ParseActionParams(tt.params)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, params)
+		})
+	}
+}
+
+func TestDispatcher_SendAction(t *testing.T) {
+	email := &mockNotifier{}
+	d := NewDispatcher(WithChannel("email", email))
+	params, err := ParseActionParams(`{
+		"channel": "email",
+		"to": ["{{event.payload.owner}}"],
+		"subject": "Leak in the {{event.payload.room}}",
+		"body": "Rule {{rule.id}} saw {{event.payload.level}} mm of water"
+	}`)
+	require.NoError(t, err)
+
+	ec := &execCtx.ExecutionContext{
+		RuleID: "rule-1",
+		Event:  map[string]any{"owner": "ann@example.com", "room": "basement", "level": 12},
+	}
+	email.On("Send", mock.Anything, Message{
+		To:      []string{"ann@example.com"},
+		Subject: "Leak in the basement",
+		Body:    "Rule rule-1 saw 12 mm of water",
+	}).Return(nil)
+
+	require.NoError(t, d.SendAction(context.Background(), params, ec))
+	email.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// SlackNotifier posts notifications to a Slack-compatible incoming webhook,
+// as used by Slack, Mattermost and Rocket.Chat. The subject is set in bold
+// above the body. A recipient, when given, overrides the channel of the
+// webhook, where the service allows it.
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// SlackOption allows to configure the Slack notifier
+type SlackOption func(n *SlackNotifier) *SlackNotifier
+
+// WithSlackClient sets the HTTP client the notifications are posted with
+func WithSlackClient(client *http.Client) SlackOption {
+	return func(n *SlackNotifier) *SlackNotifier {
+		n.client = client
+		return n
+	}
+}
+
+// NewSlackNotifier creates a notifier posting to the incoming webhook at rawURL
+func NewSlackNotifier(rawURL string, opts ...SlackOption) (*SlackNotifier, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	n := &SlackNotifier{url: rawURL, client: &http.Client{Timeout: DefaultTimeout}}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n, nil
+}
+
+type slackMessage struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Send posts msg to the webhook, once per recipient
+func (n *SlackNotifier) Send(ctx context.Context, msg Message) error {
+	text := msg.Body
+	if msg.Subject != "" {
+		text = "*" + msg.Subject + "*\n" + msg.Body
+	}
+	if len(msg.To) == 0 {
+		_, err := postJSON(ctx, n.client, n.url, nil, slackMessage{Text: text})
+		return err
+	}
+	for _, channel := range msg.To {
+		if _, err := postJSON(ctx, n.client, n.url, nil, slackMessage{Text: text, Channel: channel}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
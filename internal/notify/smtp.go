@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier sends notifications as plain text emails through an SMTP
+// server, upgrading the connection with STARTTLS when the server offers it
+type SMTPNotifier struct {
+	addr       string
+	host       string
+	from       string
+	auth       smtp.Auth
+	recipients []string
+	tlsConfig  *tls.Config
+}
+
+// SMTPOption allows to configure the SMTP notifier
+type SMTPOption func(n *SMTPNotifier) *SMTPNotifier
+
+// WithSMTPAuth authenticates to the server with the PLAIN mechanism, which
+// the standard library only allows over TLS or to localhost
+func WithSMTPAuth(username, password string) SMTPOption {
+	return func(n *SMTPNotifier) *SMTPNotifier {
+		n.auth = smtp.PlainAuth("", username, password, n.host)
+		return n
+	}
+}
+
+// WithSMTPRecipients sets the recipients of the messages that name none
+func WithSMTPRecipients(recipients ...string) SMTPOption {
+	return func(n *SMTPNotifier) *SMTPNotifier {
+		n.recipients = recipients
+		return n
+	}
+}
+
+// WithSMTPTLSConfig sets the TLS configuration used for STARTTLS
+func WithSMTPTLSConfig(config *tls.Config) SMTPOption {
+	return func(n *SMTPNotifier) *SMTPNotifier {
+		n.tlsConfig = config
+		return n
+	}
+}
+
+// NewSMTPNotifier creates a notifier sending emails from the address from
+// through the SMTP server at addr (host:port)
+func NewSMTPNotifier(addr, from string, opts ...SMTPOption) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address: %w", err)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	n := &SMTPNotifier{
+		addr:      addr,
+		host:      host,
+		from:      from,
+		tlsConfig: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n, nil
+}
+
+// Send sends msg to its recipients, or to the default recipients when it names none
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	recipients := msg.To
+	if len(recipients) == 0 {
+		recipients = n.recipients
+	}
+	if len(recipients) == 0 {
+		return ErrNoRecipient
+	}
+	for _, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return fmt.Errorf("invalid recipient '%s': %w", recipient, err)
+		}
+	}
+
+	data, err := n.compose(recipients, msg)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(n.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose returns the email of msg, with a quoted-printable UTF-8 body
+func (n *SMTPNotifier) compose(recipients []string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		// Header values come from rules, so line breaks are dropped to keep them to one header
+		value = strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", n.from)
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
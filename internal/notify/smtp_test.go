@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal SMTP server recording the emails it receives
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	auth   string
+	from   string
+	rcpts  []string
+	emails []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.rcpts = append(s.rcpts, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					s.mu.Unlock()
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.emails = append(s.emails, data.String())
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("250 OK")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier, err := NewSMTPNotifier(server.addr(), "rules@example.com",
+		WithSMTPAuth("rules", "s3cret"),
+		WithSMTPRecipients("ops@example.com"),
+	)
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Message{
+		Subject: "Température élevée",
+		Body:    "The kitchen is at 31°C",
+	})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00rules\x00s3cret")), server.auth)
+	assert.Equal(t, "MAIL FROM:<rules@example.com>", server.from)
+	assert.Equal(t, []string{"RCPT TO:<ops@example.com>"}, server.rcpts)
+	require.Len(t, server.emails, 1)
+
+	email, err := mail.ReadMessage(strings.NewReader(server.emails[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "ops@example.com", email.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(email.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Température élevée", subject)
+	body, err := io.ReadAll(quotedprintable.NewReader(email.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "The kitchen is at 31°C", strings.TrimRight(string(body), "\r\n"))
+}
+
+func TestSMTPNotifier_Recipients(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier, err := NewSMTPNotifier(server.addr(), "rules@example.com")
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Message{Body: "hello"})
+	assert.ErrorIs(t, err, ErrNoRecipient)
+
+	err = notifier.Send(context.Background(), Message{To: []string{"not an address"}, Body: "hello"})
+	assert.ErrorContains(t, err, "invalid recipient 'not an address'")
+
+	err = notifier.Send(context.Background(), Message{To: []string{"a@example.com", "b@example.com"}, Body: "hello"})
+	require.NoError(t, err)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>"}, server.rcpts)
+}
+
+func TestSMTPNotifier_HeaderInjection(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier, err := NewSMTPNotifier(server.addr(), "rules@example.com", WithSMTPRecipients("ops@example.com"))
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Message{Subject: "alert\r\nBcc: victim@example.com", Body: "hello"})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	email, err := mail.ReadMessage(strings.NewReader(server.emails[0]))
+	require.NoError(t, err)
+	assert.Empty(t, email.Header.Get("Bcc"))
+}
+
+func TestNewSMTPNotifier_Invalid(t *testing.T) {
+	_, err := NewSMTPNotifier("localhost", "rules@example.com")
+	assert.ErrorContains(t, err, "invalid SMTP address")
+
+	_, err = NewSMTPNotifier("localhost:25", "rules")
+	assert.ErrorContains(t, err, "invalid sender address")
+}
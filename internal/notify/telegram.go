@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultTelegramAPIURL is the URL of the Telegram Bot API
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramNotifier sends notifications as messages of a bot through the
+// Telegram Bot API, or a compatible one. The subject is the first line of the
+// message. Recipients are chat IDs, which default to the chat of the notifier.
+type TelegramNotifier struct {
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// TelegramOption allows to configure the Telegram notifier
+type TelegramOption func(n *TelegramNotifier) *TelegramNotifier
+
+// WithTelegramClient sets the HTTP client the messages are sent with
+func WithTelegramClient(client *http.Client) TelegramOption {
+	return func(n *TelegramNotifier) *TelegramNotifier {
+		n.client = client
+		return n
+	}
+}
+
+// WithTelegramAPIURL sets the URL of a Telegram-compatible Bot API
+func WithTelegramAPIURL(apiURL string) TelegramOption {
+	return func(n *TelegramNotifier) *TelegramNotifier {
+		n.apiURL = strings.TrimRight(apiURL, "/")
+		return n
+	}
+}
+
+// NewTelegramNotifier creates a notifier sending as the bot of token to the
+// chat chatID, unless the messages name other chats
+func NewTelegramNotifier(token, chatID string, opts ...TelegramOption) (*TelegramNotifier, error) {
+	if token == "" {
+		return nil, errors.New("bot token is required")
+	}
+	n := &TelegramNotifier{
+		apiURL: DefaultTelegramAPIURL,
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if err := checkURL(n.apiURL); err != nil {
+		return nil, fmt.Errorf("API %w", err)
+	}
+	return n, nil
+}
+
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// Send sends msg to each of its chats, or to the chat of the notifier
+func (n *TelegramNotifier) Send(ctx context.Context, msg Message) error {
+	chats := msg.To
+	if len(chats) == 0 && n.chatID != "" {
+		chats = []string{n.chatID}
+	}
+	if len(chats) == 0 {
+		return ErrNoRecipient
+	}
+
+	endpoint := n.apiURL + "/bot" + n.token + "/sendMessage"
+	for _, chat := range chats {
+		body, err := postJSON(ctx, n.client, endpoint, nil, telegramMessage{ChatID: chat, Text: msg.text()})
+		var resp telegramResponse
+		if jsonErr := json.Unmarshal(body, &resp); jsonErr == nil && !resp.OK && resp.Description != "" {
+			return fmt.Errorf("chat %s: %s", chat, resp.Description)
+		}
+		if err != nil {
+			return fmt.Errorf("chat %s: %w", chat, err)
+		}
+	}
+	return nil
+}
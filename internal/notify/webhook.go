@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxResponseBytes bounds the part of a response body read from a channel
+const maxResponseBytes = 64 << 10
+
+// WebhookNotifier posts notifications as JSON to a URL, for services that
+// relay them, for instance as SMS. The body is the Message as JSON:
+//
+//	{"to": ["+15550100"], "subject": "Door open", "body": "The front door is open"}
+type WebhookNotifier struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+// WebhookOption allows to configure the webhook notifier
+type WebhookOption func(n *WebhookNotifier) *WebhookNotifier
+
+// WithWebhookClient sets the HTTP client the notifications are posted with
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(n *WebhookNotifier) *WebhookNotifier {
+		n.client = client
+		return n
+	}
+}
+
+// WithWebhookHeader adds a header to the requests, such as an authorization token
+func WithWebhookHeader(name, value string) WebhookOption {
+	return func(n *WebhookNotifier) *WebhookNotifier {
+		n.headers[name] = value
+		return n
+	}
+}
+
+// NewWebhookNotifier creates a notifier posting to rawURL
+func NewWebhookNotifier(rawURL string, opts ...WebhookOption) (*WebhookNotifier, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	n := &WebhookNotifier{
+		url:     rawURL,
+		client:  &http.Client{Timeout: DefaultTimeout},
+		headers: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n, nil
+}
+
+// Send posts msg to the webhook
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	_, err := postJSON(ctx, n.client, n.url, n.headers, msg)
+	return err
+}
+
+// checkURL checks that rawURL is an absolute http or https URL
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL: scheme must be http or https and a host is required")
+	}
+	return nil
+}
+
+// postJSON posts body as JSON to rawURL and returns the response body. Responses
+// with a status of 300 or more are errors. The URLs of chat services hold
+// their tokens, so they are kept out of the errors.
+func postJSON(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, body any) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.New("invalid URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return respBody, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
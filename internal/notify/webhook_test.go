@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhook is a webhook recording the requests it receives
+type fakeWebhook struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	headers  []http.Header
+	bodies   []map[string]any
+	status   int
+	response string
+}
+
+func newFakeWebhook(t *testing.T) *fakeWebhook {
+	t.Helper()
+	hook := &fakeWebhook{status: http.StatusOK}
+	hook.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		hook.paths = append(hook.paths, r.URL.Path)
+		hook.headers = append(hook.headers, r.Header.Clone())
+		hook.bodies = append(hook.bodies, body)
+		w.WriteHeader(hook.status)
+		_, _ = w.Write([]byte(hook.response))
+	}))
+	t.Cleanup(hook.Close)
+	return hook
+}
+
+func TestWebhookNotifier_Send(t *testing.T) {
+	hook := newFakeWebhook(t)
+	notifier, err := NewWebhookNotifier(hook.URL+"/sms", WithWebhookHeader("Authorization", "Bearer token"))
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Message{To: []string{"+15550100"}, Subject: "Door", Body: "The door is open"})
+	require.NoError(t, err)
+
+	require.Len(t, hook.bodies, 1)
+	assert.Equal(t, "/sms", hook.paths[0])
+	assert.Equal(t, "Bearer token", hook.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", hook.headers[0].Get("Content-Type"))
+	assert.Equal(t, map[string]any{"to": []any{"+15550100"}, "subject": "Door", "body": "The door is open"}, hook.bodies[0])
+}
+
+func TestWebhookNotifier_Errors(t *testing.T) {
+	hook := newFakeWebhook(t)
+	hook.status = http.StatusBadGateway
+	notifier, err := NewWebhookNotifier(hook.URL + "/secret-token")
+	require.NoError(t, err)
+
+	err = notifier.Send(context.Background(), Message{Body: "hello"})
+	assert.EqualError(t, err, "unexpected status 502")
+
+	// Transport errors leave the URL, which may hold a token, out
+	hook.Close()
+	err = notifier.Send(context.Background(), Message{Body: "hello"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+
+	_, err = NewWebhookNotifier("ftp://example.com")
+	assert.ErrorContains(t, err, "invalid URL")
+}
+
+func TestSlackNotifier_Send(t *testing.T) {
+	hook := newFakeWebhook(t)
+	notifier, err := NewSlackNotifier(hook.URL)
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(context.Background(), Message{Subject: "Leak", Body: "Water in the basement"}))
+	require.NoError(t, notifier.Send(context.Background(), Message{To: []string{"#ops", "#home"}, Body: "hello"}))
+
+	assert.Equal(t, []map[string]any{
+		{"text": "*Leak*\nWater in the basement"},
+		{"text": "hello", "channel": "#ops"},
+		{"text": "hello", "channel": "#home"},
+	}, hook.bodies)
+}
+
+func TestTelegramNotifier_Send(t *testing.T) {
+	hook := newFakeWebhook(t)
+	hook.response = `{"ok":true}`
+	notifier, err := NewTelegramNotifier("123:abc", "42", WithTelegramAPIURL(hook.URL+"/"))
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Send(context.Background(), Message{Subject: "Leak", Body: "Water in the basement"}))
+	require.NoError(t, notifier.Send(context.Background(), Message{To: []string{"7"}, Body: "hello"}))
+
+	assert.Equal(t, []string{"/bot123:abc/sendMessage", "/bot123:abc/sendMessage"}, hook.paths)
+	assert.Equal(t, []map[string]any{
+		{"chat_id": "42", "text": "Leak\n\nWater in the basement"},
+		{"chat_id": "7", "text": "hello"},
+	}, hook.bodies)
+}
+
+func TestTelegramNotifier_Errors(t *testing.T) {
+	hook := newFakeWebhook(t)
+	hook.status = http.StatusBadRequest
+	hook.response = `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`
+
+	notifier, err := NewTelegramNotifier("123:abc", "42", WithTelegramAPIURL(hook.URL))
+	require.NoError(t, err)
+	err = notifier.Send(context.Background(), Message{Body: "hello"})
+	assert.EqualError(t, err, "chat 42: Bad Request: chat not found")
+
+	notifier, err = NewTelegramNotifier("123:abc", "", WithTelegramAPIURL(hook.URL))
+	require.NoError(t, err)
+	assert.ErrorIs(t, notifier.Send(context.Background(), Message{Body: "hello"}), ErrNoRecipient)
+
+	_, err = NewTelegramNotifier("", "42")
+	assert.EqualError(t, err, "bot token is required")
+}
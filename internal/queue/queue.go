@@ -18,11 +18,15 @@ var (
 
 // ExecutionRequest represents a rule execution request
 type ExecutionRequest struct {
-	ID        uuid.UUID      `json:"id"`
-	RuleID    uuid.UUID      `json:"rule_id"`
-	TriggerID uuid.UUID      `json:"trigger_id"`
-	EventData map[string]any `json:"event_data,omitempty"`
-	QueuedAt  time.Time      `json:"queued_at"`
+	ID           uuid.UUID      `json:"id"`
+	RuleID       uuid.UUID      `json:"rule_id"`
+	TriggerID    uuid.UUID      `json:"trigger_id"`
+	TriggerType  string         `json:"trigger_type,omitempty"`
+	EventSubject string         `json:"event_subject,omitempty"`
+	EventData    map[string]any `json:"event_data,omitempty"`
+	FiredAt      time.Time      `json:"fired_at"`
+	ChainDepth   int            `json:"chain_depth,omitempty"`
+	QueuedAt     time.Time      `json:"queued_at"`
 }
 
 // Queue interface for rule execution queuing
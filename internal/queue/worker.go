@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/actions"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/metrics"
@@ -33,6 +34,7 @@ type WorkerPool struct {
 	queue      Queue
 	ruleSvc    RuleService
 	executor   Executor
+	actions    *actions.Dispatcher
 	numWorkers int
 	wg         sync.WaitGroup
 	stopCh     chan struct{}
@@ -41,13 +43,25 @@ type WorkerPool struct {
 	cleanupCh  chan struct{} // for cleanup goroutine
 }
 
+// WorkerPoolOption allows to configure the worker pool
+type WorkerPoolOption func(wp *WorkerPool) *WorkerPool
+
+// WithActions sets the dispatcher recording processed rule executions and
+// running their publish_event and notify actions
+func WithActions(dispatcher *actions.Dispatcher) WorkerPoolOption {
+	return func(wp *WorkerPool) *WorkerPool {
+		wp.actions = dispatcher
+		return wp
+	}
+}
+
 // NewWorkerPool creates a new worker pool
-func NewWorkerPool(queue Queue, ruleSvc RuleService, executor Executor, numWorkers int) *WorkerPool {
+func NewWorkerPool(queue Queue, ruleSvc RuleService, executor Executor, numWorkers int, opts ...WorkerPoolOption) *WorkerPool {
 	if numWorkers <= 0 {
 		numWorkers = 5 // default
 	}
 
-	return &WorkerPool{
+	wp := &WorkerPool{
 		queue:      queue,
 		ruleSvc:    ruleSvc,
 		executor:   executor,
@@ -55,6 +69,31 @@ func NewWorkerPool(queue Queue, ruleSvc RuleService, executor Executor, numWorke
 		stopCh:     make(chan struct{}),
 		cleanupCh:  make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(wp)
+	}
+	return wp
+}
+
+// ExecutionContext builds the context of the execution of rule described by the request
+func (r *ExecutionRequest) ExecutionContext(ctxSvc *execCtx.Service, rule *rule.Rule) *execCtx.ExecutionContext {
+	ec := ctxSvc.CreateContext(r.RuleID.String(), r.TriggerID.String())
+	ec.RuleName = rule.Name
+	ec.TriggerType = r.TriggerType
+	ec.EventSubject = r.EventSubject
+	ec.Event = r.EventData
+	ec.ChainDepth = r.ChainDepth
+	ec.Timeout = time.Duration(rule.TimeoutMs) * time.Millisecond
+	ec.Limits = rule.Limits
+	if !r.FiredAt.IsZero() {
+		ec.FiredAt = r.FiredAt
+	}
+
+	// Event fields stay available as legacy globals
+	if r.EventData != nil {
+		maps.Copy(ec.Data, r.EventData)
+	}
+	return ec
 }
 
 // Start begins processing the queue with the worker pool
@@ -172,17 +211,13 @@ func (wp *WorkerPool) processRequest(ctx context.Context, req *ExecutionRequest,
 	)
 
 	// Create execution context
-	execCtx := wp.executor.GetContextService().CreateContext(req.RuleID.String(), req.TriggerID.String())
-
-	// Add event data to context if available
-	if req.EventData != nil {
-		maps.Copy(execCtx.Data, req.EventData)
-	}
+	execCtx := req.ExecutionContext(wp.executor.GetContextService(), rule)
 
 	// Execute rule script
 	ruleCtx, ruleSpan := tracing.StartSpan(ctx, "rule.script_execution")
 	result := wp.executor.ExecuteScript(ruleCtx, rule.LuaScript, execCtx)
 	ruleSpan.End()
+	wp.recordExecution(ctx, req, execCtx, result)
 
 	if result.Error != "" {
 		span.RecordError(fmt.Errorf("rule execution failed: %s", result.Error))
@@ -248,11 +283,19 @@ func (wp *WorkerPool) processRequest(ctx context.Context, req *ExecutionRequest,
 				"request_id", req.ID,
 				"action_id", action.ID)
 		default:
-			actionSpan.RecordError(fmt.Errorf("unknown action type: %s", action.Type))
-			slog.Error("Unknown action type",
-				"request_id", req.ID,
-				"action_id", action.ID,
-				"type", action.Type)
+			if err := wp.actions.Dispatch(actionCtx, action.Type, action.Params, execCtx); err != nil {
+				actionSpan.RecordError(err)
+				slog.Error("Action failed",
+					"request_id", req.ID,
+					"action_id", action.ID,
+					"type", action.Type,
+					"error", err)
+			} else {
+				slog.Info("Action executed",
+					"request_id", req.ID,
+					"action_id", action.ID,
+					"type", action.Type)
+			}
 		}
 
 		actionSpan.End()
@@ -314,3 +357,13 @@ func (wp *WorkerPool) sendHeartbeat(ctx context.Context) {
 		}
 	}
 }
+
+// recordExecution stores the outcome of a rule script in the execution history
+func (wp *WorkerPool) recordExecution(ctx context.Context, req *ExecutionRequest, ec *execCtx.ExecutionContext, result *executor.ExecuteResult) {
+	if err := wp.actions.Record(ctx, req.RuleID, req.TriggerID, ec, result); err != nil {
+		slog.Error("Failed to record rule execution",
+			"request_id", req.ID,
+			"rule_id", req.RuleID,
+			"error", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/malyshevhen/rule-engine/internal/action"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/trigger"
 )
 
@@ -15,6 +16,8 @@ type Rule struct {
 	LuaScript string            `json:"lua_script"`
 	Priority  int               `json:"priority"`
 	Enabled   bool              `json:"enabled"`
+	TimeoutMs int               `json:"timeout_ms"`
+	Limits    execCtx.Limits    `json:"limits"`
 	Triggers  []trigger.Trigger `json:"triggers"`
 	Actions   []action.Action   `json:"actions"`
 	CreatedAt time.Time         `json:"created_at"`
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/malyshevhen/rule-engine/internal/action"
+	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
 	"github.com/malyshevhen/rule-engine/internal/storage"
 	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
 	redisClient "github.com/malyshevhen/rule-engine/internal/storage/redis"
@@ -38,18 +39,53 @@ type Store interface {
 	GetStore() *storage.Store
 }
 
+// ScriptCache drops compiled scripts that are no longer current
+type ScriptCache interface {
+	InvalidateScript(script string)
+}
+
+// ScriptState holds the state scripts keep with the store and window modules, namespaced per rule
+type ScriptState interface {
+	Clear(ctx context.Context, namespace string) error
+}
+
 // Service handles business logic for rules
 type Service struct {
-	store Store
-	redis *redisClient.Client
+	store   Store
+	redis   *redisClient.Client
+	scripts ScriptCache
+	states  []ScriptState
+}
+
+// ServiceOption allows to configure the rule service
+type ServiceOption func(s *Service) *Service
+
+// WithScriptCache sets the cache invalidated when a rule script changes
+func WithScriptCache(scripts ScriptCache) ServiceOption {
+	return func(s *Service) *Service {
+		s.scripts = scripts
+		return s
+	}
+}
+
+// WithScriptState adds script state cleared when a rule is deleted
+func WithScriptState(state ScriptState) ServiceOption {
+	return func(s *Service) *Service {
+		s.states = append(s.states, state)
+		return s
+	}
 }
 
 // NewService creates a new rule service
-func NewService(store Store, redis *redisClient.Client) *Service {
-	return &Service{
+func NewService(store Store, redis *redisClient.Client, opts ...ServiceOption) *Service {
+	s := &Service{
 		store: store,
 		redis: redis,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Create creates a new rule
@@ -60,6 +96,8 @@ func (s *Service) Create(ctx context.Context, rule *Rule) error {
 			LuaScript: rule.LuaScript,
 			Priority:  rule.Priority,
 			Enabled:   rule.Enabled,
+			TimeoutMs: rule.TimeoutMs,
+			Limits:    encodeLimits(rule.Limits),
 		}
 		err := q.RuleRepository.Create(ctx, storageRule)
 		if err != nil {
@@ -104,7 +142,9 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
 			ID:              t.ID,
 			Type:            trigger.TriggerType(t.Type),
 			ConditionScript: t.ConditionScript,
+			Language:        t.Language,
 			Enabled:         t.Enabled,
+			TimeoutMs:       t.TimeoutMs,
 			CreatedAt:       t.CreatedAt,
 			UpdatedAt:       t.UpdatedAt,
 		}
@@ -132,6 +172,8 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
 		LuaScript: ruleStorage.LuaScript,
 		Priority:  ruleStorage.Priority,
 		Enabled:   ruleStorage.Enabled,
+		TimeoutMs: ruleStorage.TimeoutMs,
+		Limits:    decodeLimits(ruleStorage.Limits),
 		CreatedAt: ruleStorage.CreatedAt,
 		UpdatedAt: ruleStorage.UpdatedAt,
 		Triggers:  triggers,
@@ -190,6 +232,8 @@ func (s *Service) List(ctx context.Context, limit int, offset int) ([]*Rule, int
 			LuaScript: rule.LuaScript,
 			Priority:  rule.Priority,
 			Enabled:   rule.Enabled,
+			TimeoutMs: rule.TimeoutMs,
+			Limits:    decodeLimits(rule.Limits),
 			CreatedAt: rule.CreatedAt,
 			UpdatedAt: rule.UpdatedAt,
 		}
@@ -229,14 +273,23 @@ func (s *Service) Update(ctx context.Context, rule *Rule) error {
 			LuaScript: rule.LuaScript,
 			Priority:  rule.Priority,
 			Enabled:   rule.Enabled,
+			TimeoutMs: rule.TimeoutMs,
+			Limits:    encodeLimits(rule.Limits),
+		}
+		previousScript, err := s.currentScript(ctx, q, rule.ID)
+		if err != nil {
+			return err
 		}
-		err := q.RuleRepository.Update(ctx, storageRule)
+		err = q.RuleRepository.Update(ctx, storageRule)
 		if err != nil {
 			return err
 		}
 
 		// Invalidate caches
 		s.invalidateRuleCaches(ctx, rule.ID)
+		if previousScript != rule.LuaScript {
+			s.invalidateScript(previousScript)
+		}
 
 		return nil
 	})
@@ -244,17 +297,28 @@ func (s *Service) Update(ctx context.Context, rule *Rule) error {
 
 // Delete deletes a rule by ID
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.store.ExecTx(ctx, func(q *storage.Store) error {
-		err := q.RuleRepository.Delete(ctx, id)
+	err := s.store.ExecTx(ctx, func(q *storage.Store) error {
+		previousScript, err := s.currentScript(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		err = q.RuleRepository.Delete(ctx, id)
 		if err != nil {
 			return err
 		}
 
 		// Invalidate caches for this specific rule
 		s.invalidateRuleCaches(ctx, id)
+		s.invalidateScript(previousScript)
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.clearScriptState(ctx, id)
+	return nil
 }
 
 // AddAction adds an action to a rule
@@ -271,6 +335,55 @@ func (s *Service) AddAction(ctx context.Context, ruleID, actionID uuid.UUID) err
 	})
 }
 
+// encodeLimits serializes resource limit overrides for storage
+func encodeLimits(limits execCtx.Limits) string {
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// decodeLimits parses stored resource limit overrides, ignoring malformed values
+func decodeLimits(data string) execCtx.Limits {
+	var limits execCtx.Limits
+	if data == "" {
+		return limits
+	}
+	if err := json.Unmarshal([]byte(data), &limits); err != nil {
+		slog.Warn("Failed to decode rule limits", "error", err)
+	}
+	return limits
+}
+
+// currentScript returns the stored script of a rule when a script cache is configured
+func (s *Service) currentScript(ctx context.Context, q *storage.Store, id uuid.UUID) (string, error) {
+	if s.scripts == nil {
+		return "", nil
+	}
+	storageRule, err := q.RuleRepository.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return storageRule.LuaScript, nil
+}
+
+// invalidateScript drops the compiled form of a replaced or deleted script
+func (s *Service) invalidateScript(script string) {
+	if s.scripts != nil && script != "" {
+		s.scripts.InvalidateScript(script)
+	}
+}
+
+// clearScriptState drops the state the scripts of a deleted rule kept
+func (s *Service) clearScriptState(ctx context.Context, ruleID uuid.UUID) {
+	for _, state := range s.states {
+		if err := state.Clear(ctx, ruleID.String()); err != nil {
+			slog.Warn("Failed to clear script state of deleted rule", "rule_id", ruleID, "error", err)
+		}
+	}
+}
+
 // invalidateRuleCaches clears rule-related caches for a specific rule
 func (s *Service) invalidateRuleCaches(ctx context.Context, ruleID uuid.UUID) {
 	if s.redis == nil {
@@ -2,6 +2,7 @@ package rule
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
@@ -230,3 +231,107 @@ func TestService_Delete(t *testing.T) {
 	mockStore.ruleRepo.(*mockRuleRepository).AssertExpectations(t)
 	mockStore.AssertExpectations(t)
 }
+
+// mockScriptCache is a mock implementation of the ScriptCache interface
+type mockScriptCache struct {
+	mock.Mock
+}
+
+func (m *mockScriptCache) InvalidateScript(script string) {
+	m.Called(script)
+}
+
+func TestService_Update_InvalidatesScript(t *testing.T) {
+	mockStore := newMockSQLStore()
+	scripts := &mockScriptCache{}
+	svc := NewService(mockStore, nil, WithScriptCache(scripts))
+
+	rule := &Rule{
+		ID:        uuid.New(),
+		Name:      "Updated Rule",
+		LuaScript: "return false",
+		Enabled:   true,
+	}
+
+	mockStore.ruleRepo.(*mockRuleRepository).On("GetByID", mock.Anything, rule.ID).Return(&ruleStorage.Rule{ID: rule.ID, LuaScript: "return true"}, nil)
+	mockStore.ruleRepo.(*mockRuleRepository).On("Update", mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+	scripts.On("InvalidateScript", "return true").Return()
+
+	err := svc.Update(context.Background(), rule)
+
+	assert.NoError(t, err)
+	mockStore.ruleRepo.(*mockRuleRepository).AssertExpectations(t)
+	scripts.AssertExpectations(t)
+}
+
+func TestService_Update_UnchangedScriptKeepsCache(t *testing.T) {
+	mockStore := newMockSQLStore()
+	scripts := &mockScriptCache{}
+	svc := NewService(mockStore, nil, WithScriptCache(scripts))
+
+	rule := &Rule{
+		ID:        uuid.New(),
+		Name:      "Renamed Rule",
+		LuaScript: "return true",
+		Enabled:   true,
+	}
+
+	mockStore.ruleRepo.(*mockRuleRepository).On("GetByID", mock.Anything, rule.ID).Return(&ruleStorage.Rule{ID: rule.ID, LuaScript: "return true"}, nil)
+	mockStore.ruleRepo.(*mockRuleRepository).On("Update", mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+
+	err := svc.Update(context.Background(), rule)
+
+	assert.NoError(t, err)
+	scripts.AssertNotCalled(t, "InvalidateScript", mock.Anything)
+}
+
+func TestService_Delete_InvalidatesScript(t *testing.T) {
+	mockStore := newMockSQLStore()
+	scripts := &mockScriptCache{}
+	svc := NewService(mockStore, nil, WithScriptCache(scripts))
+
+	ruleID := uuid.New()
+
+	mockStore.ruleRepo.(*mockRuleRepository).On("GetByID", mock.Anything, ruleID).Return(&ruleStorage.Rule{ID: ruleID, LuaScript: "return true"}, nil)
+	mockStore.ruleRepo.(*mockRuleRepository).On("Delete", mock.Anything, ruleID).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+	scripts.On("InvalidateScript", "return true").Return()
+
+	err := svc.Delete(context.Background(), ruleID)
+
+	assert.NoError(t, err)
+	mockStore.ruleRepo.(*mockRuleRepository).AssertExpectations(t)
+	scripts.AssertExpectations(t)
+}
+
+type mockScriptState struct {
+	mock.Mock
+}
+
+func (m *mockScriptState) Clear(ctx context.Context, namespace string) error {
+	args := m.Called(ctx, namespace)
+	return args.Error(0)
+}
+
+func TestService_Delete_ClearsScriptState(t *testing.T) {
+	mockStore := newMockSQLStore()
+	state := &mockScriptState{}
+	series := &mockScriptState{}
+	svc := NewService(mockStore, nil, WithScriptState(state), WithScriptState(series))
+
+	ruleID := uuid.New()
+
+	mockStore.ruleRepo.(*mockRuleRepository).On("GetByID", mock.Anything, ruleID).Return(&ruleStorage.Rule{ID: ruleID, LuaScript: "return true"}, nil)
+	mockStore.ruleRepo.(*mockRuleRepository).On("Delete", mock.Anything, ruleID).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+	state.On("Clear", mock.Anything, ruleID.String()).Return(errors.New("redis unavailable"))
+	series.On("Clear", mock.Anything, ruleID.String()).Return(nil)
+
+	err := svc.Delete(context.Background(), ruleID)
+
+	assert.NoError(t, err)
+	state.AssertExpectations(t)
+	series.AssertExpectations(t)
+}
@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the size of the master keys and of the data keys, for AES-256
+const KeySize = 32
+
+// ErrUnknownKey is returned for values encrypted with a master key the keyring does not hold
+var ErrUnknownKey = errors.New("secret encrypted with an unknown master key")
+
+// Sealed is a value encrypted with envelope encryption: the value is encrypted
+// with a data key of its own, which is encrypted with the master key KeyID
+type Sealed struct {
+	Ciphertext []byte
+	DataKey    []byte
+	KeyID      string
+}
+
+// Keyring encrypts values with the primary master key, and decrypts values
+// encrypted with any of its master keys, so that the primary key can be
+// rotated while the values encrypted with the previous ones remain readable
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKey decodes a base64-encoded master key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid master key: %d bytes instead of %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// NewKeyring creates a keyring encrypting with primary and decrypting with
+// primary and the previous master keys
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for i, key := range append([][]byte{primary}, previous...) {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		id := keyID(key)
+		if i == 0 {
+			k.primary = id
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// Seal encrypts the value of the secret name. The name is authenticated with
+// the value, so that a value cannot be moved to another secret.
+func (k *Keyring) Seal(name string, value []byte) (*Sealed, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(aead, value, name)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{Ciphertext: ciphertext, DataKey: wrapped, KeyID: k.primary}, nil
+}
+
+// Open decrypts the value of the secret name
+func (k *Keyring) Open(name string, sealed *Sealed) ([]byte, error) {
+	master, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownKey, sealed.KeyID)
+	}
+	dataKey, err := open(master, sealed.DataKey, name)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed.Ciphertext, name)
+}
+
+// keyID identifies a master key without revealing it
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key: %d bytes instead of %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext, prefixed with a random nonce
+func seal(aead cipher.AEAD, plaintext []byte, name string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(name)), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte, name string) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("secret ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, errors.New("secret cannot be decrypted")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	keyring, err := NewKeyring(testKey(1))
+	require.NoError(t, err)
+
+	sealed, err := keyring.Seal("api_token", []byte("s3cr3t"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed.Ciphertext), "s3cr3t")
+	assert.Len(t, sealed.KeyID, 16)
+
+	value, err := keyring.Open("api_token", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(value))
+
+	// Every value has its own data key
+	other, err := keyring.Seal("api_token", []byte("s3cr3t"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.DataKey, other.DataKey)
+	assert.NotEqual(t, sealed.Ciphertext, other.Ciphertext)
+
+	// A value cannot be moved to another secret
+	_, err = keyring.Open("other_token", sealed)
+	assert.EqualError(t, err, "secret cannot be decrypted")
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, err := NewKeyring(testKey(1))
+	require.NoError(t, err)
+	sealed, err := old.Seal("api_token", []byte("s3cr3t"))
+	require.NoError(t, err)
+
+	// Values encrypted with a previous key remain readable, new ones use the primary key
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	require.NoError(t, err)
+	value, err := rotated.Open("api_token", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(value))
+	resealed, err := rotated.Seal("api_token", value)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.KeyID, resealed.KeyID)
+
+	other, err := NewKeyring(testKey(3))
+	require.NoError(t, err)
+	_, err = other.Open("api_token", sealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey(base64.StdEncoding.EncodeToString(testKey(7)) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, testKey(7), key)
+
+	_, err = ParseKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.EqualError(t, err, "invalid master key: 5 bytes instead of 32")
+	_, err = ParseKey("not base64!")
+	assert.ErrorContains(t, err, "invalid master key")
+}
@@ -0,0 +1,19 @@
+package secret
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Secret represents a secret in the business domain. Its value is write-only:
+// it is given when the secret is created or updated and only scripts of the
+// rules in RuleIDs read it back, with secrets.get(Name).
+type Secret struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	RuleIDs     []uuid.UUID `json:"rule_ids"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
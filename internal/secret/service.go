@@ -0,0 +1,176 @@
+package secret
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
+)
+
+// ErrNotConfigured is returned when values are written or read without a master key
+var ErrNotConfigured = errors.New("secrets are not configured: no master key is set")
+
+// Store interface for database operations
+type Store interface {
+	ExecTx(ctx context.Context, fn func(*storage.Store) error) error
+	GetStore() *storage.Store
+}
+
+// Service handles business logic for secrets. Values are encrypted with the
+// keyring before they are stored and only decrypted for the rules they are granted to.
+type Service struct {
+	store   Store
+	keyring *Keyring
+}
+
+// ServiceOption allows to configure the secret service
+type ServiceOption func(s *Service) *Service
+
+// WithKeyring sets the keyring values are encrypted with. Without one, the
+// metadata of secrets can be read and deleted, but values cannot be written or read.
+func WithKeyring(keyring *Keyring) ServiceOption {
+	return func(s *Service) *Service {
+		s.keyring = keyring
+		return s
+	}
+}
+
+// NewService creates a new secret service
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create creates a new secret holding value
+func (s *Service) Create(ctx context.Context, secret *Secret, value string) error {
+	sealed, err := s.seal(secret.Name, value)
+	if err != nil {
+		return err
+	}
+	return s.store.ExecTx(ctx, func(q *storage.Store) error {
+		storageSecret := &secretStorage.Secret{
+			Name:        secret.Name,
+			Description: secret.Description,
+			RuleIDs:     secret.RuleIDs,
+			Ciphertext:  sealed.Ciphertext,
+			DataKey:     sealed.DataKey,
+			KeyID:       sealed.KeyID,
+		}
+		if err := q.SecretRepository.Create(ctx, storageSecret); err != nil {
+			return err
+		}
+		*secret = *fromStorage(storageSecret)
+		return nil
+	})
+}
+
+// GetByID retrieves the metadata of a secret by its ID
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Secret, error) {
+	storageSecret, err := s.store.GetStore().SecretRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return fromStorage(storageSecret), nil
+}
+
+// List retrieves the metadata of secrets with pagination
+func (s *Service) List(ctx context.Context, limit, offset int) ([]*Secret, int, error) {
+	storageSecrets, total, err := s.store.GetStore().SecretRepository.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	secrets := make([]*Secret, len(storageSecrets))
+	for i, storageSecret := range storageSecrets {
+		secrets[i] = fromStorage(storageSecret)
+	}
+	return secrets, total, nil
+}
+
+// Update replaces the description and the rules of a secret, and its value
+// when value is not nil. The name of a secret cannot change.
+func (s *Service) Update(ctx context.Context, secret *Secret, value *string) error {
+	return s.store.ExecTx(ctx, func(q *storage.Store) error {
+		storageSecret := &secretStorage.Secret{
+			ID:          secret.ID,
+			Description: secret.Description,
+			RuleIDs:     secret.RuleIDs,
+		}
+		if value != nil {
+			// The name is authenticated with the value, so the current one is needed
+			current, err := q.SecretRepository.GetByID(ctx, secret.ID)
+			if err != nil {
+				return err
+			}
+			sealed, err := s.seal(current.Name, *value)
+			if err != nil {
+				return err
+			}
+			storageSecret.Ciphertext = sealed.Ciphertext
+			storageSecret.DataKey = sealed.DataKey
+			storageSecret.KeyID = sealed.KeyID
+		}
+		if err := q.SecretRepository.Update(ctx, storageSecret); err != nil {
+			return err
+		}
+		*secret = *fromStorage(storageSecret)
+		return nil
+	})
+}
+
+// Delete removes a secret
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.store.GetStore().SecretRepository.Delete(ctx, id)
+}
+
+// Resolve returns the value of the secret name for a script of the rule ruleID.
+// Secrets that are not granted to the rule are reported as not found.
+func (s *Service) Resolve(ctx context.Context, ruleID, name string) (string, error) {
+	if s.keyring == nil {
+		return "", ErrNotConfigured
+	}
+	id, err := uuid.Parse(ruleID)
+	if err != nil {
+		return "", secretStorage.ErrNotFound
+	}
+	storageSecret, err := s.store.GetStore().SecretRepository.GetForRule(ctx, name, id)
+	if err != nil {
+		return "", err
+	}
+	value, err := s.keyring.Open(storageSecret.Name, &Sealed{
+		Ciphertext: storageSecret.Ciphertext,
+		DataKey:    storageSecret.DataKey,
+		KeyID:      storageSecret.KeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func (s *Service) seal(name, value string) (*Sealed, error) {
+	if s.keyring == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.keyring.Seal(name, []byte(value))
+}
+
+func fromStorage(storageSecret *secretStorage.Secret) *Secret {
+	ruleIDs := storageSecret.RuleIDs
+	if ruleIDs == nil {
+		ruleIDs = []uuid.UUID{}
+	}
+	return &Secret{
+		ID:          storageSecret.ID,
+		Name:        storageSecret.Name,
+		Description: storageSecret.Description,
+		RuleIDs:     ruleIDs,
+		CreatedAt:   storageSecret.CreatedAt,
+		UpdatedAt:   storageSecret.UpdatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/storage"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSecretRepository is a mock implementation of SecretRepository interface
+type mockSecretRepository struct {
+	mock.Mock
+}
+
+func (m *mockSecretRepository) Create(ctx context.Context, secret *secretStorage.Secret) error {
+	args := m.Called(ctx, secret)
+	return args.Error(0)
+}
+
+func (m *mockSecretRepository) GetByID(ctx context.Context, id uuid.UUID) (*secretStorage.Secret, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretStorage.Secret), args.Error(1)
+}
+
+func (m *mockSecretRepository) GetForRule(ctx context.Context, name string, ruleID uuid.UUID) (*secretStorage.Secret, error) {
+	args := m.Called(ctx, name, ruleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretStorage.Secret), args.Error(1)
+}
+
+func (m *mockSecretRepository) List(ctx context.Context, limit, offset int) ([]*secretStorage.Secret, int, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*secretStorage.Secret), args.Int(1), args.Error(2)
+}
+
+func (m *mockSecretRepository) Update(ctx context.Context, secret *secretStorage.Secret) error {
+	args := m.Called(ctx, secret)
+	return args.Error(0)
+}
+
+func (m *mockSecretRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// mockStore is a mock implementation of Store interface
+type mockStore struct {
+	repo *mockSecretRepository
+}
+
+func (m *mockStore) ExecTx(ctx context.Context, fn func(*storage.Store) error) error {
+	return fn(m.GetStore())
+}
+
+func (m *mockStore) GetStore() *storage.Store {
+	return &storage.Store{SecretRepository: m.repo}
+}
+
+func newTestService(t *testing.T, repo *mockSecretRepository) *Service {
+	keyring, err := NewKeyring(testKey(1))
+	require.NoError(t, err)
+	return NewService(&mockStore{repo: repo}, WithKeyring(keyring))
+}
+
+func TestService_CreateAndResolve(t *testing.T) {
+	repo := &mockSecretRepository{}
+	service := newTestService(t, repo)
+
+	id, ruleID := uuid.New(), uuid.New()
+	var stored *secretStorage.Secret
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(s *secretStorage.Secret) bool {
+		return s.Name == "api_token" && len(s.Ciphertext) > 0 && len(s.DataKey) > 0
+	})).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*secretStorage.Secret)
+		stored.ID = id
+	}).Return(nil)
+
+	secret := &Secret{Name: "api_token", RuleIDs: []uuid.UUID{ruleID}}
+	require.NoError(t, service.Create(context.Background(), secret, "s3cr3t"))
+	assert.Equal(t, id, secret.ID)
+	assert.NotContains(t, string(stored.Ciphertext), "s3cr3t")
+
+	repo.On("GetForRule", mock.Anything, "api_token", ruleID).Return(stored, nil)
+	value, err := service.Resolve(context.Background(), ruleID.String(), "api_token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	// Other rules and executions outside of a rule do not get the secret
+	otherRule := uuid.New()
+	repo.On("GetForRule", mock.Anything, "api_token", otherRule).Return(nil, secretStorage.ErrNotFound)
+	_, err = service.Resolve(context.Background(), otherRule.String(), "api_token")
+	assert.ErrorIs(t, err, secretStorage.ErrNotFound)
+	_, err = service.Resolve(context.Background(), "", "api_token")
+	assert.ErrorIs(t, err, secretStorage.ErrNotFound)
+}
+
+func TestService_Update(t *testing.T) {
+	repo := &mockSecretRepository{}
+	service := newTestService(t, repo)
+
+	id := uuid.New()
+	repo.On("Update", mock.Anything, mock.MatchedBy(func(s *secretStorage.Secret) bool {
+		return s.ID == id && s.Ciphertext == nil
+	})).Return(nil).Once()
+	require.NoError(t, service.Update(context.Background(), &Secret{ID: id, Description: "kept value"}, nil))
+
+	value := "n3w"
+	repo.On("GetByID", mock.Anything, id).Return(&secretStorage.Secret{ID: id, Name: "api_token"}, nil)
+	repo.On("Update", mock.Anything, mock.MatchedBy(func(s *secretStorage.Secret) bool {
+		return s.ID == id && len(s.Ciphertext) > 0
+	})).Return(nil).Once()
+	require.NoError(t, service.Update(context.Background(), &Secret{ID: id}, &value))
+	repo.AssertExpectations(t)
+}
+
+func TestService_NotConfigured(t *testing.T) {
+	repo := &mockSecretRepository{}
+	service := NewService(&mockStore{repo: repo})
+
+	err := service.Create(context.Background(), &Secret{Name: "api_token"}, "s3cr3t")
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	_, err = service.Resolve(context.Background(), uuid.NewString(), "api_token")
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
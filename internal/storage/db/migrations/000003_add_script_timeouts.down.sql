@@ -0,0 +1,3 @@
+-- Remove script execution timeouts
+ALTER TABLE triggers DROP COLUMN timeout_ms;
+ALTER TABLE rules DROP COLUMN timeout_ms;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Add per-rule and per-trigger script execution timeouts (0 uses the engine default)
+ALTER TABLE rules ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE triggers ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Remove per-rule sandbox limit overrides
+ALTER TABLE rules DROP COLUMN limits;
\ No newline at end of file
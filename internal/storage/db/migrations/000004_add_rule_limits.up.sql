@@ -0,0 +1,2 @@
+-- Add per-rule sandbox limit overrides
+ALTER TABLE rules ADD COLUMN limits TEXT NOT NULL DEFAULT '{}'; -- JSON string for resource limits
@@ -0,0 +1,5 @@
+-- Remove the execution result columns
+ALTER TABLE execution_logs DROP COLUMN logs_truncated;
+ALTER TABLE execution_logs DROP COLUMN error;
+ALTER TABLE execution_logs DROP COLUMN output;
+ALTER TABLE execution_logs DROP COLUMN trigger_id;
\ No newline at end of file
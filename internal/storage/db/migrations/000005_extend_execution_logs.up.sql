@@ -0,0 +1,5 @@
+-- Record the trigger, result and error of rule executions; output_log holds the captured script logs
+ALTER TABLE execution_logs ADD COLUMN trigger_id UUID REFERENCES triggers (id) ON DELETE SET NULL;
+ALTER TABLE execution_logs ADD COLUMN output TEXT NOT NULL DEFAULT '[]'; -- JSON string for returned values
+ALTER TABLE execution_logs ADD COLUMN error TEXT NOT NULL DEFAULT '';
+ALTER TABLE execution_logs ADD COLUMN logs_truncated BOOLEAN NOT NULL DEFAULT false;
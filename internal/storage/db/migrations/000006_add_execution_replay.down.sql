@@ -0,0 +1,2 @@
+-- Remove the replay bundles
+ALTER TABLE execution_logs DROP COLUMN replay_bundle;
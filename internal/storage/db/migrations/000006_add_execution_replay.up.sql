@@ -0,0 +1,2 @@
+-- Keep the inputs recorded for replaying an execution; NULL when it was not recorded
+ALTER TABLE execution_logs ADD COLUMN replay_bundle TEXT; -- JSON string
@@ -0,0 +1,3 @@
+-- Remove the shared Lua libraries
+DROP TABLE library_versions;
+DROP TABLE libraries;
\ No newline at end of file
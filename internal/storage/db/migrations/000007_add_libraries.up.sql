@@ -0,0 +1,18 @@
+-- Shared Lua libraries that scripts load with require(name); every change of the code is kept as a new version
+CREATE TABLE libraries (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    code        TEXT NOT NULL,
+    version     INTEGER NOT NULL DEFAULT 1,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE library_versions (
+    library_id UUID NOT NULL REFERENCES libraries (id) ON DELETE CASCADE,
+    version    INTEGER NOT NULL,
+    code       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (library_id, version)
+);
@@ -0,0 +1,2 @@
+-- Remove the language of trigger conditions
+ALTER TABLE triggers DROP COLUMN language;
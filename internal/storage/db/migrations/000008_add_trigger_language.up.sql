@@ -0,0 +1,2 @@
+-- Add the language of trigger conditions: lua scripts or expr expressions
+ALTER TABLE triggers ADD COLUMN language VARCHAR(16) NOT NULL DEFAULT 'lua';
@@ -0,0 +1,2 @@
+-- Drop the state of the store Lua module
+DROP TABLE IF EXISTS script_store;
@@ -0,0 +1,9 @@
+-- Key-value state of the store Lua module, one namespace per rule
+CREATE TABLE script_store (
+    namespace  VARCHAR(255) NOT NULL,
+    key        TEXT NOT NULL,
+    value      TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (namespace, key)
+);
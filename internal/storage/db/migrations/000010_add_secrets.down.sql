@@ -0,0 +1,3 @@
+-- Remove the secrets and the rules they are granted to
+DROP TABLE secret_rules;
+DROP TABLE secrets;
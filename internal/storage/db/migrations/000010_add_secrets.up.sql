@@ -0,0 +1,21 @@
+-- Secrets scripts read with secrets.get(name). Values are encrypted with a data key
+-- of their own, which is encrypted with the master key identified by key_id.
+CREATE TABLE secrets (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    ciphertext  BYTEA NOT NULL,
+    data_key    BYTEA NOT NULL,
+    key_id      VARCHAR(64) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Rules whose scripts may read a secret
+CREATE TABLE secret_rules (
+    secret_id UUID NOT NULL REFERENCES secrets (id) ON DELETE CASCADE,
+    rule_id   UUID NOT NULL REFERENCES rules (id) ON DELETE CASCADE,
+    PRIMARY KEY (secret_id, rule_id)
+);
+
+CREATE INDEX idx_secret_rules_rule_id ON secret_rules (rule_id);
@@ -0,0 +1,24 @@
+package execution
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Execution represents a recorded rule execution in the storage layer
+type Execution struct {
+	ID            int64      `json:"id" db:"id"`
+	RuleID        uuid.UUID  `json:"rule_id" db:"rule_id"`
+	TriggerID     *uuid.UUID `json:"trigger_id" db:"trigger_id"`
+	TriggeredAt   time.Time  `json:"triggered_at" db:"triggered_at"`
+	Status        string     `json:"execution_status" db:"execution_status"`
+	DurationMs    int        `json:"duration_ms" db:"duration_ms"`
+	Output        string     `json:"output" db:"output"` // JSON string for returned values
+	Error         string     `json:"error" db:"error"`
+	OutputLog     string     `json:"output_log" db:"output_log"` // JSON string for captured script logs
+	LogsTruncated bool       `json:"logs_truncated" db:"logs_truncated"`
+	ReplayBundle  *string    `json:"replay_bundle" db:"replay_bundle"` // JSON string, nil when not recorded
+	Replayable    bool       `json:"replayable" db:"-"`                // set on read instead of loading the bundle
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
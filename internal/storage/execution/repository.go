@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/malyshevhen/rule-engine/internal/storage/db"
+)
+
+// ErrNotFound is returned when an execution is not found
+var ErrNotFound = errors.New("execution not found")
+
+// Repository handles database operations for execution logs
+type Repository struct {
+	db db.DBTX
+}
+
+// NewRepository creates a new execution repository
+func NewRepository(db db.DBTX) *Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `id, rule_id, trigger_id, triggered_at, execution_status, COALESCE(duration_ms, 0), output, error, COALESCE(output_log, '[]'), logs_truncated, replay_bundle IS NOT NULL, created_at`
+
+// Create inserts a new execution log into the database
+func (r *Repository) Create(ctx context.Context, execution *Execution) error {
+	query := `INSERT INTO execution_logs (rule_id, trigger_id, triggered_at, execution_status, duration_ms, output, error, output_log, logs_truncated, replay_bundle)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query,
+		execution.RuleID, execution.TriggerID, execution.TriggeredAt, execution.Status, execution.DurationMs,
+		execution.Output, execution.Error, execution.OutputLog, execution.LogsTruncated, execution.ReplayBundle,
+	).Scan(&execution.ID, &execution.CreatedAt)
+}
+
+// GetByID retrieves an execution log by its ID
+func (r *Repository) GetByID(ctx context.Context, id int64) (*Execution, error) {
+	query := `SELECT ` + selectColumns + ` FROM execution_logs WHERE id = $1`
+	execution, err := scanExecution(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// GetReplayBundle retrieves the replay bundle of an execution log.
+// It returns an empty string when the execution was not recorded for replay.
+func (r *Repository) GetReplayBundle(ctx context.Context, id int64) (string, error) {
+	query := `SELECT COALESCE(replay_bundle, '') FROM execution_logs WHERE id = $1`
+	var bundle string
+	err := r.db.QueryRow(ctx, query, id).Scan(&bundle)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return bundle, nil
+}
+
+// ListByRuleID retrieves the execution logs of a rule, most recent first, with pagination
+func (r *Repository) ListByRuleID(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*Execution, int, error) {
+	// First get the total count
+	countQuery := `SELECT COUNT(*) FROM execution_logs WHERE rule_id = $1`
+	var total int
+	err := r.db.QueryRow(ctx, countQuery, ruleID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	query := `SELECT ` + selectColumns + ` FROM execution_logs WHERE rule_id = $1 ORDER BY triggered_at DESC, id DESC LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(ctx, query, ruleID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var executions []*Execution
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		executions = append(executions, execution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return executions, total, nil
+}
+
+// scanExecution reads a row selected with selectColumns
+func scanExecution(row pgx.Row) (*Execution, error) {
+	var execution Execution
+	err := row.Scan(&execution.ID, &execution.RuleID, &execution.TriggerID, &execution.TriggeredAt, &execution.Status,
+		&execution.DurationMs, &execution.Output, &execution.Error, &execution.OutputLog, &execution.LogsTruncated, &execution.Replayable, &execution.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
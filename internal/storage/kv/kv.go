@@ -0,0 +1,12 @@
+// Package kv stores the key-value state of scripts. Every rule gets its own
+// namespace, and every namespace holds at most a configured number of keys.
+// Values are opaque strings, integers for the keys changed with Incr.
+package kv
+
+import "errors"
+
+// Errors returned by the stores
+var (
+	ErrKeyLimit   = errors.New("namespace key limit reached")
+	ErrNotInteger = errors.New("value is not an integer")
+)
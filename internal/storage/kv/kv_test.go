@@ -0,0 +1,171 @@
+package kv
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is the behaviour shared by the backends
+type store interface {
+	Get(ctx context.Context, namespace, key string) (string, bool, error)
+	Set(ctx context.Context, namespace, key, value string, ttl time.Duration, maxKeys int) error
+	Incr(ctx context.Context, namespace, key string, delta int64, maxKeys int) (int64, error)
+	Delete(ctx context.Context, namespace, key string) (bool, error)
+	CompareAndSwap(ctx context.Context, namespace, key string, expected *string, value string, ttl time.Duration, maxKeys int) (bool, error)
+	Clear(ctx context.Context, namespace string) error
+}
+
+// backend is a store under test, with a way to let time pass for it
+type backend struct {
+	store   store
+	advance func(d time.Duration)
+}
+
+// testStore runs the behaviour every backend must have. Every test uses its
+// own namespace, so the backend may be shared.
+func testStore(t *testing.T, b backend) {
+	ctx := context.Background()
+	namespace := func(t *testing.T) string {
+		return strings.ReplaceAll(t.Name(), "/", ":")
+	}
+
+	t.Run("set and get", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Set(ctx, ns, "a", "1", 0, 0))
+
+		value, ok, err := b.store.Get(ctx, ns, "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "1", value)
+
+		_, ok, err = b.store.Get(ctx, ns, "missing")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, ok, err = b.store.Get(ctx, ns+":other", "a")
+		require.NoError(t, err)
+		assert.False(t, ok, "namespaces are isolated")
+	})
+
+	t.Run("key limit", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Set(ctx, ns, "a", "1", 0, 2))
+		require.NoError(t, b.store.Set(ctx, ns, "b", "2", 0, 2))
+
+		assert.ErrorIs(t, b.store.Set(ctx, ns, "c", "3", 0, 2), ErrKeyLimit)
+		_, err := b.store.Incr(ctx, ns, "c", 1, 2)
+		assert.ErrorIs(t, err, ErrKeyLimit)
+		_, err = b.store.CompareAndSwap(ctx, ns, "c", nil, "3", 0, 2)
+		assert.ErrorIs(t, err, ErrKeyLimit)
+
+		// Existing keys can still be written, and deleting one frees a slot
+		require.NoError(t, b.store.Set(ctx, ns, "a", "updated", 0, 2))
+		removed, err := b.store.Delete(ctx, ns, "b")
+		require.NoError(t, err)
+		assert.True(t, removed)
+		require.NoError(t, b.store.Set(ctx, ns, "c", "3", 0, 2))
+	})
+
+	t.Run("ttl expiry", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Set(ctx, ns, "short", "1", 100*time.Millisecond, 2))
+		require.NoError(t, b.store.Set(ctx, ns, "long", "2", 0, 2))
+
+		_, ok, err := b.store.Get(ctx, ns, "short")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		b.advance(200 * time.Millisecond)
+
+		_, ok, err = b.store.Get(ctx, ns, "short")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = b.store.Get(ctx, ns, "long")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// Expired keys no longer count towards the limit
+		require.NoError(t, b.store.Set(ctx, ns, "new", "3", 0, 2))
+		removed, err := b.store.Delete(ctx, ns, "short")
+		require.NoError(t, err)
+		assert.False(t, removed)
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		ns := namespace(t)
+		one, two := "1", "2"
+
+		swapped, err := b.store.CompareAndSwap(ctx, ns, "k", &one, "x", 0, 0)
+		require.NoError(t, err)
+		assert.False(t, swapped, "a missing key does not match a value")
+
+		swapped, err = b.store.CompareAndSwap(ctx, ns, "k", nil, one, 0, 0)
+		require.NoError(t, err)
+		assert.True(t, swapped, "a missing key matches nil")
+
+		swapped, err = b.store.CompareAndSwap(ctx, ns, "k", nil, "x", 0, 0)
+		require.NoError(t, err)
+		assert.False(t, swapped, "an existing key does not match nil")
+
+		swapped, err = b.store.CompareAndSwap(ctx, ns, "k", &two, "x", 0, 0)
+		require.NoError(t, err)
+		assert.False(t, swapped)
+
+		swapped, err = b.store.CompareAndSwap(ctx, ns, "k", &one, two, 0, 0)
+		require.NoError(t, err)
+		assert.True(t, swapped)
+
+		value, _, err := b.store.Get(ctx, ns, "k")
+		require.NoError(t, err)
+		assert.Equal(t, two, value)
+	})
+
+	t.Run("incr", func(t *testing.T) {
+		ns := namespace(t)
+		n, err := b.store.Incr(ctx, ns, "counter", 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		n, err = b.store.Incr(ctx, ns, "counter", -5, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-3), n)
+
+		require.NoError(t, b.store.Set(ctx, ns, "text", "abc", 0, 0))
+		_, err = b.store.Incr(ctx, ns, "text", 1, 0)
+		assert.ErrorIs(t, err, ErrNotInteger)
+		value, _, err := b.store.Get(ctx, ns, "text")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", value)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Set(ctx, ns, "a", "1", 0, 1))
+		require.NoError(t, b.store.Set(ctx, ns+":other", "a", "1", 0, 1))
+
+		require.NoError(t, b.store.Clear(ctx, ns))
+
+		_, ok, err := b.store.Get(ctx, ns, "a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = b.store.Get(ctx, ns+":other", "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.NoError(t, b.store.Set(ctx, ns, "b", "2", 0, 1), "the key limit is reset")
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	testStore(t, backend{
+		store:   s,
+		advance: func(d time.Duration) { now = now.Add(d) },
+	})
+}
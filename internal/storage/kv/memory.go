@@ -0,0 +1,136 @@
+package kv
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // zero when the key does not expire
+}
+
+// MemoryStore keeps the state in process memory. It suits tests and single
+// instance deployments that can afford to lose the state on restart.
+type MemoryStore struct {
+	mu         sync.Mutex
+	namespaces map[string]map[string]memoryEntry
+	now        func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		namespaces: make(map[string]map[string]memoryEntry),
+		now:        time.Now,
+	}
+}
+
+// entries returns the live keys of namespace, dropping the expired ones
+func (s *MemoryStore) entries(namespace string) map[string]memoryEntry {
+	entries, ok := s.namespaces[namespace]
+	if !ok {
+		entries = make(map[string]memoryEntry)
+		s.namespaces[namespace] = entries
+	}
+	now := s.now()
+	for key, entry := range entries {
+		if !entry.expiresAt.IsZero() && !entry.expiresAt.After(now) {
+			delete(entries, key)
+		}
+	}
+	return entries
+}
+
+// put stores value under key unless that would exceed maxKeys
+func (s *MemoryStore) put(entries map[string]memoryEntry, key, value string, ttl time.Duration, maxKeys int) error {
+	if _, exists := entries[key]; !exists && maxKeys > 0 && len(entries) >= maxKeys {
+		return ErrKeyLimit
+	}
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = s.now().Add(ttl)
+	}
+	entries[key] = entry
+	return nil
+}
+
+// Get returns the value of key and whether it exists
+func (s *MemoryStore) Get(_ context.Context, namespace, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries(namespace)[key]
+	return entry.value, ok, nil
+}
+
+// Set stores value under key, expiring it after ttl when positive
+func (s *MemoryStore) Set(_ context.Context, namespace, key, value string, ttl time.Duration, maxKeys int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(s.entries(namespace), key, value, ttl, maxKeys)
+}
+
+// Incr adds delta to the integer stored under key, which starts at 0, and
+// returns the new value. The expiry of the key is kept.
+func (s *MemoryStore) Incr(_ context.Context, namespace, key string, delta int64, maxKeys int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries(namespace)
+	entry, exists := entries[key]
+	if !exists {
+		if err := s.put(entries, key, strconv.FormatInt(delta, 10), 0, maxKeys); err != nil {
+			return 0, err
+		}
+		return delta, nil
+	}
+	current, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	entry.value = strconv.FormatInt(current+delta, 10)
+	entries[key] = entry
+	return current + delta, nil
+}
+
+// Delete removes key and reports whether it existed
+func (s *MemoryStore) Delete(_ context.Context, namespace, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries(namespace)
+	_, exists := entries[key]
+	delete(entries, key)
+	return exists, nil
+}
+
+// CompareAndSwap stores value under key if its current value is expected, or if
+// the key does not exist when expected is nil, and reports whether it did
+func (s *MemoryStore) CompareAndSwap(
+	_ context.Context,
+	namespace, key string,
+	expected *string,
+	value string,
+	ttl time.Duration,
+	maxKeys int,
+) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries(namespace)
+	entry, exists := entries[key]
+	if exists != (expected != nil) || (exists && entry.value != *expected) {
+		return false, nil
+	}
+	if err := s.put(entries, key, value, ttl, maxKeys); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Clear removes every key of namespace
+func (s *MemoryStore) Clear(_ context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.namespaces, namespace)
+	return nil
+}
@@ -0,0 +1,180 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps the state in the script_store table. Writes to a
+// namespace are serialized with an advisory lock so that the key limit holds
+// under concurrent executions of the same rule.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a store on top of a connection pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// live restricts a query to the keys that have not expired
+const live = `(expires_at IS NULL OR expires_at > now())`
+
+// Get returns the value of key and whether it exists
+func (s *PostgresStore) Get(ctx context.Context, namespace, key string) (string, bool, error) {
+	query := `SELECT value FROM script_store WHERE namespace = $1 AND key = $2 AND ` + live
+	var value string
+	err := s.pool.QueryRow(ctx, query, namespace, key).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, expiring it after ttl when positive
+func (s *PostgresStore) Set(ctx context.Context, namespace, key, value string, ttl time.Duration, maxKeys int) error {
+	return s.write(ctx, namespace, func(tx pgx.Tx) error {
+		return put(ctx, tx, namespace, key, value, ttl, maxKeys)
+	})
+}
+
+// Incr adds delta to the integer stored under key, which starts at 0, and
+// returns the new value. The expiry of the key is kept.
+func (s *PostgresStore) Incr(ctx context.Context, namespace, key string, delta int64, maxKeys int) (int64, error) {
+	var result int64
+	err := s.write(ctx, namespace, func(tx pgx.Tx) error {
+		current, exists, err := get(ctx, tx, namespace, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			result = delta
+			return put(ctx, tx, namespace, key, strconv.FormatInt(delta, 10), 0, maxKeys)
+		}
+		n, err := strconv.ParseInt(current, 10, 64)
+		if err != nil {
+			return ErrNotInteger
+		}
+		result = n + delta
+		query := `UPDATE script_store SET value = $3, updated_at = now() WHERE namespace = $1 AND key = $2`
+		_, err = tx.Exec(ctx, query, namespace, key, strconv.FormatInt(result, 10))
+		return err
+	})
+	return result, err
+}
+
+// Delete removes key and reports whether it existed
+func (s *PostgresStore) Delete(ctx context.Context, namespace, key string) (bool, error) {
+	query := `WITH deleted AS (
+		DELETE FROM script_store WHERE namespace = $1 AND key = $2 RETURNING expires_at
+	) SELECT count(*) FROM deleted WHERE ` + live
+	var removed int
+	if err := s.pool.QueryRow(ctx, query, namespace, key).Scan(&removed); err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// CompareAndSwap stores value under key if its current value is expected, or if
+// the key does not exist when expected is nil, and reports whether it did
+func (s *PostgresStore) CompareAndSwap(
+	ctx context.Context,
+	namespace, key string,
+	expected *string,
+	value string,
+	ttl time.Duration,
+	maxKeys int,
+) (bool, error) {
+	swapped := false
+	err := s.write(ctx, namespace, func(tx pgx.Tx) error {
+		current, exists, err := get(ctx, tx, namespace, key)
+		if err != nil {
+			return err
+		}
+		if exists != (expected != nil) || (exists && current != *expected) {
+			return nil
+		}
+		if err := put(ctx, tx, namespace, key, value, ttl, maxKeys); err != nil {
+			return err
+		}
+		swapped = true
+		return nil
+	})
+	return swapped, err
+}
+
+// Clear removes every key of namespace
+func (s *PostgresStore) Clear(ctx context.Context, namespace string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM script_store WHERE namespace = $1`, namespace)
+	return err
+}
+
+// write runs fn in a transaction holding the lock of namespace, after the
+// expired keys of the namespace have been removed
+func (s *PostgresStore) write(ctx context.Context, namespace string, fn func(tx pgx.Tx) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("Failed to roll back script store transaction", "error", err)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, namespace); err != nil {
+		return err
+	}
+	query := `DELETE FROM script_store WHERE namespace = $1 AND expires_at <= now()`
+	if _, err := tx.Exec(ctx, query, namespace); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// get reads key within a write, where the expired keys are already gone
+func get(ctx context.Context, tx pgx.Tx, namespace, key string) (string, bool, error) {
+	var value string
+	query := `SELECT value FROM script_store WHERE namespace = $1 AND key = $2`
+	err := tx.QueryRow(ctx, query, namespace, key).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// put upserts key within a write unless that would exceed maxKeys
+func put(ctx context.Context, tx pgx.Tx, namespace, key, value string, ttl time.Duration, maxKeys int) error {
+	if maxKeys > 0 {
+		var exists bool
+		var count int
+		query := `SELECT count(*), bool_or(key = $2) IS TRUE FROM script_store WHERE namespace = $1`
+		if err := tx.QueryRow(ctx, query, namespace, key).Scan(&count, &exists); err != nil {
+			return err
+		}
+		if !exists && count >= maxKeys {
+			return ErrKeyLimit
+		}
+	}
+	query := `INSERT INTO script_store (namespace, key, value, expires_at)
+		VALUES ($1, $2, $3, CASE WHEN $4::bigint > 0 THEN now() + $4::bigint * interval '1 millisecond' END)
+		ON CONFLICT (namespace, key) DO UPDATE
+		SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at, updated_at = now()`
+	_, err := tx.Exec(ctx, query, namespace, key, value, ttl.Milliseconds())
+	return err
+}
@@ -0,0 +1,53 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/malyshevhen/rule-engine/internal/storage/db"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestPostgresStore(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	// Start a PostgreSQL container
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:15-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_PASSWORD": "password",
+				"POSTGRES_DB":       "rule_engine_test",
+			},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+	connString := fmt.Sprintf("postgres://postgres:password@%s:%s/rule_engine_test?sslmode=disable", host, port.Port())
+
+	pool, err := db.NewPostgresPool(ctx, connString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	require.NoError(t, db.RunMigrations(pool))
+
+	// Expiry follows the clock of the database
+	testStore(t, backend{
+		store:   NewPostgresStore(pool),
+		advance: time.Sleep,
+	})
+}
@@ -0,0 +1,181 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// prelude starts every write script: it computes the Redis server time in
+// milliseconds and drops the expired keys from the index of the namespace.
+// KEYS[1] is the value key and KEYS[2] the index, a sorted set of the keys of
+// the namespace scored by their expiry time.
+const prelude = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now)
+local function full(max)
+  return redis.call('EXISTS', KEYS[1]) == 0 and max > 0 and redis.call('ZCARD', KEYS[2]) >= max
+end
+local function store(key, value, ttl)
+  if ttl > 0 then
+    redis.call('SET', KEYS[1], value, 'PX', ttl)
+    redis.call('ZADD', KEYS[2], now + ttl, key)
+  else
+    redis.call('SET', KEYS[1], value)
+    redis.call('ZADD', KEYS[2], '+inf', key)
+  end
+end
+`
+
+// ARGV: key, value, ttl in ms, max keys. Returns 0 when the namespace is full.
+var setScript = redis.NewScript(prelude + `
+if full(tonumber(ARGV[4])) then return 0 end
+store(ARGV[1], ARGV[2], tonumber(ARGV[3]))
+return 1
+`)
+
+// ARGV: key, delta, max keys. Returns {ok, value}, ok is 0 when the namespace is full.
+var incrScript = redis.NewScript(prelude + `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  if full(tonumber(ARGV[3])) then return {0, 0} end
+  redis.call('ZADD', KEYS[2], '+inf', ARGV[1])
+end
+return {1, redis.call('INCRBY', KEYS[1], ARGV[2])}
+`)
+
+// ARGV: key. Returns the number of keys removed.
+var deleteScript = redis.NewScript(prelude + `
+redis.call('ZREM', KEYS[2], ARGV[1])
+return redis.call('DEL', KEYS[1])
+`)
+
+// ARGV: key, expected value, 1 when a value is expected, new value, ttl in ms,
+// max keys. Returns 1 when swapped, 0 when the value differs and -1 when the
+// namespace is full.
+var casScript = redis.NewScript(prelude + `
+local current = redis.call('GET', KEYS[1])
+if ARGV[3] == '1' then
+  if current ~= ARGV[2] then return 0 end
+else
+  if current then return 0 end
+  if full(tonumber(ARGV[6])) then return -1 end
+end
+store(ARGV[1], ARGV[4], tonumber(ARGV[5]))
+return 1
+`)
+
+// RedisStore keeps the state in Redis. The keys of a namespace share a hash
+// tag, so the scripts that update them also run on Redis Cluster.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store on top of a Redis client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func valueKey(namespace, key string) string {
+	return "store:{" + namespace + "}:k:" + key
+}
+
+func indexKey(namespace string) string {
+	return "store:{" + namespace + "}:keys"
+}
+
+// Get returns the value of key and whether it exists
+func (s *RedisStore) Get(ctx context.Context, namespace, key string) (string, bool, error) {
+	value, err := s.client.Get(ctx, valueKey(namespace, key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, expiring it after ttl when positive
+func (s *RedisStore) Set(ctx context.Context, namespace, key, value string, ttl time.Duration, maxKeys int) error {
+	keys := []string{valueKey(namespace, key), indexKey(namespace)}
+	ok, err := setScript.Run(ctx, s.client, keys, key, value, ttl.Milliseconds(), maxKeys).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrKeyLimit
+	}
+	return nil
+}
+
+// Incr adds delta to the integer stored under key, which starts at 0, and
+// returns the new value. The expiry of the key is kept.
+func (s *RedisStore) Incr(ctx context.Context, namespace, key string, delta int64, maxKeys int) (int64, error) {
+	keys := []string{valueKey(namespace, key), indexKey(namespace)}
+	result, err := incrScript.Run(ctx, s.client, keys, key, delta, maxKeys).Int64Slice()
+	if err != nil {
+		if strings.Contains(err.Error(), "not an integer") {
+			return 0, ErrNotInteger
+		}
+		return 0, err
+	}
+	if result[0] == 0 {
+		return 0, ErrKeyLimit
+	}
+	return result[1], nil
+}
+
+// Delete removes key and reports whether it existed
+func (s *RedisStore) Delete(ctx context.Context, namespace, key string) (bool, error) {
+	keys := []string{valueKey(namespace, key), indexKey(namespace)}
+	removed, err := deleteScript.Run(ctx, s.client, keys, key).Int()
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+// CompareAndSwap stores value under key if its current value is expected, or if
+// the key does not exist when expected is nil, and reports whether it did
+func (s *RedisStore) CompareAndSwap(
+	ctx context.Context,
+	namespace, key string,
+	expected *string,
+	value string,
+	ttl time.Duration,
+	maxKeys int,
+) (bool, error) {
+	keys := []string{valueKey(namespace, key), indexKey(namespace)}
+	expectedValue, hasExpected := "", "0"
+	if expected != nil {
+		expectedValue, hasExpected = *expected, "1"
+	}
+	result, err := casScript.Run(ctx, s.client, keys, key, expectedValue, hasExpected, value, ttl.Milliseconds(), maxKeys).Int()
+	if err != nil {
+		return false, err
+	}
+	if result < 0 {
+		return false, ErrKeyLimit
+	}
+	return result == 1, nil
+}
+
+// Clear removes every key of namespace
+func (s *RedisStore) Clear(ctx context.Context, namespace string) error {
+	index := indexKey(namespace)
+	keys, err := s.client.ZRange(ctx, index, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, valueKey(namespace, key))
+	}
+	pipe.Del(ctx, index)
+	_, err = pipe.Exec(ctx)
+	return err
+}
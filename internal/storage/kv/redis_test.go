@@ -0,0 +1,28 @@
+package kv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	// The scripts read the clock of the server, which miniredis only moves on demand
+	now := time.Now()
+	server.SetTime(now)
+
+	testStore(t, backend{
+		store: NewRedisStore(client),
+		advance: func(d time.Duration) {
+			now = now.Add(d)
+			server.SetTime(now)
+			server.FastForward(d)
+		},
+	})
+}
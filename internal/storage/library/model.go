@@ -0,0 +1,26 @@
+package library
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Library represents a shared Lua library in the storage layer
+type Library struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Code        string    `json:"code" db:"code"`
+	Version     int       `json:"version" db:"version"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Version represents a past or current version of the code of a library
+type Version struct {
+	LibraryID uuid.UUID `json:"library_id" db:"library_id"`
+	Version   int       `json:"version" db:"version"`
+	Code      string    `json:"code" db:"code"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
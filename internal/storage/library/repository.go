@@ -0,0 +1,189 @@
+package library
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/malyshevhen/rule-engine/internal/storage/db"
+)
+
+// Errors returned by the repository
+var (
+	ErrNotFound      = errors.New("library not found")
+	ErrDuplicateName = errors.New("library name already exists")
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// Repository handles database operations for libraries
+type Repository struct {
+	db db.DBTX
+}
+
+// NewRepository creates a new library repository
+func NewRepository(db db.DBTX) *Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `id, name, description, code, version, created_at, updated_at`
+
+// Create inserts a new library with its first version into the database
+func (r *Repository) Create(ctx context.Context, library *Library) error {
+	query := `INSERT INTO libraries (name, description, code) VALUES ($1, $2, $3) RETURNING id, version, created_at, updated_at`
+	err := r.db.QueryRow(ctx, query, library.Name, library.Description, library.Code).
+		Scan(&library.ID, &library.Version, &library.CreatedAt, &library.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.createVersion(ctx, library)
+}
+
+// GetByID retrieves a library by its ID
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Library, error) {
+	query := `SELECT ` + selectColumns + ` FROM libraries WHERE id = $1`
+	library, err := scanLibrary(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return library, nil
+}
+
+// List retrieves libraries ordered by name with pagination
+func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Library, int, error) {
+	// First get the total count
+	countQuery := `SELECT COUNT(*) FROM libraries`
+	var total int
+	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	query := `SELECT ` + selectColumns + ` FROM libraries ORDER BY name LIMIT $1 OFFSET $2`
+	libraries, err := r.query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return libraries, total, nil
+}
+
+// ListAll retrieves every library ordered by name
+func (r *Repository) ListAll(ctx context.Context) ([]*Library, error) {
+	return r.query(ctx, `SELECT `+selectColumns+` FROM libraries ORDER BY name`)
+}
+
+// Update modifies an existing library. A change of its code is stored as a new version.
+func (r *Repository) Update(ctx context.Context, library *Library) error {
+	query := `UPDATE libraries l
+		SET name = $1, description = $2, code = $3,
+			version = CASE WHEN old.code = $3 THEN l.version ELSE l.version + 1 END, updated_at = NOW()
+		FROM (SELECT code FROM libraries WHERE id = $4 FOR UPDATE) old
+		WHERE l.id = $4
+		RETURNING l.version, l.created_at, l.updated_at, old.code <> $3`
+	var changed bool
+	err := r.db.QueryRow(ctx, query, library.Name, library.Description, library.Code, library.ID).
+		Scan(&library.Version, &library.CreatedAt, &library.UpdatedAt, &changed)
+	if err != nil {
+		return mapError(err)
+	}
+	if !changed {
+		return nil
+	}
+	return r.createVersion(ctx, library)
+}
+
+// Delete removes a library and its versions from the database
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM libraries WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListVersions retrieves the versions of a library, most recent first
+func (r *Repository) ListVersions(ctx context.Context, id uuid.UUID) ([]*Version, error) {
+	query := `SELECT library_id, version, code, created_at FROM library_versions WHERE library_id = $1 ORDER BY version DESC`
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*Version
+	for rows.Next() {
+		var version Version
+		if err := rows.Scan(&version.LibraryID, &version.Version, &version.Code, &version.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &version)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion retrieves one version of a library
+func (r *Repository) GetVersion(ctx context.Context, id uuid.UUID, version int) (*Version, error) {
+	query := `SELECT library_id, version, code, created_at FROM library_versions WHERE library_id = $1 AND version = $2`
+	var v Version
+	err := r.db.QueryRow(ctx, query, id, version).Scan(&v.LibraryID, &v.Version, &v.Code, &v.CreatedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &v, nil
+}
+
+func (r *Repository) createVersion(ctx context.Context, library *Library) error {
+	query := `INSERT INTO library_versions (library_id, version, code) VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(ctx, query, library.ID, library.Version, library.Code)
+	return err
+}
+
+func (r *Repository) query(ctx context.Context, query string, args ...any) ([]*Library, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var libraries []*Library
+	for rows.Next() {
+		library, err := scanLibrary(rows)
+		if err != nil {
+			return nil, err
+		}
+		libraries = append(libraries, library)
+	}
+	return libraries, rows.Err()
+}
+
+func scanLibrary(row pgx.Row) (*Library, error) {
+	var library Library
+	err := row.Scan(&library.ID, &library.Name, &library.Description, &library.Code, &library.Version, &library.CreatedAt, &library.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &library, nil
+}
+
+// mapError converts the errors of pgx to the errors of the repository
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrNotFound
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return ErrDuplicateName
+	default:
+		return err
+	}
+}
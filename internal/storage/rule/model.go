@@ -13,6 +13,8 @@ type Rule struct {
 	LuaScript string    `json:"lua_script" db:"lua_script"`
 	Priority  int       `json:"priority" db:"priority"`
 	Enabled   bool      `json:"enabled" db:"enabled"`
+	TimeoutMs int       `json:"timeout_ms" db:"timeout_ms"`
+	Limits    string    `json:"limits" db:"limits"` // JSON string for resource limits
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -26,15 +26,15 @@ func NewRepository(db db.DBTX) *Repository {
 
 // Create inserts a new rule into the database
 func (r *Repository) Create(ctx context.Context, rule *Rule) error {
-	query := `INSERT INTO rules (name, lua_script, priority, enabled) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
-	return r.db.QueryRow(ctx, query, rule.Name, rule.LuaScript, rule.Priority, rule.Enabled).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	query := `INSERT INTO rules (name, lua_script, priority, enabled, timeout_ms, limits) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(ctx, query, rule.Name, rule.LuaScript, rule.Priority, rule.Enabled, rule.TimeoutMs, rule.Limits).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
 }
 
 // GetByID retrieves a rule by its ID
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
-	query := `SELECT id, name, lua_script, priority, enabled, created_at, updated_at FROM rules WHERE id = $1`
+	query := `SELECT id, name, lua_script, priority, enabled, timeout_ms, limits, created_at, updated_at FROM rules WHERE id = $1`
 	var rule Rule
-	err := r.db.QueryRow(ctx, query, id).Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+	err := r.db.QueryRow(ctx, query, id).Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.TimeoutMs, &rule.Limits, &rule.CreatedAt, &rule.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -44,16 +44,16 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
 // GetByIDWithAssociations retrieves a rule with its triggers and actions using JOINs
 func (r *Repository) GetByIDWithAssociations(ctx context.Context, id uuid.UUID) (*Rule, []*triggerStorage.Trigger, []*actionStorage.Action, error) {
 	// Get the rule
-	ruleQuery := `SELECT id, name, lua_script, priority, enabled, created_at, updated_at FROM rules WHERE id = $1`
+	ruleQuery := `SELECT id, name, lua_script, priority, enabled, timeout_ms, limits, created_at, updated_at FROM rules WHERE id = $1`
 	var rule Rule
-	err := r.db.QueryRow(ctx, ruleQuery, id).Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+	err := r.db.QueryRow(ctx, ruleQuery, id).Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.TimeoutMs, &rule.Limits, &rule.CreatedAt, &rule.UpdatedAt)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// Get triggers directly
 	triggersQuery := `
-		SELECT t.id, t.rule_id, t.type, t.condition_script, t.enabled, t.created_at, t.updated_at
+		SELECT t.id, t.rule_id, t.type, t.condition_script, t.language, t.enabled, t.timeout_ms, t.created_at, t.updated_at
 		FROM triggers t
 		WHERE t.rule_id = $1
 		ORDER BY t.created_at
@@ -67,7 +67,7 @@ func (r *Repository) GetByIDWithAssociations(ctx context.Context, id uuid.UUID)
 	var triggers []*triggerStorage.Trigger
 	for triggersRows.Next() {
 		var t triggerStorage.Trigger
-		err := triggersRows.Scan(&t.ID, &t.RuleID, &t.Type, &t.ConditionScript, &t.Enabled, &t.CreatedAt, &t.UpdatedAt)
+		err := triggersRows.Scan(&t.ID, &t.RuleID, &t.Type, &t.ConditionScript, &t.Language, &t.Enabled, &t.TimeoutMs, &t.CreatedAt, &t.UpdatedAt)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -104,7 +104,7 @@ func (r *Repository) GetByIDWithAssociations(ctx context.Context, id uuid.UUID)
 // GetTriggersByRuleID retrieves all triggers associated with a rule
 func (r *Repository) GetTriggersByRuleID(ctx context.Context, ruleID uuid.UUID) ([]*triggerStorage.Trigger, error) {
 	query := `
-		SELECT t.id, t.rule_id, t.type, t.condition_script, t.enabled, t.created_at, t.updated_at
+		SELECT t.id, t.rule_id, t.type, t.condition_script, t.language, t.enabled, t.timeout_ms, t.created_at, t.updated_at
 		FROM triggers t
 		WHERE t.rule_id = $1
 	`
@@ -117,7 +117,7 @@ func (r *Repository) GetTriggersByRuleID(ctx context.Context, ruleID uuid.UUID)
 	var triggers []*triggerStorage.Trigger
 	for rows.Next() {
 		var t triggerStorage.Trigger
-		err := rows.Scan(&t.ID, &t.RuleID, &t.Type, &t.ConditionScript, &t.Enabled, &t.CreatedAt, &t.UpdatedAt)
+		err := rows.Scan(&t.ID, &t.RuleID, &t.Type, &t.ConditionScript, &t.Language, &t.Enabled, &t.TimeoutMs, &t.CreatedAt, &t.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -163,7 +163,7 @@ func (r *Repository) List(ctx context.Context, limit int, offset int) ([]*Rule,
 	}
 
 	// Then get the paginated results
-	query := `SELECT id, name, lua_script, priority, enabled, created_at, updated_at FROM rules ORDER BY priority DESC, created_at DESC LIMIT $1 OFFSET $2`
+	query := `SELECT id, name, lua_script, priority, enabled, timeout_ms, limits, created_at, updated_at FROM rules ORDER BY priority DESC, created_at DESC LIMIT $1 OFFSET $2`
 	rows, err := r.db.Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -173,7 +173,7 @@ func (r *Repository) List(ctx context.Context, limit int, offset int) ([]*Rule,
 	var rules []*Rule
 	for rows.Next() {
 		var rule Rule
-		err := rows.Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+		err := rows.Scan(&rule.ID, &rule.Name, &rule.LuaScript, &rule.Priority, &rule.Enabled, &rule.TimeoutMs, &rule.Limits, &rule.CreatedAt, &rule.UpdatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -190,8 +190,8 @@ func (r *Repository) ListAll(ctx context.Context) ([]*Rule, error) {
 
 // Update updates an existing rule
 func (r *Repository) Update(ctx context.Context, rule *Rule) error {
-	query := `UPDATE rules SET name = $1, lua_script = $2, priority = $3, enabled = $4, updated_at = NOW() WHERE id = $5`
-	_, err := r.db.Exec(ctx, query, rule.Name, rule.LuaScript, rule.Priority, rule.Enabled, rule.ID)
+	query := `UPDATE rules SET name = $1, lua_script = $2, priority = $3, enabled = $4, timeout_ms = $5, limits = $6, updated_at = NOW() WHERE id = $7`
+	_, err := r.db.Exec(ctx, query, rule.Name, rule.LuaScript, rule.Priority, rule.Enabled, rule.TimeoutMs, rule.Limits, rule.ID)
 	return err
 }
 
@@ -0,0 +1,21 @@
+package secret
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Secret represents an encrypted secret in the storage layer.
+// Ciphertext and DataKey are nil when the secret is read without its value.
+type Secret struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	Name        string      `json:"name" db:"name"`
+	Description string      `json:"description" db:"description"`
+	RuleIDs     []uuid.UUID `json:"rule_ids" db:"rule_ids"`
+	Ciphertext  []byte      `json:"-" db:"ciphertext"`
+	DataKey     []byte      `json:"-" db:"data_key"`
+	KeyID       string      `json:"key_id" db:"key_id"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,191 @@
+package secret
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/malyshevhen/rule-engine/internal/storage/db"
+)
+
+// Errors returned by the repository
+var (
+	ErrNotFound      = errors.New("secret not found")
+	ErrDuplicateName = errors.New("secret name already exists")
+	ErrUnknownRule   = errors.New("secret granted to an unknown rule")
+)
+
+// Postgres error codes of the constraint violations mapped to repository errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Repository handles database operations for secrets
+type Repository struct {
+	db db.DBTX
+}
+
+// NewRepository creates a new secret repository
+func NewRepository(db db.DBTX) *Repository {
+	return &Repository{db: db}
+}
+
+// selectColumns selects the metadata of a secret, without its value
+const selectColumns = `s.id, s.name, s.description,
+	ARRAY(SELECT sr.rule_id::text FROM secret_rules sr WHERE sr.secret_id = s.id ORDER BY sr.rule_id),
+	s.key_id, s.created_at, s.updated_at`
+
+// Create inserts a new secret and the rules it is granted to into the database
+func (r *Repository) Create(ctx context.Context, secret *Secret) error {
+	query := `INSERT INTO secrets (name, description, ciphertext, data_key, key_id) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(ctx, query, secret.Name, secret.Description, secret.Ciphertext, secret.DataKey, secret.KeyID).
+		Scan(&secret.ID, &secret.CreatedAt, &secret.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.setRules(ctx, secret.ID, secret.RuleIDs)
+}
+
+// GetByID retrieves the metadata of a secret by its ID
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Secret, error) {
+	query := `SELECT ` + selectColumns + ` FROM secrets s WHERE s.id = $1`
+	secret, err := scanSecret(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return secret, nil
+}
+
+// GetForRule retrieves a secret with its encrypted value by its name,
+// provided it is granted to the rule
+func (r *Repository) GetForRule(ctx context.Context, name string, ruleID uuid.UUID) (*Secret, error) {
+	query := `SELECT s.id, s.name, s.ciphertext, s.data_key, s.key_id
+		FROM secrets s JOIN secret_rules sr ON sr.secret_id = s.id
+		WHERE s.name = $1 AND sr.rule_id = $2`
+	var secret Secret
+	err := r.db.QueryRow(ctx, query, name, ruleID).
+		Scan(&secret.ID, &secret.Name, &secret.Ciphertext, &secret.DataKey, &secret.KeyID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &secret, nil
+}
+
+// List retrieves the metadata of secrets ordered by name with pagination
+func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Secret, int, error) {
+	// First get the total count
+	countQuery := `SELECT COUNT(*) FROM secrets`
+	var total int
+	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	query := `SELECT ` + selectColumns + ` FROM secrets s ORDER BY s.name LIMIT $1 OFFSET $2`
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var secrets []*Secret
+	for rows.Next() {
+		secret, err := scanSecret(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, total, rows.Err()
+}
+
+// Update modifies the description and the rules of a secret, and its value
+// when Ciphertext is set
+func (r *Repository) Update(ctx context.Context, secret *Secret) error {
+	query := `UPDATE secrets
+		SET description = $1,
+			ciphertext = COALESCE($2, ciphertext), data_key = COALESCE($3, data_key), key_id = COALESCE($4, key_id),
+			updated_at = NOW()
+		WHERE id = $5
+		RETURNING name, key_id, created_at, updated_at`
+	var keyID *string
+	if secret.Ciphertext != nil {
+		keyID = &secret.KeyID
+	}
+	err := r.db.QueryRow(ctx, query, secret.Description, secret.Ciphertext, secret.DataKey, keyID, secret.ID).
+		Scan(&secret.Name, &secret.KeyID, &secret.CreatedAt, &secret.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	if _, err := r.db.Exec(ctx, `DELETE FROM secret_rules WHERE secret_id = $1`, secret.ID); err != nil {
+		return err
+	}
+	return r.setRules(ctx, secret.ID, secret.RuleIDs)
+}
+
+// Delete removes a secret from the database
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM secrets WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// setRules grants a secret to rules
+func (r *Repository) setRules(ctx context.Context, id uuid.UUID, ruleIDs []uuid.UUID) error {
+	if len(ruleIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(ruleIDs))
+	for i, ruleID := range ruleIDs {
+		ids[i] = ruleID.String()
+	}
+	query := `INSERT INTO secret_rules (secret_id, rule_id) SELECT $1, unnest($2::uuid[]) ON CONFLICT DO NOTHING`
+	if _, err := r.db.Exec(ctx, query, id, ids); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func scanSecret(row pgx.Row) (*Secret, error) {
+	var secret Secret
+	var ruleIDs []string
+	err := row.Scan(&secret.ID, &secret.Name, &secret.Description, &ruleIDs, &secret.KeyID, &secret.CreatedAt, &secret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	secret.RuleIDs = make([]uuid.UUID, len(ruleIDs))
+	for i, ruleID := range ruleIDs {
+		if secret.RuleIDs[i], err = uuid.Parse(ruleID); err != nil {
+			return nil, err
+		}
+	}
+	return &secret, nil
+}
+
+// mapError converts the errors of pgx to the errors of the repository
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrNotFound
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return ErrDuplicateName
+	case errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation:
+		return ErrUnknownRule
+	default:
+		return err
+	}
+}
@@ -0,0 +1,139 @@
+package series
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Push looks for series that expired in namespaces
+// nothing reads or writes any more
+const sweepInterval = time.Minute
+
+type memorySeries struct {
+	points    []Point // in push order, which is time order
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the series in process memory. It suits tests and single
+// instance deployments that can afford to lose the series on restart.
+type MemoryStore struct {
+	mu         sync.Mutex
+	namespaces map[string]map[string]*memorySeries
+	nextSweep  time.Time
+	now        func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		namespaces: make(map[string]map[string]*memorySeries),
+		now:        time.Now,
+	}
+}
+
+// series returns the live series of namespace, dropping the expired ones. A
+// namespace left without series is removed, so the result may be nil.
+func (s *MemoryStore) series(namespace string, now time.Time) map[string]*memorySeries {
+	series := s.namespaces[namespace]
+	for key, ser := range series {
+		if !ser.expiresAt.After(now) {
+			delete(series, key)
+		}
+	}
+	if series != nil && len(series) == 0 {
+		delete(s.namespaces, namespace)
+		return nil
+	}
+	return series
+}
+
+// sweep drops the expired series of every namespace once per sweepInterval,
+// so that the namespaces of deleted or idle rules do not stay around
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(sweepInterval)
+	for namespace := range s.namespaces {
+		s.series(namespace, now)
+	}
+}
+
+// Push appends value to the series key, keeping the points of the last
+// retention and at most maxPoints of them, unless that would exceed maxSeries
+func (s *MemoryStore) Push(_ context.Context, namespace, key string, value float64, retention time.Duration, maxPoints, maxSeries int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	series := s.series(namespace, now)
+	ser, exists := series[key]
+	if !exists {
+		if maxSeries > 0 && len(series) >= maxSeries {
+			return ErrSeriesLimit
+		}
+		if series == nil {
+			series = make(map[string]*memorySeries)
+			s.namespaces[namespace] = series
+		}
+		ser = &memorySeries{}
+		series[key] = ser
+	}
+
+	ser.points = append(ser.points, Point{At: now, Value: value})
+	ser.expiresAt = now.Add(retention)
+	first := 0
+	for first < len(ser.points) && !ser.points[first].At.After(now.Add(-retention)) {
+		first++
+	}
+	if maxPoints > 0 && len(ser.points)-first > maxPoints {
+		first = len(ser.points) - maxPoints
+	}
+	// Dropping the oldest points only moves the start of the slice. The next
+	// append that outgrows the capacity copies the live points to a new array
+	// with room to spare, so a full series is copied once in a number of
+	// pushes that grows with maxPoints, not on each one.
+	ser.points = ser.points[first:]
+	return nil
+}
+
+// Range returns the points of the series key pushed within the last window, oldest first
+func (s *MemoryStore) Range(_ context.Context, namespace, key string, window time.Duration) ([]Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	ser, ok := s.series(namespace, now)[key]
+	if !ok {
+		return nil, nil
+	}
+	since := now.Add(-window)
+	var points []Point
+	for _, p := range ser.points {
+		if p.At.After(since) {
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+// Delete removes the series key and reports whether it existed
+func (s *MemoryStore) Delete(_ context.Context, namespace, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := s.series(namespace, s.now())
+	_, exists := series[key]
+	delete(series, key)
+	if exists && len(series) == 0 {
+		delete(s.namespaces, namespace)
+	}
+	return exists, nil
+}
+
+// Clear removes every series of namespace
+func (s *MemoryStore) Clear(_ context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.namespaces, namespace)
+	return nil
+}
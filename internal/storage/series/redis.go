@@ -0,0 +1,131 @@
+package series
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clock computes the Redis server time in milliseconds, so that every instance
+// of the engine timestamps points with the same clock
+const clock = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// KEYS[1] is the series, a sorted set of points scored by their time, and
+// KEYS[2] the index, a sorted set of the series of the namespace scored by
+// their expiry time. ARGV: key, member, retention in ms, max points, max
+// series. Returns 0 when the namespace is full.
+var pushScript = redis.NewScript(clock + `
+local retention = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now)
+local max = tonumber(ARGV[5])
+if not redis.call('ZSCORE', KEYS[2], ARGV[1]) and max > 0 and redis.call('ZCARD', KEYS[2]) >= max then
+  return 0
+end
+redis.call('ZADD', KEYS[1], now, ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - retention)
+local extra = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[4])
+if tonumber(ARGV[4]) > 0 and extra > 0 then
+  redis.call('ZREMRANGEBYRANK', KEYS[1], 0, extra - 1)
+end
+redis.call('PEXPIRE', KEYS[1], retention)
+redis.call('ZADD', KEYS[2], now + retention, ARGV[1])
+return 1
+`)
+
+// KEYS[1] is the series. ARGV: window in ms. Returns the members and scores of
+// the points of the window.
+var rangeScript = redis.NewScript(clock + `
+return redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. (now - tonumber(ARGV[1])), '+inf', 'WITHSCORES')
+`)
+
+// RedisStore keeps the series in Redis sorted sets. The keys of a namespace
+// share a hash tag, so the scripts that update them also run on Redis Cluster.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store on top of a Redis client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func seriesKey(namespace, key string) string {
+	return "window:{" + namespace + "}:s:" + key
+}
+
+func indexKey(namespace string) string {
+	return "window:{" + namespace + "}:series"
+}
+
+// Push appends value to the series key, keeping the points of the last
+// retention and at most maxPoints of them, unless that would exceed maxSeries
+func (s *RedisStore) Push(ctx context.Context, namespace, key string, value float64, retention time.Duration, maxPoints, maxSeries int) error {
+	// Members of a sorted set are unique, so equal values are told apart by a random prefix
+	member := strconv.FormatUint(rand.Uint64(), 36) + ":" + strconv.FormatFloat(value, 'g', -1, 64)
+	keys := []string{seriesKey(namespace, key), indexKey(namespace)}
+	ok, err := pushScript.Run(ctx, s.client, keys, key, member, retention.Milliseconds(), maxPoints, maxSeries).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrSeriesLimit
+	}
+	return nil
+}
+
+// Range returns the points of the series key pushed within the last window, oldest first
+func (s *RedisStore) Range(ctx context.Context, namespace, key string, window time.Duration) ([]Point, error) {
+	result, err := rangeScript.Run(ctx, s.client, []string{seriesKey(namespace, key)}, window.Milliseconds()).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, 0, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		_, raw, _ := strings.Cut(result[i], ":")
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt point: %w", err)
+		}
+		ms, err := strconv.ParseFloat(result[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt point: %w", err)
+		}
+		points = append(points, Point{At: time.UnixMilli(int64(ms)), Value: value})
+	}
+	return points, nil
+}
+
+// Delete removes the series key and reports whether it existed
+func (s *RedisStore) Delete(ctx context.Context, namespace, key string) (bool, error) {
+	pipe := s.client.TxPipeline()
+	removed := pipe.Del(ctx, seriesKey(namespace, key))
+	pipe.ZRem(ctx, indexKey(namespace), key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return removed.Val() > 0, nil
+}
+
+// Clear removes every series of namespace
+func (s *RedisStore) Clear(ctx context.Context, namespace string) error {
+	index := indexKey(namespace)
+	keys, err := s.client.ZRange(ctx, index, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, seriesKey(namespace, key))
+	}
+	pipe.Del(ctx, index)
+	_, err = pipe.Exec(ctx)
+	return err
+}
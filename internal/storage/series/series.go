@@ -0,0 +1,19 @@
+// Package series stores the time series of the window module. Every rule gets
+// its own namespace, and every namespace holds at most a configured number of
+// series. Points older than the retention of their series are dropped, as
+// are series nothing was pushed to for longer than their retention.
+package series
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSeriesLimit is returned when a point would start a series in a full namespace
+var ErrSeriesLimit = errors.New("namespace series limit reached")
+
+// Point is a value pushed to a series at a time
+type Point struct {
+	At    time.Time
+	Value float64
+}
@@ -0,0 +1,176 @@
+package series
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is the behaviour shared by the backends
+type store interface {
+	Push(ctx context.Context, namespace, key string, value float64, retention time.Duration, maxPoints, maxSeries int) error
+	Range(ctx context.Context, namespace, key string, window time.Duration) ([]Point, error)
+	Delete(ctx context.Context, namespace, key string) (bool, error)
+	Clear(ctx context.Context, namespace string) error
+}
+
+// backend is a store under test, with a way to let time pass for it
+type backend struct {
+	store   store
+	advance func(d time.Duration)
+}
+
+// values returns the values of points, oldest first
+func values(points []Point) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		values = append(values, p.Value)
+	}
+	return values
+}
+
+// testStore runs the behaviour every backend must have. Every test uses its
+// own namespace, so the backend may be shared.
+func testStore(t *testing.T, b backend) {
+	ctx := context.Background()
+	namespace := func(t *testing.T) string {
+		return strings.ReplaceAll(t.Name(), "/", ":")
+	}
+	rangeValues := func(t *testing.T, ns, key string, window time.Duration) []float64 {
+		t.Helper()
+		points, err := b.store.Range(ctx, ns, key, window)
+		require.NoError(t, err)
+		return values(points)
+	}
+
+	t.Run("push and range", func(t *testing.T) {
+		ns := namespace(t)
+		for _, v := range []float64{1, 2, 2, 3.5} {
+			require.NoError(t, b.store.Push(ctx, ns, "temp", v, time.Hour, 0, 0))
+			b.advance(time.Second)
+		}
+
+		assert.Equal(t, []float64{1, 2, 2, 3.5}, rangeValues(t, ns, "temp", time.Hour), "equal values are kept apart")
+		assert.Equal(t, []float64{2, 3.5}, rangeValues(t, ns, "temp", 2500*time.Millisecond))
+		assert.Empty(t, rangeValues(t, ns, "missing", time.Hour))
+		assert.Empty(t, rangeValues(t, ns+":other", "temp", time.Hour), "namespaces are isolated")
+	})
+
+	t.Run("max points", func(t *testing.T) {
+		ns := namespace(t)
+		for v := 1; v <= 10; v++ {
+			require.NoError(t, b.store.Push(ctx, ns, "temp", float64(v), time.Hour, 3, 0))
+			b.advance(time.Millisecond)
+		}
+
+		assert.Equal(t, []float64{8, 9, 10}, rangeValues(t, ns, "temp", time.Hour))
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Push(ctx, ns, "temp", 1, time.Second, 0, 0))
+		b.advance(600 * time.Millisecond)
+		require.NoError(t, b.store.Push(ctx, ns, "temp", 2, time.Second, 0, 0))
+		b.advance(600 * time.Millisecond)
+		require.NoError(t, b.store.Push(ctx, ns, "temp", 3, time.Second, 0, 0))
+
+		assert.Equal(t, []float64{2, 3}, rangeValues(t, ns, "temp", time.Hour), "points older than the retention are dropped")
+
+		b.advance(2 * time.Second)
+		assert.Empty(t, rangeValues(t, ns, "temp", time.Hour), "idle series expire")
+	})
+
+	t.Run("series limit", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Push(ctx, ns, "a", 1, time.Second, 0, 2))
+		require.NoError(t, b.store.Push(ctx, ns, "b", 1, time.Hour, 0, 2))
+
+		assert.ErrorIs(t, b.store.Push(ctx, ns, "c", 1, time.Hour, 0, 2), ErrSeriesLimit)
+
+		// Existing series can still grow, and deleting one frees a slot
+		require.NoError(t, b.store.Push(ctx, ns, "a", 2, time.Second, 0, 2))
+		removed, err := b.store.Delete(ctx, ns, "a")
+		require.NoError(t, err)
+		assert.True(t, removed)
+		require.NoError(t, b.store.Push(ctx, ns, "c", 1, time.Second, 0, 2))
+
+		// Expired series no longer count towards the limit
+		b.advance(2 * time.Second)
+		require.NoError(t, b.store.Push(ctx, ns, "d", 1, time.Hour, 0, 2))
+		removed, err = b.store.Delete(ctx, ns, "c")
+		require.NoError(t, err)
+		assert.False(t, removed)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		ns := namespace(t)
+		require.NoError(t, b.store.Push(ctx, ns, "a", 1, time.Hour, 0, 1))
+		require.NoError(t, b.store.Push(ctx, ns+":other", "a", 1, time.Hour, 0, 1))
+
+		require.NoError(t, b.store.Clear(ctx, ns))
+
+		assert.Empty(t, rangeValues(t, ns, "a", time.Hour))
+		assert.Equal(t, []float64{1}, rangeValues(t, ns+":other", "a", time.Hour))
+		require.NoError(t, b.store.Push(ctx, ns, "b", 1, time.Hour, 0, 1), "the series limit is reset")
+	})
+}
+
+func newTestMemoryStore() (*MemoryStore, func(d time.Duration)) {
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStore(t *testing.T) {
+	s, advance := newTestMemoryStore()
+	testStore(t, backend{store: s, advance: advance})
+}
+
+func TestMemoryStore_SweepsIdleNamespaces(t *testing.T) {
+	ctx := context.Background()
+	s, advance := newTestMemoryStore()
+	require.NoError(t, s.Push(ctx, "deleted-rule", "temp", 1, time.Second, 0, 0))
+	require.NoError(t, s.Push(ctx, "active-rule", "temp", 1, time.Hour, 0, 0))
+
+	// Nothing touches the namespace of the deleted rule again
+	advance(sweepInterval)
+	require.NoError(t, s.Push(ctx, "active-rule", "temp", 2, time.Hour, 0, 0))
+
+	assert.NotContains(t, s.namespaces, "deleted-rule")
+	assert.Contains(t, s.namespaces, "active-rule")
+
+	removed, err := s.Delete(ctx, "active-rule", "temp")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Empty(t, s.namespaces, "deleting the last series removes the namespace")
+}
+
+func TestMemoryStore_FullSeriesIsNotCopiedOnEveryPush(t *testing.T) {
+	ctx := context.Background()
+	s, advance := newTestMemoryStore()
+	const maxPoints = 1000
+
+	copies := 0
+	var last *Point
+	for v := range 10 * maxPoints {
+		require.NoError(t, s.Push(ctx, "rule", "temp", float64(v), time.Hour, maxPoints, 0))
+		advance(time.Millisecond)
+
+		points := s.namespaces["rule"]["temp"].points
+		if end := &points[:cap(points)][cap(points)-1]; end != last {
+			copies++
+			last = end
+		}
+	}
+
+	points, err := s.Range(ctx, "rule", "temp", time.Hour)
+	require.NoError(t, err)
+	require.Len(t, points, maxPoints)
+	assert.Equal(t, float64(10*maxPoints-1), points[maxPoints-1].Value)
+	assert.Less(t, copies, 100, "the points are copied in batches")
+}
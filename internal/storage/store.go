@@ -6,7 +6,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	actionStorage "github.com/malyshevhen/rule-engine/internal/storage/action"
+	executionStorage "github.com/malyshevhen/rule-engine/internal/storage/execution"
+	libraryStorage "github.com/malyshevhen/rule-engine/internal/storage/library"
 	ruleStorage "github.com/malyshevhen/rule-engine/internal/storage/rule"
+	secretStorage "github.com/malyshevhen/rule-engine/internal/storage/secret"
 	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
 )
 
@@ -42,11 +45,44 @@ type RuleRepository interface {
 	AddAction(ctx context.Context, ruleID, actionID uuid.UUID) error
 }
 
+// ExecutionRepository interface for execution log storage operations
+type ExecutionRepository interface {
+	Create(ctx context.Context, execution *executionStorage.Execution) error
+	GetByID(ctx context.Context, id int64) (*executionStorage.Execution, error)
+	ListByRuleID(ctx context.Context, ruleID uuid.UUID, limit, offset int) ([]*executionStorage.Execution, int, error)
+	GetReplayBundle(ctx context.Context, id int64) (string, error)
+}
+
+// LibraryRepository interface for shared library storage operations
+type LibraryRepository interface {
+	Create(ctx context.Context, library *libraryStorage.Library) error
+	GetByID(ctx context.Context, id uuid.UUID) (*libraryStorage.Library, error)
+	List(ctx context.Context, limit, offset int) ([]*libraryStorage.Library, int, error)
+	ListAll(ctx context.Context) ([]*libraryStorage.Library, error)
+	Update(ctx context.Context, library *libraryStorage.Library) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListVersions(ctx context.Context, id uuid.UUID) ([]*libraryStorage.Version, error)
+	GetVersion(ctx context.Context, id uuid.UUID, version int) (*libraryStorage.Version, error)
+}
+
+// SecretRepository interface for secret storage operations
+type SecretRepository interface {
+	Create(ctx context.Context, secret *secretStorage.Secret) error
+	GetByID(ctx context.Context, id uuid.UUID) (*secretStorage.Secret, error)
+	GetForRule(ctx context.Context, name string, ruleID uuid.UUID) (*secretStorage.Secret, error)
+	List(ctx context.Context, limit, offset int) ([]*secretStorage.Secret, int, error)
+	Update(ctx context.Context, secret *secretStorage.Secret) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
 // Store provides all functions to execute db queries and transactions
 type Store struct {
-	RuleRepository    RuleRepository
-	TriggerRepository TriggerRepository
-	ActionRepository  ActionRepository
+	RuleRepository      RuleRepository
+	TriggerRepository   TriggerRepository
+	ActionRepository    ActionRepository
+	ExecutionRepository ExecutionRepository
+	LibraryRepository   LibraryRepository
+	SecretRepository    SecretRepository
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
@@ -60,9 +96,12 @@ func NewSQLStore(pool *pgxpool.Pool) *SQLStore {
 	return &SQLStore{
 		pool: pool,
 		Store: &Store{
-			RuleRepository:    ruleStorage.NewRepository(pool),
-			TriggerRepository: triggerStorage.NewRepository(pool),
-			ActionRepository:  actionStorage.NewRepository(pool),
+			RuleRepository:      ruleStorage.NewRepository(pool),
+			TriggerRepository:   triggerStorage.NewRepository(pool),
+			ActionRepository:    actionStorage.NewRepository(pool),
+			ExecutionRepository: executionStorage.NewRepository(pool),
+			LibraryRepository:   libraryStorage.NewRepository(pool),
+			SecretRepository:    secretStorage.NewRepository(pool),
 		},
 	}
 }
@@ -75,9 +114,12 @@ func (s *SQLStore) ExecTx(ctx context.Context, fn func(*Store) error) error {
 	}
 
 	store := &Store{
-		RuleRepository:    ruleStorage.NewRepository(tx),
-		TriggerRepository: triggerStorage.NewRepository(tx),
-		ActionRepository:  actionStorage.NewRepository(tx),
+		RuleRepository:      ruleStorage.NewRepository(tx),
+		TriggerRepository:   triggerStorage.NewRepository(tx),
+		ActionRepository:    actionStorage.NewRepository(tx),
+		ExecutionRepository: executionStorage.NewRepository(tx),
+		LibraryRepository:   libraryStorage.NewRepository(tx),
+		SecretRepository:    secretStorage.NewRepository(tx),
 	}
 
 	if err := fn(store); err != nil {
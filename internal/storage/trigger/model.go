@@ -20,7 +20,9 @@ type Trigger struct {
 	RuleID          uuid.UUID   `json:"rule_id" db:"rule_id"`
 	Type            TriggerType `json:"type" db:"type"`
 	ConditionScript string      `json:"condition_script" db:"condition_script"`
+	Language        string      `json:"language" db:"language"`
 	Enabled         bool        `json:"enabled" db:"enabled"`
+	TimeoutMs       int         `json:"timeout_ms" db:"timeout_ms"`
 	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
 }
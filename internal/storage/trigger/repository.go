@@ -23,15 +23,15 @@ func NewRepository(db db.DBTX) *Repository {
 
 // Create inserts a new trigger into the database
 func (r *Repository) Create(ctx context.Context, trigger *Trigger) error {
-	query := `INSERT INTO triggers (rule_id, type, condition_script, enabled) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
-	return r.db.QueryRow(ctx, query, trigger.RuleID, trigger.Type, trigger.ConditionScript, trigger.Enabled).Scan(&trigger.ID, &trigger.CreatedAt, &trigger.UpdatedAt)
+	query := `INSERT INTO triggers (rule_id, type, condition_script, language, enabled, timeout_ms) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(ctx, query, trigger.RuleID, trigger.Type, trigger.ConditionScript, trigger.Language, trigger.Enabled, trigger.TimeoutMs).Scan(&trigger.ID, &trigger.CreatedAt, &trigger.UpdatedAt)
 }
 
 // GetByID retrieves a trigger by its ID
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Trigger, error) {
-	query := `SELECT id, rule_id, type, condition_script, enabled, created_at, updated_at FROM triggers WHERE id = $1`
+	query := `SELECT id, rule_id, type, condition_script, language, enabled, timeout_ms, created_at, updated_at FROM triggers WHERE id = $1`
 	var trigger Trigger
-	err := r.db.QueryRow(ctx, query, id).Scan(&trigger.ID, &trigger.RuleID, &trigger.Type, &trigger.ConditionScript, &trigger.Enabled, &trigger.CreatedAt, &trigger.UpdatedAt)
+	err := r.db.QueryRow(ctx, query, id).Scan(&trigger.ID, &trigger.RuleID, &trigger.Type, &trigger.ConditionScript, &trigger.Language, &trigger.Enabled, &trigger.TimeoutMs, &trigger.CreatedAt, &trigger.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +49,7 @@ func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Trigger, i
 	}
 
 	// Then get the paginated results
-	query := `SELECT id, rule_id, type, condition_script, enabled, created_at, updated_at FROM triggers ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+	query := `SELECT id, rule_id, type, condition_script, language, enabled, timeout_ms, created_at, updated_at FROM triggers ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 	rows, err := r.db.Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -59,7 +59,7 @@ func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Trigger, i
 	var triggers []*Trigger
 	for rows.Next() {
 		var trigger Trigger
-		err := rows.Scan(&trigger.ID, &trigger.RuleID, &trigger.Type, &trigger.ConditionScript, &trigger.Enabled, &trigger.CreatedAt, &trigger.UpdatedAt)
+		err := rows.Scan(&trigger.ID, &trigger.RuleID, &trigger.Type, &trigger.ConditionScript, &trigger.Language, &trigger.Enabled, &trigger.TimeoutMs, &trigger.CreatedAt, &trigger.UpdatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -75,8 +75,8 @@ func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Trigger, i
 
 // Update modifies an existing trigger in the database
 func (r *Repository) Update(ctx context.Context, trigger *Trigger) error {
-	query := `UPDATE triggers SET type = $1, condition_script = $2, enabled = $3, updated_at = NOW() WHERE id = $4`
-	result, err := r.db.Exec(ctx, query, trigger.Type, trigger.ConditionScript, trigger.Enabled, trigger.ID)
+	query := `UPDATE triggers SET type = $1, condition_script = $2, language = $3, enabled = $4, timeout_ms = $5, updated_at = NOW() WHERE id = $6`
+	result, err := r.db.Exec(ctx, query, trigger.Type, trigger.ConditionScript, trigger.Language, trigger.Enabled, trigger.TimeoutMs, trigger.ID)
 	if err != nil {
 		return err
 	}
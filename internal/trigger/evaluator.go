@@ -40,6 +40,18 @@ type EvaluationResult struct {
 
 // EvaluateCondition evaluates a trigger condition script against an event
 func (e *Evaluator) EvaluateCondition(ctx context.Context, triggerID, ruleID uuid.UUID, conditionScript string, eventData map[string]any) *EvaluationResult {
+	return e.evaluate(ctx, triggerID, ruleID, conditionScript, "", 0, "", eventData)
+}
+
+// EvaluateTrigger evaluates a trigger's condition against an event received on subject,
+// honouring the trigger's language and execution timeout
+func (e *Evaluator) EvaluateTrigger(ctx context.Context, trigger *Trigger, subject string, eventData map[string]any) *EvaluationResult {
+	timeout := time.Duration(trigger.TimeoutMs) * time.Millisecond
+	return e.evaluate(ctx, trigger.ID, trigger.RuleID, trigger.ConditionScript, trigger.Language, timeout, subject, eventData)
+}
+
+// evaluate runs a condition script in language (empty for Lua) with the given timeout (0 uses the executor default)
+func (e *Evaluator) evaluate(ctx context.Context, triggerID, ruleID uuid.UUID, conditionScript, language string, timeout time.Duration, subject string, eventData map[string]any) *EvaluationResult {
 	start := time.Now()
 
 	// Record metric
@@ -49,10 +61,15 @@ func (e *Evaluator) EvaluateCondition(ctx context.Context, triggerID, ruleID uui
 	}()
 
 	// Create execution context with event data
-	execContext := e.executor.GetContextService().CreateContext(triggerID.String(), "")
+	execContext := e.executor.GetContextService().CreateContext(ruleID.String(), triggerID.String())
+	execContext.TriggerType = string(Conditional)
+	execContext.EventSubject = subject
+	execContext.Event = eventData
 
-	// Set event data in the context
+	// Legacy scripts read the event from the event global
 	execContext.Data["event"] = eventData
+	execContext.Timeout = timeout
+	execContext.Language = language
 
 	// Execute the condition script
 	result := e.executor.ExecuteScript(ctx, conditionScript, execContext)
@@ -67,7 +84,11 @@ func (e *Evaluator) EvaluateCondition(ctx context.Context, triggerID, ruleID uui
 			"error", result.Error,
 			"duration", duration)
 
-		metrics.TriggerEvaluationTotal.WithLabelValues("conditional", "error").Inc()
+		outcome := "error"
+		if result.Status == executor.StatusTimeout {
+			outcome = "timeout"
+		}
+		metrics.TriggerEvaluationTotal.WithLabelValues("conditional", outcome).Inc()
 
 		return &EvaluationResult{
 			TriggerID: triggerID,
@@ -110,8 +131,8 @@ func (e *Evaluator) EvaluateCondition(ctx context.Context, triggerID, ruleID uui
 	}
 }
 
-// EvaluateTriggers evaluates multiple triggers against an event
-func (e *Evaluator) EvaluateTriggers(ctx context.Context, triggers []*Trigger, eventData map[string]any) []*EvaluationResult {
+// EvaluateTriggers evaluates multiple triggers against an event received on subject
+func (e *Evaluator) EvaluateTriggers(ctx context.Context, triggers []*Trigger, subject string, eventData map[string]any) []*EvaluationResult {
 	results := make([]*EvaluationResult, 0, len(triggers))
 
 	for _, trigger := range triggers {
@@ -119,7 +140,7 @@ func (e *Evaluator) EvaluateTriggers(ctx context.Context, triggers []*Trigger, e
 			continue
 		}
 
-		result := e.EvaluateCondition(ctx, trigger.ID, trigger.RuleID, trigger.ConditionScript, eventData)
+		result := e.EvaluateTrigger(ctx, trigger, subject, eventData)
 		results = append(results, result)
 	}
 
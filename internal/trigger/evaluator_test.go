@@ -3,10 +3,12 @@ package trigger
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	execCtx "github.com/malyshevhen/rule-engine/internal/engine/executor/context"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor/platform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -168,7 +170,7 @@ func TestEvaluator_EvaluateTriggers(t *testing.T) {
 	})
 
 	// Execute
-	results := evaluator.EvaluateTriggers(context.Background(), triggers, eventData)
+	results := evaluator.EvaluateTriggers(context.Background(), triggers, "events.sensor_1", eventData)
 
 	// Assert
 	assert.Len(t, results, 1) // Only the enabled trigger should be evaluated
@@ -205,3 +207,104 @@ func TestEvaluator_EvaluateCondition_EventDataInContext(t *testing.T) {
 
 	mockExec.AssertExpectations(t)
 }
+
+func TestEvaluator_EvaluateTrigger_ExecutionContext(t *testing.T) {
+	mockExec := &mockExecutor{}
+	evaluator := NewEvaluator(mockExec)
+
+	contextSvc := execCtx.NewService()
+	mockExec.On("GetContextService").Return(contextSvc)
+
+	var capturedContext *execCtx.ExecutionContext
+	mockExec.On("ExecuteScript", mock.Anything, mock.Anything, mock.MatchedBy(func(ctx *execCtx.ExecutionContext) bool {
+		capturedContext = ctx
+		return true
+	})).Return(&executor.ExecuteResult{
+		Success: true,
+		Output:  []any{true},
+	})
+
+	trg := &Trigger{
+		ID:              uuid.New(),
+		RuleID:          uuid.New(),
+		Type:            Conditional,
+		ConditionScript: "return true",
+		Enabled:         true,
+	}
+	eventData := map[string]any{"temperature": 28.5}
+	evaluator.EvaluateTrigger(context.Background(), trg, "events.sensor_1", eventData)
+
+	assert.NotNil(t, capturedContext)
+	assert.Equal(t, trg.RuleID.String(), capturedContext.RuleID)
+	assert.Equal(t, trg.ID.String(), capturedContext.TriggerID)
+	assert.Equal(t, "CONDITIONAL", capturedContext.TriggerType)
+	assert.Equal(t, "events.sensor_1", capturedContext.EventSubject)
+	assert.Equal(t, eventData, capturedContext.Event)
+	assert.Equal(t, 1, capturedContext.Attempt)
+	assert.False(t, capturedContext.FiredAt.IsZero())
+
+	mockExec.AssertExpectations(t)
+}
+
+func TestEvaluator_EvaluateTrigger_Timeout(t *testing.T) {
+	mockExec := &mockExecutor{}
+	evaluator := NewEvaluator(mockExec)
+
+	contextSvc := execCtx.NewService()
+	mockExec.On("GetContextService").Return(contextSvc)
+
+	var capturedContext *execCtx.ExecutionContext
+	mockExec.On("ExecuteScript", mock.Anything, mock.Anything, mock.MatchedBy(func(ctx *execCtx.ExecutionContext) bool {
+		capturedContext = ctx
+		return true
+	})).Return(&executor.ExecuteResult{
+		Success: false,
+		Status:  executor.StatusTimeout,
+		Error:   "script execution timed out after 250ms",
+	})
+
+	trg := &Trigger{
+		ID:              uuid.New(),
+		RuleID:          uuid.New(),
+		Type:            Conditional,
+		ConditionScript: "while true do end",
+		Enabled:         true,
+		TimeoutMs:       250,
+	}
+	result := evaluator.EvaluateTrigger(context.Background(), trg, "", map[string]any{})
+
+	assert.False(t, result.Matched)
+	assert.Contains(t, result.Error, "timed out")
+	assert.NotNil(t, capturedContext)
+	assert.Equal(t, 250*time.Millisecond, capturedContext.Timeout)
+
+	mockExec.AssertExpectations(t)
+}
+
+func TestEvaluator_EvaluateTrigger_Expression(t *testing.T) {
+	executorSvc := executor.NewService(execCtx.NewService(), platform.NewService())
+	evaluator := NewEvaluator(executorSvc)
+
+	trg := &Trigger{
+		ID:              uuid.New(),
+		RuleID:          uuid.New(),
+		Type:            Conditional,
+		ConditionScript: `ctx.event.subject == "events.sensor_1" && event.temperature > 25`,
+		Language:        executor.LanguageExpr,
+		Enabled:         true,
+	}
+
+	result := evaluator.EvaluateTrigger(context.Background(), trg, "events.sensor_1", map[string]any{"temperature": 28.5})
+	assert.Empty(t, result.Error)
+	assert.True(t, result.Matched)
+
+	result = evaluator.EvaluateTrigger(context.Background(), trg, "events.sensor_1", map[string]any{"temperature": 20})
+	assert.Empty(t, result.Error)
+	assert.False(t, result.Matched)
+
+	// Reading a field of a missing value fails the evaluation
+	trg.ConditionScript = `event.sensor.battery < 20`
+	result = evaluator.EvaluateTrigger(context.Background(), trg, "events.sensor_1", map[string]any{"temperature": 28.5})
+	assert.False(t, result.Matched)
+	assert.Contains(t, result.Error, "cannot read field 'battery' of null")
+}
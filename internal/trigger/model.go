@@ -20,7 +20,10 @@ type Trigger struct {
 	RuleID          uuid.UUID   `json:"rule_id"`
 	Type            TriggerType `json:"type"`
 	ConditionScript string      `json:"condition_script"`
-	Enabled         bool        `json:"enabled"`
-	CreatedAt       time.Time   `json:"created_at"`
-	UpdatedAt       time.Time   `json:"updated_at"`
+	// Language of the condition script: lua, the default, or expr
+	Language  string    `json:"language"`
+	Enabled   bool      `json:"enabled"`
+	TimeoutMs int       `json:"timeout_ms"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
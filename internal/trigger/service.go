@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/malyshevhen/rule-engine/internal/engine/executor"
 	"github.com/malyshevhen/rule-engine/internal/storage"
 	redisClient "github.com/malyshevhen/rule-engine/internal/storage/redis"
 	triggerStorage "github.com/malyshevhen/rule-engine/internal/storage/trigger"
@@ -27,25 +28,51 @@ type Store interface {
 	GetStore() *storage.Store
 }
 
+// ScriptCache drops compiled scripts that are no longer current
+type ScriptCache interface {
+	InvalidateScript(script string)
+}
+
 // Service handles business logic for triggers
 type Service struct {
-	store Store
-	redis *redisClient.Client
+	store   Store
+	redis   *redisClient.Client
+	scripts ScriptCache
+}
+
+// ServiceOption allows to configure the trigger service
+type ServiceOption func(s *Service) *Service
+
+// WithScriptCache sets the cache invalidated when a condition script changes
+func WithScriptCache(scripts ScriptCache) ServiceOption {
+	return func(s *Service) *Service {
+		s.scripts = scripts
+		return s
+	}
 }
 
 // NewService creates a new trigger service
-func NewService(store Store, redis *redisClient.Client) *Service {
-	return &Service{store: store, redis: redis}
+func NewService(store Store, redis *redisClient.Client, opts ...ServiceOption) *Service {
+	s := &Service{store: store, redis: redis}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Create creates a new trigger
+// Create creates a new trigger. Its condition is a Lua script unless a language is set.
 func (s *Service) Create(ctx context.Context, trigger *Trigger) error {
+	if trigger.Language == "" {
+		trigger.Language = executor.LanguageLua
+	}
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
 		storageTrigger := &triggerStorage.Trigger{
 			RuleID:          trigger.RuleID,
 			Type:            triggerStorage.TriggerType(trigger.Type),
 			ConditionScript: trigger.ConditionScript,
+			Language:        trigger.Language,
 			Enabled:         trigger.Enabled,
+			TimeoutMs:       trigger.TimeoutMs,
 		}
 		err := q.TriggerRepository.Create(ctx, storageTrigger)
 		if err != nil {
@@ -75,7 +102,9 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Trigger, error) {
 		RuleID:          storageTrigger.RuleID,
 		Type:            TriggerType(storageTrigger.Type),
 		ConditionScript: storageTrigger.ConditionScript,
+		Language:        storageTrigger.Language,
 		Enabled:         storageTrigger.Enabled,
+		TimeoutMs:       storageTrigger.TimeoutMs,
 		CreatedAt:       storageTrigger.CreatedAt,
 		UpdatedAt:       storageTrigger.UpdatedAt,
 	}
@@ -97,7 +126,9 @@ func (s *Service) List(ctx context.Context, limit, offset int) ([]*Trigger, int,
 			RuleID:          storageTrigger.RuleID,
 			Type:            TriggerType(storageTrigger.Type),
 			ConditionScript: storageTrigger.ConditionScript,
+			Language:        storageTrigger.Language,
 			Enabled:         storageTrigger.Enabled,
+			TimeoutMs:       storageTrigger.TimeoutMs,
 			CreatedAt:       storageTrigger.CreatedAt,
 			UpdatedAt:       storageTrigger.UpdatedAt,
 		}
@@ -108,21 +139,33 @@ func (s *Service) List(ctx context.Context, limit, offset int) ([]*Trigger, int,
 
 // Update modifies an existing trigger
 func (s *Service) Update(ctx context.Context, trigger *Trigger) error {
+	if trigger.Language == "" {
+		trigger.Language = executor.LanguageLua
+	}
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
 		storageTrigger := &triggerStorage.Trigger{
 			ID:              trigger.ID,
 			RuleID:          trigger.RuleID,
 			Type:            triggerStorage.TriggerType(trigger.Type),
 			ConditionScript: trigger.ConditionScript,
+			Language:        trigger.Language,
 			Enabled:         trigger.Enabled,
+			TimeoutMs:       trigger.TimeoutMs,
+		}
+		previousScript, err := s.currentScript(ctx, q, trigger.ID)
+		if err != nil {
+			return err
 		}
-		err := q.TriggerRepository.Update(ctx, storageTrigger)
+		err = q.TriggerRepository.Update(ctx, storageTrigger)
 		if err != nil {
 			return err
 		}
 
 		// Invalidate caches
 		s.invalidateTriggerCaches(ctx)
+		if previousScript != trigger.ConditionScript {
+			s.invalidateScript(previousScript)
+		}
 
 		return nil
 	})
@@ -131,13 +174,18 @@ func (s *Service) Update(ctx context.Context, trigger *Trigger) error {
 // Delete removes a trigger
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.store.ExecTx(ctx, func(q *storage.Store) error {
-		err := q.TriggerRepository.Delete(ctx, id)
+		previousScript, err := s.currentScript(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		err = q.TriggerRepository.Delete(ctx, id)
 		if err != nil {
 			return err
 		}
 
 		// Invalidate caches
 		s.invalidateTriggerCaches(ctx)
+		s.invalidateScript(previousScript)
 
 		return nil
 	})
@@ -219,6 +267,25 @@ func (s *Service) GetEnabledScheduledTriggers(ctx context.Context) ([]*Trigger,
 	return scheduledTriggers, nil
 }
 
+// currentScript returns the stored condition script of a trigger when a script cache is configured
+func (s *Service) currentScript(ctx context.Context, q *storage.Store, id uuid.UUID) (string, error) {
+	if s.scripts == nil {
+		return "", nil
+	}
+	storageTrigger, err := q.TriggerRepository.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return storageTrigger.ConditionScript, nil
+}
+
+// invalidateScript drops the compiled form of a replaced or deleted script
+func (s *Service) invalidateScript(script string) {
+	if s.scripts != nil && script != "" {
+		s.scripts.InvalidateScript(script)
+	}
+}
+
 // invalidateTriggerCaches clears all trigger-related caches
 func (s *Service) invalidateTriggerCaches(ctx context.Context) {
 	if s.redis == nil {
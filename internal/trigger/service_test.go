@@ -163,3 +163,37 @@ func TestService_GetByID_Error(t *testing.T) {
 	assert.Nil(t, trigger)
 	mockStore.triggerRepo.(*mockTriggerRepository).AssertExpectations(t)
 }
+
+// mockScriptCache is a mock implementation of the ScriptCache interface
+type mockScriptCache struct {
+	mock.Mock
+}
+
+func (m *mockScriptCache) InvalidateScript(script string) {
+	m.Called(script)
+}
+
+func TestService_Update_InvalidatesScript(t *testing.T) {
+	mockStore := newMockSQLStore()
+	scripts := &mockScriptCache{}
+	service := NewService(mockStore, nil, WithScriptCache(scripts))
+
+	trigger := &Trigger{
+		ID:              uuid.New(),
+		RuleID:          uuid.New(),
+		Type:            Conditional,
+		ConditionScript: "return event.temperature > 30",
+		Enabled:         true,
+	}
+
+	mockStore.triggerRepo.(*mockTriggerRepository).On("GetByID", mock.Anything, trigger.ID).Return(&triggerStorage.Trigger{ID: trigger.ID, ConditionScript: "return event.temperature > 25"}, nil)
+	mockStore.triggerRepo.(*mockTriggerRepository).On("Update", mock.Anything, mock.Anything).Return(nil)
+	mockStore.On("ExecTx", mock.Anything, mock.Anything).Return(nil)
+	scripts.On("InvalidateScript", "return event.temperature > 25").Return()
+
+	err := service.Update(context.Background(), trigger)
+
+	assert.NoError(t, err)
+	mockStore.triggerRepo.(*mockTriggerRepository).AssertExpectations(t)
+	scripts.AssertExpectations(t)
+}
@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	re_client "github.com/malyshevhen/rule-engine/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceModule(t *testing.T) {
+	ctx := context.Background()
+
+	// Verify environment is set up correctly
+	require.NotNil(t, testEnv)
+
+	// Devices are simulated from fixtures/devices/devices.json
+	client := re_client.NewClient(testEnv.GetRuleEngineURL(ctx, t), re_client.AuthConfig{
+		APIKey: "test-api-key",
+	})
+
+	t.Run("GetState", func(t *testing.T) {
+		result, err := client.EvaluateScript(ctx, re_client.EvaluateScriptRequest{
+			Script: `
+				local device = require("device")
+				local state, err = device.get_state("thermostat_1")
+				if err then error(err) end
+				return state.temperature, state.mode
+			`,
+			Context: map[string]any{},
+		})
+
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+		require.Equal(t, []any{26.5, "heat"}, result.Output)
+	})
+
+	t.Run("SendCommand", func(t *testing.T) {
+		result, err := client.EvaluateScript(ctx, re_client.EvaluateScriptRequest{
+			Script: `
+				local device = require("device")
+				local ok, err = device.send_command("ac_unit", "turn_on", {on = true, target = 21})
+				if err then error(err) end
+				return device.get_state("ac_unit").target
+			`,
+			Context: map[string]any{},
+		})
+
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+		require.Equal(t, []any{21.0}, result.Output)
+	})
+
+	t.Run("OfflineDevice", func(t *testing.T) {
+		result, err := client.EvaluateScript(ctx, re_client.EvaluateScriptRequest{
+			Script: `
+				local ok, err = require("device").send_command("garage_door", "open")
+				return ok == nil, err
+			`,
+			Context: map[string]any{},
+		})
+
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+		require.Equal(t, []any{true, "device: 'garage_door' is offline"}, result.Output)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		result, err := client.EvaluateScript(ctx, re_client.EvaluateScriptRequest{
+			Script: `
+				local devices = require("device").list({online = true})
+				local ids = {}
+				for _, d in ipairs(devices) do
+					table.insert(ids, d.id)
+				end
+				return table.concat(ids, ",")
+			`,
+			Context: map[string]any{},
+		})
+
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+		require.Equal(t, []any{"ac_unit,thermostat_1"}, result.Output)
+	})
+}